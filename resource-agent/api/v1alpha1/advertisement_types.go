@@ -33,6 +33,11 @@ type AdvertisementSpec struct {
 	// +optional
 	Cost *CostInfo `json:"cost,omitempty"`
 
+	// Labels describe the cluster for broker placement constraints
+	// (e.g., topology.kubernetes.io/region=eu-west-1)
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Timestamp when this advertisement was created
 	Timestamp metav1.Time `json:"timestamp"`
 }
@@ -71,6 +76,11 @@ type ResourceQuantities struct {
 	// Storage (optional)
 	// +optional
 	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Extended resources keyed by Kubernetes resource name
+	// (e.g., "amd.com/gpu", "hugepages-2Mi", "ephemeral-storage")
+	// +optional
+	Extended map[string]resource.Quantity `json:"extended,omitempty"`
 }
 
 // CostInfo represents cost information
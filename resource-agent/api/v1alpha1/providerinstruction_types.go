@@ -4,6 +4,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ProviderInstructionRejectAnnotation lets provider operators veto an
+// instruction; its value is the reason reported to the broker.
+const ProviderInstructionRejectAnnotation = "rear.fluidos.eu/reject"
+
 // ProviderInstructionSpec carries information for the providing cluster.
 type ProviderInstructionSpec struct {
 	// ReservationName identifies the broker reservation.
@@ -18,6 +22,14 @@ type ProviderInstructionSpec struct {
 	// RequestedMemory amount.
 	RequestedMemory string `json:"requestedMemory"`
 
+	// RequestedGPU amount.
+	// +optional
+	RequestedGPU string `json:"requestedGPU,omitempty"`
+
+	// RequestedExtended amounts keyed by resource name.
+	// +optional
+	RequestedExtended map[string]string `json:"requestedExtended,omitempty"`
+
 	// Message is a human description.
 	// +optional
 	Message string `json:"message,omitempty"`
@@ -33,6 +45,18 @@ type ProviderInstructionStatus struct {
 	// +optional
 	Enforced bool `json:"enforced,omitempty"`
 
+	// Rejected marks an instruction the provider refused to hold.
+	// +optional
+	Rejected bool `json:"rejected,omitempty"`
+
+	// RejectionReason explains a rejection, e.g. "local policy".
+	// +optional
+	RejectionReason string `json:"rejectionReason,omitempty"`
+
+	// Acknowledged marks whether the acceptance or rejection was reported to the broker.
+	// +optional
+	Acknowledged bool `json:"acknowledged,omitempty"`
+
 	// LastUpdateTime records status updates.
 	// +optional
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
@@ -45,6 +69,7 @@ type ProviderInstructionStatus struct {
 // +kubebuilder:printcolumn:name="Requester",type=string,JSONPath=`.spec.requesterClusterID`
 // +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.requestedCPU`
 // +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.requestedMemory`
+// +kubebuilder:printcolumn:name="GPU",type=string,JSONPath=`.spec.requestedGPU`,priority=1
 
 // ProviderInstruction notifies provider clusters.
 type ProviderInstruction struct {
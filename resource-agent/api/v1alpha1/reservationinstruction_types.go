@@ -18,6 +18,14 @@ type ReservationInstructionSpec struct {
 	// RequestedMemory is the memory quantity to consume.
 	RequestedMemory string `json:"requestedMemory"`
 
+	// RequestedGPU is the GPU quantity to consume.
+	// +optional
+	RequestedGPU string `json:"requestedGPU,omitempty"`
+
+	// RequestedExtended are the extended resource quantities to consume.
+	// +optional
+	RequestedExtended map[string]string `json:"requestedExtended,omitempty"`
+
 	// Message provides human-readable hints for operators.
 	// +optional
 	Message string `json:"message,omitempty"`
@@ -37,6 +45,10 @@ type ReservationInstructionStatus struct {
 	// +optional
 	Delivered bool `json:"delivered,omitempty"`
 
+	// Activated marks that the broker was told the reservation is in use.
+	// +optional
+	Activated bool `json:"activated,omitempty"`
+
 	// LastUpdateTime records the last update timestamp.
 	// +optional
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
@@ -4,6 +4,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ResourceRequestFinalizer releases the broker reservation before a ResourceRequest is deleted
+const ResourceRequestFinalizer = "resourcerequest.rear.fluidos.eu/finalizer"
+
 // ResourceRequestSpec defines the desired state of ResourceRequest.
 // A user creates this CRD to request resources from remote clusters.
 // The agent sends a synchronous reservation request to the broker.
@@ -14,6 +17,16 @@ type ResourceRequestSpec struct {
 	// RequestedMemory is the memory quantity to request (e.g., "256Mi", "1Gi").
 	RequestedMemory string `json:"requestedMemory"`
 
+	// RequestedGPU is the number of GPUs to request (e.g., "1").
+	// Only providers advertising enough GPUs are considered.
+	// +optional
+	RequestedGPU string `json:"requestedGPU,omitempty"`
+
+	// RequestedExtended requests extended resources keyed by resource name
+	// (e.g., {"amd.com/gpu": "1", "hugepages-2Mi": "512Mi"}).
+	// +optional
+	RequestedExtended map[string]string `json:"requestedExtended,omitempty"`
+
 	// Priority of this request (higher number = higher priority).
 	// +optional
 	Priority int32 `json:"priority,omitempty"`
@@ -21,11 +34,98 @@ type ResourceRequestSpec struct {
 	// Duration is how long the reservation should last (e.g., "1h", "30m").
 	// +optional
 	Duration string `json:"duration,omitempty"`
+
+	// StartTime schedules the reservation for a future window of Duration
+	// starting at this time. The broker admits it only if the capacity is
+	// free for the whole window and locks it when the window opens.
+	// Requires Duration; Queue, Splittable and GangMembers do not apply.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// ScoringStrategy overrides the broker's default cluster ranking for this request.
+	// Empty uses the broker default.
+	// +kubebuilder:validation:Enum=LeastAllocated;MostAllocated;LowestCost;BalancedResource
+	// +optional
+	ScoringStrategy string `json:"scoringStrategy,omitempty"`
+
+	// Placement restricts and ranks provider clusters by their advertised labels.
+	// +optional
+	Placement *PlacementConstraints `json:"placement,omitempty"`
+
+	// Queue asks the broker to keep the request Pending until capacity frees up,
+	// instead of failing it when no provider fits right now.
+	// +optional
+	Queue bool `json:"queue,omitempty"`
+
+	// Splittable lets the broker divide the request across several providers
+	// when no single one has enough capacity. Only CPU and memory requests can
+	// be split; each provider then gets its own ReservationInstruction.
+	// +optional
+	Splittable bool `json:"splittable,omitempty"`
+
+	// MinChunkCPU is the smallest CPU share one provider may hold (e.g., "1").
+	// +optional
+	MinChunkCPU string `json:"minChunkCPU,omitempty"`
+
+	// MinChunkMemory is the smallest memory share one provider may hold (e.g., "2Gi").
+	// +optional
+	MinChunkMemory string `json:"minChunkMemory,omitempty"`
+
+	// GangMembers turns the request into a gang: the requested resources above
+	// form the first block and each member an additional block. The broker
+	// reserves all blocks or none, possibly on different providers.
+	// Queue and Splittable do not apply to gangs.
+	// +optional
+	GangMembers []ResourceBlock `json:"gangMembers,omitempty"`
+}
+
+// ResourceBlock is one additional block of a gang request. It shares the
+// request's priority, duration and scoring strategy.
+type ResourceBlock struct {
+	// RequestedCPU is the CPU quantity of this block (e.g., "4").
+	RequestedCPU string `json:"requestedCPU"`
+
+	// RequestedMemory is the memory quantity of this block (e.g., "8Gi").
+	RequestedMemory string `json:"requestedMemory"`
+
+	// RequestedGPU is the number of GPUs of this block.
+	// +optional
+	RequestedGPU string `json:"requestedGPU,omitempty"`
+
+	// RequestedExtended requests extended resources for this block.
+	// +optional
+	RequestedExtended map[string]string `json:"requestedExtended,omitempty"`
+
+	// Placement restricts and ranks providers for this block.
+	// +optional
+	Placement *PlacementConstraints `json:"placement,omitempty"`
+}
+
+// PlacementConstraints are matched by the broker against provider cluster labels.
+type PlacementConstraints struct {
+	// Required requirements must all match (e.g., region In [eu-west-1, eu-central-1]).
+	// +optional
+	Required []metav1.LabelSelectorRequirement `json:"required,omitempty"`
+
+	// Preferred terms favour matching clusters without excluding the others.
+	// +optional
+	Preferred []PreferredPlacementTerm `json:"preferred,omitempty"`
+}
+
+// PreferredPlacementTerm adds its weight to clusters matching all of its requirements.
+type PreferredPlacementTerm struct {
+	// Weight of this term relative to the other preferred terms.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Requirements that must all match for the term to apply.
+	Requirements []metav1.LabelSelectorRequirement `json:"requirements"`
 }
 
 // ResourceRequestStatus defines the observed state of ResourceRequest.
 type ResourceRequestStatus struct {
-	// Phase represents the current state: Pending, Reserved, Failed.
+	// Phase represents the current state: Pending, Scheduled, Reserved, Failed, Preempted.
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
@@ -41,16 +141,48 @@ type ResourceRequestStatus struct {
 	// +optional
 	Message string `json:"message,omitempty"`
 
+	// QueuePosition is the 1-based position in the broker queue while queued.
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// Parts lists the per-provider reservations when the broker split the
+	// request or reserved a gang.
+	// ReservationName is then the group ID and TargetClusterID is empty.
+	// +optional
+	Parts []ReservationPart `json:"parts,omitempty"`
+
+	// ObservedGeneration is the spec generation the status reflects. A newer
+	// generation on a Reserved request is a resize of its CPU and memory.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// LastUpdateTime records the last status update.
 	// +optional
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
+// ReservationPart is the share of a split request or the gang block reserved on one provider.
+type ReservationPart struct {
+	// ReservationName is the broker-side reservation ID of this part.
+	ReservationName string `json:"reservationName"`
+
+	// TargetClusterID is the provider holding this part.
+	TargetClusterID string `json:"targetClusterID"`
+
+	// CPU reserved on this provider.
+	CPU string `json:"cpu"`
+
+	// Memory reserved on this provider.
+	Memory string `json:"memory"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Namespaced
 // +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.requestedCPU`
 // +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.requestedMemory`
+// +kubebuilder:printcolumn:name="GPU",type=string,JSONPath=`.spec.requestedGPU`,priority=1
+// +kubebuilder:printcolumn:name="Start",type=date,JSONPath=`.spec.startTime`,priority=1
 // +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.status.targetClusterID`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
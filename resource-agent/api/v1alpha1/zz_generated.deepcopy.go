@@ -21,6 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -92,6 +94,13 @@ func (in *AdvertisementSpec) DeepCopyInto(out *AdvertisementSpec) {
 		*out = new(CostInfo)
 		**out = **in
 	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	in.Timestamp.DeepCopyInto(&out.Timestamp)
 }
 
@@ -136,6 +145,57 @@ func (in *CostInfo) DeepCopy() *CostInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementConstraints) DeepCopyInto(out *PlacementConstraints) {
+	*out = *in
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = make([]v1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Preferred != nil {
+		in, out := &in.Preferred, &out.Preferred
+		*out = make([]PreferredPlacementTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementConstraints.
+func (in *PlacementConstraints) DeepCopy() *PlacementConstraints {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementConstraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredPlacementTerm) DeepCopyInto(out *PreferredPlacementTerm) {
+	*out = *in
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = make([]v1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredPlacementTerm.
+func (in *PreferredPlacementTerm) DeepCopy() *PreferredPlacementTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredPlacementTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderInstruction) DeepCopyInto(out *ProviderInstruction) {
 	*out = *in
@@ -198,6 +258,13 @@ func (in *ProviderInstructionList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderInstructionSpec) DeepCopyInto(out *ProviderInstructionSpec) {
 	*out = *in
+	if in.RequestedExtended != nil {
+		in, out := &in.RequestedExtended, &out.RequestedExtended
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.ExpiresAt != nil {
 		in, out := &in.ExpiresAt, &out.ExpiresAt
 		*out = (*in).DeepCopy()
@@ -292,6 +359,13 @@ func (in *ReservationInstructionList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReservationInstructionSpec) DeepCopyInto(out *ReservationInstructionSpec) {
 	*out = *in
+	if in.RequestedExtended != nil {
+		in, out := &in.RequestedExtended, &out.RequestedExtended
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.ExpiresAt != nil {
 		in, out := &in.ExpiresAt, &out.ExpiresAt
 		*out = (*in).DeepCopy()
@@ -324,6 +398,48 @@ func (in *ReservationInstructionStatus) DeepCopy() *ReservationInstructionStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationPart) DeepCopyInto(out *ReservationPart) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationPart.
+func (in *ReservationPart) DeepCopy() *ReservationPart {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationPart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceBlock) DeepCopyInto(out *ResourceBlock) {
+	*out = *in
+	if in.RequestedExtended != nil {
+		in, out := &in.RequestedExtended, &out.RequestedExtended
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementConstraints)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceBlock.
+func (in *ResourceBlock) DeepCopy() *ResourceBlock {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceBlock)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMetrics) DeepCopyInto(out *ResourceMetrics) {
 	*out = *in
@@ -363,6 +479,13 @@ func (in *ResourceQuantities) DeepCopyInto(out *ResourceQuantities) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Extended != nil {
+		in, out := &in.Extended, &out.Extended
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuantities.
@@ -380,7 +503,7 @@ func (in *ResourceRequest) DeepCopyInto(out *ResourceRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -437,6 +560,29 @@ func (in *ResourceRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequestSpec) DeepCopyInto(out *ResourceRequestSpec) {
 	*out = *in
+	if in.RequestedExtended != nil {
+		in, out := &in.RequestedExtended, &out.RequestedExtended
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementConstraints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GangMembers != nil {
+		in, out := &in.GangMembers, &out.GangMembers
+		*out = make([]ResourceBlock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequestSpec.
@@ -452,6 +598,11 @@ func (in *ResourceRequestSpec) DeepCopy() *ResourceRequestSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequestStatus) DeepCopyInto(out *ResourceRequestStatus) {
 	*out = *in
+	if in.Parts != nil {
+		in, out := &in.Parts, &out.Parts
+		*out = make([]ReservationPart, len(*in))
+		copy(*out, *in)
+	}
 	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 }
 
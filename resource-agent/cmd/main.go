@@ -22,17 +22,21 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
-	"github.com/mehdiazizian/liqo-resource-agent/internal/publisher" // ← Add this
 	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
-	transporthttp "github.com/mehdiazizian/liqo-resource-agent/internal/transport/http" // Used by NewCommunicator
+	transportgrpc "github.com/mehdiazizian/liqo-resource-agent/internal/transport/grpc"             // Used by NewCommunicator
+	transporthttp "github.com/mehdiazizian/liqo-resource-agent/internal/transport/http"             // Used by NewCommunicator
+	transportkubernetes "github.com/mehdiazizian/liqo-resource-agent/internal/transport/kubernetes" // Used by NewCommunicator
+	transportmqtt "github.com/mehdiazizian/liqo-resource-agent/internal/transport/mqtt"             // Used by NewCommunicator
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -85,7 +89,16 @@ func main() {
 	var brokerNamespace string
 	var advertisementRequeueInterval time.Duration
 	var instructionPollInterval time.Duration
+	var watchInstructions bool
 	var kubeconfigsDir string
+	var autoRenewBefore time.Duration
+	var heartbeatInterval time.Duration
+	var acceptedRequesters string
+	var cpuCost string
+	var memoryCost string
+	var costCurrency string
+	var extendedResources string
+	var clusterLabels string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -104,17 +117,37 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
-	flag.StringVar(&brokerKubeconfig, "broker-kubeconfig", "", "Path to kubeconfig for broker cluster (optional)") // ← Add this line
-	flag.StringVar(&brokerTransport, "broker-transport", "", "Transport protocol for broker communication (http|kubernetes, empty disables broker)")
-	flag.StringVar(&brokerURL, "broker-url", "", "Broker URL for HTTP transport (e.g., https://broker.example.com:8443)")
-	flag.StringVar(&brokerCertPath, "broker-cert-path", "", "Client certificate path for HTTP transport")
+	flag.StringVar(&brokerKubeconfig, "broker-kubeconfig", "", "Path to kubeconfig for the broker cluster, used by the Kubernetes transport")
+	flag.StringVar(&brokerTransport, "broker-transport", "", "Transport protocol for broker communication (http|grpc|mqtt|kubernetes, empty disables broker)")
+	flag.StringVar(&brokerURL, "broker-url", "",
+		"Broker URL for HTTP transport (e.g., https://broker.example.com:8443), "+
+			"host:port for gRPC transport (e.g., broker.example.com:9443), "+
+			"or MQTT server URL for MQTT transport (e.g., tls://broker.example.com:8883)")
+	flag.StringVar(&brokerCertPath, "broker-cert-path", "", "Client certificate path for HTTP, gRPC and MQTT transports")
 	flag.StringVar(&clusterIDFlag, "cluster-id", "", "Optional override for the agent cluster ID")
 	flag.StringVar(&advertisementName, "advertisement-name", "cluster-advertisement", "Advertisement resource name")
 	flag.StringVar(&advertisementNamespace, "advertisement-namespace", "default", "Advertisement namespace")
 	flag.StringVar(&instructionNamespace, "instruction-namespace", "", "Namespace for ReservationInstruction objects (defaults to advertisement namespace)")
-	flag.StringVar(&brokerNamespace, "broker-namespace", "default", "Namespace containing broker CRDs")
+	flag.StringVar(&brokerNamespace, "broker-namespace", "default", "Namespace containing broker CRDs, used by the Kubernetes transport")
 	flag.DurationVar(&advertisementRequeueInterval, "advertisement-requeue-interval", 30*time.Second, "Interval for periodic advertisement updates")
 	flag.DurationVar(&instructionPollInterval, "instruction-poll-interval", 5*time.Second, "Interval for polling broker for provider instructions (0 to disable)")
+	flag.BoolVar(&watchInstructions, "watch-instructions", true,
+		"Stream provider instructions from the broker as they are issued; polling only runs while the stream is down")
+	flag.StringVar(&cpuCost, "cpu-cost", "", "Advertised price per CPU core per hour (e.g., 0.03), used by the broker's LowestCost scoring")
+	flag.StringVar(&memoryCost, "memory-cost", "", "Advertised price per GB of memory per hour (e.g., 0.004)")
+	flag.StringVar(&costCurrency, "cost-currency", "", "Currency of the advertised prices (e.g., EUR)")
+	flag.StringVar(&extendedResources, "extended-resources", "",
+		"Comma-separated extended resource names to advertise (e.g., amd.com/gpu,hugepages-2Mi,ephemeral-storage)")
+	flag.StringVar(&clusterLabels, "cluster-labels", "",
+		"Comma-separated key=value labels advertised for broker placement constraints (e.g., topology.kubernetes.io/region=eu-west-1)")
+	flag.DurationVar(&autoRenewBefore, "auto-renew-before", 0,
+		"Renew reservations this long before they expire while workloads still run on the provider's "+
+			"Liqo virtual node (0 disables auto-renewal)")
+	flag.DurationVar(&heartbeatInterval, "heartbeat-interval", time.Minute,
+		"Interval for telling the broker that activated reservations are still in use (0 to disable)")
+	flag.StringVar(&acceptedRequesters, "accepted-requesters", "",
+		"Comma-separated requester cluster IDs this provider holds capacity for; "+
+			"instructions from other requesters are rejected (default: accept all)")
 	flag.StringVar(&kubeconfigsDir, "kubeconfigs-dir", "", "Directory containing kubeconfig files for Liqo peering (enables automatic peering)")
 
 	opts := zap.Options{
@@ -250,7 +283,7 @@ func main() {
 	// AGENT TRANSPORT SELECTION
 	// =============================================================================
 	// The agent uses ONE transport protocol to communicate with the broker,
-	// selected by --broker-transport flag. Must match broker's --broker-interface.
+	// selected by --broker-transport flag. The broker must have its interface enabled.
 	//
 	// HINT: To enable MULTIPLE transports simultaneously (e.g., failover scenarios),
 	// create multiple communicators and use them based on availability:
@@ -268,7 +301,6 @@ func main() {
 	//
 	// =============================================================================
 
-	var brokerClient *publisher.BrokerClient
 	var brokerCommunicator transport.BrokerCommunicator
 
 	// Support legacy kubeconfig flag (maps to kubernetes transport)
@@ -281,51 +313,56 @@ func main() {
 			"transport", brokerTransport,
 			"clusterID", clusterID)
 
-		switch brokerTransport {
-		case "http":
-			var err error
-			brokerCommunicator, err = NewCommunicator(
-				brokerTransport,
-				brokerURL,
-				brokerKubeconfig,
-				brokerCertPath,
-				clusterID,
-			)
-			if err != nil {
-				setupLog.Error(err, "failed to create HTTP broker communicator")
-				os.Exit(1)
-			}
-			setupLog.Info("HTTP broker communicator initialized successfully",
-				"brokerURL", brokerURL)
-
-		case "kubernetes":
-			// Legacy Kubernetes transport using BrokerClient
-			var err error
-			brokerClient, err = publisher.NewBrokerClient(brokerKubeconfig, clusterID, brokerNamespace)
-			if err != nil {
-				setupLog.Error(err, "failed to create broker client")
-				os.Exit(1)
-			}
-			setupLog.Info("Kubernetes broker client initialized successfully")
-
-		default:
-			setupLog.Error(nil, "Unknown broker transport", "transport", brokerTransport)
+		var err error
+		brokerCommunicator, err = NewCommunicator(
+			brokerTransport,
+			brokerURL,
+			brokerKubeconfig,
+			brokerNamespace,
+			brokerCertPath,
+			clusterID,
+		)
+		if err != nil {
+			setupLog.Error(err, "failed to create broker communicator", "transport", brokerTransport)
 			os.Exit(1)
 		}
+		setupLog.Info("Broker communicator initialized successfully",
+			"transport", brokerTransport,
+			"brokerURL", brokerURL)
 	} else {
 		setupLog.Info("Broker transport not specified, broker communication disabled")
 	}
 
+	advertisedLabels, err := labels.ConvertSelectorToLabelsMap(clusterLabels)
+	if err != nil {
+		setupLog.Error(err, "invalid --cluster-labels")
+		os.Exit(1)
+	}
+
+	var advertisedCost *rearv1alpha1.CostInfo
+	if cpuCost != "" || memoryCost != "" {
+		advertisedCost = &rearv1alpha1.CostInfo{
+			CPUCost:    cpuCost,
+			MemoryCost: memoryCost,
+			Currency:   costCurrency,
+		}
+	}
+
+	metricsCollector := &metrics.Collector{
+		Client:            mgr.GetClient(),
+		ClusterIDOverride: clusterID,
+		ExtendedResources: parseList(extendedResources),
+	}
+
 	if err = (&controller.AdvertisementReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		MetricsCollector: &metrics.Collector{
-			ClusterIDOverride: clusterID,
-		},
-		BrokerClient:         brokerClient,         // Legacy Kubernetes transport
-		BrokerCommunicator:   brokerCommunicator,   // New transport abstraction (HTTP)
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		MetricsCollector:     metricsCollector,
+		BrokerCommunicator:   brokerCommunicator,
 		RequeueInterval:      advertisementRequeueInterval,
-		InstructionNamespace: instructionNamespace,  // For provider instructions from response
+		InstructionNamespace: instructionNamespace, // For provider instructions from response
+		Cost:                 advertisedCost,
+		Labels:               advertisedLabels,
 		TargetKey: types.NamespacedName{
 			Name:      advertisementName,
 			Namespace: advertisementNamespace,
@@ -335,19 +372,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Provider side: accept or reject instructions and report the answer to the broker
 	if err = (&controller.ProviderInstructionReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		BrokerCommunicator: brokerCommunicator,
+		MetricsCollector:   metricsCollector,
+		AcceptedRequesters: parseList(acceptedRequesters),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ProviderInstruction")
 		os.Exit(1)
 	}
 
 	if err = (&controller.ReservationInstructionReconciler{
-		Client:         mgr.GetClient(),
-		Scheme:         mgr.GetScheme(),
-		KubeconfigsDir: kubeconfigsDir,
-		ClusterID:      clusterID,
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		KubeconfigsDir:     kubeconfigsDir,
+		ClusterID:          clusterID,
+		BrokerCommunicator: brokerCommunicator,
+		AutoRenewBefore:    autoRenewBefore,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ReservationInstruction")
 		os.Exit(1)
@@ -364,31 +407,39 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Start instruction poller for near-instant provider instruction delivery (HTTP transport)
-	if brokerCommunicator != nil && instructionPollInterval > 0 {
+	// Start instruction poller for near-instant provider instruction delivery
+	if brokerCommunicator != nil && (instructionPollInterval > 0 || watchInstructions) {
 		poller := &controller.InstructionPoller{
 			Client:               mgr.GetClient(),
 			BrokerCommunicator:   brokerCommunicator,
 			PollInterval:         instructionPollInterval,
 			InstructionNamespace: instructionNamespace,
+			Watch:                watchInstructions,
 		}
 		go func() {
 			if err := poller.Start(context.Background()); err != nil {
 				setupLog.Error(err, "Instruction poller failed")
 			}
 		}()
-		setupLog.Info("Instruction poller started", "interval", instructionPollInterval)
+		setupLog.Info("Instruction poller started", "interval", instructionPollInterval, "watch", watchInstructions)
 	}
 
-	// Start Reservation Watcher if broker client is available (Kubernetes transport)
-	if brokerClient != nil && brokerClient.Enabled {
-		watcher := publisher.NewReservationWatcher(brokerClient, mgr.GetClient(), instructionNamespace)
+	// Heartbeat activated reservations so the broker does not orphan them
+	if brokerCommunicator != nil && heartbeatInterval > 0 {
+		heartbeater := &controller.ReservationHeartbeater{
+			Client:               mgr.GetClient(),
+			BrokerCommunicator:   brokerCommunicator,
+			Interval:             heartbeatInterval,
+			InstructionNamespace: instructionNamespace,
+		}
 		go func() {
-			if err := watcher.Start(context.Background()); err != nil {
-				setupLog.Error(err, "Reservation watcher failed")
+			if err := heartbeater.Start(context.Background()); err != nil {
+				setupLog.Error(err, "Reservation heartbeats failed")
 			}
 		}()
+		setupLog.Info("Reservation heartbeats started", "interval", heartbeatInterval)
 	}
+
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -457,6 +508,7 @@ func NewCommunicator(
 	transportType string,
 	brokerURL string,
 	brokerKubeconfig string,
+	brokerNamespace string,
 	certPath string,
 	clusterID string,
 ) (transport.BrokerCommunicator, error) {
@@ -470,10 +522,42 @@ func NewCommunicator(
 		}
 		return transporthttp.NewHTTPCommunicator(brokerURL, certPath, clusterID)
 
+	case "grpc":
+		if brokerURL == "" {
+			return nil, fmt.Errorf("broker-url is required for gRPC transport")
+		}
+		if certPath == "" {
+			return nil, fmt.Errorf("broker-cert-path is required for gRPC transport")
+		}
+		return transportgrpc.NewGRPCCommunicator(brokerURL, certPath, clusterID)
+
+	case "mqtt":
+		if brokerURL == "" {
+			return nil, fmt.Errorf("broker-url is required for MQTT transport")
+		}
+		if certPath == "" {
+			return nil, fmt.Errorf("broker-cert-path is required for MQTT transport")
+		}
+		return transportmqtt.NewMQTTCommunicator(brokerURL, certPath, clusterID)
+
 	case "kubernetes":
-		return nil, fmt.Errorf("kubernetes transport not yet implemented (coming in future iteration)")
+		if brokerKubeconfig == "" {
+			return nil, fmt.Errorf("broker-kubeconfig is required for Kubernetes transport")
+		}
+		return transportkubernetes.NewKubernetesCommunicator(brokerKubeconfig, brokerNamespace, clusterID)
 
 	default:
-		return nil, fmt.Errorf("unknown transport type: %s (supported: http, kubernetes)", transportType)
+		return nil, fmt.Errorf("unknown transport type: %s (supported: http, grpc, mqtt, kubernetes)", transportType)
+	}
+}
+
+// parseList splits a comma-separated flag value, dropping blanks
+func parseList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
 	}
+	return names
 }
@@ -33,7 +33,6 @@ import (
 
 	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
 	"github.com/mehdiazizian/liqo-resource-agent/internal/metrics"
-	"github.com/mehdiazizian/liqo-resource-agent/internal/publisher" // ← Add this line
 	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
 	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,11 +43,12 @@ type AdvertisementReconciler struct {
 	client.Client
 	Scheme               *runtime.Scheme
 	MetricsCollector     *metrics.Collector
-	BrokerClient         *publisher.BrokerClient      // Legacy Kubernetes transport
-	BrokerCommunicator   transport.BrokerCommunicator // New transport abstraction
+	BrokerCommunicator   transport.BrokerCommunicator // nil keeps the advertisement local
 	TargetKey            types.NamespacedName
-	RequeueInterval      time.Duration // Configurable requeue interval
-	InstructionNamespace string        // Namespace for ProviderInstruction CRDs
+	RequeueInterval      time.Duration          // Configurable requeue interval
+	InstructionNamespace string                 // Namespace for ProviderInstruction CRDs
+	Cost                 *rearv1alpha1.CostInfo // Pricing published to the broker (nil keeps spec value)
+	Labels               map[string]string      // Cluster labels for broker placement (nil keeps spec value)
 }
 
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=advertisements,verbs=get;list;watch;create;update;patch;delete
@@ -98,6 +98,12 @@ func (r *AdvertisementReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	advertisement.Spec.ClusterID = clusterID
 	advertisement.Spec.Resources = *resourceData
 	advertisement.Spec.Timestamp = metav1.Now()
+	if r.Cost != nil {
+		advertisement.Spec.Cost = r.Cost.DeepCopy()
+	}
+	if r.Labels != nil {
+		advertisement.Spec.Labels = r.Labels
+	}
 
 	// Update the Advertisement resource
 	if err := r.Update(ctx, advertisement); err != nil {
@@ -136,28 +142,20 @@ func (r *AdvertisementReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 func (r *AdvertisementReconciler) publishToBroker(ctx context.Context, advertisement *rearv1alpha1.Advertisement, clusterID string) error {
 	logger := log.FromContext(ctx)
 
-	if r.BrokerCommunicator != nil {
-		advDTO := dto.ToAdvertisementDTO(advertisement)
-		providerInstructions, err := r.BrokerCommunicator.PublishAdvertisement(ctx, advDTO)
-		if err != nil {
-			logger.Error(err, fmt.Sprintf("Failed to publish to broker (will retry)\n  Cluster: %s", clusterID))
-			return err
-		}
-		logger.Info(fmt.Sprintf("Published to broker successfully (via transport abstraction)\n  Cluster: %s", clusterID))
-
-		// Process piggybacked provider instructions
-		r.processProviderInstructions(ctx, providerInstructions, clusterID)
+	if r.BrokerCommunicator == nil {
 		return nil
 	}
 
-	if r.BrokerClient != nil && r.BrokerClient.Enabled {
-		if err := r.BrokerClient.PublishAdvertisement(ctx, advertisement); err != nil {
-			logger.Error(err, fmt.Sprintf("Failed to publish to broker (will retry)\n  Cluster: %s", clusterID))
-			return err
-		}
-		logger.Info(fmt.Sprintf("Published to broker successfully (via legacy client)\n  Cluster: %s", clusterID))
+	advDTO := dto.ToAdvertisementDTO(advertisement)
+	providerInstructions, err := r.BrokerCommunicator.PublishAdvertisement(ctx, advDTO)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to publish to broker (will retry)\n  Cluster: %s", clusterID))
+		return err
 	}
+	logger.Info(fmt.Sprintf("Published to broker successfully\n  Cluster: %s", clusterID))
 
+	// Process piggybacked provider instructions
+	r.processProviderInstructions(ctx, providerInstructions, clusterID)
 	return nil
 }
 
@@ -200,9 +198,10 @@ func (r *AdvertisementReconciler) processProviderInstructions(ctx context.Contex
 				RequesterClusterID: rsv.RequesterID,
 				RequestedCPU:       rsv.RequestedResources.CPU,
 				RequestedMemory:    rsv.RequestedResources.Memory,
-				Message: fmt.Sprintf("Hold %s CPU / %s Memory for requester %s",
-					rsv.RequestedResources.CPU,
-					rsv.RequestedResources.Memory,
+				RequestedGPU:       rsv.RequestedResources.GPU,
+				RequestedExtended:  rsv.RequestedResources.Extended,
+				Message: fmt.Sprintf("Hold %s for requester %s",
+					dto.DescribeResources(rsv.RequestedResources),
 					rsv.RequesterID),
 				ExpiresAt: expiresAt,
 			},
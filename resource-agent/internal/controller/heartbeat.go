@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
+)
+
+// ReservationHeartbeater tells the broker at a fixed interval that this
+// cluster still uses its activated reservations. A broker with a heartbeat
+// timeout orphans Active reservations whose requester went silent and
+// releases them after a grace period.
+type ReservationHeartbeater struct {
+	Client               client.Client
+	BrokerCommunicator   transport.BrokerCommunicator
+	Interval             time.Duration
+	InstructionNamespace string
+}
+
+// Start runs the heartbeat loop until the context is cancelled.
+func (h *ReservationHeartbeater) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("reservation-heartbeat")
+	logger.Info("Starting reservation heartbeats", "interval", h.Interval)
+
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Reservation heartbeats stopped")
+			return nil
+		case <-ticker.C:
+			h.sendHeartbeats(ctx)
+		}
+	}
+}
+
+// sendHeartbeats sends one heartbeat per activated, unexpired reservation
+func (h *ReservationHeartbeater) sendHeartbeats(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("reservation-heartbeat")
+
+	instructions := &rearv1alpha1.ReservationInstructionList{}
+	if err := h.Client.List(ctx, instructions, client.InNamespace(h.InstructionNamespace)); err != nil {
+		logger.Error(err, "Failed to list reservation instructions")
+		return
+	}
+
+	now := time.Now()
+	for i := range instructions.Items {
+		instruction := &instructions.Items[i]
+		if !instruction.Status.Activated ||
+			(instruction.Spec.ExpiresAt != nil && instruction.Spec.ExpiresAt.Time.Before(now)) {
+			continue
+		}
+
+		if err := h.BrokerCommunicator.HeartbeatReservation(ctx, instruction.Spec.ReservationName); err != nil {
+			logger.Error(err, "Failed to send heartbeat",
+				"reservation", instruction.Spec.ReservationName,
+				"targetCluster", instruction.Spec.TargetClusterID)
+		}
+	}
+}
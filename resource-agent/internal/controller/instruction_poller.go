@@ -3,6 +3,9 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,28 +20,50 @@ import (
 
 // InstructionPoller polls the broker for provider instructions at a configurable interval.
 // This provides near-instant instruction delivery instead of waiting for the next
-// advertisement cycle (30s). The poller creates ProviderInstruction CRDs locally.
+// advertisement cycle (30s). The poller creates ProviderInstruction CRDs locally
+// and withdraws local instructions for reservations the broker preempted, the
+// requester released or the provider rejected.
+// With Watch, instructions are streamed from the broker as they are issued
+// and polling only runs while the stream is down.
 type InstructionPoller struct {
 	Client               client.Client
 	BrokerCommunicator   transport.BrokerCommunicator
-	PollInterval         time.Duration
+	PollInterval         time.Duration // 0 disables polling
 	InstructionNamespace string
+	Watch                bool
+
+	streaming atomic.Bool
+	mu        sync.Mutex // Serializes processing of streamed and polled instructions
 }
 
+// maxWatchBackoff caps the wait between attempts to reopen the instruction stream
+const maxWatchBackoff = 1 * time.Minute
+
 // Start runs the instruction polling loop until the context is cancelled.
 func (p *InstructionPoller) Start(ctx context.Context) error {
 	logger := log.FromContext(ctx).WithName("instruction-poller")
-	logger.Info("Starting instruction poller", "interval", p.PollInterval)
+	logger.Info("Starting instruction poller", "interval", p.PollInterval, "watch", p.Watch)
 
-	ticker := time.NewTicker(p.PollInterval)
-	defer ticker.Stop()
+	if p.Watch {
+		go p.watch(ctx)
+	}
+
+	var ticks <-chan time.Time
+	if p.PollInterval > 0 {
+		ticker := time.NewTicker(p.PollInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Info("Instruction poller stopped")
 			return nil
-		case <-ticker.C:
+		case <-ticks:
+			if p.streaming.Load() {
+				continue
+			}
 			instructions, err := p.BrokerCommunicator.FetchInstructions(ctx)
 			if err != nil {
 				logger.V(1).Info("Failed to fetch instructions", "error", err)
@@ -51,28 +76,108 @@ func (p *InstructionPoller) Start(ctx context.Context) error {
 	}
 }
 
+// watch follows the broker's instruction stream until the context is
+// cancelled, reopening it with its resume token whenever it ends
+func (p *InstructionPoller) watch(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("instruction-poller")
+
+	var resumeToken string
+	backoff := time.Second
+	for {
+		opened := time.Now()
+		p.streaming.Store(true)
+		token, err := p.BrokerCommunicator.WatchInstructions(ctx, resumeToken, func(instruction *dto.ReservationDTO) {
+			p.processInstructions(ctx, []*dto.ReservationDTO{instruction})
+		})
+		p.streaming.Store(false)
+		resumeToken = token
+
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(opened) > maxWatchBackoff {
+			backoff = time.Second
+		}
+		logger.V(1).Info("Instruction stream ended, polling until it is reopened",
+			"error", err,
+			"retryIn", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxWatchBackoff)
+	}
+}
+
 // processInstructions creates ProviderInstruction CRDs from fetched instructions.
 func (p *InstructionPoller) processInstructions(ctx context.Context, instructions []*dto.ReservationDTO) {
 	logger := log.FromContext(ctx).WithName("instruction-poller")
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	for _, rsv := range instructions {
-		if rsv.Status.Phase != "Reserved" {
+		if rsv.Status.Phase == "Preempted" || rsv.Status.Phase == "Failed" {
+			if err := p.handleEndedReservation(ctx, rsv); err != nil {
+				logger.Error(err, "Failed to handle ended reservation",
+					"reservation", rsv.ID,
+					"phase", rsv.Status.Phase)
+			}
+			continue
+		}
+
+		if rsv.Status.Phase == "Released" {
+			if err := p.withdrawProviderInstruction(ctx, rsv, "released"); err != nil {
+				logger.Error(err, "Failed to handle released reservation", "reservation", rsv.ID)
+			}
+			continue
+		}
+
+		if rsv.Status.Phase != "Reserved" && rsv.Status.Phase != "Active" {
 			continue
 		}
 
 		instructionName := fmt.Sprintf("%s-provider", rsv.ID)
 
-		// Check if instruction already exists
+		var expiresAt *metav1.Time
+		if rsv.Status.ExpiresAt != nil {
+			expiresAt = &metav1.Time{Time: *rsv.Status.ExpiresAt}
+		}
+
+		// Check if instruction already exists; follow renewals and resizes
 		existing := &rearv1alpha1.ProviderInstruction{}
 		if err := p.Client.Get(ctx, types.NamespacedName{
 			Name: instructionName, Namespace: p.InstructionNamespace,
 		}, existing); err == nil {
-			continue // Already exists
-		}
+			renewed := expiresAt != nil && (existing.Spec.ExpiresAt == nil || !existing.Spec.ExpiresAt.Equal(expiresAt))
+			resized := existing.Spec.RequestedCPU != rsv.RequestedResources.CPU ||
+				existing.Spec.RequestedMemory != rsv.RequestedResources.Memory
+			if !renewed && !resized {
+				continue
+			}
 
-		var expiresAt *metav1.Time
-		if rsv.Status.ExpiresAt != nil {
-			expiresAt = &metav1.Time{Time: *rsv.Status.ExpiresAt}
+			if renewed {
+				existing.Spec.ExpiresAt = expiresAt
+			}
+			if resized {
+				existing.Spec.RequestedCPU = rsv.RequestedResources.CPU
+				existing.Spec.RequestedMemory = rsv.RequestedResources.Memory
+				existing.Spec.Message = fmt.Sprintf("Hold %s for requester %s",
+					dto.DescribeResources(rsv.RequestedResources),
+					rsv.RequesterID)
+			}
+			if err := p.Client.Update(ctx, existing); err != nil {
+				logger.Error(err, "Failed to update provider instruction", "reservation", rsv.ID)
+			} else {
+				logger.Info("Provider instruction updated",
+					"reservation", rsv.ID,
+					"expiresAt", existing.Spec.ExpiresAt,
+					"cpu", existing.Spec.RequestedCPU,
+					"memory", existing.Spec.RequestedMemory)
+			}
+			continue
 		}
 
 		instruction := &rearv1alpha1.ProviderInstruction{
@@ -85,9 +190,10 @@ func (p *InstructionPoller) processInstructions(ctx context.Context, instruction
 				RequesterClusterID: rsv.RequesterID,
 				RequestedCPU:       rsv.RequestedResources.CPU,
 				RequestedMemory:    rsv.RequestedResources.Memory,
-				Message: fmt.Sprintf("Hold %s CPU / %s Memory for requester %s",
-					rsv.RequestedResources.CPU,
-					rsv.RequestedResources.Memory,
+				RequestedGPU:       rsv.RequestedResources.GPU,
+				RequestedExtended:  rsv.RequestedResources.Extended,
+				Message: fmt.Sprintf("Hold %s for requester %s",
+					dto.DescribeResources(rsv.RequestedResources),
 					rsv.RequesterID),
 				ExpiresAt: expiresAt,
 			},
@@ -106,3 +212,85 @@ func (p *InstructionPoller) processInstructions(ctx context.Context, instruction
 		}
 	}
 }
+
+// +kubebuilder:rbac:groups=rear.fluidos.eu,resources=providerinstructions,verbs=delete;update
+// +kubebuilder:rbac:groups=rear.fluidos.eu,resources=reservationinstructions,verbs=delete
+
+// handleEndedReservation withdraws the local instructions of a reservation
+// that was preempted, or that failed because its provider rejected it.
+// On the provider this stops counting the capacity as reserved; on the requester
+// the ResourceRequest takes the reservation's phase with the broker's reason.
+// Notices are repeated on every poll, so this must be idempotent.
+func (p *InstructionPoller) handleEndedReservation(ctx context.Context, rsv *dto.ReservationDTO) error {
+	logger := log.FromContext(ctx).WithName("instruction-poller")
+	phase := rsv.Status.Phase
+
+	if err := p.withdrawProviderInstruction(ctx, rsv, strings.ToLower(phase)); err != nil {
+		return err
+	}
+
+	reservationInstruction := &rearv1alpha1.ReservationInstruction{
+		ObjectMeta: metav1.ObjectMeta{Name: rsv.ID, Namespace: p.InstructionNamespace},
+	}
+	if err := p.Client.Delete(ctx, reservationInstruction); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	requests := &rearv1alpha1.ResourceRequestList{}
+	if err := p.Client.List(ctx, requests); err != nil {
+		return err
+	}
+	for i := range requests.Items {
+		resourceReq := &requests.Items[i]
+		if !holdsReservation(resourceReq, rsv.ID) || resourceReq.Status.Phase == phase {
+			continue
+		}
+
+		resourceReq.Status.Phase = phase
+		resourceReq.Status.Message = rsv.Status.Message
+		resourceReq.Status.LastUpdateTime = metav1.Now()
+		if err := p.Client.Status().Update(ctx, resourceReq); err != nil {
+			return err
+		}
+		logger.Info("ResourceRequest ended by broker",
+			"resourceRequest", resourceReq.Name,
+			"reservation", rsv.ID,
+			"phase", phase,
+			"reason", rsv.Status.Message)
+	}
+
+	return nil
+}
+
+// withdrawProviderInstruction deletes the local ProviderInstruction of a
+// reservation that ended at the broker, if this cluster had one
+func (p *InstructionPoller) withdrawProviderInstruction(ctx context.Context, rsv *dto.ReservationDTO, reason string) error {
+	logger := log.FromContext(ctx).WithName("instruction-poller")
+
+	providerInstruction := &rearv1alpha1.ProviderInstruction{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-provider", rsv.ID), Namespace: p.InstructionNamespace},
+	}
+	if err := p.Client.Delete(ctx, providerInstruction); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		logger.Info("Withdrew provider instruction",
+			"reservation", rsv.ID,
+			"requester", rsv.RequesterID,
+			"reason", reason)
+	}
+	return nil
+}
+
+// holdsReservation reports whether the ResourceRequest was placed by the given
+// broker reservation, either directly or as one part of a split request
+func holdsReservation(resourceReq *rearv1alpha1.ResourceRequest, reservationID string) bool {
+	if resourceReq.Status.ReservationName == reservationID {
+		return true
+	}
+	for _, part := range resourceReq.Status.Parts {
+		if part.ReservationName == reservationID {
+			return true
+		}
+	}
+	return false
+}
@@ -3,9 +3,11 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -13,12 +15,27 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/metrics"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
 )
 
-// ProviderInstructionReconciler acknowledges provider instructions.
+// ProviderInstructionReconciler accepts or rejects provider instructions and
+// reports the answer to the broker. An instruction is rejected when its
+// requester is not allowed by local policy, when the cluster no longer has the
+// capacity, or at any time when an operator sets the reject annotation.
 type ProviderInstructionReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// BrokerCommunicator reports acceptance and rejection to the broker. Optional.
+	BrokerCommunicator transport.BrokerCommunicator
+
+	// MetricsCollector checks that new instructions still fit the cluster. Optional.
+	MetricsCollector *metrics.Collector
+
+	// AcceptedRequesters limits the requester clusters this provider holds
+	// capacity for. Empty accepts every requester.
+	AcceptedRequesters []string
 }
 
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=providerinstructions,verbs=get;list;watch;update;patch
@@ -56,8 +73,53 @@ func (r *ProviderInstructionReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, nil
 	}
 
+	if instruction.Status.Rejected {
+		if !instruction.Status.Acknowledged && r.BrokerCommunicator != nil {
+			return r.reportAnswer(ctx, instruction)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Operators may veto an instruction at any time; new ones are also checked
+	// against local policy and capacity
+	reason, vetoed := instruction.Annotations[rearv1alpha1.ProviderInstructionRejectAnnotation]
+	if vetoed && reason == "" {
+		reason = "rejected by provider operator"
+	}
+	if !vetoed && !instruction.Status.Enforced {
+		var err error
+		if reason, err = r.rejectionReason(ctx, instruction); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if reason != "" {
+		logger.Info("rejecting provider instruction",
+			"instruction", instruction.Name,
+			"reservation", instruction.Spec.ReservationName,
+			"requester", instruction.Spec.RequesterClusterID,
+			"reason", reason)
+
+		instruction.Status.Enforced = false
+		instruction.Status.Rejected = true
+		instruction.Status.RejectionReason = reason
+		instruction.Status.Acknowledged = false
+		instruction.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, instruction); err != nil {
+			logger.Error(err, "failed to reject provider instruction")
+			return ctrl.Result{}, err
+		}
+		if r.BrokerCommunicator != nil {
+			return r.reportAnswer(ctx, instruction)
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// If already enforced, just requeue to check expiration later
 	if instruction.Status.Enforced {
+		// A failed acceptance report is retried
+		if !instruction.Status.Acknowledged && r.BrokerCommunicator != nil {
+			return r.reportAnswer(ctx, instruction)
+		}
 		// Requeue before expiration to mark it as expired promptly
 		if instruction.Spec.ExpiresAt != nil {
 			timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time)
@@ -91,6 +153,11 @@ func (r *ProviderInstructionReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	// Tell the broker the hold is real
+	if r.BrokerCommunicator != nil {
+		return r.reportAnswer(ctx, instruction)
+	}
+
 	// Requeue to check for expiration
 	if instruction.Spec.ExpiresAt != nil {
 		timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time)
@@ -104,6 +171,71 @@ func (r *ProviderInstructionReconciler) Reconcile(ctx context.Context, req ctrl.
 	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
+// rejectionReason returns why a new instruction cannot be held, or "" if it can
+func (r *ProviderInstructionReconciler) rejectionReason(
+	ctx context.Context,
+	instruction *rearv1alpha1.ProviderInstruction,
+) (string, error) {
+	if len(r.AcceptedRequesters) > 0 && !slices.Contains(r.AcceptedRequesters, instruction.Spec.RequesterClusterID) {
+		return fmt.Sprintf("local policy: requester %s is not accepted", instruction.Spec.RequesterClusterID), nil
+	}
+
+	if r.MetricsCollector == nil {
+		return "", nil
+	}
+	// Available already excludes enforced instructions, but not this one
+	resources, err := r.MetricsCollector.CollectClusterResources(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to collect cluster resources: %w", err)
+	}
+	cpu, err := resource.ParseQuantity(instruction.Spec.RequestedCPU)
+	if err != nil {
+		return fmt.Sprintf("invalid CPU quantity %q", instruction.Spec.RequestedCPU), nil
+	}
+	memory, err := resource.ParseQuantity(instruction.Spec.RequestedMemory)
+	if err != nil {
+		return fmt.Sprintf("invalid memory quantity %q", instruction.Spec.RequestedMemory), nil
+	}
+	if resources.Available.CPU.Cmp(cpu) < 0 || resources.Available.Memory.Cmp(memory) < 0 {
+		return fmt.Sprintf("capacity changed: %s CPU and %s memory available",
+			resources.Available.CPU.String(), resources.Available.Memory.String()), nil
+	}
+	return "", nil
+}
+
+// reportAnswer tells the broker whether this provider holds the instruction's
+// capacity and records that the answer was delivered
+func (r *ProviderInstructionReconciler) reportAnswer(
+	ctx context.Context,
+	instruction *rearv1alpha1.ProviderInstruction,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if err := r.BrokerCommunicator.AcknowledgeReservation(ctx, instruction.Spec.ReservationName,
+		!instruction.Status.Rejected, instruction.Status.RejectionReason); err != nil {
+		logger.Error(err, "failed to report answer to broker, will retry",
+			"reservation", instruction.Spec.ReservationName)
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	instruction.Status.Acknowledged = true
+	instruction.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, instruction); err != nil {
+		logger.Error(err, "failed to mark provider instruction as acknowledged")
+		return ctrl.Result{}, err
+	}
+
+	if instruction.Status.Rejected {
+		return ctrl.Result{}, nil
+	}
+	if instruction.Spec.ExpiresAt != nil {
+		if timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time); timeUntilExpiry > 0 {
+			return ctrl.Result{RequeueAfter: timeUntilExpiry}, nil
+		}
+	}
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
 func (r *ProviderInstructionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&rearv1alpha1.ProviderInstruction{}).
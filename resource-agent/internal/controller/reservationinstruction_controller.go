@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,9 +18,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
 )
 
 // ReservationInstructionReconciler processes reservation instructions from the broker.
+// Once an instruction is delivered (after Liqo peering, when configured), the
+// reservation is reported to the broker as in use so it becomes Active.
+// Optionally, reservations are renewed while workloads still use them.
 type ReservationInstructionReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
@@ -31,10 +36,21 @@ type ReservationInstructionReconciler struct {
 
 	// ClusterID is this agent's cluster identifier (needed to locate own kubeconfig).
 	ClusterID string
+
+	// BrokerCommunicator reports activation to the broker. Optional.
+	BrokerCommunicator transport.BrokerCommunicator
+
+	// AutoRenewBefore renews reservations this long before they expire while
+	// workloads still run on the provider's virtual node. 0 disables auto-renewal.
+	AutoRenewBefore time.Duration
 }
 
+// liqoRemoteClusterIDLabel identifies the Liqo virtual node of a peered cluster
+const liqoRemoteClusterIDLabel = "liqo.io/remote-cluster-id"
+
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=reservationinstructions,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=reservationinstructions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 
 func (r *ReservationInstructionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -73,14 +89,15 @@ func (r *ReservationInstructionReconciler) Reconcile(ctx context.Context, req ct
 
 	// If already delivered, just requeue to check expiration later
 	if instruction.Status.Delivered {
-		// Requeue before expiration to mark it as expired promptly
-		if instruction.Spec.ExpiresAt != nil {
-			timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time)
-			if timeUntilExpiry > 0 {
-				return ctrl.Result{RequeueAfter: timeUntilExpiry}, nil
-			}
+		// A failed activation report is retried
+		if !instruction.Status.Activated && r.BrokerCommunicator != nil {
+			return r.reportActivation(ctx, instruction)
+		}
+		if r.renewalDue(instruction) {
+			return r.autoRenew(ctx, instruction)
 		}
-		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		// Requeue before expiration to mark it as expired promptly
+		return r.requeueForExpiry(instruction), nil
 	}
 
 	// Process the instruction
@@ -124,17 +141,147 @@ func (r *ReservationInstructionReconciler) Reconcile(ctx context.Context, req ct
 		return ctrl.Result{}, err
 	}
 
+	// Tell the broker the reservation is now in use
+	if r.BrokerCommunicator != nil {
+		return r.reportActivation(ctx, instruction)
+	}
+
 	// Requeue to check for expiration
+	result := r.requeueForExpiry(instruction)
 	if instruction.Spec.ExpiresAt != nil {
-		timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time)
-		if timeUntilExpiry > 0 {
-			logger.Info("reservation instruction delivered, will requeue to check expiration",
-				"timeUntilExpiry", timeUntilExpiry)
-			return ctrl.Result{RequeueAfter: timeUntilExpiry}, nil
+		logger.Info("reservation instruction delivered, will requeue to check expiration",
+			"requeueAfter", result.RequeueAfter)
+	}
+	return result, nil
+}
+
+// reportActivation tells the broker that the requester uses the reservation,
+// so it is promoted from Reserved to Active, and records it on the instruction
+func (r *ReservationInstructionReconciler) reportActivation(
+	ctx context.Context,
+	instruction *rearv1alpha1.ReservationInstruction,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if err := r.BrokerCommunicator.ActivateReservation(ctx, instruction.Spec.ReservationName); err != nil {
+		logger.Error(err, "failed to report activation to broker, will retry",
+			"reservation", instruction.Spec.ReservationName)
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	instruction.Status.Activated = true
+	instruction.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, instruction); err != nil {
+		logger.Error(err, "failed to mark reservation instruction as activated")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("reservation activated at broker",
+		"reservation", instruction.Spec.ReservationName,
+		"targetCluster", instruction.Spec.TargetClusterID)
+
+	return r.requeueForExpiry(instruction), nil
+}
+
+// renewalDue reports whether auto-renewal is enabled and the instruction's
+// reservation expires within the renewal window
+func (r *ReservationInstructionReconciler) renewalDue(instruction *rearv1alpha1.ReservationInstruction) bool {
+	return r.AutoRenewBefore > 0 && r.BrokerCommunicator != nil && instruction.Spec.ExpiresAt != nil &&
+		time.Until(instruction.Spec.ExpiresAt.Time) <= r.AutoRenewBefore
+}
+
+// requeueForExpiry requeues at the start of the renewal window, or at the
+// expiry when auto-renewal is off
+func (r *ReservationInstructionReconciler) requeueForExpiry(instruction *rearv1alpha1.ReservationInstruction) ctrl.Result {
+	if instruction.Spec.ExpiresAt == nil {
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}
+	}
+
+	timeUntilExpiry := time.Until(instruction.Spec.ExpiresAt.Time)
+	if r.AutoRenewBefore > 0 && r.BrokerCommunicator != nil {
+		if untilRenewal := timeUntilExpiry - r.AutoRenewBefore; untilRenewal > 0 {
+			return ctrl.Result{RequeueAfter: untilRenewal}
 		}
+		// Inside the window: check again soon
+		return ctrl.Result{RequeueAfter: min(timeUntilExpiry, time.Minute)}
+	}
+	if timeUntilExpiry > 0 {
+		return ctrl.Result{RequeueAfter: timeUntilExpiry}
+	}
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}
+}
+
+// autoRenew renews the reservation at the broker while workloads still run on
+// the provider's virtual node. Without workloads the reservation is left to
+// expire, unless workloads appear before it does.
+func (r *ReservationInstructionReconciler) autoRenew(
+	ctx context.Context,
+	instruction *rearv1alpha1.ReservationInstruction,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	running, err := r.workloadsRunningOn(ctx, instruction.Spec.TargetClusterID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !running {
+		logger.V(1).Info("no workloads on virtual node, not renewing reservation",
+			"reservation", instruction.Spec.ReservationName,
+			"targetCluster", instruction.Spec.TargetClusterID)
+		return r.requeueForExpiry(instruction), nil
 	}
 
-	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	reservation, err := r.BrokerCommunicator.RenewReservation(ctx, instruction.Spec.ReservationName, "")
+	if err != nil {
+		logger.Error(err, "failed to renew reservation, will retry",
+			"reservation", instruction.Spec.ReservationName)
+		return ctrl.Result{RequeueAfter: min(time.Until(instruction.Spec.ExpiresAt.Time), 30*time.Second)}, nil
+	}
+	if reservation.Status.ExpiresAt == nil {
+		return r.requeueForExpiry(instruction), nil
+	}
+
+	instruction.Spec.ExpiresAt = &metav1.Time{Time: *reservation.Status.ExpiresAt}
+	if err := r.Update(ctx, instruction); err != nil {
+		logger.Error(err, "failed to record renewed expiry")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("reservation renewed, workloads still running",
+		"reservation", instruction.Spec.ReservationName,
+		"targetCluster", instruction.Spec.TargetClusterID,
+		"expiresAt", instruction.Spec.ExpiresAt.Time)
+
+	return r.requeueForExpiry(instruction), nil
+}
+
+// workloadsRunningOn reports whether any unfinished pod is scheduled on the
+// Liqo virtual node of the given provider cluster
+func (r *ReservationInstructionReconciler) workloadsRunningOn(ctx context.Context, clusterID string) (bool, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes, client.MatchingLabels{liqoRemoteClusterIDLabel: clusterID}); err != nil {
+		return false, fmt.Errorf("failed to list virtual nodes: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return false, nil
+	}
+
+	virtualNodes := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		virtualNodes[node.Name] = true
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods); err != nil {
+		return false, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if virtualNodes[pod.Spec.NodeName] &&
+			pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // executeLiqoPeering runs liqoctl peer to establish Liqo peering with the target cluster.
@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -10,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
@@ -17,10 +20,17 @@ import (
 	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
 )
 
+// queuedPollInterval is how often a queued reservation is checked at the broker
+const queuedPollInterval = 15 * time.Second
+
 // ResourceRequestReconciler reconciles a ResourceRequest object.
 // When a user creates a ResourceRequest, this controller sends a synchronous
 // reservation request to the broker and creates a ReservationInstruction
-// from the response. No polling needed.
+// from the response. No polling needed, unless the request asked to be queued
+// and the broker had no capacity, or was scheduled for a future window: then
+// the reservation is followed until placed.
+// Changing the CPU or memory of a Reserved request resizes its reservation in
+// place. Deleting a ResourceRequest releases its reservation at the broker.
 type ResourceRequestReconciler struct {
 	client.Client
 	Scheme               *runtime.Scheme
@@ -30,7 +40,8 @@ type ResourceRequestReconciler struct {
 
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=resourcerequests,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rear.fluidos.eu,resources=resourcerequests/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=rear.fluidos.eu,resources=reservationinstructions,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=rear.fluidos.eu,resources=resourcerequests/finalizers,verbs=update
+// +kubebuilder:rbac:groups=rear.fluidos.eu,resources=reservationinstructions,verbs=get;list;watch;create;update;patch;delete
 
 func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
@@ -44,8 +55,26 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
-	// Skip if already processed (Reserved or Failed)
-	if resourceReq.Status.Phase == "Reserved" || resourceReq.Status.Phase == "Failed" {
+	// Give the reservation back to the broker before the request goes away
+	if !resourceReq.DeletionTimestamp.IsZero() {
+		return r.releaseReservation(ctx, resourceReq)
+	}
+
+	if !controllerutil.ContainsFinalizer(resourceReq, rearv1alpha1.ResourceRequestFinalizer) {
+		controllerutil.AddFinalizer(resourceReq, rearv1alpha1.ResourceRequestFinalizer)
+		if err := r.Update(ctx, resourceReq); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// A spec change on a Reserved request is a resize
+	if resourceReq.Status.Phase == "Reserved" && resourceReq.Generation != resourceReq.Status.ObservedGeneration {
+		return r.resizeReservation(ctx, resourceReq)
+	}
+
+	// Skip if already processed (Reserved, Failed or Preempted)
+	if resourceReq.Status.Phase == "Reserved" || resourceReq.Status.Phase == "Failed" ||
+		resourceReq.Status.Phase == "Preempted" {
 		return ctrl.Result{}, nil
 	}
 
@@ -56,10 +85,22 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			"No broker communicator configured")
 	}
 
+	// Already queued or scheduled at the broker: follow that reservation instead of resubmitting
+	if (resourceReq.Status.Phase == "Pending" || resourceReq.Status.Phase == "Scheduled") &&
+		resourceReq.Status.ReservationName != "" {
+		return r.followQueuedReservation(ctx, resourceReq)
+	}
+
+	if resourceReq.Spec.StartTime != nil && len(resourceReq.Spec.GangMembers) > 0 {
+		return r.updateStatus(ctx, resourceReq, "Failed", "", "",
+			"Gang requests cannot be scheduled with startTime")
+	}
+
 	logger.Info("Processing ResourceRequest",
 		"name", resourceReq.Name,
 		"cpu", resourceReq.Spec.RequestedCPU,
-		"memory", resourceReq.Spec.RequestedMemory)
+		"memory", resourceReq.Spec.RequestedMemory,
+		"gpu", resourceReq.Spec.RequestedGPU)
 
 	// Mark as Pending
 	if resourceReq.Status.Phase == "" {
@@ -69,25 +110,175 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
+	// Gangs are reserved all-or-nothing in a single call
+	if len(resourceReq.Spec.GangMembers) > 0 {
+		return r.requestGang(ctx, resourceReq)
+	}
+
 	// Send synchronous reservation request to broker
 	reservationReq := &dto.ReservationRequestDTO{
 		RequestedResources: dto.ResourceQuantitiesDTO{
-			CPU:    resourceReq.Spec.RequestedCPU,
-			Memory: resourceReq.Spec.RequestedMemory,
+			CPU:      resourceReq.Spec.RequestedCPU,
+			Memory:   resourceReq.Spec.RequestedMemory,
+			GPU:      resourceReq.Spec.RequestedGPU,
+			Extended: resourceReq.Spec.RequestedExtended,
 		},
-		Priority: resourceReq.Spec.Priority,
-		Duration: resourceReq.Spec.Duration,
+		Priority:        resourceReq.Spec.Priority,
+		Duration:        resourceReq.Spec.Duration,
+		ScoringStrategy: resourceReq.Spec.ScoringStrategy,
+		Placement:       dto.ToPlacementDTO(resourceReq.Spec.Placement),
+		Queue:           resourceReq.Spec.Queue,
+		Splittable:      resourceReq.Spec.Splittable,
+	}
+	if resourceReq.Spec.StartTime != nil {
+		reservationReq.StartTime = &resourceReq.Spec.StartTime.Time
+	}
+	if resourceReq.Spec.Splittable && (resourceReq.Spec.MinChunkCPU != "" || resourceReq.Spec.MinChunkMemory != "") {
+		reservationReq.MinChunk = &dto.ResourceQuantitiesDTO{
+			CPU:    resourceReq.Spec.MinChunkCPU,
+			Memory: resourceReq.Spec.MinChunkMemory,
+		}
 	}
 
-	reservation, err := r.BrokerCommunicator.RequestReservation(ctx, reservationReq)
+	// Keyed by the request's UID, so a retry after a lost response gets the same reservation
+	reservation, err := r.BrokerCommunicator.RequestReservation(ctx, reservationReq, string(resourceReq.UID))
 	if err != nil {
 		logger.Error(err, "Reservation request failed",
 			"cpu", resourceReq.Spec.RequestedCPU,
 			"memory", resourceReq.Spec.RequestedMemory)
+		if transport.IsRetryable(err) {
+			return r.retryLater(ctx, resourceReq, err)
+		}
 		return r.updateStatus(ctx, resourceReq, "Failed", "", "",
 			fmt.Sprintf("Reservation request failed: %v", err))
 	}
 
+	switch reservation.Status.Phase {
+	case "Pending":
+		return r.markQueued(ctx, resourceReq, reservation)
+	case "Scheduled":
+		return r.markScheduled(ctx, resourceReq, reservation)
+	}
+
+	return r.completeReservation(ctx, resourceReq, reservation)
+}
+
+// followQueuedReservation checks a queued or scheduled reservation at the
+// broker and completes the ResourceRequest once the broker has placed it
+func (r *ResourceRequestReconciler) followQueuedReservation(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	reservation, err := r.BrokerCommunicator.GetReservation(ctx, resourceReq.Status.ReservationName)
+	if err != nil {
+		logger.Error(err, "Failed to check queued reservation, will retry",
+			"reservation", resourceReq.Status.ReservationName)
+		return ctrl.Result{RequeueAfter: queuedPollInterval}, nil
+	}
+
+	switch reservation.Status.Phase {
+	case "Pending":
+		return r.markQueued(ctx, resourceReq, reservation)
+	case "Scheduled":
+		return r.markScheduled(ctx, resourceReq, reservation)
+	case "Reserved", "Active":
+		return r.completeReservation(ctx, resourceReq, reservation)
+	default:
+		return r.updateStatus(ctx, resourceReq, "Failed", reservation.TargetClusterID, reservation.ID,
+			fmt.Sprintf("%s reservation ended as %s: %s",
+				resourceReq.Status.Phase, reservation.Status.Phase, reservation.Status.Message))
+	}
+}
+
+// retryLater keeps a request Pending while the broker fails it, and sends it
+// again later: with the request's UID as idempotency key the broker never
+// reserves twice. The status is only written when it changed.
+func (r *ResourceRequestReconciler) retryLater(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+	brokerErr error,
+) (ctrl.Result, error) {
+	message := fmt.Sprintf("Broker unavailable, will retry: %v", brokerErr)
+
+	if resourceReq.Status.Phase != "Pending" || resourceReq.Status.Message != message {
+		if _, err := r.updateStatus(ctx, resourceReq, "Pending", "", "", message); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: queuedPollInterval}, nil
+}
+
+// markQueued records the broker queue position and checks again later.
+// The status is only written when it changed to avoid reconcile loops.
+func (r *ResourceRequestReconciler) markQueued(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+	reservation *dto.ReservationDTO,
+) (ctrl.Result, error) {
+	message := fmt.Sprintf("Queued at broker (position %d)", reservation.Status.QueuePosition)
+
+	if resourceReq.Status.Phase != "Pending" ||
+		resourceReq.Status.ReservationName != reservation.ID ||
+		resourceReq.Status.QueuePosition != reservation.Status.QueuePosition ||
+		resourceReq.Status.Message != message {
+		resourceReq.Status.Phase = "Pending"
+		resourceReq.Status.ReservationName = reservation.ID
+		resourceReq.Status.QueuePosition = reservation.Status.QueuePosition
+		resourceReq.Status.Message = message
+		resourceReq.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, resourceReq); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: queuedPollInterval}, nil
+}
+
+// markScheduled records a reservation the broker scheduled for a future
+// window and checks again once the window opened. The ReservationInstruction
+// is only created when the broker locked the resources.
+func (r *ResourceRequestReconciler) markScheduled(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+	reservation *dto.ReservationDTO,
+) (ctrl.Result, error) {
+	message := reservation.Status.Message
+
+	if resourceReq.Status.Phase != "Scheduled" ||
+		resourceReq.Status.ReservationName != reservation.ID ||
+		resourceReq.Status.TargetClusterID != reservation.TargetClusterID ||
+		resourceReq.Status.Message != message {
+		if _, err := r.updateStatus(ctx, resourceReq, "Scheduled", reservation.TargetClusterID, reservation.ID,
+			message); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	wait := queuedPollInterval
+	if reservation.StartTime != nil {
+		if untilStart := time.Until(*reservation.StartTime); untilStart > wait {
+			wait = untilStart
+		}
+	}
+	return ctrl.Result{RequeueAfter: wait}, nil
+}
+
+// completeReservation creates the local ReservationInstruction for a placed
+// reservation and marks the ResourceRequest Reserved
+func (r *ResourceRequestReconciler) completeReservation(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+	reservation *dto.ReservationDTO,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	if len(reservation.Parts) > 0 {
+		return r.completeGroupReservation(ctx, resourceReq, reservation)
+	}
+
 	// Create ReservationInstruction from the response
 	if err := r.createReservationInstruction(ctx, resourceReq, reservation); err != nil {
 		logger.Error(err, "Failed to create ReservationInstruction")
@@ -105,16 +296,215 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		fmt.Sprintf("Resources reserved in cluster %s", reservation.TargetClusterID))
 }
 
+// resizeReservation brings the reservation of a Reserved request to the
+// request's CPU and memory, on the same provider. On failure the reservation
+// keeps its old size and the error is reported in the status message.
+// Split and gang reservations cannot be resized.
+func (r *ResourceRequestReconciler) resizeReservation(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	status := resourceReq.Status
+	if len(status.Parts) > 0 {
+		return r.updateStatus(ctx, resourceReq, status.Phase, status.TargetClusterID, status.ReservationName,
+			"Split and gang reservations cannot be resized")
+	}
+
+	instruction := &rearv1alpha1.ReservationInstruction{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Name: status.ReservationName, Namespace: r.instructionNamespace(resourceReq),
+	}, instruction); err != nil {
+		return ctrl.Result{}, err
+	}
+	if instruction.Spec.RequestedCPU == resourceReq.Spec.RequestedCPU &&
+		instruction.Spec.RequestedMemory == resourceReq.Spec.RequestedMemory {
+		// Nothing to resize, e.g. another field changed
+		return r.updateStatus(ctx, resourceReq, status.Phase, status.TargetClusterID, status.ReservationName,
+			status.Message)
+	}
+
+	reservation, err := r.BrokerCommunicator.ResizeReservation(ctx, status.ReservationName, dto.ResourceQuantitiesDTO{
+		CPU:    resourceReq.Spec.RequestedCPU,
+		Memory: resourceReq.Spec.RequestedMemory,
+	})
+	if err != nil {
+		logger.Error(err, "Resize failed",
+			"reservation", status.ReservationName,
+			"cpu", resourceReq.Spec.RequestedCPU,
+			"memory", resourceReq.Spec.RequestedMemory)
+		return r.updateStatus(ctx, resourceReq, status.Phase, status.TargetClusterID, status.ReservationName,
+			fmt.Sprintf("Resize failed, reservation keeps %s CPU and %s memory: %v",
+				instruction.Spec.RequestedCPU, instruction.Spec.RequestedMemory, err))
+	}
+
+	instruction.Spec.RequestedCPU = reservation.RequestedResources.CPU
+	instruction.Spec.RequestedMemory = reservation.RequestedResources.Memory
+	instruction.Spec.Message = fmt.Sprintf("Use %s for %s",
+		reservation.TargetClusterID,
+		dto.DescribeResources(reservation.RequestedResources))
+	if err := r.Update(ctx, instruction); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Reservation resized",
+		"reservation", reservation.ID,
+		"targetCluster", reservation.TargetClusterID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return r.updateStatus(ctx, resourceReq, status.Phase, status.TargetClusterID, status.ReservationName,
+		fmt.Sprintf("Reservation resized to %s", dto.DescribeResources(reservation.RequestedResources)))
+}
+
+// requestGang sends the request's blocks to the broker as one gang
+func (r *ResourceRequestReconciler) requestGang(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	spec := resourceReq.Spec
+	member := func(cpu, memory, gpu string, extended map[string]string,
+		placement *rearv1alpha1.PlacementConstraints) dto.ReservationRequestDTO {
+		return dto.ReservationRequestDTO{
+			RequestedResources: dto.ResourceQuantitiesDTO{
+				CPU:      cpu,
+				Memory:   memory,
+				GPU:      gpu,
+				Extended: extended,
+			},
+			Priority:        spec.Priority,
+			Duration:        spec.Duration,
+			ScoringStrategy: spec.ScoringStrategy,
+			Placement:       dto.ToPlacementDTO(placement),
+		}
+	}
+
+	gangReq := &dto.GangReservationRequestDTO{
+		Members: []dto.ReservationRequestDTO{
+			member(spec.RequestedCPU, spec.RequestedMemory, spec.RequestedGPU, spec.RequestedExtended, spec.Placement),
+		},
+	}
+	for _, block := range spec.GangMembers {
+		gangReq.Members = append(gangReq.Members,
+			member(block.RequestedCPU, block.RequestedMemory, block.RequestedGPU, block.RequestedExtended, block.Placement))
+	}
+
+	reservation, err := r.BrokerCommunicator.RequestGangReservation(ctx, gangReq, string(resourceReq.UID))
+	if err != nil {
+		logger.Error(err, "Gang reservation request failed", "members", len(gangReq.Members))
+		if transport.IsRetryable(err) {
+			return r.retryLater(ctx, resourceReq, err)
+		}
+		return r.updateStatus(ctx, resourceReq, "Failed", "", "",
+			fmt.Sprintf("Gang reservation request failed: %v", err))
+	}
+
+	return r.completeReservation(ctx, resourceReq, reservation)
+}
+
+// completeGroupReservation creates one ReservationInstruction per part of a
+// split request or gang, and records the parts on the ResourceRequest
+func (r *ResourceRequestReconciler) completeGroupReservation(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+	group *dto.ReservationDTO,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	parts := make([]rearv1alpha1.ReservationPart, 0, len(group.Parts))
+	clusterIDs := make([]string, 0, len(group.Parts))
+	for _, part := range group.Parts {
+		if err := r.createReservationInstruction(ctx, resourceReq, part); err != nil {
+			logger.Error(err, "Failed to create ReservationInstruction for part", "reservation", part.ID)
+			return r.updateStatus(ctx, resourceReq, "Failed", "", group.ID,
+				fmt.Sprintf("Reservation succeeded but failed to create local instruction for part %s: %v", part.ID, err))
+		}
+		parts = append(parts, rearv1alpha1.ReservationPart{
+			ReservationName: part.ID,
+			TargetClusterID: part.TargetClusterID,
+			CPU:             part.RequestedResources.CPU,
+			Memory:          part.RequestedResources.Memory,
+		})
+		clusterIDs = append(clusterIDs, part.TargetClusterID)
+	}
+
+	logger.Info("ResourceRequest reserved in parts",
+		"group", group.ID,
+		"targetClusters", clusterIDs,
+		"cpu", group.RequestedResources.CPU,
+		"memory", group.RequestedResources.Memory)
+
+	resourceReq.Status.Parts = parts
+	return r.updateStatus(ctx, resourceReq, "Reserved", "", group.ID,
+		fmt.Sprintf("Resources reserved in %d parts on clusters %s", len(parts), strings.Join(clusterIDs, ", ")))
+}
+
+// releaseReservation releases the request's reservation (or reservation group)
+// at the broker, deletes its local ReservationInstructions and removes the
+// finalizer. A broker error keeps the finalizer so the release is retried.
+func (r *ResourceRequestReconciler) releaseReservation(
+	ctx context.Context,
+	resourceReq *rearv1alpha1.ResourceRequest,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithName("resourcerequest-controller")
+
+	if !controllerutil.ContainsFinalizer(resourceReq, rearv1alpha1.ResourceRequestFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if reservationName := resourceReq.Status.ReservationName; reservationName != "" && r.BrokerCommunicator != nil {
+		if err := r.BrokerCommunicator.ReleaseReservation(ctx, reservationName); err != nil {
+			logger.Error(err, "Failed to release reservation at broker, will retry",
+				"resourceRequest", resourceReq.Name,
+				"reservation", reservationName)
+			return ctrl.Result{}, err
+		}
+		logger.Info("Released reservation of deleted ResourceRequest",
+			"resourceRequest", resourceReq.Name,
+			"reservation", reservationName)
+	}
+
+	instructionNames := []string{resourceReq.Status.ReservationName}
+	for _, part := range resourceReq.Status.Parts {
+		instructionNames = append(instructionNames, part.ReservationName)
+	}
+	for _, name := range instructionNames {
+		if name == "" {
+			continue
+		}
+		instruction := &rearv1alpha1.ReservationInstruction{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.instructionNamespace(resourceReq)},
+		}
+		if err := r.Delete(ctx, instruction); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(resourceReq, rearv1alpha1.ResourceRequestFinalizer)
+	if err := r.Update(ctx, resourceReq); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// instructionNamespace is where the request's ReservationInstructions live
+func (r *ResourceRequestReconciler) instructionNamespace(resourceReq *rearv1alpha1.ResourceRequest) string {
+	if r.InstructionNamespace != "" {
+		return r.InstructionNamespace
+	}
+	return resourceReq.Namespace
+}
+
 func (r *ResourceRequestReconciler) createReservationInstruction(
 	ctx context.Context,
 	resourceReq *rearv1alpha1.ResourceRequest,
 	reservation *dto.ReservationDTO,
 ) error {
 	instructionName := reservation.ID
-	ns := r.InstructionNamespace
-	if ns == "" {
-		ns = resourceReq.Namespace
-	}
+	ns := r.instructionNamespace(resourceReq)
 
 	// Check if instruction already exists
 	existing := &rearv1alpha1.ReservationInstruction{}
@@ -137,14 +527,15 @@ func (r *ResourceRequestReconciler) createReservationInstruction(
 			Namespace: ns,
 		},
 		Spec: rearv1alpha1.ReservationInstructionSpec{
-			ReservationName: reservation.ID,
-			TargetClusterID: reservation.TargetClusterID,
-			RequestedCPU:    reservation.RequestedResources.CPU,
-			RequestedMemory: reservation.RequestedResources.Memory,
-			Message: fmt.Sprintf("Use %s for %s CPU / %s Memory",
+			ReservationName:   reservation.ID,
+			TargetClusterID:   reservation.TargetClusterID,
+			RequestedCPU:      reservation.RequestedResources.CPU,
+			RequestedMemory:   reservation.RequestedResources.Memory,
+			RequestedGPU:      reservation.RequestedResources.GPU,
+			RequestedExtended: reservation.RequestedResources.Extended,
+			Message: fmt.Sprintf("Use %s for %s",
 				reservation.TargetClusterID,
-				reservation.RequestedResources.CPU,
-				reservation.RequestedResources.Memory),
+				dto.DescribeResources(reservation.RequestedResources)),
 			ExpiresAt: expiresAt,
 		},
 	}
@@ -161,6 +552,8 @@ func (r *ResourceRequestReconciler) updateStatus(
 	resourceReq.Status.TargetClusterID = targetClusterID
 	resourceReq.Status.ReservationName = reservationName
 	resourceReq.Status.Message = message
+	resourceReq.Status.QueuePosition = 0
+	resourceReq.Status.ObservedGeneration = resourceReq.Generation
 	resourceReq.Status.LastUpdateTime = metav1.Now()
 
 	if err := r.Status().Update(ctx, resourceReq); err != nil {
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	rearv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+)
+
+// fakeBroker answers reservation requests and releases with fixed errors and
+// records the releases. Other BrokerCommunicator methods are not used by these tests.
+type fakeBroker struct {
+	transport.BrokerCommunicator
+	reserveErr error
+	releaseErr error
+	released   []string
+}
+
+func (b *fakeBroker) RequestReservation(context.Context, *dto.ReservationRequestDTO, string) (*dto.ReservationDTO, error) {
+	return nil, b.reserveErr
+}
+
+func (b *fakeBroker) ReleaseReservation(_ context.Context, reservationID string) error {
+	b.released = append(b.released, reservationID)
+	return b.releaseErr
+}
+
+// newFakeReconciler creates a reconciler on a fake client holding objects
+func newFakeReconciler(broker transport.BrokerCommunicator, objects ...client.Object) (*ResourceRequestReconciler, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = rearv1alpha1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&rearv1alpha1.ResourceRequest{}).
+		Build()
+	return &ResourceRequestReconciler{
+		Client:               fakeClient,
+		Scheme:               scheme,
+		BrokerCommunicator:   broker,
+		InstructionNamespace: "default",
+	}, fakeClient
+}
+
+// newResourceRequest creates a ResourceRequest for 1 CPU and 1Gi
+func newResourceRequest(name string) *rearv1alpha1.ResourceRequest {
+	return &rearv1alpha1.ResourceRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+		Spec: rearv1alpha1.ResourceRequestSpec{
+			RequestedCPU:    "1",
+			RequestedMemory: "1Gi",
+		},
+	}
+}
+
+// Test: A broker failure (5xx) keeps the request Pending and requeues it, while a refusal fails it
+func TestReconcile_RetriesBrokerFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expectPhase string
+		expectRetry bool
+	}{
+		{
+			name:        "broker locking the key",
+			err:         &transport.StatusError{StatusCode: http.StatusServiceUnavailable, Message: "in progress"},
+			expectPhase: "Pending",
+			expectRetry: true,
+		},
+		{
+			name:        "broker error",
+			err:         &transport.StatusError{StatusCode: http.StatusInternalServerError, Message: "failed"},
+			expectPhase: "Pending",
+			expectRetry: true,
+		},
+		{
+			name:        "no capacity",
+			err:         &transport.StatusError{StatusCode: http.StatusConflict, Message: "No cluster has enough resources"},
+			expectPhase: "Failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			reconciler, fakeClient := newFakeReconciler(&fakeBroker{reserveErr: tt.err}, newResourceRequest("job"))
+			key := types.NamespacedName{Name: "job", Namespace: "default"}
+
+			result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			var resourceReq rearv1alpha1.ResourceRequest
+			if err := fakeClient.Get(ctx, key, &resourceReq); err != nil {
+				t.Fatalf("Failed to get ResourceRequest: %v", err)
+			}
+			if resourceReq.Status.Phase != tt.expectPhase {
+				t.Errorf("Expected phase %s, got %s (%s)", tt.expectPhase, resourceReq.Status.Phase, resourceReq.Status.Message)
+			}
+			if retried := result.RequeueAfter > 0; retried != tt.expectRetry {
+				t.Errorf("Expected requeue %v, got %+v", tt.expectRetry, result)
+			}
+		})
+	}
+}
+
+// Test: Deleting a Reserved request releases its reservation, deletes its instruction and removes the finalizer,
+// which stays while the broker cannot release
+func TestReconcile_ReleasesDeletedRequest(t *testing.T) {
+	deleted := func() *rearv1alpha1.ResourceRequest {
+		resourceReq := newResourceRequest("job")
+		resourceReq.Finalizers = []string{rearv1alpha1.ResourceRequestFinalizer}
+		resourceReq.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		resourceReq.Status = rearv1alpha1.ResourceRequestStatus{Phase: "Reserved", ReservationName: "rsv-1"}
+		return resourceReq
+	}
+	instruction := func() *rearv1alpha1.ReservationInstruction {
+		return &rearv1alpha1.ReservationInstruction{ObjectMeta: metav1.ObjectMeta{Name: "rsv-1", Namespace: "default"}}
+	}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "job", Namespace: "default"}
+
+	t.Run("released", func(t *testing.T) {
+		broker := &fakeBroker{}
+		reconciler, fakeClient := newFakeReconciler(broker, deleted(), instruction())
+
+		if _, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+		if len(broker.released) != 1 || broker.released[0] != "rsv-1" {
+			t.Errorf("Expected rsv-1 released at the broker, got %v", broker.released)
+		}
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "rsv-1", Namespace: "default"}, &rearv1alpha1.ReservationInstruction{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("Expected the ReservationInstruction deleted, got %v", err)
+		}
+		// Without its finalizer the deleted request goes away
+		if err := fakeClient.Get(ctx, key, &rearv1alpha1.ResourceRequest{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected the ResourceRequest gone, got %v", err)
+		}
+	})
+
+	t.Run("broker unavailable", func(t *testing.T) {
+		broker := &fakeBroker{releaseErr: &transport.StatusError{StatusCode: http.StatusServiceUnavailable}}
+		reconciler, fakeClient := newFakeReconciler(broker, deleted(), instruction())
+
+		if _, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key}); err == nil {
+			t.Error("Expected the failed release to be retried")
+		}
+		var resourceReq rearv1alpha1.ResourceRequest
+		if err := fakeClient.Get(ctx, key, &resourceReq); err != nil {
+			t.Fatalf("Expected the ResourceRequest kept, got %v", err)
+		}
+		if !controllerutil.ContainsFinalizer(&resourceReq, rearv1alpha1.ResourceRequestFinalizer) {
+			t.Error("Expected the finalizer kept until the broker released the reservation")
+		}
+	})
+}
@@ -17,6 +17,11 @@ import (
 type Collector struct {
 	Client            client.Client
 	ClusterIDOverride string
+
+	// ExtendedResources lists additional resource names to advertise
+	// (e.g., "amd.com/gpu", "hugepages-2Mi", "ephemeral-storage").
+	// nvidia.com/gpu is always reported through the dedicated GPU field.
+	ExtendedResources []string
 }
 
 // CollectClusterResources collects detailed resource information from all nodes
@@ -40,6 +45,9 @@ func (c *Collector) CollectClusterResources(ctx context.Context) (*rearv1alpha1.
 		Memory: *resource.NewQuantity(0, resource.BinarySI),
 	}
 
+	capacity.Extended = c.newExtended()
+	allocatable.Extended = c.newExtended()
+
 	var capacityGPU, allocatableGPU resource.Quantity
 	hasGPU := false
 
@@ -71,6 +79,10 @@ func (c *Collector) CollectClusterResources(ctx context.Context) (*rearv1alpha1.
 		if gpu, ok := node.Status.Allocatable["nvidia.com/gpu"]; ok {
 			allocatableGPU.Add(gpu)
 		}
+
+		// Extended resources
+		c.addExtended(capacity.Extended, node.Status.Capacity)
+		c.addExtended(allocatable.Extended, node.Status.Allocatable)
 	}
 
 	if hasGPU {
@@ -124,6 +136,19 @@ func (c *Collector) CollectClusterResources(ctx context.Context) (*rearv1alpha1.
 		available.GPU = &availableGPU
 	}
 
+	if len(allocatable.Extended) > 0 {
+		available.Extended = make(map[string]resource.Quantity, len(allocatable.Extended))
+		for name, allocatableQty := range allocatable.Extended {
+			availableQty := allocatableQty.DeepCopy()
+			availableQty.Sub(allocated.Extended[name])
+			availableQty.Sub(reserved.Extended[name])
+			if availableQty.Cmp(zero) < 0 {
+				availableQty = *resource.NewQuantity(0, allocatableQty.Format)
+			}
+			available.Extended[name] = availableQty
+		}
+	}
+
 	return &rearv1alpha1.ResourceMetrics{
 		Capacity:    *capacity,
 		Allocatable: *allocatable,
@@ -145,6 +170,8 @@ func (c *Collector) calculateAllocatedResources(ctx context.Context) (*rearv1alp
 		Memory: *resource.NewQuantity(0, resource.BinarySI),
 	}
 
+	allocated.Extended = c.newExtended()
+
 	var allocatedGPU resource.Quantity
 	hasGPU := false
 
@@ -153,6 +180,8 @@ func (c *Collector) calculateAllocatedResources(ctx context.Context) (*rearv1alp
 			continue
 		}
 
+		c.addPodExtended(allocated.Extended, &pod)
+
 		containersCPU := resource.NewQuantity(0, resource.DecimalSI)
 		containersMemory := resource.NewQuantity(0, resource.BinarySI)
 		containersGPU := resource.NewQuantity(0, resource.DecimalSI)
@@ -248,6 +277,8 @@ func (c *Collector) calculateReservedResources(ctx context.Context) (*rearv1alph
 		Memory: *resource.NewQuantity(0, resource.BinarySI),
 	}
 
+	reserved.Extended = c.newExtended()
+
 	var reservedGPU resource.Quantity
 	hasGPU := false
 	now := time.Now()
@@ -287,24 +318,106 @@ func (c *Collector) calculateReservedResources(ctx context.Context) (*rearv1alph
 			reserved.Memory.Add(memQuantity)
 		}
 
-		// GPU support (if added later to ProviderInstruction)
-		// For now, skip GPU handling in provider instructions
+		// Parse GPU
+		if instruction.Spec.RequestedGPU != "" {
+			gpuQuantity, err := resource.ParseQuantity(instruction.Spec.RequestedGPU)
+			if err != nil {
+				logger.Error(err, "failed to parse GPU from provider instruction",
+					"instruction", instruction.Name,
+					"gpu", instruction.Spec.RequestedGPU)
+				continue
+			}
+			reservedGPU.Add(gpuQuantity)
+			hasGPU = true
+		}
+
+		// Parse extended resources (only the configured ones are tracked)
+		for name, value := range instruction.Spec.RequestedExtended {
+			total, tracked := reserved.Extended[name]
+			if !tracked {
+				continue
+			}
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				logger.Error(err, "failed to parse extended resource from provider instruction",
+					"instruction", instruction.Name,
+					"resource", name,
+					"quantity", value)
+				continue
+			}
+			total.Add(quantity)
+			reserved.Extended[name] = total
+		}
 	}
 
 	if hasGPU {
 		reserved.GPU = &reservedGPU
 	}
 
-	if reserved.CPU.Sign() > 0 || reserved.Memory.Sign() > 0 {
+	if reserved.CPU.Sign() > 0 || reserved.Memory.Sign() > 0 || reservedGPU.Sign() > 0 {
 		logger.Info("calculated reserved resources from provider instructions",
 			"reservedCPU", reserved.CPU.String(),
 			"reservedMemory", reserved.Memory.String(),
+			"reservedGPU", reservedGPU.String(),
 			"instructionCount", len(providerInstructionList.Items))
 	}
 
 	return reserved, nil
 }
 
+// newExtended returns zeroed totals for the configured extended resources,
+// or nil when none are configured
+func (c *Collector) newExtended() map[string]resource.Quantity {
+	if len(c.ExtendedResources) == 0 {
+		return nil
+	}
+	totals := make(map[string]resource.Quantity, len(c.ExtendedResources))
+	for _, name := range c.ExtendedResources {
+		totals[name] = resource.Quantity{}
+	}
+	return totals
+}
+
+// addExtended adds the configured extended resources found in list to totals
+func (c *Collector) addExtended(totals map[string]resource.Quantity, list corev1.ResourceList) {
+	for name, total := range totals {
+		if qty, ok := list[corev1.ResourceName(name)]; ok {
+			total.Add(qty)
+			totals[name] = total
+		}
+	}
+}
+
+// addPodExtended adds a pod's effective requests for the configured extended resources:
+// max(sum of containers, largest init container) plus pod overhead
+func (c *Collector) addPodExtended(totals map[string]resource.Quantity, pod *corev1.Pod) {
+	for name, total := range totals {
+		resourceName := corev1.ResourceName(name)
+
+		var containers, initMax resource.Quantity
+		for _, container := range pod.Spec.Containers {
+			if qty, ok := container.Resources.Requests[resourceName]; ok {
+				containers.Add(qty)
+			}
+		}
+		for _, initContainer := range pod.Spec.InitContainers {
+			if qty, ok := initContainer.Resources.Requests[resourceName]; ok && qty.Cmp(initMax) > 0 {
+				initMax = qty.DeepCopy()
+			}
+		}
+
+		if initMax.Cmp(containers) > 0 {
+			total.Add(initMax)
+		} else {
+			total.Add(containers)
+		}
+		if qty, ok := pod.Spec.Overhead[resourceName]; ok {
+			total.Add(qty)
+		}
+		totals[name] = total
+	}
+}
+
 // isNodeReady checks if a node is in Ready condition
 func isNodeReady(node *corev1.Node) bool {
 	for _, condition := range node.Status.Conditions {
@@ -483,3 +483,70 @@ func TestCollectClusterResources_InitContainerMax(t *testing.T) {
 			expectedAllocatedCPU.String(), result.Allocated.CPU.String())
 	}
 }
+
+// Test: GPUs held by enforced ProviderInstructions reduce available GPUs
+func TestCollectClusterResources_IncludesReservedGPUFromProviderInstructions(t *testing.T) {
+	node := makeNode("node-1", "4000m", "8Gi", "3500m", "7Gi")
+	node.Status.Capacity["nvidia.com/gpu"] = resource.MustParse("2")
+	node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("2")
+
+	instruction := makeProviderInstruction("pi-1", "500m", "1Gi", true, nil)
+	instruction.Spec.RequestedGPU = "1"
+
+	fakeClient := createFakeClient(node, instruction)
+	collector := &Collector{Client: fakeClient}
+
+	result, err := collector.CollectClusterResources(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Available GPU should be: 2 - 0 - 1 = 1
+	expectedAvailableGPU := resource.MustParse("1")
+	if result.Available.GPU == nil {
+		t.Fatal("expected available GPU to be set")
+	}
+	if result.Available.GPU.Cmp(expectedAvailableGPU) != 0 {
+		t.Errorf("expected available GPU %s, got %s",
+			expectedAvailableGPU.String(), result.Available.GPU.String())
+	}
+}
+
+// Test: Configured extended resources are aggregated and reduced by pods and instructions
+func TestCollectClusterResources_ExtendedResources(t *testing.T) {
+	node1 := makeNode("node-1", "4000m", "8Gi", "3500m", "7Gi")
+	node1.Status.Allocatable["amd.com/gpu"] = resource.MustParse("2")
+	node2 := makeNode("node-2", "4000m", "8Gi", "3500m", "7Gi")
+	node2.Status.Allocatable["amd.com/gpu"] = resource.MustParse("2")
+	node2.Status.Allocatable["example.com/unlisted"] = resource.MustParse("5")
+
+	pod := makePod("gpu-pod", "default", "500m", "1Gi", corev1.PodRunning)
+	pod.Spec.Containers[0].Resources.Requests["amd.com/gpu"] = resource.MustParse("1")
+
+	instruction := makeProviderInstruction("pi-1", "500m", "1Gi", true, nil)
+	instruction.Spec.RequestedExtended = map[string]string{"amd.com/gpu": "2"}
+
+	fakeClient := createFakeClient(node1, node2, pod, instruction)
+	collector := &Collector{Client: fakeClient, ExtendedResources: []string{"amd.com/gpu"}}
+
+	result, err := collector.CollectClusterResources(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := result.Allocatable.Extended["example.com/unlisted"]; ok {
+		t.Error("expected unlisted extended resources not to be advertised")
+	}
+
+	// Available should be: 4 - 1 - 2 = 1
+	expected := resource.MustParse("1")
+	available, ok := result.Available.Extended["amd.com/gpu"]
+	if !ok {
+		t.Fatal("expected amd.com/gpu to be advertised")
+	}
+	if available.Cmp(expected) != 0 {
+		t.Errorf("expected available amd.com/gpu %s, got %s", expected.String(), available.String())
+	}
+}
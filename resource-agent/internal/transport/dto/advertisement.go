@@ -8,6 +8,8 @@ type AdvertisementDTO struct {
 	ClusterID   string             `json:"clusterID"`
 	ClusterName string             `json:"clusterName"`
 	Resources   ResourceMetricsDTO `json:"resources"`
+	Cost        *CostInfoDTO       `json:"cost,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"` // e.g., {"topology.kubernetes.io/region": "eu-west-1"}
 	Timestamp   time.Time          `json:"timestamp"`
 }
 
@@ -27,6 +29,15 @@ type ResourceQuantitiesDTO struct {
 	Memory  string `json:"memory"`            // e.g., "8Gi" or "8589934592"
 	GPU     string `json:"gpu,omitempty"`     // e.g., "2"
 	Storage string `json:"storage,omitempty"` // e.g., "100Gi"
+
+	Extended map[string]string `json:"extended,omitempty"` // e.g., {"amd.com/gpu": "2"}
+}
+
+// CostInfoDTO represents provider pricing used by cost-aware scoring
+type CostInfoDTO struct {
+	CPUCost    string `json:"cpuCost,omitempty"`    // per core per hour, e.g., "0.03"
+	MemoryCost string `json:"memoryCost,omitempty"` // per GB per hour, e.g., "0.004"
+	Currency   string `json:"currency,omitempty"`   // e.g., "EUR"
 }
 
 // AdvertisementResponseDTO is the response from POST /api/v1/advertisements.
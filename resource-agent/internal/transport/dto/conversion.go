@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"fmt"
+	"sort"
+
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -14,6 +17,7 @@ func ToAdvertisementDTO(adv *rearv1alpha1.Advertisement) *AdvertisementDTO {
 		ClusterID:   adv.Spec.ClusterID,
 		ClusterName: adv.Name,
 		Timestamp:   adv.Spec.Timestamp.Time,
+		Labels:      adv.Spec.Labels,
 		Resources: ResourceMetricsDTO{
 			Capacity:    toResourceQuantitiesDTO(adv.Spec.Resources.Capacity),
 			Allocatable: toResourceQuantitiesDTO(adv.Spec.Resources.Allocatable),
@@ -23,6 +27,14 @@ func ToAdvertisementDTO(adv *rearv1alpha1.Advertisement) *AdvertisementDTO {
 		},
 	}
 
+	if adv.Spec.Cost != nil {
+		dto.Cost = &CostInfoDTO{
+			CPUCost:    adv.Spec.Cost.CPUCost,
+			MemoryCost: adv.Spec.Cost.MemoryCost,
+			Currency:   adv.Spec.Cost.Currency,
+		}
+	}
+
 	return dto
 }
 
@@ -41,6 +53,13 @@ func toResourceQuantitiesDTO(rq rearv1alpha1.ResourceQuantities) ResourceQuantit
 		dto.Storage = rq.Storage.String()
 	}
 
+	if len(rq.Extended) > 0 {
+		dto.Extended = make(map[string]string, len(rq.Extended))
+		for name, qty := range rq.Extended {
+			dto.Extended[name] = qty.String()
+		}
+	}
+
 	return dto
 }
 
@@ -80,6 +99,18 @@ func fromResourceQuantitiesDTO(dto ResourceQuantitiesDTO) (rearv1alpha1.Resource
 		rq.Storage = &storageQty
 	}
 
+	// Parse optional extended resources
+	if len(dto.Extended) > 0 {
+		rq.Extended = make(map[string]resource.Quantity, len(dto.Extended))
+		for name, value := range dto.Extended {
+			qty, err := resource.ParseQuantity(value)
+			if err != nil {
+				return rq, fmt.Errorf("invalid quantity for %s: %w", name, err)
+			}
+			rq.Extended[name] = qty
+		}
+	}
+
 	return rq, nil
 }
 
@@ -97,3 +128,57 @@ func FromReservationDTO(dto *ReservationDTO) (requesterID, targetClusterID, cpu,
 
 	return
 }
+
+// DescribeResources renders requested quantities for human-readable messages,
+// e.g. "500m CPU / 1Gi Memory / 1 GPU / 2 amd.com/gpu"
+func DescribeResources(rq ResourceQuantitiesDTO) string {
+	description := fmt.Sprintf("%s CPU / %s Memory", rq.CPU, rq.Memory)
+	if rq.GPU != "" {
+		description += fmt.Sprintf(" / %s GPU", rq.GPU)
+	}
+
+	names := make([]string, 0, len(rq.Extended))
+	for name := range rq.Extended {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		description += fmt.Sprintf(" / %s %s", rq.Extended[name], name)
+	}
+	return description
+}
+
+// ToPlacementDTO converts a ResourceRequest placement to the transport format.
+// Returns nil when no placement is set.
+func ToPlacementDTO(placement *rearv1alpha1.PlacementConstraints) *PlacementDTO {
+	if placement == nil {
+		return nil
+	}
+
+	dto := &PlacementDTO{
+		Required: toLabelRequirementDTOs(placement.Required),
+	}
+	for _, term := range placement.Preferred {
+		dto.Preferred = append(dto.Preferred, PreferredPlacementDTO{
+			Weight:       term.Weight,
+			Requirements: toLabelRequirementDTOs(term.Requirements),
+		})
+	}
+	return dto
+}
+
+func toLabelRequirementDTOs(requirements []metav1.LabelSelectorRequirement) []LabelRequirementDTO {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	result := make([]LabelRequirementDTO, 0, len(requirements))
+	for _, requirement := range requirements {
+		result = append(result, LabelRequirementDTO{
+			Key:      requirement.Key,
+			Operator: string(requirement.Operator),
+			Values:   requirement.Values,
+		})
+	}
+	return result
+}
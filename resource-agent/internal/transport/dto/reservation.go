@@ -21,14 +21,26 @@ type ReservationDTO struct {
 	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
 	Status             ReservationStatusDTO  `json:"status"`
 	CreatedAt          time.Time             `json:"createdAt"`
+	StartTime          *time.Time            `json:"startTime,omitempty"` // set for scheduled reservations
+
+	// Set when the broker split the request across several providers. The
+	// group response carries the group ID as ID and one entry per provider in Parts.
+	GroupID string            `json:"groupID,omitempty"`
+	Parts   []*ReservationDTO `json:"parts,omitempty"`
 }
 
 // ReservationStatusDTO represents the status of a reservation
 type ReservationStatusDTO struct {
-	Phase      string     `json:"phase"` // Pending, Reserved, Active, Released, Failed
+	Phase      string     `json:"phase"` // Pending, Scheduled, Reserved, Active, Released, Failed
 	Message    string     `json:"message"`
 	ReservedAt *time.Time `json:"reservedAt,omitempty"`
 	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+
+	QueuePosition int32 `json:"queuePosition,omitempty"` // 1-based, set while Pending in the broker queue
+
+	// ProviderAccepted is the provider's answer: true once it holds the
+	// capacity, false if it rejected the reservation, unset until it answers
+	ProviderAccepted *bool `json:"providerAccepted,omitempty"`
 }
 
 // ReservationRequestDTO is sent by the agent to request a resource reservation.
@@ -36,5 +48,55 @@ type ReservationStatusDTO struct {
 type ReservationRequestDTO struct {
 	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
 	Priority           int32                 `json:"priority,omitempty"`
-	Duration           string                `json:"duration,omitempty"` // e.g., "1h", "30m"
+	Duration           string                `json:"duration,omitempty"`        // e.g., "1h", "30m"
+	StartTime          *time.Time            `json:"startTime,omitempty"`       // future window start; requires duration
+	ScoringStrategy    string                `json:"scoringStrategy,omitempty"` // e.g., "LowestCost"
+	Placement          *PlacementDTO         `json:"placement,omitempty"`
+	Queue              bool                  `json:"queue,omitempty"` // wait for capacity instead of failing
+
+	// Splittable lets the broker divide the request across several providers
+	// when no single one fits. Each part is at least MinChunk (CPU and memory only).
+	Splittable bool                   `json:"splittable,omitempty"`
+	MinChunk   *ResourceQuantitiesDTO `json:"minChunk,omitempty"`
+}
+
+// RenewRequestDTO extends a reservation's expiry
+type RenewRequestDTO struct {
+	Duration string `json:"duration,omitempty"` // new expiry is now + duration; empty uses the reservation's duration
+}
+
+// AcknowledgeRequestDTO is the provider's answer to a reservation it was instructed to hold
+type AcknowledgeRequestDTO struct {
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"` // why it was rejected, e.g. "local policy"
+}
+
+// ResizeRequestDTO changes the CPU and memory of a reservation in place
+type ResizeRequestDTO struct {
+	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
+}
+
+// GangReservationRequestDTO reserves several resource blocks all-or-nothing.
+// Each member is placed on its own; members may share a cluster.
+type GangReservationRequestDTO struct {
+	Members []ReservationRequestDTO `json:"members"`
+}
+
+// PlacementDTO restricts and ranks candidate clusters by their advertised labels
+type PlacementDTO struct {
+	Required  []LabelRequirementDTO   `json:"required,omitempty"`  // all must match
+	Preferred []PreferredPlacementDTO `json:"preferred,omitempty"` // matching clusters rank higher
+}
+
+// LabelRequirementDTO matches one cluster label
+type LabelRequirementDTO struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"` // In, NotIn, Exists, DoesNotExist
+	Values   []string `json:"values,omitempty"`
+}
+
+// PreferredPlacementDTO favours clusters matching all of its requirements
+type PreferredPlacementDTO struct {
+	Weight       int32                 `json:"weight"` // 1-100
+	Requirements []LabelRequirementDTO `json:"requirements"`
 }
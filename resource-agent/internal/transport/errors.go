@@ -0,0 +1,36 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusError is a broker response with a status the call did not expect
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("broker returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("broker returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsRetryable reports whether err is the broker failing rather than refusing
+// the request (a 5xx status, or an Unavailable or Internal gRPC status), so
+// the same request may succeed later
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code() == codes.Unavailable || st.Code() == codes.Internal
+	}
+	return false
+}
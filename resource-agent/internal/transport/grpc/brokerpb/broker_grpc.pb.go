@@ -0,0 +1,576 @@
+// gRPC interface between resource agents and the broker.
+// Messages mirror the JSON DTOs of the HTTP API field for field (with the
+// same JSON names), so both transports carry the same data. The calling
+// cluster is identified by the CN of its mTLS client certificate.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: broker/v1/broker.proto
+
+package brokerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Broker_PublishAdvertisement_FullMethodName   = "/broker.v1.Broker/PublishAdvertisement"
+	Broker_RequestReservation_FullMethodName     = "/broker.v1.Broker/RequestReservation"
+	Broker_RequestGangReservation_FullMethodName = "/broker.v1.Broker/RequestGangReservation"
+	Broker_GetReservation_FullMethodName         = "/broker.v1.Broker/GetReservation"
+	Broker_ActivateReservation_FullMethodName    = "/broker.v1.Broker/ActivateReservation"
+	Broker_AcknowledgeReservation_FullMethodName = "/broker.v1.Broker/AcknowledgeReservation"
+	Broker_HeartbeatReservation_FullMethodName   = "/broker.v1.Broker/HeartbeatReservation"
+	Broker_RenewReservation_FullMethodName       = "/broker.v1.Broker/RenewReservation"
+	Broker_ResizeReservation_FullMethodName      = "/broker.v1.Broker/ResizeReservation"
+	Broker_ReleaseReservation_FullMethodName     = "/broker.v1.Broker/ReleaseReservation"
+	Broker_FetchInstructions_FullMethodName      = "/broker.v1.Broker/FetchInstructions"
+	Broker_WatchInstructions_FullMethodName      = "/broker.v1.Broker/WatchInstructions"
+)
+
+// BrokerClient is the client API for Broker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Broker places and tracks resource reservations across clusters
+type BrokerClient interface {
+	// PublishAdvertisement stores the calling cluster's advertisement and returns its pending provider instructions
+	PublishAdvertisement(ctx context.Context, in *Advertisement, opts ...grpc.CallOption) (*PublishAdvertisementResponse, error)
+	// RequestReservation reserves resources synchronously, or queues or schedules the request
+	RequestReservation(ctx context.Context, in *RequestReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// RequestGangReservation reserves several resource blocks all-or-nothing
+	RequestGangReservation(ctx context.Context, in *RequestGangReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// GetReservation returns a reservation or reservation group
+	GetReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error)
+	// ActivateReservation reports that the requester started using a reservation
+	ActivateReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error)
+	// AcknowledgeReservation gives the provider's answer to a reservation it was instructed to hold
+	AcknowledgeReservation(ctx context.Context, in *AcknowledgeReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// HeartbeatReservation reports that the requester still uses a reservation
+	HeartbeatReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error)
+	// RenewReservation extends a reservation's expiry
+	RenewReservation(ctx context.Context, in *RenewReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// ResizeReservation changes the CPU and memory of a reservation in place
+	ResizeReservation(ctx context.Context, in *ResizeReservationRequest, opts ...grpc.CallOption) (*Reservation, error)
+	// ReleaseReservation gives a reservation or reservation group back
+	ReleaseReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error)
+	// FetchInstructions returns the calling cluster's current instructions
+	FetchInstructions(ctx context.Context, in *FetchInstructionsRequest, opts ...grpc.CallOption) (*FetchInstructionsResponse, error)
+	// WatchInstructions streams the calling cluster's instructions as they are issued
+	WatchInstructions(ctx context.Context, in *WatchInstructionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstructionEvent], error)
+}
+
+type brokerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBrokerClient(cc grpc.ClientConnInterface) BrokerClient {
+	return &brokerClient{cc}
+}
+
+func (c *brokerClient) PublishAdvertisement(ctx context.Context, in *Advertisement, opts ...grpc.CallOption) (*PublishAdvertisementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishAdvertisementResponse)
+	err := c.cc.Invoke(ctx, Broker_PublishAdvertisement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) RequestReservation(ctx context.Context, in *RequestReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_RequestReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) RequestGangReservation(ctx context.Context, in *RequestGangReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_RequestGangReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) GetReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_GetReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) ActivateReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_ActivateReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) AcknowledgeReservation(ctx context.Context, in *AcknowledgeReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_AcknowledgeReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) HeartbeatReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_HeartbeatReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) RenewReservation(ctx context.Context, in *RenewReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_RenewReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) ResizeReservation(ctx context.Context, in *ResizeReservationRequest, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_ResizeReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) ReleaseReservation(ctx context.Context, in *ReservationRef, opts ...grpc.CallOption) (*Reservation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, Broker_ReleaseReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) FetchInstructions(ctx context.Context, in *FetchInstructionsRequest, opts ...grpc.CallOption) (*FetchInstructionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchInstructionsResponse)
+	err := c.cc.Invoke(ctx, Broker_FetchInstructions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brokerClient) WatchInstructions(ctx context.Context, in *WatchInstructionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InstructionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Broker_ServiceDesc.Streams[0], Broker_WatchInstructions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchInstructionsRequest, InstructionEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Broker_WatchInstructionsClient = grpc.ServerStreamingClient[InstructionEvent]
+
+// BrokerServer is the server API for Broker service.
+// All implementations must embed UnimplementedBrokerServer
+// for forward compatibility.
+//
+// Broker places and tracks resource reservations across clusters
+type BrokerServer interface {
+	// PublishAdvertisement stores the calling cluster's advertisement and returns its pending provider instructions
+	PublishAdvertisement(context.Context, *Advertisement) (*PublishAdvertisementResponse, error)
+	// RequestReservation reserves resources synchronously, or queues or schedules the request
+	RequestReservation(context.Context, *RequestReservationRequest) (*Reservation, error)
+	// RequestGangReservation reserves several resource blocks all-or-nothing
+	RequestGangReservation(context.Context, *RequestGangReservationRequest) (*Reservation, error)
+	// GetReservation returns a reservation or reservation group
+	GetReservation(context.Context, *ReservationRef) (*Reservation, error)
+	// ActivateReservation reports that the requester started using a reservation
+	ActivateReservation(context.Context, *ReservationRef) (*Reservation, error)
+	// AcknowledgeReservation gives the provider's answer to a reservation it was instructed to hold
+	AcknowledgeReservation(context.Context, *AcknowledgeReservationRequest) (*Reservation, error)
+	// HeartbeatReservation reports that the requester still uses a reservation
+	HeartbeatReservation(context.Context, *ReservationRef) (*Reservation, error)
+	// RenewReservation extends a reservation's expiry
+	RenewReservation(context.Context, *RenewReservationRequest) (*Reservation, error)
+	// ResizeReservation changes the CPU and memory of a reservation in place
+	ResizeReservation(context.Context, *ResizeReservationRequest) (*Reservation, error)
+	// ReleaseReservation gives a reservation or reservation group back
+	ReleaseReservation(context.Context, *ReservationRef) (*Reservation, error)
+	// FetchInstructions returns the calling cluster's current instructions
+	FetchInstructions(context.Context, *FetchInstructionsRequest) (*FetchInstructionsResponse, error)
+	// WatchInstructions streams the calling cluster's instructions as they are issued
+	WatchInstructions(*WatchInstructionsRequest, grpc.ServerStreamingServer[InstructionEvent]) error
+	mustEmbedUnimplementedBrokerServer()
+}
+
+// UnimplementedBrokerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBrokerServer struct{}
+
+func (UnimplementedBrokerServer) PublishAdvertisement(context.Context, *Advertisement) (*PublishAdvertisementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishAdvertisement not implemented")
+}
+func (UnimplementedBrokerServer) RequestReservation(context.Context, *RequestReservationRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestReservation not implemented")
+}
+func (UnimplementedBrokerServer) RequestGangReservation(context.Context, *RequestGangReservationRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestGangReservation not implemented")
+}
+func (UnimplementedBrokerServer) GetReservation(context.Context, *ReservationRef) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReservation not implemented")
+}
+func (UnimplementedBrokerServer) ActivateReservation(context.Context, *ReservationRef) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActivateReservation not implemented")
+}
+func (UnimplementedBrokerServer) AcknowledgeReservation(context.Context, *AcknowledgeReservationRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcknowledgeReservation not implemented")
+}
+func (UnimplementedBrokerServer) HeartbeatReservation(context.Context, *ReservationRef) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HeartbeatReservation not implemented")
+}
+func (UnimplementedBrokerServer) RenewReservation(context.Context, *RenewReservationRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewReservation not implemented")
+}
+func (UnimplementedBrokerServer) ResizeReservation(context.Context, *ResizeReservationRequest) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResizeReservation not implemented")
+}
+func (UnimplementedBrokerServer) ReleaseReservation(context.Context, *ReservationRef) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseReservation not implemented")
+}
+func (UnimplementedBrokerServer) FetchInstructions(context.Context, *FetchInstructionsRequest) (*FetchInstructionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchInstructions not implemented")
+}
+func (UnimplementedBrokerServer) WatchInstructions(*WatchInstructionsRequest, grpc.ServerStreamingServer[InstructionEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInstructions not implemented")
+}
+func (UnimplementedBrokerServer) mustEmbedUnimplementedBrokerServer() {}
+func (UnimplementedBrokerServer) testEmbeddedByValue()                {}
+
+// UnsafeBrokerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BrokerServer will
+// result in compilation errors.
+type UnsafeBrokerServer interface {
+	mustEmbedUnimplementedBrokerServer()
+}
+
+func RegisterBrokerServer(s grpc.ServiceRegistrar, srv BrokerServer) {
+	// If the following call pancis, it indicates UnimplementedBrokerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Broker_ServiceDesc, srv)
+}
+
+func _Broker_PublishAdvertisement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Advertisement)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).PublishAdvertisement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_PublishAdvertisement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).PublishAdvertisement(ctx, req.(*Advertisement))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_RequestReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).RequestReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_RequestReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).RequestReservation(ctx, req.(*RequestReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_RequestGangReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestGangReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).RequestGangReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_RequestGangReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).RequestGangReservation(ctx, req.(*RequestGangReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_GetReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservationRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).GetReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_GetReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).GetReservation(ctx, req.(*ReservationRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_ActivateReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservationRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).ActivateReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_ActivateReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).ActivateReservation(ctx, req.(*ReservationRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_AcknowledgeReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcknowledgeReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).AcknowledgeReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_AcknowledgeReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).AcknowledgeReservation(ctx, req.(*AcknowledgeReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_HeartbeatReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservationRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).HeartbeatReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_HeartbeatReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).HeartbeatReservation(ctx, req.(*ReservationRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_RenewReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).RenewReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_RenewReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).RenewReservation(ctx, req.(*RenewReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_ResizeReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResizeReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).ResizeReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_ResizeReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).ResizeReservation(ctx, req.(*ResizeReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_ReleaseReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservationRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).ReleaseReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_ReleaseReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).ReleaseReservation(ctx, req.(*ReservationRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_FetchInstructions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchInstructionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrokerServer).FetchInstructions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Broker_FetchInstructions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrokerServer).FetchInstructions(ctx, req.(*FetchInstructionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Broker_WatchInstructions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchInstructionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrokerServer).WatchInstructions(m, &grpc.GenericServerStream[WatchInstructionsRequest, InstructionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Broker_WatchInstructionsServer = grpc.ServerStreamingServer[InstructionEvent]
+
+// Broker_ServiceDesc is the grpc.ServiceDesc for Broker service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Broker_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "broker.v1.Broker",
+	HandlerType: (*BrokerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PublishAdvertisement",
+			Handler:    _Broker_PublishAdvertisement_Handler,
+		},
+		{
+			MethodName: "RequestReservation",
+			Handler:    _Broker_RequestReservation_Handler,
+		},
+		{
+			MethodName: "RequestGangReservation",
+			Handler:    _Broker_RequestGangReservation_Handler,
+		},
+		{
+			MethodName: "GetReservation",
+			Handler:    _Broker_GetReservation_Handler,
+		},
+		{
+			MethodName: "ActivateReservation",
+			Handler:    _Broker_ActivateReservation_Handler,
+		},
+		{
+			MethodName: "AcknowledgeReservation",
+			Handler:    _Broker_AcknowledgeReservation_Handler,
+		},
+		{
+			MethodName: "HeartbeatReservation",
+			Handler:    _Broker_HeartbeatReservation_Handler,
+		},
+		{
+			MethodName: "RenewReservation",
+			Handler:    _Broker_RenewReservation_Handler,
+		},
+		{
+			MethodName: "ResizeReservation",
+			Handler:    _Broker_ResizeReservation_Handler,
+		},
+		{
+			MethodName: "ReleaseReservation",
+			Handler:    _Broker_ReleaseReservation_Handler,
+		},
+		{
+			MethodName: "FetchInstructions",
+			Handler:    _Broker_FetchInstructions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchInstructions",
+			Handler:       _Broker_WatchInstructions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "broker/v1/broker.proto",
+}
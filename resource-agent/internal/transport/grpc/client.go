@@ -0,0 +1,445 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/grpc/brokerpb"
+)
+
+// retryServiceConfig gives unary calls a 30 s timeout and retries the ones the
+// broker could not serve with exponential backoff, like the HTTP transport
+// does for 5xx responses. Instruction streams have no timeout.
+const retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "broker.v1.Broker"}],
+		"timeout": "30s",
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "1s",
+			"maxBackoff": "16s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}, {
+		"name": [{"service": "broker.v1.Broker", "method": "WatchInstructions"}]
+	}]
+}`
+
+// GRPCCommunicator implements BrokerCommunicator interface using the broker's gRPC service
+type GRPCCommunicator struct {
+	conn      *grpc.ClientConn
+	client    brokerpb.BrokerClient
+	health    healthpb.HealthClient
+	clusterID string
+}
+
+// NewGRPCCommunicator creates a new gRPC-based broker communicator with mTLS.
+// brokerAddress is host:port; the certificates are those of the HTTP transport.
+func NewGRPCCommunicator(brokerAddress, certPath, clusterID string) (*GRPCCommunicator, error) {
+	// Load client certificate (tls.crt, tls.key)
+	cert, err := tls.LoadX509KeyPair(
+		filepath.Join(certPath, "tls.crt"),
+		filepath.Join(certPath, "tls.key"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	// Load CA certificate for server verification
+	caCert, err := os.ReadFile(filepath.Join(certPath, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to append CA certificate")
+	}
+
+	// Create TLS config with mTLS
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caCertPool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	// The connection is established lazily and re-established as needed
+	conn, err := grpc.NewClient(brokerAddress,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultServiceConfig(retryServiceConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	return &GRPCCommunicator{
+		conn:      conn,
+		client:    brokerpb.NewBrokerClient(conn),
+		health:    healthpb.NewHealthClient(conn),
+		clusterID: clusterID,
+	}, nil
+}
+
+// PublishAdvertisement publishes cluster advertisement to broker via gRPC.
+// The broker keeps its own Reserved field, so unlike the HTTP transport no
+// read is needed first. Returns the provider instructions of the response.
+func (c *GRPCCommunicator) PublishAdvertisement(ctx context.Context, adv *dto.AdvertisementDTO) ([]*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	msg := &brokerpb.Advertisement{}
+	if err := toMessage(adv, msg); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.PublishAdvertisement(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish advertisement: %w", err)
+	}
+
+	var advResponse dto.AdvertisementResponseDTO
+	if err := fromMessage(resp, &advResponse); err != nil {
+		// Non-fatal: advertisement was published, just can't convert provider instructions
+		logger.Error(err, "Failed to decode advertisement response (advertisement was published)")
+		return nil, nil
+	}
+
+	logger.Info("Advertisement published successfully",
+		"clusterID", adv.ClusterID,
+		"availableCPU", adv.Resources.Available.CPU,
+		"availableMemory", adv.Resources.Available.Memory,
+		"providerInstructions", len(advResponse.ProviderInstructions))
+
+	return advResponse.ProviderInstructions, nil
+}
+
+// RequestReservation sends a synchronous reservation request to the broker.
+// Requests with the same idempotency key return the same reservation.
+func (c *GRPCCommunicator) RequestReservation(
+	ctx context.Context,
+	reqDTO *dto.ReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	req := &brokerpb.RequestReservationRequest{
+		Reservation:    &brokerpb.ReservationRequest{},
+		IdempotencyKey: idempotencyKey,
+	}
+	if err := toMessage(reqDTO, req.Reservation); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.RequestReservation(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send reservation request: %w", err)
+	}
+
+	reservation, err := toReservation(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// A Pending reservation was queued until capacity frees up
+	if reservation.Status.Phase == "Pending" {
+		logger.Info("Reservation queued by broker",
+			"reservationID", reservation.ID,
+			"queuePosition", reservation.Status.QueuePosition)
+		return reservation, nil
+	}
+
+	logger.Info("Reservation created synchronously",
+		"reservationID", reservation.ID,
+		"targetCluster", reservation.TargetClusterID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return reservation, nil
+}
+
+// RequestGangReservation sends an all-or-nothing multi-block reservation request.
+// The broker either locks every member or none of them.
+func (c *GRPCCommunicator) RequestGangReservation(
+	ctx context.Context,
+	reqDTO *dto.GangReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	req := &brokerpb.RequestGangReservationRequest{}
+	if err := toMessage(reqDTO, req); err != nil {
+		return nil, err
+	}
+	req.IdempotencyKey = idempotencyKey
+
+	resp, err := c.client.RequestGangReservation(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send gang reservation request: %w", err)
+	}
+
+	reservation, err := toReservation(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Gang reservation created synchronously",
+		"groupID", reservation.ID,
+		"members", len(reservation.Parts))
+
+	return reservation, nil
+}
+
+// GetReservation fetches the current state of a reservation from the broker
+func (c *GRPCCommunicator) GetReservation(ctx context.Context, reservationID string) (*dto.ReservationDTO, error) {
+	resp, err := c.client.GetReservation(ctx, &brokerpb.ReservationRef{Id: reservationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return toReservation(resp)
+}
+
+// ActivateReservation tells the broker that this cluster started using the reservation
+func (c *GRPCCommunicator) ActivateReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	if _, err := c.client.ActivateReservation(ctx, &brokerpb.ReservationRef{Id: reservationID}); err != nil {
+		return fmt.Errorf("failed to activate reservation: %w", err)
+	}
+
+	logger.Info("Reservation activated at broker", "reservation", reservationID)
+	return nil
+}
+
+// AcknowledgeReservation accepts or rejects a reservation as its provider
+func (c *GRPCCommunicator) AcknowledgeReservation(ctx context.Context, reservationID string, accepted bool, reason string) error {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	_, err := c.client.AcknowledgeReservation(ctx, &brokerpb.AcknowledgeReservationRequest{
+		Id:       reservationID,
+		Accepted: accepted,
+		Reason:   reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge reservation: %w", err)
+	}
+
+	logger.Info("Reservation acknowledged at broker",
+		"reservation", reservationID,
+		"accepted", accepted,
+		"reason", reason)
+	return nil
+}
+
+// HeartbeatReservation tells the broker the requester still uses a reservation
+func (c *GRPCCommunicator) HeartbeatReservation(ctx context.Context, reservationID string) error {
+	if _, err := c.client.HeartbeatReservation(ctx, &brokerpb.ReservationRef{Id: reservationID}); err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	return nil
+}
+
+// RenewReservation extends the expiry of a reservation at the broker
+func (c *GRPCCommunicator) RenewReservation(ctx context.Context, reservationID, duration string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	resp, err := c.client.RenewReservation(ctx, &brokerpb.RenewReservationRequest{Id: reservationID, Duration: duration})
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew reservation: %w", err)
+	}
+
+	reservation, err := toReservation(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Reservation renewed at broker",
+		"reservation", reservationID,
+		"expiresAt", reservation.Status.ExpiresAt)
+
+	return reservation, nil
+}
+
+// ResizeReservation changes the CPU and memory of a reservation at the broker
+func (c *GRPCCommunicator) ResizeReservation(
+	ctx context.Context,
+	reservationID string,
+	resources dto.ResourceQuantitiesDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	req := &brokerpb.ResizeReservationRequest{Id: reservationID, RequestedResources: &brokerpb.ResourceQuantities{}}
+	if err := toMessage(&resources, req.RequestedResources); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.ResizeReservation(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resize reservation: %w", err)
+	}
+
+	reservation, err := toReservation(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Reservation resized at broker",
+		"reservation", reservationID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return reservation, nil
+}
+
+// ReleaseReservation releases a reservation, or every part of a group, at the broker.
+// A reservation the broker no longer knows is treated as already released.
+func (c *GRPCCommunicator) ReleaseReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("grpc-communicator")
+
+	_, err := c.client.ReleaseReservation(ctx, &brokerpb.ReservationRef{Id: reservationID})
+	switch status.Code(err) {
+	case codes.OK:
+		logger.Info("Reservation released at broker", "reservation", reservationID)
+		return nil
+	case codes.NotFound:
+		logger.Info("Reservation not found at broker, nothing to release", "reservation", reservationID)
+		return nil
+	default:
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+}
+
+// FetchInstructions polls the broker for pending provider instructions
+func (c *GRPCCommunicator) FetchInstructions(ctx context.Context) ([]*dto.ReservationDTO, error) {
+	resp, err := c.client.FetchInstructions(ctx, &brokerpb.FetchInstructionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch instructions: %w", err)
+	}
+
+	instructions := make([]*dto.ReservationDTO, 0, len(resp.Instructions))
+	for _, msg := range resp.Instructions {
+		instruction, err := toReservation(msg)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, instruction)
+	}
+	return instructions, nil
+}
+
+// streamIdleTimeout ends an instruction stream that received nothing, not
+// even a bookmark, for this long (the broker sends one every 30 s)
+const streamIdleTimeout = 90 * time.Second
+
+// WatchInstructions streams instructions via the WatchInstructions RPC.
+// Every event carries a resume token; bookmarks carry no instruction and
+// only advance it.
+func (c *GRPCCommunicator) WatchInstructions(
+	ctx context.Context,
+	resumeToken string,
+	handle func(instruction *dto.ReservationDTO),
+) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A silent connection is presumed dead
+	idle := time.AfterFunc(streamIdleTimeout, cancel)
+	defer idle.Stop()
+
+	stream, err := c.client.WatchInstructions(ctx, &brokerpb.WatchInstructionsRequest{ResumeToken: resumeToken})
+	if err != nil {
+		return resumeToken, fmt.Errorf("failed to open instruction stream: %w", err)
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return resumeToken, fmt.Errorf("instruction stream closed by broker")
+		}
+		if err != nil {
+			return resumeToken, fmt.Errorf("instruction stream broken: %w", err)
+		}
+		idle.Reset(streamIdleTimeout)
+
+		if event.Instruction != nil {
+			instruction, err := toReservation(event.Instruction)
+			if err != nil {
+				return resumeToken, err
+			}
+			handle(instruction)
+		}
+		if event.ResumeToken != "" {
+			resumeToken = event.ResumeToken
+		}
+	}
+}
+
+// Ping checks connectivity to broker via the standard health service
+func (c *GRPCCommunicator) Ping(ctx context.Context) error {
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("broker is %s", resp.Status)
+	}
+	return nil
+}
+
+// Close cleans up resources
+func (c *GRPCCommunicator) Close() error {
+	return c.conn.Close()
+}
+
+// unmarshalOptions tolerate fields added to newer brokers' messages
+var unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// toMessage converts a DTO into the message with the same JSON form
+func toMessage(in any, out proto.Message) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %T: %w", in, err)
+	}
+	if err := unmarshalOptions.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to convert %T: %w", in, err)
+	}
+	return nil
+}
+
+// fromMessage converts a message into the DTO with the same JSON form
+func fromMessage(in proto.Message, out any) error {
+	data, err := protojson.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %T: %w", in, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to convert %T: %w", in, err)
+	}
+	return nil
+}
+
+// toReservation converts a reservation message into its DTO
+func toReservation(msg *brokerpb.Reservation) (*dto.ReservationDTO, error) {
+	var reservation dto.ReservationDTO
+	if err := fromMessage(msg, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
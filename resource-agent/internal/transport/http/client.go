@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -11,8 +12,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
 	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -127,7 +130,7 @@ func (c *HTTPCommunicator) PublishAdvertisement(ctx context.Context, adv *dto.Ad
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("broker returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
 	}
 
 	// STEP 3: Parse response which includes provider instructions
@@ -149,8 +152,14 @@ func (c *HTTPCommunicator) PublishAdvertisement(ctx context.Context, adv *dto.Ad
 
 // RequestReservation sends a synchronous reservation request to the broker.
 // The broker runs its decision engine inline and returns the instruction
-// in the response. No polling needed.
-func (c *HTTPCommunicator) RequestReservation(ctx context.Context, reqDTO *dto.ReservationRequestDTO) (*dto.ReservationDTO, error) {
+// in the response. No polling needed. The idempotency key is sent as the
+// Idempotency-Key header, so retries of a request the broker already
+// committed return the same reservation.
+func (c *HTTPCommunicator) RequestReservation(
+	ctx context.Context,
+	reqDTO *dto.ReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
 	logger := log.FromContext(ctx).WithName("http-communicator")
 
 	body, err := json.Marshal(reqDTO)
@@ -164,6 +173,7 @@ func (c *HTTPCommunicator) RequestReservation(ctx context.Context, reqDTO *dto.R
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	setIdempotencyKey(req, idempotencyKey)
 
 	resp, err := c.doWithRetry(ctx, req)
 	if err != nil {
@@ -171,9 +181,11 @@ func (c *HTTPCommunicator) RequestReservation(ctx context.Context, reqDTO *dto.R
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+	// 202 Accepted means the broker queued the request until capacity frees up
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusAccepted {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("broker returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
 	}
 
 	var reservation dto.ReservationDTO
@@ -181,6 +193,13 @@ func (c *HTTPCommunicator) RequestReservation(ctx context.Context, reqDTO *dto.R
 		return nil, fmt.Errorf("failed to decode reservation response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusAccepted {
+		logger.Info("Reservation queued by broker",
+			"reservationID", reservation.ID,
+			"queuePosition", reservation.Status.QueuePosition)
+		return &reservation, nil
+	}
+
 	logger.Info("Reservation created synchronously",
 		"reservationID", reservation.ID,
 		"targetCluster", reservation.TargetClusterID,
@@ -190,6 +209,275 @@ func (c *HTTPCommunicator) RequestReservation(ctx context.Context, reqDTO *dto.R
 	return &reservation, nil
 }
 
+// RequestGangReservation sends an all-or-nothing multi-block reservation request.
+// The broker either locks every member or none of them.
+func (c *HTTPCommunicator) RequestGangReservation(
+	ctx context.Context,
+	reqDTO *dto.GangReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gang reservation request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/reservations:gang", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setIdempotencyKey(req, idempotencyKey)
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send gang reservation request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	var reservation dto.ReservationDTO
+	if err := json.NewDecoder(resp.Body).Decode(&reservation); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation response: %w", err)
+	}
+
+	logger.Info("Gang reservation created synchronously",
+		"groupID", reservation.ID,
+		"members", len(reservation.Parts))
+
+	return &reservation, nil
+}
+
+// GetReservation fetches the current state of a reservation from the broker
+func (c *HTTPCommunicator) GetReservation(ctx context.Context, reservationID string) (*dto.ReservationDTO, error) {
+	url := fmt.Sprintf("%s/api/v1/reservations/%s", c.baseURL, reservationID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	var reservation dto.ReservationDTO
+	if err := json.NewDecoder(resp.Body).Decode(&reservation); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation: %w", err)
+	}
+
+	return &reservation, nil
+}
+
+// ActivateReservation tells the broker that this cluster started using the reservation
+func (c *HTTPCommunicator) ActivateReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	url := fmt.Sprintf("%s/api/v1/reservations/%s/activate", c.baseURL, reservationID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to activate reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	logger.Info("Reservation activated at broker", "reservation", reservationID)
+	return nil
+}
+
+// AcknowledgeReservation accepts or rejects a reservation as its provider
+func (c *HTTPCommunicator) AcknowledgeReservation(ctx context.Context, reservationID string, accepted bool, reason string) error {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	body, err := json.Marshal(&dto.AcknowledgeRequestDTO{Accepted: accepted, Reason: reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal acknowledgement: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/reservations/%s/acknowledge", c.baseURL, reservationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	logger.Info("Reservation acknowledged at broker",
+		"reservation", reservationID,
+		"accepted", accepted,
+		"reason", reason)
+	return nil
+}
+
+// HeartbeatReservation tells the broker the requester still uses a reservation
+func (c *HTTPCommunicator) HeartbeatReservation(ctx context.Context, reservationID string) error {
+	url := fmt.Sprintf("%s/api/v1/reservations/%s/heartbeat", c.baseURL, reservationID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+	return nil
+}
+
+// RenewReservation extends the expiry of a reservation at the broker
+func (c *HTTPCommunicator) RenewReservation(ctx context.Context, reservationID, duration string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	body, err := json.Marshal(&dto.RenewRequestDTO{Duration: duration})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal renew request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/reservations/%s/renew", c.baseURL, reservationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	var reservation dto.ReservationDTO
+	if err := json.NewDecoder(resp.Body).Decode(&reservation); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation: %w", err)
+	}
+
+	logger.Info("Reservation renewed at broker",
+		"reservation", reservationID,
+		"expiresAt", reservation.Status.ExpiresAt)
+
+	return &reservation, nil
+}
+
+// ResizeReservation changes the CPU and memory of a reservation at the broker
+func (c *HTTPCommunicator) ResizeReservation(
+	ctx context.Context,
+	reservationID string,
+	resources dto.ResourceQuantitiesDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	body, err := json.Marshal(&dto.ResizeRequestDTO{RequestedResources: resources})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resize request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/reservations/%s/resize", c.baseURL, reservationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resize reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+
+	var reservation dto.ReservationDTO
+	if err := json.NewDecoder(resp.Body).Decode(&reservation); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation: %w", err)
+	}
+
+	logger.Info("Reservation resized at broker",
+		"reservation", reservationID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return &reservation, nil
+}
+
+// ReleaseReservation releases a reservation, or every part of a group, at the broker.
+// A reservation the broker no longer knows is treated as already released.
+func (c *HTTPCommunicator) ReleaseReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("http-communicator")
+
+	url := fmt.Sprintf("%s/api/v1/reservations/%s", c.baseURL, reservationID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNoContent:
+		logger.Info("Reservation released at broker", "reservation", reservationID)
+		return nil
+	case http.StatusNotFound:
+		logger.Info("Reservation not found at broker, nothing to release", "reservation", reservationID)
+		return nil
+	default:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+}
+
 // FetchInstructions polls the broker for pending provider instructions.
 // This is a lightweight GET request that returns near-instantly.
 func (c *HTTPCommunicator) FetchInstructions(ctx context.Context) ([]*dto.ReservationDTO, error) {
@@ -208,7 +496,7 @@ func (c *HTTPCommunicator) FetchInstructions(ctx context.Context) ([]*dto.Reserv
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("broker returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
 	}
 
 	var instructions []*dto.ReservationDTO
@@ -219,6 +507,88 @@ func (c *HTTPCommunicator) FetchInstructions(ctx context.Context) ([]*dto.Reserv
 	return instructions, nil
 }
 
+// streamIdleTimeout ends an instruction stream that received nothing, not
+// even a bookmark, for this long (the broker sends one every 30 s)
+const streamIdleTimeout = 90 * time.Second
+
+// WatchInstructions streams instructions via GET /api/v1/instructions?watch=true.
+// The broker sends Server-Sent Events; every event id is a resume token and
+// "bookmark" events only advance it.
+func (c *HTTPCommunicator) WatchInstructions(
+	ctx context.Context,
+	resumeToken string,
+	handle func(instruction *dto.ReservationDTO),
+) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A silent connection is presumed dead
+	idle := time.AfterFunc(streamIdleTimeout, cancel)
+	defer idle.Stop()
+
+	url := fmt.Sprintf("%s/api/v1/instructions?watch=true", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return resumeToken, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if resumeToken != "" {
+		req.Header.Set("Last-Event-ID", resumeToken)
+	}
+
+	// Same connection pool, without the timeout of unary requests
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return resumeToken, fmt.Errorf("failed to open instruction stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return resumeToken, &transport.StatusError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		return resumeToken, fmt.Errorf("broker does not stream instructions (content type %q)", contentType)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	var event, id string
+	var data []byte
+	for scanner.Scan() {
+		idle.Reset(streamIdleTimeout)
+
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line dispatches the event
+			if event == "instruction" {
+				var instruction dto.ReservationDTO
+				if err := json.Unmarshal(data, &instruction); err != nil {
+					return resumeToken, fmt.Errorf("failed to decode instruction: %w", err)
+				}
+				handle(&instruction)
+			}
+			if id != "" {
+				resumeToken = id
+			}
+			event, id, data = "", "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:"))...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return resumeToken, fmt.Errorf("instruction stream broken: %w", err)
+	}
+	return resumeToken, fmt.Errorf("instruction stream closed by broker")
+}
+
 // Ping checks connectivity to broker
 func (c *HTTPCommunicator) Ping(ctx context.Context) error {
 	url := fmt.Sprintf("%s/healthz", c.baseURL)
@@ -235,7 +605,7 @@ func (c *HTTPCommunicator) Ping(ctx context.Context) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("broker returned status %d", resp.StatusCode)
+		return &transport.StatusError{StatusCode: resp.StatusCode}
 	}
 
 	return nil
@@ -248,6 +618,13 @@ func (c *HTTPCommunicator) Close() error {
 	return nil
 }
 
+// setIdempotencyKey lets the broker recognise retries of the same request
+func setIdempotencyKey(req *http.Request, idempotencyKey string) {
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+}
+
 // doWithRetry executes HTTP request with exponential backoff retry logic
 func (c *HTTPCommunicator) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
 	backoff := 1 * time.Second
@@ -16,14 +16,67 @@ type BrokerCommunicator interface {
 
 	// RequestReservation sends a synchronous reservation request to the broker.
 	// The broker decides and reserves resources inline, returning the instruction
-	// in the response. No polling needed.
-	RequestReservation(ctx context.Context, req *dto.ReservationRequestDTO) (*dto.ReservationDTO, error)
+	// in the response. No polling needed. Requests with the same non-empty
+	// idempotencyKey return the reservation created by the first one, so
+	// retries never reserve twice.
+	RequestReservation(ctx context.Context, req *dto.ReservationRequestDTO, idempotencyKey string) (*dto.ReservationDTO, error)
+
+	// RequestGangReservation reserves several resource blocks all-or-nothing.
+	// The response lists one part per block, each with its target cluster.
+	// idempotencyKey works as for RequestReservation.
+	RequestGangReservation(
+		ctx context.Context,
+		req *dto.GangReservationRequestDTO,
+		idempotencyKey string,
+	) (*dto.ReservationDTO, error)
+
+	// GetReservation fetches the current state of a reservation by ID.
+	// Used to follow queued reservations until the broker places them.
+	GetReservation(ctx context.Context, reservationID string) (*dto.ReservationDTO, error)
+
+	// ActivateReservation reports that the requester started using a reservation
+	// (e.g. after Liqo peering), so the broker promotes it from Reserved to Active.
+	ActivateReservation(ctx context.Context, reservationID string) error
+
+	// AcknowledgeReservation gives the provider's answer to a reservation it
+	// was instructed to hold. On rejection the broker frees the capacity and
+	// fails the reservation with the reason.
+	AcknowledgeReservation(ctx context.Context, reservationID string, accepted bool, reason string) error
+
+	// HeartbeatReservation reports that the requester still uses a reservation
+	// (group IDs cover every part). Brokers with a heartbeat timeout orphan
+	// and eventually release Active reservations without heartbeats.
+	HeartbeatReservation(ctx context.Context, reservationID string) error
+
+	// RenewReservation extends a reservation's expiry to now plus duration
+	// (empty uses the reservation's own duration), within the broker's
+	// maximum lifetime. Returns the reservation with its new expiry.
+	RenewReservation(ctx context.Context, reservationID, duration string) (*dto.ReservationDTO, error)
+
+	// ResizeReservation changes the CPU and memory of a Reserved or Active
+	// reservation on its current provider. Returns the resized reservation.
+	ResizeReservation(ctx context.Context, reservationID string, resources dto.ResourceQuantitiesDTO) (*dto.ReservationDTO, error)
+
+	// ReleaseReservation gives a reservation back to the broker, which frees the
+	// capacity locked for it. Group IDs release every part of the group.
+	ReleaseReservation(ctx context.Context, reservationID string) error
 
 	// FetchInstructions polls the broker for pending provider instructions.
 	// This provides near-instant instruction delivery (every few seconds)
 	// instead of waiting for the next advertisement cycle.
 	FetchInstructions(ctx context.Context) ([]*dto.ReservationDTO, error)
 
+	// WatchInstructions streams the instructions FetchInstructions returns as
+	// the broker issues them, calling handle for each. The stream continues
+	// after resumeToken, or starts with every current instruction if it is
+	// empty or the broker can no longer resume from it, so instructions may
+	// repeat. Blocks until the stream ends and returns the token to resume with.
+	WatchInstructions(
+		ctx context.Context,
+		resumeToken string,
+		handle func(instruction *dto.ReservationDTO),
+	) (string, error)
+
 	// Ping checks connectivity to broker
 	Ping(ctx context.Context) error
 
@@ -0,0 +1,374 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReservationFinalizer is the finalizer for reservations
+const ReservationFinalizer = "reservation.broker.fluidos.eu/finalizer"
+
+// ReservationGroupLabel carries the GroupID on the parts of a split reservation
+const ReservationGroupLabel = "broker.fluidos.eu/reservation-group"
+
+// ReservationIdempotencyKeyAnnotation records the Idempotency-Key of the
+// request that created a reservation
+const ReservationIdempotencyKeyAnnotation = "broker.fluidos.eu/idempotency-key"
+
+// ReservationRequestHashAnnotation records a hash of the request that created
+// a reservation with an idempotency key, so reusing the key for a different
+// request is detected
+const ReservationRequestHashAnnotation = "broker.fluidos.eu/request-hash"
+
+// ReservationDecidedByAPIAnnotation marks reservations created by the broker's
+// API (HTTP, gRPC or MQTT), which decides and locks them itself. The
+// reservation controller leaves them alone until the API moved them out of
+// the initial phase, so their resources are never locked twice.
+const ReservationDecidedByAPIAnnotation = "broker.fluidos.eu/decided-by-api"
+
+// ReservationLockedByAPIAnnotation is set by the broker's API once it locked a
+// reservation's resources, before marking it Reserved. A reservation the
+// controller takes over with this annotation is marked Reserved without
+// locking again.
+const ReservationLockedByAPIAnnotation = "broker.fluidos.eu/locked-by-api"
+
+// ReservationRenewalRequestAnnotation is set by a requester that renews a
+// reservation through the Kubernetes API. Its value is the requested extension
+// as a duration, or empty for the reservation's own duration. The reservation
+// controller applies it as POST /api/v1/reservations/{id}/renew does,
+// including the maximum reservation lifetime, records the outcome in the
+// Renewed condition and removes the annotation.
+const ReservationRenewalRequestAnnotation = "broker.fluidos.eu/renewal-request"
+
+// ReservationSpec defines the desired state of Reservation
+type ReservationSpec struct {
+	// TargetClusterID is the cluster where resources should be reserved
+	// If not specified, the broker will automatically select the best cluster
+	// +optional
+	TargetClusterID string `json:"targetClusterID,omitempty"`
+
+	// RequestedResources are the resources being requested
+	RequestedResources RequestedResourceQuantities `json:"requestedResources"`
+
+	// Duration is how long the reservation should last (optional)
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// StartTime schedules the reservation for a future window of Duration
+	// starting at this time. The reservation stays Scheduled, holding a slot
+	// in the target cluster's future capacity, and locks its resources when
+	// the window opens. Requires Duration.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Priority of this reservation (higher number = higher priority)
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// RequesterID identifies who is requesting the reservation
+	// +optional
+	RequesterID string `json:"requesterID,omitempty"`
+
+	// ScoringStrategy overrides the broker's default strategy for ranking candidate clusters
+	// +optional
+	ScoringStrategy ScoringStrategyType `json:"scoringStrategy,omitempty"`
+
+	// Placement restricts and ranks candidate clusters by their advertised labels
+	// +optional
+	Placement *PlacementConstraints `json:"placement,omitempty"`
+
+	// Queue keeps the reservation Pending when no cluster has enough capacity,
+	// instead of failing it. Queued reservations are retried whenever a
+	// ClusterAdvertisement changes, ordered by Priority and then age.
+	// +optional
+	Queue bool `json:"queue,omitempty"`
+
+	// GroupID links the parts of a request that was split across several
+	// clusters. Every part is a Reservation of its own; all parts were
+	// locked together or not at all.
+	// +optional
+	GroupID string `json:"groupID,omitempty"`
+}
+
+// PlacementConstraints are matched against ClusterAdvertisement labels.
+// Modelled after node affinity: required terms filter, preferred terms rank.
+type PlacementConstraints struct {
+	// Required requirements must all match; other clusters are never selected
+	// (e.g., region In [eu-west-1, eu-central-1] for EU-only data residency)
+	// +optional
+	Required []metav1.LabelSelectorRequirement `json:"required,omitempty"`
+
+	// Preferred terms favour matching clusters without excluding the others
+	// +optional
+	Preferred []PreferredPlacementTerm `json:"preferred,omitempty"`
+}
+
+// PreferredPlacementTerm adds its weight to clusters matching all of its requirements
+type PreferredPlacementTerm struct {
+	// Weight of this term relative to the other preferred terms
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Requirements that must all match for the term to apply
+	Requirements []metav1.LabelSelectorRequirement `json:"requirements"`
+}
+
+// ScoringStrategyType names a strategy the decision engine uses to rank candidate clusters
+// +kubebuilder:validation:Enum=LeastAllocated;MostAllocated;LowestCost;BalancedResource
+type ScoringStrategyType string
+
+const (
+	// ScoringStrategyLeastAllocated - Prefer clusters with the most headroom left (spread)
+	ScoringStrategyLeastAllocated ScoringStrategyType = "LeastAllocated"
+
+	// ScoringStrategyMostAllocated - Prefer clusters with the least headroom left (bin-pack)
+	ScoringStrategyMostAllocated ScoringStrategyType = "MostAllocated"
+
+	// ScoringStrategyLowestCost - Prefer clusters with the lowest advertised cost for the request
+	ScoringStrategyLowestCost ScoringStrategyType = "LowestCost"
+
+	// ScoringStrategyBalancedResource - Prefer clusters whose CPU and memory usage stay balanced
+	ScoringStrategyBalancedResource ScoringStrategyType = "BalancedResource"
+)
+
+// RequestedResourceQuantities represents requested resource amounts
+type RequestedResourceQuantities struct {
+	// CPU cores requested
+	CPU resource.Quantity `json:"cpu"`
+
+	// Memory requested
+	Memory resource.Quantity `json:"memory"`
+
+	// GPU requested (optional)
+	// +optional
+	GPU *resource.Quantity `json:"gpu,omitempty"`
+
+	// Storage requested (optional)
+	// +optional
+	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Extended resources requested, keyed by Kubernetes resource name (optional)
+	// +optional
+	Extended map[string]resource.Quantity `json:"extended,omitempty"`
+}
+
+// ReservationStatus defines the observed state of Reservation
+type ReservationStatus struct {
+	// Phase represents the current state of the reservation
+	// Possible values: Pending, Scheduled, Reserved, Active, Orphaned, Failed, Released, Preempted
+	// +optional
+	Phase ReservationPhase `json:"phase,omitempty"`
+
+	// Message provides additional information about the status
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ReservedAt is when the reservation was confirmed
+	// +optional
+	ReservedAt *metav1.Time `json:"reservedAt,omitempty"`
+
+	// ExpiresAt is when the reservation expires
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// LastHeartbeatTime is when the requester last reported that it still uses
+	// the reservation
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+
+	// LastUpdateTime
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// QueuePosition is the 1-based position in the capacity queue while the
+	// reservation is queued (0 when not queued)
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// Decision explains how the broker chose (or failed to choose) a cluster
+	// +optional
+	Decision *DecisionRecord `json:"decision,omitempty"`
+
+	// Conditions represent the latest observations of the reservation state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DecisionRecord lists every candidate cluster the decision engine looked at,
+// why it was filtered out or how it scored, and which one was selected
+type DecisionRecord struct {
+	// DecidedAt is when the decision was made
+	DecidedAt metav1.Time `json:"decidedAt"`
+
+	// Strategy is the scoring strategy used to rank eligible clusters
+	// +optional
+	Strategy ScoringStrategyType `json:"strategy,omitempty"`
+
+	// SelectedClusterID is the chosen cluster (empty if none fit)
+	// +optional
+	SelectedClusterID string `json:"selectedClusterID,omitempty"`
+
+	// Message summarizes the outcome (e.g., placed by preemption)
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Candidates are all advertised clusters, in the order they were evaluated
+	// +optional
+	Candidates []CandidateEvaluation `json:"candidates,omitempty"`
+}
+
+// CandidateEvaluation is the outcome of evaluating one cluster
+type CandidateEvaluation struct {
+	// ClusterID of the candidate
+	ClusterID string `json:"clusterID"`
+
+	// Eligible is true if the cluster passed every filter
+	Eligible bool `json:"eligible"`
+
+	// Reason the cluster was filtered out (empty if eligible)
+	// +optional
+	Reason CandidateRejectionReason `json:"reason,omitempty"`
+
+	// Detail gives the numbers behind the reason (e.g., requested vs. available)
+	// +optional
+	Detail string `json:"detail,omitempty"`
+
+	// Score of an eligible cluster including placement preferences (higher is better)
+	// +optional
+	Score string `json:"score,omitempty"`
+
+	// StrategyScore is the part of Score given by the scoring strategy
+	// +optional
+	StrategyScore string `json:"strategyScore,omitempty"`
+
+	// PlacementBonus is the part of Score given by preferred placement terms (0-1)
+	// +optional
+	PlacementBonus string `json:"placementBonus,omitempty"`
+}
+
+// CandidateRejectionReason explains why a cluster was filtered out
+type CandidateRejectionReason string
+
+const (
+	// CandidateRejectedSelf - The cluster is the requester itself
+	CandidateRejectedSelf CandidateRejectionReason = "Self"
+
+	// CandidateRejectedStale - The cluster is inactive or its advertisement is stale
+	CandidateRejectedStale CandidateRejectionReason = "Stale"
+
+	// CandidateRejectedPlacement - The cluster's labels violate the required placement
+	CandidateRejectedPlacement CandidateRejectionReason = "PlacementMismatch"
+
+	// CandidateRejectedCPU - Not enough available CPU
+	CandidateRejectedCPU CandidateRejectionReason = "InsufficientCPU"
+
+	// CandidateRejectedMemory - Not enough available memory
+	CandidateRejectedMemory CandidateRejectionReason = "InsufficientMemory"
+
+	// CandidateRejectedGPU - Not enough available GPUs
+	CandidateRejectedGPU CandidateRejectionReason = "InsufficientGPU"
+
+	// CandidateRejectedExtended - Not enough of a requested extended resource
+	CandidateRejectedExtended CandidateRejectionReason = "InsufficientExtendedResource"
+)
+
+const (
+	// ReservationConditionRequesterActive indicates the requester signaled readiness.
+	ReservationConditionRequesterActive = "RequesterActive"
+	// ReservationConditionRequesterReleased indicates the requester finished consuming resources.
+	ReservationConditionRequesterReleased = "RequesterReleased"
+	// ReservationConditionPreempted indicates a higher-priority reservation evicted this one.
+	ReservationConditionPreempted = "Preempted"
+	// ReservationConditionProviderAccepted records the provider's answer to the
+	// reservation: True when it holds the capacity, False when it rejected it.
+	ReservationConditionProviderAccepted = "ProviderAccepted"
+	// ReservationConditionOrphaned indicates the requester stopped sending heartbeats.
+	ReservationConditionOrphaned = "Orphaned"
+	// ReservationConditionRenewed records the outcome of the last renewal
+	// requested with the renewal-request annotation: True when the expiry was
+	// extended, False when the renewal was rejected.
+	ReservationConditionRenewed = "Renewed"
+)
+
+// ReservationPhase represents the phase of a reservation
+type ReservationPhase string
+
+const (
+	// ReservationPhasePending - Reservation request is pending
+	ReservationPhasePending ReservationPhase = "Pending"
+
+	// ReservationPhaseScheduled - Reservation holds a slot in a future window;
+	// its resources are locked when the window opens
+	ReservationPhaseScheduled ReservationPhase = "Scheduled"
+
+	// ReservationPhaseReserved - Resources are reserved but not yet active
+	ReservationPhaseReserved ReservationPhase = "Reserved"
+
+	// ReservationPhaseActive - Reservation is active and in use
+	ReservationPhaseActive ReservationPhase = "Active"
+
+	// ReservationPhaseOrphaned - Active reservation whose requester missed its heartbeats;
+	// resources stay locked until the grace period ends
+	ReservationPhaseOrphaned ReservationPhase = "Orphaned"
+
+	// ReservationPhaseFailed - Reservation failed
+	ReservationPhaseFailed ReservationPhase = "Failed"
+
+	// ReservationPhaseReleased - Reservation has been released
+	ReservationPhaseReleased ReservationPhase = "Released"
+
+	// ReservationPhasePreempted - Reservation was evicted by a higher-priority one before activation
+	ReservationPhasePreempted ReservationPhase = "Preempted"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Target-Cluster",type=string,JSONPath=`.spec.targetClusterID`
+// +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.requestedResources.cpu`
+// +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.requestedResources.memory`
+// +kubebuilder:printcolumn:name="GPU",type=string,JSONPath=`.spec.requestedResources.gpu`,priority=1
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Group",type=string,JSONPath=`.spec.groupID`,priority=1
+// +kubebuilder:printcolumn:name="Queue-Position",type=integer,JSONPath=`.status.queuePosition`,priority=1
+// +kubebuilder:printcolumn:name="Start",type=date,JSONPath=`.spec.startTime`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Reservation is the Schema for the reservations API
+type Reservation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReservationSpec   `json:"spec,omitempty"`
+	Status ReservationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReservationList contains a list of Reservation
+type ReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Reservation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Reservation{}, &ReservationList{})
+}
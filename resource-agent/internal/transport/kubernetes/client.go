@@ -0,0 +1,682 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/internal/transport/kubernetes/brokerapi/v1alpha1"
+)
+
+const (
+	// decisionTimeout bounds how long RequestReservation waits for the broker's
+	// reservation controller to decide a new Reservation, and RenewReservation
+	// for it to answer a renewal. Undecided reservations are returned as
+	// Pending and followed like queued ones.
+	decisionTimeout = 30 * time.Second
+
+	// decisionPollInterval is how often a Reservation is re-read while waiting for the broker
+	decisionPollInterval = 500 * time.Millisecond
+)
+
+// KubernetesCommunicator implements BrokerCommunicator interface on top of the
+// broker's CRDs, with a kubeconfig for the broker cluster. Advertisements are
+// ClusterAdvertisements; reservations are Reservations decided by the broker's
+// reservation controller; lifecycle requests set the status conditions the
+// broker's REST API sets, except renewals, which the reservation controller
+// applies; instructions are read and watched from Reservations.
+//
+// Requests that need the broker's API-side locking (gang reservations, split
+// reservations and resizes) are not supported. Unlike the other transports,
+// the broker cannot authenticate the cluster: the kubeconfig's RBAC decides
+// what it may read and write.
+type KubernetesCommunicator struct {
+	client    client.WithWatch
+	namespace string
+	clusterID string
+}
+
+// NewKubernetesCommunicator creates a new broker communicator using the
+// kubeconfig of the broker cluster. Broker CRDs live in namespace.
+func NewKubernetesCommunicator(brokerKubeconfig, namespace, clusterID string) (*KubernetesCommunicator, error) {
+	config, err := loadBrokerConfig(brokerKubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load broker kubeconfig: %w", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to register broker types: %w", err)
+	}
+
+	brokerClient, err := client.NewWithWatch(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create broker client: %w", err)
+	}
+
+	return NewKubernetesCommunicatorWithClient(brokerClient, namespace, clusterID), nil
+}
+
+// NewKubernetesCommunicatorWithClient creates a broker communicator that uses
+// an existing client of the broker cluster, whose scheme must include the
+// broker types
+func NewKubernetesCommunicatorWithClient(brokerClient client.WithWatch, namespace, clusterID string) *KubernetesCommunicator {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &KubernetesCommunicator{
+		client:    brokerClient,
+		namespace: namespace,
+		clusterID: clusterID,
+	}
+}
+
+// loadBrokerConfig loads kubeconfig from file
+func loadBrokerConfig(kubeconfigPath string) (*rest.Config, error) {
+	// Expand ~ to home directory
+	if len(kubeconfigPath) >= 2 && kubeconfigPath[:2] == "~/" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		kubeconfigPath = filepath.Join(home, kubeconfigPath[2:])
+	}
+
+	return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+}
+
+// PublishAdvertisement creates or updates the cluster's ClusterAdvertisement.
+// The broker's Reserved field is preserved; the broker recalculates Available
+// from it. Provider instructions are not piggybacked: they are watched or
+// fetched from Reservations.
+func (c *KubernetesCommunicator) PublishAdvertisement(ctx context.Context, adv *dto.AdvertisementDTO) ([]*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	spec, err := toClusterAdvertisementSpec(adv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert advertisement: %w", err)
+	}
+
+	key := types.NamespacedName{Name: adv.ClusterID + "-adv", Namespace: c.namespace}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv := &brokerv1alpha1.ClusterAdvertisement{}
+		if err := c.client.Get(ctx, key, clusterAdv); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			clusterAdv = &brokerv1alpha1.ClusterAdvertisement{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Spec:       spec,
+			}
+			return c.client.Create(ctx, clusterAdv)
+		}
+
+		// The broker manages reservations independently, so its Reserved
+		// tracking must not be overwritten
+		reserved := clusterAdv.Spec.Resources.Reserved
+		clusterAdv.Spec = spec
+		clusterAdv.Spec.Resources.Reserved = reserved
+		return c.client.Update(ctx, clusterAdv)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish advertisement: %w", err)
+	}
+
+	logger.Info("Advertisement published successfully",
+		"clusterID", adv.ClusterID,
+		"availableCPU", adv.Resources.Available.CPU,
+		"availableMemory", adv.Resources.Available.Memory)
+
+	return nil, nil
+}
+
+// RequestReservation creates a Reservation for the broker's reservation
+// controller to decide and waits for the decision. The Reservation is named
+// after the idempotency key as the broker's REST API names it, so a retry
+// returns the reservation created by the first request.
+func (c *KubernetesCommunicator) RequestReservation(
+	ctx context.Context,
+	reqDTO *dto.ReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	spec, err := toReservationSpec(reqDTO, c.clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reservation request: %w", err)
+	}
+
+	reservation := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.reservationName(idempotencyKey),
+			Namespace: c.namespace,
+		},
+		Spec: spec,
+	}
+	if idempotencyKey != "" {
+		reservation.Annotations = map[string]string{
+			brokerv1alpha1.ReservationIdempotencyKeyAnnotation: idempotencyKey,
+		}
+	}
+
+	if err := c.client.Create(ctx, reservation); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create reservation: %w", err)
+		}
+		logger.Info("Reservation already requested, following it", "reservationID", reservation.Name)
+	}
+
+	reservation, err = c.awaitDecision(ctx, client.ObjectKeyFromObject(reservation))
+	if err != nil {
+		return nil, err
+	}
+	result := fromReservation(reservation)
+
+	switch reservation.Status.Phase {
+	case brokerv1alpha1.ReservationPhaseFailed:
+		return nil, fmt.Errorf("broker could not reserve resources: %s", reservation.Status.Message)
+
+	case "", brokerv1alpha1.ReservationPhasePending:
+		// Queued, or not decided yet: either way the broker places it later
+		result.Status.Phase = string(brokerv1alpha1.ReservationPhasePending)
+		logger.Info("Reservation queued by broker",
+			"reservationID", result.ID,
+			"queuePosition", result.Status.QueuePosition)
+		return result, nil
+	}
+
+	logger.Info("Reservation decided by broker",
+		"reservationID", result.ID,
+		"phase", result.Status.Phase,
+		"targetCluster", result.TargetClusterID,
+		"cpu", result.RequestedResources.CPU,
+		"memory", result.RequestedResources.Memory)
+
+	return result, nil
+}
+
+// reservationName names a Reservation as the broker's REST API does, so
+// requests with the same idempotency key share it on every transport
+func (c *KubernetesCommunicator) reservationName(idempotencyKey string) string {
+	if idempotencyKey == "" {
+		return fmt.Sprintf("rsv-%s-%d", c.clusterID, time.Now().UnixMilli())
+	}
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	return fmt.Sprintf("rsv-%s-%s", c.clusterID, hex.EncodeToString(sum[:8]))
+}
+
+// awaitDecision re-reads a Reservation until the broker's reservation
+// controller gives it a phase or decisionTimeout passes, and returns its
+// last state
+func (c *KubernetesCommunicator) awaitDecision(ctx context.Context, key types.NamespacedName) (*brokerv1alpha1.Reservation, error) {
+	reservation := &brokerv1alpha1.Reservation{}
+	err := wait.PollUntilContextTimeout(ctx, decisionPollInterval, decisionTimeout, true,
+		func(ctx context.Context) (bool, error) {
+			if err := c.client.Get(ctx, key, reservation); err != nil {
+				return false, err
+			}
+			return reservation.Status.Phase != "", nil
+		})
+	if err != nil && !wait.Interrupted(err) {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if reservation.Spec.RequesterID != c.clusterID {
+		return nil, fmt.Errorf("reservation %s belongs to another requester", key.Name)
+	}
+	return reservation, nil
+}
+
+// RequestGangReservation is not supported: gang members are locked together
+// by the broker's REST API, which the CRD interface bypasses
+func (c *KubernetesCommunicator) RequestGangReservation(
+	_ context.Context,
+	_ *dto.GangReservationRequestDTO,
+	_ string,
+) (*dto.ReservationDTO, error) {
+	return nil, errors.New("gang reservations are not supported by the kubernetes transport")
+}
+
+// GetReservation fetches the current state of a reservation from the broker
+func (c *KubernetesCommunicator) GetReservation(ctx context.Context, reservationID string) (*dto.ReservationDTO, error) {
+	reservation := &brokerv1alpha1.Reservation{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: reservationID, Namespace: c.namespace}, reservation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	// Don't read other clusters' reservations
+	if reservation.Spec.RequesterID != c.clusterID && reservation.Spec.TargetClusterID != c.clusterID {
+		return nil, fmt.Errorf("reservation %s not found", reservationID)
+	}
+
+	result := fromReservation(reservation)
+	if reservation.Status.Phase == "" {
+		result.Status.Phase = string(brokerv1alpha1.ReservationPhasePending)
+	}
+	return result, nil
+}
+
+// ActivateReservation tells the broker that this cluster started using the
+// reservation (or every part of a group), so its reservation controller
+// promotes it from Reserved to Active
+func (c *KubernetesCommunicator) ActivateReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	reservations, err := c.requesterReservations(ctx, reservationID)
+	if err != nil {
+		return fmt.Errorf("failed to activate reservation: %w", err)
+	}
+
+	for i := range reservations {
+		err := c.updateReservationStatus(ctx, &reservations[i], func(reservation *brokerv1alpha1.Reservation) (bool, error) {
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseActive:
+				return false, nil
+			case brokerv1alpha1.ReservationPhaseReserved:
+				return brokerv1alpha1.SetRequesterCondition(reservation, brokerv1alpha1.ReservationConditionRequesterActive,
+					"ActivatedByRequester", "Requester started using the reservation"), nil
+			default:
+				return false, fmt.Errorf("reservation %s is %s and cannot be activated",
+					reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to activate reservation: %w", err)
+		}
+	}
+
+	logger.Info("Reservation activated at broker", "reservation", reservationID)
+	return nil
+}
+
+// AcknowledgeReservation accepts or rejects a reservation as its provider
+func (c *KubernetesCommunicator) AcknowledgeReservation(ctx context.Context, reservationID string, accepted bool, reason string) error {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	reservation := &brokerv1alpha1.Reservation{}
+	if err := c.client.Get(ctx, types.NamespacedName{Name: reservationID, Namespace: c.namespace}, reservation); err != nil {
+		return fmt.Errorf("failed to acknowledge reservation: %w", err)
+	}
+	if reservation.Spec.TargetClusterID != c.clusterID {
+		return fmt.Errorf("failed to acknowledge reservation: reservation %s not found", reservationID)
+	}
+
+	status, conditionReason, message := metav1.ConditionTrue, "AcceptedByProvider", "Provider holds the capacity"
+	if !accepted {
+		status, conditionReason, message = metav1.ConditionFalse, "RejectedByProvider", "Provider rejected the reservation"
+		if reason != "" {
+			message = fmt.Sprintf("%s: %s", message, reason)
+		}
+	}
+
+	err := c.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) (bool, error) {
+		// A repeated answer is a no-op, even if the reservation ended because of it
+		if cond := meta.FindStatusCondition(reservation.Status.Conditions,
+			brokerv1alpha1.ReservationConditionProviderAccepted); cond != nil && cond.Status == status {
+			return false, nil
+		}
+		switch reservation.Status.Phase {
+		case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+			brokerv1alpha1.ReservationPhaseOrphaned:
+		default:
+			return false, fmt.Errorf("reservation %s is %s", reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+		}
+		return meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionProviderAccepted,
+			Status:  status,
+			Reason:  conditionReason,
+			Message: message,
+		}), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge reservation: %w", err)
+	}
+
+	logger.Info("Reservation acknowledged at broker",
+		"reservation", reservationID,
+		"accepted", accepted,
+		"reason", reason)
+	return nil
+}
+
+// HeartbeatReservation tells the broker this cluster still uses the reservation
+func (c *KubernetesCommunicator) HeartbeatReservation(ctx context.Context, reservationID string) error {
+	reservations, err := c.requesterReservations(ctx, reservationID)
+	if err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+
+	now := metav1.Now()
+	for i := range reservations {
+		err := c.updateReservationStatus(ctx, &reservations[i], func(reservation *brokerv1alpha1.Reservation) (bool, error) {
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+				brokerv1alpha1.ReservationPhaseOrphaned:
+				reservation.Status.LastHeartbeatTime = &now
+				return true, nil
+			default:
+				return false, fmt.Errorf("reservation %s is %s", reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send heartbeat: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RenewReservation asks the broker to extend the reservation's expiry to now
+// plus duration (empty uses the reservation's own duration). The request is
+// left in the renewal-request annotation for the broker's reservation
+// controller, which renews like the REST API, including the maximum
+// reservation lifetime; this waits for its answer in the Renewed condition.
+// The parts of a group are renewed one by one.
+func (c *KubernetesCommunicator) RenewReservation(ctx context.Context, reservationID, duration string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	reservations, err := c.requesterReservations(ctx, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew reservation: %w", err)
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := c.client.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+				return err
+			}
+			if reservation.Annotations == nil {
+				reservation.Annotations = make(map[string]string)
+			}
+			reservation.Annotations[brokerv1alpha1.ReservationRenewalRequestAnnotation] = duration
+			return c.client.Update(ctx, reservation)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to renew reservation: %w", err)
+		}
+	}
+
+	for i := range reservations {
+		if err := c.awaitRenewal(ctx, &reservations[i]); err != nil {
+			return nil, fmt.Errorf("failed to renew reservation: %w", err)
+		}
+	}
+
+	result := c.reservationsDTO(reservationID, reservations, fmt.Sprintf("Renewed %d parts", len(reservations)))
+	logger.Info("Reservation renewed at broker",
+		"reservation", reservationID,
+		"expiresAt", result.Status.ExpiresAt)
+	return result, nil
+}
+
+// awaitRenewal re-reads a reservation until the broker's reservation
+// controller answered its renewal request or decisionTimeout passes, and
+// returns the rejection if the renewal was rejected
+func (c *KubernetesCommunicator) awaitRenewal(ctx context.Context, reservation *brokerv1alpha1.Reservation) error {
+	err := wait.PollUntilContextTimeout(ctx, decisionPollInterval, decisionTimeout, true,
+		func(ctx context.Context) (bool, error) {
+			if err := c.client.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+				return false, err
+			}
+			_, pending := reservation.Annotations[brokerv1alpha1.ReservationRenewalRequestAnnotation]
+			return !pending, nil
+		})
+	if err != nil {
+		if wait.Interrupted(err) && ctx.Err() == nil {
+			return fmt.Errorf("broker did not answer the renewal of %s in time", reservation.Name)
+		}
+		return err
+	}
+
+	cond := meta.FindStatusCondition(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionRenewed)
+	if cond != nil && cond.Status == metav1.ConditionFalse {
+		return fmt.Errorf("broker rejected the renewal of %s: %s", reservation.Name, cond.Message)
+	}
+	return nil
+}
+
+// ResizeReservation is not supported: resizes are locked against concurrent
+// decisions by the broker's REST API, which the CRD interface bypasses
+func (c *KubernetesCommunicator) ResizeReservation(
+	_ context.Context,
+	_ string,
+	_ dto.ResourceQuantitiesDTO,
+) (*dto.ReservationDTO, error) {
+	return nil, errors.New("resizing reservations is not supported by the kubernetes transport")
+}
+
+// ReleaseReservation gives a reservation (or every part of a group) back to
+// the broker, whose reservation controller frees the locked capacity
+func (c *KubernetesCommunicator) ReleaseReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("kubernetes-communicator")
+
+	reservations, err := c.requesterReservations(ctx, reservationID)
+	if err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	for i := range reservations {
+		err := c.updateReservationStatus(ctx, &reservations[i], func(reservation *brokerv1alpha1.Reservation) (bool, error) {
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseReleased, brokerv1alpha1.ReservationPhaseFailed,
+				brokerv1alpha1.ReservationPhasePreempted:
+				return false, nil
+			}
+			return brokerv1alpha1.SetRequesterCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased,
+				"ReleasedByRequester", "Requester released the reservation"), nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to release reservation: %w", err)
+		}
+	}
+
+	logger.Info("Reservation released at broker", "reservation", reservationID)
+	return nil
+}
+
+// FetchInstructions lists the Reservations the broker's GET /api/v1/instructions
+// would return to this cluster
+func (c *KubernetesCommunicator) FetchInstructions(ctx context.Context) ([]*dto.ReservationDTO, error) {
+	list := &brokerv1alpha1.ReservationList{}
+	if err := c.client.List(ctx, list, client.InNamespace(c.namespace)); err != nil {
+		return nil, fmt.Errorf("failed to fetch instructions: %w", err)
+	}
+
+	var instructions []*dto.ReservationDTO
+	for i := range list.Items {
+		if isInstructionFor(&list.Items[i], c.clusterID) {
+			instructions = append(instructions, fromReservation(&list.Items[i]))
+		}
+	}
+	return instructions, nil
+}
+
+// WatchInstructions watches the broker's Reservations and handles those that
+// are instructions for this cluster. The resume token is the resourceVersion
+// of the last event; without one, or once the API server has compacted it,
+// every current instruction is listed first.
+func (c *KubernetesCommunicator) WatchInstructions(
+	ctx context.Context,
+	resumeToken string,
+	handle func(instruction *dto.ReservationDTO),
+) (string, error) {
+	if resumeToken == "" {
+		list := &brokerv1alpha1.ReservationList{}
+		if err := c.client.List(ctx, list, client.InNamespace(c.namespace)); err != nil {
+			return "", fmt.Errorf("failed to list instructions: %w", err)
+		}
+		for i := range list.Items {
+			if isInstructionFor(&list.Items[i], c.clusterID) {
+				handle(fromReservation(&list.Items[i]))
+			}
+		}
+		resumeToken = list.ResourceVersion
+	}
+
+	watcher, err := c.client.Watch(ctx, &brokerv1alpha1.ReservationList{},
+		client.InNamespace(c.namespace),
+		&client.ListOptions{Raw: &metav1.ListOptions{
+			ResourceVersion:     resumeToken,
+			AllowWatchBookmarks: true,
+		}})
+	if err != nil {
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return "", fmt.Errorf("cannot resume instruction watch: %w", err)
+		}
+		return resumeToken, fmt.Errorf("failed to watch instructions: %w", err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return resumeToken, ctx.Err()
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resumeToken, errors.New("instruction watch closed by broker")
+			}
+
+			switch event.Type {
+			case watch.Error:
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return "", fmt.Errorf("cannot resume instruction watch: %w", err)
+				}
+				return resumeToken, fmt.Errorf("instruction watch failed: %w", err)
+
+			case watch.Bookmark, watch.Added, watch.Modified, watch.Deleted:
+				reservation, ok := event.Object.(*brokerv1alpha1.Reservation)
+				if !ok {
+					continue
+				}
+				resumeToken = reservation.ResourceVersion
+				if event.Type != watch.Bookmark && event.Type != watch.Deleted &&
+					isInstructionFor(reservation, c.clusterID) {
+					handle(fromReservation(reservation))
+				}
+			}
+		}
+	}
+}
+
+// Ping checks that the broker cluster serves Reservations
+func (c *KubernetesCommunicator) Ping(ctx context.Context) error {
+	list := &brokerv1alpha1.ReservationList{}
+	if err := c.client.List(ctx, list, client.InNamespace(c.namespace), client.Limit(1)); err != nil {
+		return fmt.Errorf("broker unreachable: %w", err)
+	}
+	return nil
+}
+
+// Close cleans up resources
+func (c *KubernetesCommunicator) Close() error {
+	return nil
+}
+
+// requesterReservations returns the reservation with the given name or, if
+// there is none, every part of the group with that ID. Reservations of other
+// requesters are reported as not found.
+func (c *KubernetesCommunicator) requesterReservations(ctx context.Context, id string) ([]brokerv1alpha1.Reservation, error) {
+	var reservations []brokerv1alpha1.Reservation
+
+	reservation := &brokerv1alpha1.Reservation{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: id, Namespace: c.namespace}, reservation)
+	switch {
+	case err == nil:
+		reservations = []brokerv1alpha1.Reservation{*reservation}
+	case apierrors.IsNotFound(err):
+		group := &brokerv1alpha1.ReservationList{}
+		if err := c.client.List(ctx, group,
+			client.InNamespace(c.namespace),
+			client.MatchingLabels{brokerv1alpha1.ReservationGroupLabel: id}); err != nil {
+			return nil, err
+		}
+		sort.Slice(group.Items, func(i, j int) bool {
+			return group.Items[i].Name < group.Items[j].Name
+		})
+		reservations = group.Items
+	default:
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return nil, fmt.Errorf("reservation %s not found", id)
+	}
+	for i := range reservations {
+		if reservations[i].Spec.RequesterID != c.clusterID {
+			return nil, fmt.Errorf("reservation %s not found", id)
+		}
+	}
+	return reservations, nil
+}
+
+// updateReservationStatus re-reads the reservation and writes its status if
+// mutate changed it, retrying on conflicts with the broker's reservation
+// controller. An error from mutate is returned without writing.
+func (c *KubernetesCommunicator) updateReservationStatus(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	mutate func(*brokerv1alpha1.Reservation) (bool, error),
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.client.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+			return err
+		}
+		changed, err := mutate(reservation)
+		if err != nil || !changed {
+			return err
+		}
+		reservation.Status.LastUpdateTime = metav1.Now()
+		return c.client.Status().Update(ctx, reservation)
+	})
+}
+
+// reservationsDTO renders a single reservation, or the parts of a group when
+// id is a group ID, as the broker's REST API does
+func (c *KubernetesCommunicator) reservationsDTO(
+	id string,
+	reservations []brokerv1alpha1.Reservation,
+	groupMessage string,
+) *dto.ReservationDTO {
+	if reservations[0].Name == id {
+		return fromReservation(&reservations[0])
+	}
+
+	group := &dto.ReservationDTO{
+		ID:          id,
+		RequesterID: reservations[0].Spec.RequesterID,
+		Status: dto.ReservationStatusDTO{
+			Message: groupMessage,
+		},
+		GroupID: id,
+	}
+	for i := range reservations {
+		group.Parts = append(group.Parts, fromReservation(&reservations[i]))
+	}
+	return group
+}
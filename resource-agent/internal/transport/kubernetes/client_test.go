@@ -0,0 +1,265 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/internal/transport/kubernetes/brokerapi/v1alpha1"
+)
+
+// Helper to create a communicator on a fake broker cluster
+func newFakeCommunicator(clusterID string, objects ...client.Object) (*KubernetesCommunicator, client.WithWatch) {
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	brokerClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}).
+		Build()
+	return NewKubernetesCommunicatorWithClient(brokerClient, "default", clusterID), brokerClient
+}
+
+// Helper to create a broker reservation
+func makeReservation(name, requesterID, targetClusterID string, phase brokerv1alpha1.ReservationPhase) *brokerv1alpha1.Reservation {
+	return &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: brokerv1alpha1.ReservationSpec{
+			RequesterID:     requesterID,
+			TargetClusterID: targetClusterID,
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU:    resource.MustParse("2"),
+				Memory: resource.MustParse("4Gi"),
+			},
+		},
+		Status: brokerv1alpha1.ReservationStatus{Phase: phase},
+	}
+}
+
+// Test: Publishing keeps the broker's Reserved field and carries GPU, storage and extended resources
+func TestPublishAdvertisement_PreservesReserved(t *testing.T) {
+	reserved := brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("1"), Memory: resource.MustParse("1Gi")}
+	existing := &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-1-adv", Namespace: "default"},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: "cluster-1",
+			Resources: brokerv1alpha1.ResourceMetrics{Reserved: &reserved},
+		},
+	}
+	communicator, brokerClient := newFakeCommunicator("cluster-1", existing)
+
+	quantities := dto.ResourceQuantitiesDTO{
+		CPU:      "8",
+		Memory:   "16Gi",
+		GPU:      "2",
+		Storage:  "100Gi",
+		Extended: map[string]string{"hugepages-2Mi": "1Gi"},
+	}
+	_, err := communicator.PublishAdvertisement(context.Background(), &dto.AdvertisementDTO{
+		ClusterID: "cluster-1",
+		Resources: dto.ResourceMetricsDTO{
+			Capacity:    quantities,
+			Allocatable: quantities,
+			Allocated:   dto.ResourceQuantitiesDTO{CPU: "0", Memory: "0"},
+			Available:   quantities,
+		},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clusterAdv := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := brokerClient.Get(context.Background(), types.NamespacedName{Name: "cluster-1-adv", Namespace: "default"}, clusterAdv); err != nil {
+		t.Fatalf("Failed to get advertisement: %v", err)
+	}
+	resources := clusterAdv.Spec.Resources
+	if resources.Reserved == nil || resources.Reserved.CPU.String() != "1" {
+		t.Errorf("Expected Reserved CPU 1 to be preserved, got %v", resources.Reserved)
+	}
+	if resources.Available.GPU == nil || resources.Available.GPU.String() != "2" {
+		t.Errorf("Expected available GPU 2, got %v", resources.Available.GPU)
+	}
+	if resources.Capacity.Storage == nil || resources.Capacity.Storage.String() != "100Gi" {
+		t.Errorf("Expected storage capacity 100Gi, got %v", resources.Capacity.Storage)
+	}
+	if qty, ok := resources.Available.Extended["hugepages-2Mi"]; !ok || qty.String() != "1Gi" {
+		t.Errorf("Expected extended resource hugepages-2Mi 1Gi, got %v", resources.Available.Extended)
+	}
+}
+
+// Test: Lifecycle requests set the status conditions the broker's reservation controller acts on
+func TestLifecycle_SetsConditions(t *testing.T) {
+	communicator, brokerClient := newFakeCommunicator("cluster-1",
+		makeReservation("rsv-1", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhaseReserved),
+		makeReservation("rsv-2", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhasePending),
+		makeReservation("rsv-3", "cluster-3", "cluster-2", brokerv1alpha1.ReservationPhaseReserved))
+	ctx := context.Background()
+
+	if err := communicator.ActivateReservation(ctx, "rsv-1"); err != nil {
+		t.Fatalf("Unexpected activation error: %v", err)
+	}
+	if err := communicator.ReleaseReservation(ctx, "rsv-1"); err != nil {
+		t.Fatalf("Unexpected release error: %v", err)
+	}
+
+	reservation := &brokerv1alpha1.Reservation{}
+	_ = brokerClient.Get(ctx, types.NamespacedName{Name: "rsv-1", Namespace: "default"}, reservation)
+	if !meta.IsStatusConditionTrue(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterActive) {
+		t.Error("Expected the RequesterActive condition")
+	}
+	if !meta.IsStatusConditionTrue(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		t.Error("Expected the RequesterReleased condition")
+	}
+
+	// Only Reserved reservations can be activated
+	if err := communicator.ActivateReservation(ctx, "rsv-2"); err == nil {
+		t.Error("Expected activating a Pending reservation to fail")
+	}
+
+	// Other requesters' reservations are not found
+	if err := communicator.ReleaseReservation(ctx, "rsv-3"); err == nil {
+		t.Error("Expected releasing another requester's reservation to fail")
+	}
+}
+
+// Test: Only reservations the broker would send as instructions are fetched
+func TestFetchInstructions_FiltersByCluster(t *testing.T) {
+	rejected := makeReservation("rsv-rejected", "cluster-1", "cluster-3", brokerv1alpha1.ReservationPhaseFailed)
+	rejected.Status.Conditions = []metav1.Condition{{
+		Type:   brokerv1alpha1.ReservationConditionProviderAccepted,
+		Status: metav1.ConditionFalse,
+		Reason: "RejectedByProvider",
+	}}
+
+	communicator, _ := newFakeCommunicator("cluster-2",
+		makeReservation("rsv-provided", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhaseReserved),
+		makeReservation("rsv-active", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhaseActive),
+		makeReservation("rsv-other", "cluster-1", "cluster-3", brokerv1alpha1.ReservationPhaseReserved),
+		makeReservation("rsv-pending", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhasePending),
+		rejected)
+
+	instructions, err := communicator.FetchInstructions(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, instruction := range instructions {
+		got[instruction.ID] = true
+	}
+	if len(got) != 2 || !got["rsv-provided"] || !got["rsv-active"] {
+		t.Errorf("Expected rsv-provided and rsv-active, got %v", got)
+	}
+}
+
+// Test: A reservation request waits for the broker's decision, and a retry with the same key returns the same reservation
+func TestRequestReservation_WaitsForDecision(t *testing.T) {
+	communicator, brokerClient := newFakeCommunicator("cluster-1")
+	ctx := context.Background()
+
+	// Stand in for the broker's reservation controller
+	go func() {
+		for {
+			list := &brokerv1alpha1.ReservationList{}
+			_ = brokerClient.List(ctx, list)
+			if len(list.Items) > 0 {
+				reservation := &list.Items[0]
+				reservation.Spec.TargetClusterID = "cluster-2"
+				_ = brokerClient.Update(ctx, reservation)
+				reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+				if brokerClient.Status().Update(ctx, reservation) == nil {
+					return
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	request := &dto.ReservationRequestDTO{
+		RequestedResources: dto.ResourceQuantitiesDTO{CPU: "2", Memory: "4Gi", GPU: "1"},
+		Duration:           "1h",
+	}
+	first, err := communicator.RequestReservation(ctx, request, "request-uid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first.Status.Phase != "Reserved" || first.TargetClusterID != "cluster-2" || first.RequestedResources.GPU != "1" {
+		t.Errorf("Expected a Reserved reservation with 1 GPU on cluster-2, got %+v", first)
+	}
+
+	second, err := communicator.RequestReservation(ctx, request, "request-uid")
+	if err != nil {
+		t.Fatalf("Unexpected error on retry: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected the retry to return %s, got %s", first.ID, second.ID)
+	}
+
+	// Split requests need the broker's group locking
+	request.Splittable = true
+	if _, err := communicator.RequestReservation(ctx, request, "other-uid"); err == nil {
+		t.Error("Expected a split request to fail")
+	}
+}
+
+// Test: A renewal leaves the new expiry to the broker and reports its rejection
+func TestRenewReservation_LeavesExpiryToBroker(t *testing.T) {
+	reservation := makeReservation("rsv-1", "cluster-1", "cluster-2", brokerv1alpha1.ReservationPhaseActive)
+	expiresAt := metav1.NewTime(time.Now().Add(10 * time.Minute).Truncate(time.Second))
+	reservation.Status.ExpiresAt = &expiresAt
+	communicator, brokerClient := newFakeCommunicator("cluster-1", reservation)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stand in for the broker's reservation controller: the first renewal is
+	// capped at 30 minutes, later ones are rejected
+	capped := metav1.NewTime(expiresAt.Add(20 * time.Minute))
+	go func() {
+		for answered := 0; ctx.Err() == nil; time.Sleep(50 * time.Millisecond) {
+			current := &brokerv1alpha1.Reservation{}
+			if brokerClient.Get(ctx, client.ObjectKeyFromObject(reservation), current) != nil {
+				continue
+			}
+			if requested, ok := current.Annotations[brokerv1alpha1.ReservationRenewalRequestAnnotation]; !ok || requested != "1h" {
+				continue
+			}
+			condition := metav1.Condition{Type: brokerv1alpha1.ReservationConditionRenewed, Status: metav1.ConditionTrue, Reason: "RenewedByRequester"}
+			if answered > 0 {
+				condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "RenewalRejected", "maximum lifetime reached"
+			} else {
+				current.Status.ExpiresAt = &capped
+			}
+			meta.RemoveStatusCondition(&current.Status.Conditions, condition.Type)
+			meta.SetStatusCondition(&current.Status.Conditions, condition)
+			if brokerClient.Status().Update(ctx, current) != nil {
+				continue
+			}
+			delete(current.Annotations, brokerv1alpha1.ReservationRenewalRequestAnnotation)
+			if brokerClient.Update(ctx, current) == nil {
+				answered++
+			}
+		}
+	}()
+
+	renewed, err := communicator.RenewReservation(ctx, "rsv-1", "1h")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if renewed.Status.ExpiresAt == nil || !renewed.Status.ExpiresAt.Equal(capped.Time) {
+		t.Errorf("Expected the broker's expiry %v, got %v", capped.Time, renewed.Status.ExpiresAt)
+	}
+
+	if _, err := communicator.RenewReservation(ctx, "rsv-1", "1h"); err == nil {
+		t.Error("Expected the rejected renewal to fail")
+	}
+}
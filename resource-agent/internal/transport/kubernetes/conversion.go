@@ -0,0 +1,273 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-agent/internal/transport/kubernetes/brokerapi/v1alpha1"
+)
+
+// toClusterAdvertisementSpec converts an advertisement DTO to the broker's
+// ClusterAdvertisement spec. Reserved is left unset: it is broker-managed.
+func toClusterAdvertisementSpec(adv *dto.AdvertisementDTO) (brokerv1alpha1.ClusterAdvertisementSpec, error) {
+	spec := brokerv1alpha1.ClusterAdvertisementSpec{
+		ClusterID:   adv.ClusterID,
+		ClusterName: adv.ClusterName,
+		Timestamp:   metav1.Time{Time: adv.Timestamp},
+		Labels:      adv.Labels,
+	}
+
+	var err error
+	if spec.Resources.Capacity, err = toResourceQuantities(adv.Resources.Capacity); err != nil {
+		return spec, fmt.Errorf("invalid capacity: %w", err)
+	}
+	if spec.Resources.Allocatable, err = toResourceQuantities(adv.Resources.Allocatable); err != nil {
+		return spec, fmt.Errorf("invalid allocatable: %w", err)
+	}
+	if spec.Resources.Allocated, err = toResourceQuantities(adv.Resources.Allocated); err != nil {
+		return spec, fmt.Errorf("invalid allocated: %w", err)
+	}
+	if spec.Resources.Available, err = toResourceQuantities(adv.Resources.Available); err != nil {
+		return spec, fmt.Errorf("invalid available: %w", err)
+	}
+
+	if adv.Cost != nil {
+		spec.Cost = &brokerv1alpha1.CostInfo{
+			CPUCost:    adv.Cost.CPUCost,
+			MemoryCost: adv.Cost.MemoryCost,
+			Currency:   adv.Cost.Currency,
+		}
+	}
+
+	return spec, nil
+}
+
+// toResourceQuantities converts DTO (string-based) quantities, including GPU,
+// storage and extended resources, to the broker's ResourceQuantities
+func toResourceQuantities(rq dto.ResourceQuantitiesDTO) (brokerv1alpha1.ResourceQuantities, error) {
+	quantities := brokerv1alpha1.ResourceQuantities{}
+
+	var err error
+	if quantities.CPU, err = resource.ParseQuantity(rq.CPU); err != nil {
+		return quantities, fmt.Errorf("invalid CPU quantity: %w", err)
+	}
+	if quantities.Memory, err = resource.ParseQuantity(rq.Memory); err != nil {
+		return quantities, fmt.Errorf("invalid memory quantity: %w", err)
+	}
+
+	if rq.GPU != "" {
+		gpu, err := resource.ParseQuantity(rq.GPU)
+		if err != nil {
+			return quantities, fmt.Errorf("invalid GPU quantity: %w", err)
+		}
+		quantities.GPU = &gpu
+	}
+
+	if rq.Storage != "" {
+		storage, err := resource.ParseQuantity(rq.Storage)
+		if err != nil {
+			return quantities, fmt.Errorf("invalid storage quantity: %w", err)
+		}
+		quantities.Storage = &storage
+	}
+
+	if quantities.Extended, err = parseExtended(rq.Extended); err != nil {
+		return quantities, err
+	}
+
+	return quantities, nil
+}
+
+// toReservationSpec converts a reservation request to the spec of a Reservation
+// the broker's reservation controller decides. Split requests need the
+// broker's group locking and cannot be expressed as a single Reservation.
+func toReservationSpec(req *dto.ReservationRequestDTO, requesterID string) (brokerv1alpha1.ReservationSpec, error) {
+	spec := brokerv1alpha1.ReservationSpec{
+		RequesterID:     requesterID,
+		Priority:        req.Priority,
+		ScoringStrategy: brokerv1alpha1.ScoringStrategyType(req.ScoringStrategy),
+		Placement:       toPlacementConstraints(req.Placement),
+		Queue:           req.Queue,
+	}
+
+	if req.Splittable {
+		return spec, errors.New("split reservations are not supported by the kubernetes transport")
+	}
+	if req.RequestedResources.CPU == "" || req.RequestedResources.Memory == "" {
+		return spec, errors.New("requested CPU and memory are required")
+	}
+
+	var err error
+	if spec.RequestedResources.CPU, err = resource.ParseQuantity(req.RequestedResources.CPU); err != nil {
+		return spec, fmt.Errorf("invalid CPU quantity: %w", err)
+	}
+	if spec.RequestedResources.Memory, err = resource.ParseQuantity(req.RequestedResources.Memory); err != nil {
+		return spec, fmt.Errorf("invalid memory quantity: %w", err)
+	}
+
+	if req.RequestedResources.GPU != "" {
+		gpu, err := resource.ParseQuantity(req.RequestedResources.GPU)
+		if err != nil {
+			return spec, fmt.Errorf("invalid GPU quantity: %w", err)
+		}
+		if gpu.Sign() > 0 {
+			spec.RequestedResources.GPU = &gpu
+		}
+	}
+
+	if spec.RequestedResources.Extended, err = parseExtended(req.RequestedResources.Extended); err != nil {
+		return spec, err
+	}
+
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return spec, fmt.Errorf("invalid duration: %w", err)
+		}
+		spec.Duration = &metav1.Duration{Duration: d}
+	}
+
+	if req.StartTime != nil {
+		spec.StartTime = &metav1.Time{Time: *req.StartTime}
+	}
+
+	return spec, nil
+}
+
+// parseExtended converts string-based extended resources to quantities.
+// Returns nil for an empty map.
+func parseExtended(extended map[string]string) (map[string]resource.Quantity, error) {
+	if len(extended) == 0 {
+		return nil, nil
+	}
+
+	quantities := make(map[string]resource.Quantity, len(extended))
+	for name, value := range extended {
+		qty, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %w", name, err)
+		}
+		quantities[name] = qty
+	}
+	return quantities, nil
+}
+
+// toPlacementConstraints converts placement DTO to the Reservation spec format.
+// Returns nil when no placement was requested.
+func toPlacementConstraints(placement *dto.PlacementDTO) *brokerv1alpha1.PlacementConstraints {
+	if placement == nil || (len(placement.Required) == 0 && len(placement.Preferred) == 0) {
+		return nil
+	}
+
+	constraints := &brokerv1alpha1.PlacementConstraints{
+		Required: toLabelSelectorRequirements(placement.Required),
+	}
+	for _, term := range placement.Preferred {
+		constraints.Preferred = append(constraints.Preferred, brokerv1alpha1.PreferredPlacementTerm{
+			Weight:       term.Weight,
+			Requirements: toLabelSelectorRequirements(term.Requirements),
+		})
+	}
+	return constraints
+}
+
+func toLabelSelectorRequirements(requirements []dto.LabelRequirementDTO) []metav1.LabelSelectorRequirement {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	result := make([]metav1.LabelSelectorRequirement, 0, len(requirements))
+	for _, requirement := range requirements {
+		result = append(result, metav1.LabelSelectorRequirement{
+			Key:      requirement.Key,
+			Operator: metav1.LabelSelectorOperator(requirement.Operator),
+			Values:   requirement.Values,
+		})
+	}
+	return result
+}
+
+// fromReservation converts a broker Reservation to DTO, as the broker's REST API renders it
+func fromReservation(rsv *brokerv1alpha1.Reservation) *dto.ReservationDTO {
+	reservation := &dto.ReservationDTO{
+		ID:                 rsv.Name,
+		RequesterID:        rsv.Spec.RequesterID,
+		TargetClusterID:    rsv.Spec.TargetClusterID,
+		RequestedResources: fromRequestedResources(rsv.Spec.RequestedResources),
+		Status: dto.ReservationStatusDTO{
+			Phase:         string(rsv.Status.Phase),
+			Message:       rsv.Status.Message,
+			QueuePosition: rsv.Status.QueuePosition,
+		},
+		CreatedAt: rsv.CreationTimestamp.Time,
+		GroupID:   rsv.Spec.GroupID,
+	}
+
+	if rsv.Spec.StartTime != nil {
+		reservation.StartTime = &rsv.Spec.StartTime.Time
+	}
+	if rsv.Status.ReservedAt != nil {
+		reservation.Status.ReservedAt = &rsv.Status.ReservedAt.Time
+	}
+	if rsv.Status.ExpiresAt != nil {
+		reservation.Status.ExpiresAt = &rsv.Status.ExpiresAt.Time
+	}
+
+	if cond := meta.FindStatusCondition(rsv.Status.Conditions,
+		brokerv1alpha1.ReservationConditionProviderAccepted); cond != nil {
+		accepted := cond.Status == metav1.ConditionTrue
+		reservation.Status.ProviderAccepted = &accepted
+	}
+
+	return reservation
+}
+
+// fromRequestedResources converts requested quantities to DTO format (string-based)
+func fromRequestedResources(rq brokerv1alpha1.RequestedResourceQuantities) dto.ResourceQuantitiesDTO {
+	quantities := dto.ResourceQuantitiesDTO{
+		CPU:    rq.CPU.String(),
+		Memory: rq.Memory.String(),
+	}
+
+	if rq.GPU != nil {
+		quantities.GPU = rq.GPU.String()
+	}
+
+	if len(rq.Extended) > 0 {
+		quantities.Extended = make(map[string]string, len(rq.Extended))
+		for name, qty := range rq.Extended {
+			quantities.Extended[name] = qty.String()
+		}
+	}
+
+	return quantities
+}
+
+// isInstructionFor reports whether the broker's GET /api/v1/instructions
+// would return the reservation to the cluster
+func isInstructionFor(rsv *brokerv1alpha1.Reservation, clusterID string) bool {
+	switch rsv.Status.Phase {
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive:
+		// Active ones are repeated so renewed expiries reach the provider
+		return rsv.Spec.TargetClusterID == clusterID
+	case brokerv1alpha1.ReservationPhasePreempted:
+		// Both sides must drop their local instruction for the evicted reservation
+		return rsv.Spec.TargetClusterID == clusterID || rsv.Spec.RequesterID == clusterID
+	case brokerv1alpha1.ReservationPhaseFailed:
+		// The requester learns that the provider rejected its reservation
+		return (rsv.Spec.TargetClusterID == clusterID || rsv.Spec.RequesterID == clusterID) &&
+			meta.IsStatusConditionFalse(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted)
+	case brokerv1alpha1.ReservationPhaseReleased:
+		// The provider stops holding capacity the requester gave back or abandoned
+		return rsv.Spec.TargetClusterID == clusterID &&
+			(meta.IsStatusConditionTrue(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterReleased) ||
+				meta.IsStatusConditionTrue(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionOrphaned))
+	}
+	return false
+}
@@ -0,0 +1,679 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport"
+	"github.com/mehdiazizian/liqo-resource-agent/internal/transport/dto"
+)
+
+// Topics of one cluster, as the broker defines them (rear/clusters/<clusterID>/<kind>).
+// Every message is a SignedMessage.
+const (
+	topicRoot = "rear/clusters"
+
+	topicAdvertisement = "advertisement" // AdvertisementDTO, agent to broker
+	topicRequests      = "requests"      // request, agent to broker
+	topicResponses     = "responses"     // response, broker to agent
+	topicInstructions  = "instructions"  // ReservationDTO, broker to agent
+)
+
+const (
+	// publishTimeout bounds how long the agent waits for the MQTT server to accept a message
+	publishTimeout = 10 * time.Second
+
+	// responseTimeout bounds how long the agent waits for the response to one
+	// attempt of a request (the broker gives up serving it after 30 s)
+	responseTimeout = 35 * time.Second
+
+	// instructionBuffer is how many instructions may wait for the handler
+	// before the watch gives up and resyncs
+	instructionBuffer = 256
+)
+
+// operation is one broker call, as the broker defines them
+type operation string
+
+const (
+	operationReserve           operation = "reserve"
+	operationReserveGang       operation = "reserveGang"
+	operationGetReservation    operation = "getReservation"
+	operationActivate          operation = "activate"
+	operationAcknowledge       operation = "acknowledge"
+	operationHeartbeat         operation = "heartbeat"
+	operationRenew             operation = "renew"
+	operationResize            operation = "resize"
+	operationRelease           operation = "release"
+	operationFetchInstructions operation = "fetchInstructions"
+)
+
+// request is one call to the broker; only the fields of its operation are set
+type request struct {
+	RequestID string    `json:"requestID"`
+	Operation operation `json:"operation"`
+
+	ReservationID  string                         `json:"reservationID,omitempty"`
+	IdempotencyKey string                         `json:"idempotencyKey,omitempty"`
+	Reservation    *dto.ReservationRequestDTO     `json:"reservation,omitempty"`
+	Gang           *dto.GangReservationRequestDTO `json:"gang,omitempty"`
+	Acknowledge    *dto.AcknowledgeRequestDTO     `json:"acknowledge,omitempty"`
+	Renew          *dto.RenewRequestDTO           `json:"renew,omitempty"`
+	Resize         *dto.ResizeRequestDTO          `json:"resize,omitempty"`
+}
+
+// response is the broker's answer to a request
+type response struct {
+	RequestID string `json:"requestID"`
+	Status    int    `json:"status"` // HTTP status code the REST API would answer
+	Error     string `json:"error,omitempty"`
+
+	Reservation  *dto.ReservationDTO   `json:"reservation,omitempty"`
+	Instructions []*dto.ReservationDTO `json:"instructions,omitempty"`
+}
+
+// errNoResponse is a request the MQTT server accepted but the broker did not
+// answer in time; the broker may still have served it
+var errNoResponse = errors.New("no response from broker")
+
+// MQTTCommunicator implements BrokerCommunicator interface over MQTT.
+// Advertisements are published to the cluster's advertisement topic and
+// instructions arrive on its instructions topic. Every other call is a
+// request on the requests topic, answered on the responses topic.
+//
+// Every message is signed: the agent signs with its client certificate, and
+// only messages signed by the broker's certificate are accepted. The MQTT
+// server delivers at most once to connected clients, so requests are retried
+// when they get no response, and instructions are fetched again after every
+// reconnect.
+type MQTTCommunicator struct {
+	client     paho.Client
+	clusterID  string
+	signer     *Signer
+	verifier   *Verifier
+	maxRetries int
+
+	mu      sync.Mutex
+	pending map[string]chan *response // Requests awaiting a response, by request ID
+	lost    chan struct{}             // Closed while disconnected
+}
+
+// NewMQTTCommunicator creates a new MQTT-based broker communicator with mTLS.
+// brokerURL is the MQTT server (e.g., tls://broker.example.com:8883); the
+// certificates are those of the HTTP transport and the client ID is the
+// cluster ID.
+func NewMQTTCommunicator(brokerURL, certPath, clusterID string) (*MQTTCommunicator, error) {
+	// Load client certificate (tls.crt, tls.key)
+	cert, err := tls.LoadX509KeyPair(
+		filepath.Join(certPath, "tls.crt"),
+		filepath.Join(certPath, "tls.key"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	// Load CA certificate for server verification
+	caCert, err := os.ReadFile(filepath.Join(certPath, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to append CA certificate")
+	}
+
+	signer, err := NewSigner(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &MQTTCommunicator{
+		clusterID: clusterID,
+		signer:    signer,
+		// Only the broker's certificate is issued for server auth
+		verifier:   NewVerifier(caCertPool, x509.ExtKeyUsageServerAuth),
+		maxRetries: 3,
+		pending:    map[string]chan *response{},
+		lost:       make(chan struct{}),
+	}
+	close(c.lost)
+
+	opts := paho.NewClientOptions().
+		AddBroker(brokerURL).
+		SetClientID(clusterID).
+		SetTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      caCertPool,
+			MinVersion:   tls.VersionTLS12,
+		}).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetOnConnectHandler(c.onConnect).
+		SetConnectionLostHandler(c.onConnectionLost)
+
+	// Connects in the background, retrying until the server is reachable
+	c.client = paho.NewClient(opts)
+	c.client.Connect()
+	return c, nil
+}
+
+// onConnect subscribes to the responses topic after every connect
+func (c *MQTTCommunicator) onConnect(client paho.Client) {
+	logger := log.Log.WithName("mqtt-communicator")
+
+	token := client.Subscribe(c.topic(topicResponses), 1, c.handleResponse)
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		logger.Error(token.Error(), "Failed to subscribe to broker responses")
+	}
+
+	c.mu.Lock()
+	select {
+	case <-c.lost:
+		c.lost = make(chan struct{})
+	default:
+	}
+	c.mu.Unlock()
+	logger.Info("Connected to MQTT server")
+}
+
+// onConnectionLost ends running instruction watches; their subscriptions are gone
+func (c *MQTTCommunicator) onConnectionLost(_ paho.Client, err error) {
+	log.Log.WithName("mqtt-communicator").Info("Connection to MQTT server lost, reconnecting", "error", err)
+
+	c.mu.Lock()
+	select {
+	case <-c.lost:
+	default:
+		close(c.lost)
+	}
+	c.mu.Unlock()
+}
+
+// handleResponse hands a response signed by the broker to the request waiting for it
+func (c *MQTTCommunicator) handleResponse(_ paho.Client, msg paho.Message) {
+	_, payload, err := c.verifier.Verify(msg.Topic(), msg.Payload())
+	if err != nil {
+		log.Log.WithName("mqtt-communicator").Info("Dropped unverified response", "error", err.Error())
+		return
+	}
+	var resp response
+	if err := json.Unmarshal(payload, &resp); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	responses := c.pending[resp.RequestID]
+	c.mu.Unlock()
+	if responses != nil {
+		select {
+		case responses <- &resp:
+		default: // Duplicate delivery
+		}
+	}
+}
+
+// topic is a topic of this cluster
+func (c *MQTTCommunicator) topic(kind string) string {
+	return topicRoot + "/" + c.clusterID + "/" + kind
+}
+
+// publish signs a message for topic, sends it with QoS 1 and waits for the
+// server to accept it
+func (c *MQTTCommunicator) publish(ctx context.Context, topic string, payload any) error {
+	data, err := c.signer.Sign(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	token := c.client.Publish(topic, 1, false, data)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return fmt.Errorf("failed to publish to %s: %w", topic, ctx.Err())
+	}
+}
+
+// call sends a request and waits for the broker's response. Like the HTTP
+// transport it retries with exponential backoff on 5xx responses, and also
+// when the request got no response. Reservation requests without an
+// idempotency key are not sent again after a failed attempt, since the
+// broker may have served it and would reserve twice.
+func (c *MQTTCommunicator) call(ctx context.Context, req *request) (*response, error) {
+	backoff := 1 * time.Second
+	maxBackoff := 16 * time.Second
+	resend := req.IdempotencyKey != "" ||
+		(req.Operation != operationReserve && req.Operation != operationReserveGang)
+
+	requestID, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	req.RequestID = requestID
+
+	// A late response to an earlier attempt answers the request as well
+	responses := make(chan *response, 1)
+	c.mu.Lock()
+	c.pending[requestID] = responses
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, requestID)
+		c.mu.Unlock()
+	}()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.roundTrip(ctx, req, responses)
+
+		// Success or non-retryable error
+		if err == nil && resp.Status < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if err != nil && (ctx.Err() != nil || !resend) {
+			return nil, err
+		}
+
+		// Don't retry on last attempt
+		if attempt == c.maxRetries {
+			if err != nil {
+				return nil, fmt.Errorf("max retries exceeded: %w", err)
+			}
+			return resp, nil // Return the 5xx response
+		}
+
+		// Wait before retry with exponential backoff
+		select {
+		case <-time.After(backoff):
+			backoff = min(backoff*2, maxBackoff)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// roundTrip publishes one attempt of a request, signed anew, and waits for its response
+func (c *MQTTCommunicator) roundTrip(ctx context.Context, req *request, responses <-chan *response) (*response, error) {
+	if err := c.publish(ctx, c.topic(topicRequests), req); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(responseTimeout)
+	defer timer.Stop()
+	select {
+	case resp := <-responses:
+		return resp, nil
+	case <-timer.C:
+		return nil, errNoResponse
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", errNoResponse, ctx.Err())
+	}
+}
+
+// expectStatus fails a response whose status is not one of statuses
+func expectStatus(resp *response, statuses ...int) error {
+	if !slices.Contains(statuses, resp.Status) {
+		return &transport.StatusError{StatusCode: resp.Status, Message: resp.Error}
+	}
+	return nil
+}
+
+// callReservation sends a request on one reservation and returns the
+// reservation the broker answers with one of statuses
+func (c *MQTTCommunicator) callReservation(ctx context.Context, req *request, statuses ...int) (*dto.ReservationDTO, error) {
+	resp, err := c.call(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectStatus(resp, statuses...); err != nil {
+		return nil, err
+	}
+	return resp.Reservation, nil
+}
+
+// PublishAdvertisement publishes cluster advertisement to the advertisement topic.
+// The broker keeps its own Reserved field, so no read is needed first.
+// Provider instructions arrive on the instructions topic, so none are returned.
+func (c *MQTTCommunicator) PublishAdvertisement(ctx context.Context, adv *dto.AdvertisementDTO) ([]*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	if err := c.publish(ctx, c.topic(topicAdvertisement), adv); err != nil {
+		return nil, fmt.Errorf("failed to publish advertisement: %w", err)
+	}
+
+	logger.Info("Advertisement published successfully",
+		"clusterID", adv.ClusterID,
+		"availableCPU", adv.Resources.Available.CPU,
+		"availableMemory", adv.Resources.Available.Memory)
+
+	return nil, nil
+}
+
+// RequestReservation sends a synchronous reservation request to the broker.
+// 202 means the broker queued it until capacity frees up.
+func (c *MQTTCommunicator) RequestReservation(
+	ctx context.Context,
+	reqDTO *dto.ReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	resp, err := c.call(ctx, &request{
+		Operation:      operationReserve,
+		IdempotencyKey: idempotencyKey,
+		Reservation:    reqDTO,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send reservation request: %w", err)
+	}
+	if err := expectStatus(resp, http.StatusCreated, http.StatusOK, http.StatusAccepted); err != nil {
+		return nil, err
+	}
+	if resp.Reservation == nil {
+		return nil, fmt.Errorf("broker returned no reservation")
+	}
+
+	reservation := resp.Reservation
+	if resp.Status == http.StatusAccepted {
+		logger.Info("Reservation queued by broker",
+			"reservationID", reservation.ID,
+			"queuePosition", reservation.Status.QueuePosition)
+		return reservation, nil
+	}
+
+	logger.Info("Reservation created synchronously",
+		"reservationID", reservation.ID,
+		"targetCluster", reservation.TargetClusterID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return reservation, nil
+}
+
+// RequestGangReservation sends an all-or-nothing multi-block reservation request
+func (c *MQTTCommunicator) RequestGangReservation(
+	ctx context.Context,
+	reqDTO *dto.GangReservationRequestDTO,
+	idempotencyKey string,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	reservation, err := c.callReservation(ctx, &request{
+		Operation:      operationReserveGang,
+		IdempotencyKey: idempotencyKey,
+		Gang:           reqDTO,
+	}, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send gang reservation request: %w", err)
+	}
+	if reservation == nil {
+		return nil, fmt.Errorf("broker returned no reservation")
+	}
+
+	logger.Info("Gang reservation created synchronously",
+		"groupID", reservation.ID,
+		"members", len(reservation.Parts))
+
+	return reservation, nil
+}
+
+// GetReservation fetches the current state of a reservation from the broker
+func (c *MQTTCommunicator) GetReservation(ctx context.Context, reservationID string) (*dto.ReservationDTO, error) {
+	reservation, err := c.callReservation(ctx, &request{
+		Operation:     operationGetReservation,
+		ReservationID: reservationID,
+	}, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	if reservation == nil {
+		return nil, fmt.Errorf("broker returned no reservation")
+	}
+	return reservation, nil
+}
+
+// ActivateReservation tells the broker that this cluster started using the reservation
+func (c *MQTTCommunicator) ActivateReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	if _, err := c.callReservation(ctx, &request{
+		Operation:     operationActivate,
+		ReservationID: reservationID,
+	}, http.StatusAccepted, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to activate reservation: %w", err)
+	}
+
+	logger.Info("Reservation activated at broker", "reservation", reservationID)
+	return nil
+}
+
+// AcknowledgeReservation accepts or rejects a reservation as its provider
+func (c *MQTTCommunicator) AcknowledgeReservation(ctx context.Context, reservationID string, accepted bool, reason string) error {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	if _, err := c.callReservation(ctx, &request{
+		Operation:     operationAcknowledge,
+		ReservationID: reservationID,
+		Acknowledge:   &dto.AcknowledgeRequestDTO{Accepted: accepted, Reason: reason},
+	}, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to acknowledge reservation: %w", err)
+	}
+
+	logger.Info("Reservation acknowledged at broker",
+		"reservation", reservationID,
+		"accepted", accepted,
+		"reason", reason)
+	return nil
+}
+
+// HeartbeatReservation tells the broker the requester still uses a reservation
+func (c *MQTTCommunicator) HeartbeatReservation(ctx context.Context, reservationID string) error {
+	if _, err := c.callReservation(ctx, &request{
+		Operation:     operationHeartbeat,
+		ReservationID: reservationID,
+	}, http.StatusOK); err != nil {
+		return fmt.Errorf("failed to send heartbeat: %w", err)
+	}
+	return nil
+}
+
+// RenewReservation extends the expiry of a reservation at the broker
+func (c *MQTTCommunicator) RenewReservation(ctx context.Context, reservationID, duration string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	reservation, err := c.callReservation(ctx, &request{
+		Operation:     operationRenew,
+		ReservationID: reservationID,
+		Renew:         &dto.RenewRequestDTO{Duration: duration},
+	}, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew reservation: %w", err)
+	}
+	if reservation == nil {
+		return nil, fmt.Errorf("broker returned no reservation")
+	}
+
+	logger.Info("Reservation renewed at broker",
+		"reservation", reservationID,
+		"expiresAt", reservation.Status.ExpiresAt)
+
+	return reservation, nil
+}
+
+// ResizeReservation changes the CPU and memory of a reservation at the broker
+func (c *MQTTCommunicator) ResizeReservation(
+	ctx context.Context,
+	reservationID string,
+	resources dto.ResourceQuantitiesDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	reservation, err := c.callReservation(ctx, &request{
+		Operation:     operationResize,
+		ReservationID: reservationID,
+		Resize:        &dto.ResizeRequestDTO{RequestedResources: resources},
+	}, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resize reservation: %w", err)
+	}
+	if reservation == nil {
+		return nil, fmt.Errorf("broker returned no reservation")
+	}
+
+	logger.Info("Reservation resized at broker",
+		"reservation", reservationID,
+		"cpu", reservation.RequestedResources.CPU,
+		"memory", reservation.RequestedResources.Memory)
+
+	return reservation, nil
+}
+
+// ReleaseReservation releases a reservation, or every part of a group, at the broker.
+// A reservation the broker no longer knows is treated as already released.
+func (c *MQTTCommunicator) ReleaseReservation(ctx context.Context, reservationID string) error {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	resp, err := c.call(ctx, &request{
+		Operation:     operationRelease,
+		ReservationID: reservationID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	switch resp.Status {
+	case http.StatusAccepted, http.StatusOK:
+		logger.Info("Reservation released at broker", "reservation", reservationID)
+		return nil
+	case http.StatusNotFound:
+		logger.Info("Reservation not found at broker, nothing to release", "reservation", reservationID)
+		return nil
+	default:
+		return expectStatus(resp)
+	}
+}
+
+// FetchInstructions asks the broker for the cluster's current provider instructions
+func (c *MQTTCommunicator) FetchInstructions(ctx context.Context) ([]*dto.ReservationDTO, error) {
+	resp, err := c.call(ctx, &request{Operation: operationFetchInstructions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch instructions: %w", err)
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.Instructions, nil
+}
+
+// WatchInstructions follows the cluster's instructions topic. MQTT has no
+// resume tokens: every watch fetches the current instructions once
+// subscribed, and the token is returned unchanged. The watch ends when the
+// connection is lost, since the subscription does not survive it and
+// instructions published meanwhile are not redelivered.
+func (c *MQTTCommunicator) WatchInstructions(
+	ctx context.Context,
+	resumeToken string,
+	handle func(instruction *dto.ReservationDTO),
+) (string, error) {
+	logger := log.FromContext(ctx).WithName("mqtt-communicator")
+
+	c.mu.Lock()
+	lost := c.lost
+	c.mu.Unlock()
+	select {
+	case <-lost:
+		return resumeToken, errors.New("not connected to MQTT server")
+	default:
+	}
+
+	// Instructions are queued so the client never blocks on the handler
+	messages := make(chan paho.Message, instructionBuffer)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	topic := c.topic(topicInstructions)
+	token := c.client.Subscribe(topic, 1, func(_ paho.Client, msg paho.Message) {
+		select {
+		case messages <- msg:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+	})
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		return resumeToken, fmt.Errorf("failed to subscribe to instructions: %v", token.Error())
+	}
+	defer c.client.Unsubscribe(topic)
+
+	// Instructions issued before the subscription are fetched instead
+	current, err := c.FetchInstructions(ctx)
+	if err != nil {
+		return resumeToken, err
+	}
+	for _, instruction := range current {
+		handle(instruction)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return resumeToken, ctx.Err()
+		case <-lost:
+			return resumeToken, errors.New("connection to MQTT server lost")
+		case <-overflow:
+			return resumeToken, errors.New("instructions arrived faster than they were handled")
+		case msg := <-messages:
+			_, payload, err := c.verifier.Verify(msg.Topic(), msg.Payload())
+			if err != nil {
+				logger.Info("Dropped unverified instruction", "error", err.Error())
+				continue
+			}
+			var instruction dto.ReservationDTO
+			if err := json.Unmarshal(payload, &instruction); err != nil {
+				return resumeToken, fmt.Errorf("failed to decode instruction: %w", err)
+			}
+			handle(&instruction)
+		}
+	}
+}
+
+// Ping checks connectivity to broker with a request it answers
+func (c *MQTTCommunicator) Ping(ctx context.Context) error {
+	if _, err := c.FetchInstructions(ctx); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects from the MQTT server
+func (c *MQTTCommunicator) Close() error {
+	c.client.Disconnect(250)
+	return nil
+}
+
+// newRequestID returns a random request ID, unique across agent restarts
+func newRequestID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate request ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,204 @@
+package mqtt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxMessageAge bounds how far a message's signing time may be from the
+// receiver's clock. Older messages are dropped, and so are replays of newer
+// ones (see Verifier).
+const maxMessageAge = 5 * time.Minute
+
+// SignedMessage is the payload of every message on the cluster topics. The
+// MQTT server only relays it: the receiver trusts the sender's certificate,
+// issued by the broker's CA, instead of the topic the message came on. The
+// broker defines the format; this is a copy.
+type SignedMessage struct {
+	Certificate []byte          `json:"certificate"` // DER, of the sender
+	SignedAt    int64           `json:"signedAt"`    // Unix milliseconds
+	Payload     json.RawMessage `json:"payload"`
+	Signature   []byte          `json:"signature"` // Over topic, signedAt and payload (see messageDigest)
+}
+
+// Signer signs messages with the agent's client certificate
+type Signer struct {
+	certificate []byte
+	key         crypto.Signer
+}
+
+// LoadSigner loads the client certificate and key (tls.crt, tls.key in certPath)
+func LoadSigner(certPath string) (*Signer, error) {
+	cert, err := tls.LoadX509KeyPair(
+		filepath.Join(certPath, "tls.crt"),
+		filepath.Join(certPath, "tls.key"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	return NewSigner(cert)
+}
+
+// NewSigner creates a signer for a certificate and its key
+func NewSigner(cert tls.Certificate) (*Signer, error) {
+	key, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok || len(cert.Certificate) == 0 {
+		return nil, errors.New("certificate has no signing key")
+	}
+	return &Signer{certificate: cert.Certificate[0], key: key}, nil
+}
+
+// Sign encodes payload as the SignedMessage to publish on topic
+func (s *Signer) Sign(topic string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	msg := SignedMessage{
+		Certificate: s.certificate,
+		SignedAt:    time.Now().UnixMilli(),
+		Payload:     data,
+	}
+	digest := messageDigest(topic, msg.SignedAt, data)
+
+	// Ed25519 signs the digest itself; RSA and ECDSA sign it as a SHA-256 hash
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		opts = crypto.Hash(0)
+	}
+	msg.Signature, err = s.key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	return json.Marshal(&msg)
+}
+
+// Verifier checks the SignedMessages received on the cluster topics
+type Verifier struct {
+	roots *x509.CertPool
+	usage x509.ExtKeyUsage
+
+	mu     sync.Mutex
+	seen   map[string]time.Time // Signatures already verified, until they expire
+	pruned time.Time
+}
+
+// LoadVerifier trusts the certificates the broker's CA (ca.crt in certPath)
+// issued for usage: server auth for the broker, client auth for agents
+func LoadVerifier(certPath string, usage x509.ExtKeyUsage) (*Verifier, error) {
+	caCert, err := os.ReadFile(filepath.Join(certPath, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to append CA certificate")
+	}
+	return NewVerifier(roots, usage), nil
+}
+
+// NewVerifier creates a verifier trusting the certificates roots issued for usage
+func NewVerifier(roots *x509.CertPool, usage x509.ExtKeyUsage) *Verifier {
+	return &Verifier{roots: roots, usage: usage, seen: map[string]time.Time{}}
+}
+
+// Verify checks a message received on topic and returns the CN of its
+// sender's certificate and its payload.
+// A message is accepted once, and only on the topic it was signed for.
+func (v *Verifier) Verify(topic string, data []byte) (string, json.RawMessage, error) {
+	var msg SignedMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return "", nil, fmt.Errorf("malformed message: %w", err)
+	}
+
+	signedAt := time.UnixMilli(msg.SignedAt)
+	if age := time.Since(signedAt); age > maxMessageAge || age < -maxMessageAge {
+		return "", nil, fmt.Errorf("message signed at %s is too old or too far ahead", signedAt.UTC().Format(time.RFC3339))
+	}
+
+	cert, err := x509.ParseCertificate(msg.Certificate)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     v.roots,
+		KeyUsages: []x509.ExtKeyUsage{v.usage},
+	}); err != nil {
+		return "", nil, fmt.Errorf("untrusted certificate: %w", err)
+	}
+	if err := verifySignature(cert.PublicKey, messageDigest(topic, msg.SignedAt, msg.Payload), msg.Signature); err != nil {
+		return "", nil, err
+	}
+
+	if !v.firstSeen(msg.Signature, signedAt.Add(maxMessageAge)) {
+		return "", nil, errors.New("message replayed")
+	}
+	return cert.Subject.CommonName, msg.Payload, nil
+}
+
+// firstSeen records a verified signature until expiry, and reports whether
+// it was new. Expired signatures are forgotten: their messages are too old anyway.
+func (v *Verifier) firstSeen(signature []byte, expiry time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(v.pruned) > time.Minute {
+		for key, until := range v.seen {
+			if now.After(until) {
+				delete(v.seen, key)
+			}
+		}
+		v.pruned = now
+	}
+
+	key := string(signature)
+	if _, seen := v.seen[key]; seen {
+		return false
+	}
+	v.seen[key] = expiry
+	return true
+}
+
+// messageDigest binds a payload to its topic and signing time, so a message
+// cannot be replayed on another cluster's topic or with a fresh timestamp
+func messageDigest(topic string, signedAt int64, payload []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(topic + "\n" + strconv.FormatInt(signedAt, 10) + "\n"))
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// verifySignature checks a signature made by Signer.Sign
+func verifySignature(publicKey any, digest, signature []byte) error {
+	valid := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest, signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, digest, signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
@@ -43,6 +43,11 @@ type ClusterAdvertisementSpec struct {
 	// EndpointURL is the API endpoint of the source cluster
 	// +optional
 	EndpointURL string `json:"endpointURL,omitempty"`
+
+	// Labels describe the cluster for placement constraints
+	// (e.g., topology.kubernetes.io/region=eu-west-1, compliance.fluidos.eu/gdpr=true)
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // ResourceMetrics represents available resources with detailed breakdown
@@ -79,6 +84,11 @@ type ResourceQuantities struct {
 	// Storage (optional)
 	// +optional
 	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Extended resources keyed by Kubernetes resource name
+	// (e.g., "amd.com/gpu", "hugepages-2Mi", "ephemeral-storage")
+	// +optional
+	Extended map[string]resource.Quantity `json:"extended,omitempty"`
 }
 
 // CostInfo represents cost information
@@ -127,6 +137,9 @@ const (
 	ClusterAdvertisementConditionStale = "Stale"
 	// ClusterAdvertisementConditionOvercommitted indicates reserved > available
 	ClusterAdvertisementConditionOvercommitted = "Overcommitted"
+	// ClusterAdvertisementConditionReservedDrift indicates the last audit found
+	// Reserved out of line with the reservations holding locks and corrected it
+	ClusterAdvertisementConditionReservedDrift = "ReservedDrift"
 )
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,45 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetRequesterCondition sets a requester signal for the reservation
+// controller. Returns false if the condition was already set.
+func SetRequesterCondition(reservation *Reservation, conditionType, reason, message string) bool {
+	if meta.IsStatusConditionTrue(reservation.Status.Conditions, conditionType) {
+		return false
+	}
+	meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	return true
+}
+
+// PhaseOrPending names a reservation phase, treating a new reservation as Pending
+func PhaseOrPending(phase ReservationPhase) ReservationPhase {
+	if phase == "" {
+		return ReservationPhasePending
+	}
+	return phase
+}
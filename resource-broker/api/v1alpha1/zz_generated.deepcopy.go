@@ -21,10 +21,26 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CandidateEvaluation) DeepCopyInto(out *CandidateEvaluation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CandidateEvaluation.
+func (in *CandidateEvaluation) DeepCopy() *CandidateEvaluation {
+	if in == nil {
+		return nil
+	}
+	out := new(CandidateEvaluation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterAdvertisement) DeepCopyInto(out *ClusterAdvertisement) {
 	*out = *in
@@ -94,6 +110,13 @@ func (in *ClusterAdvertisementSpec) DeepCopyInto(out *ClusterAdvertisementSpec)
 		**out = **in
 	}
 	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAdvertisementSpec.
@@ -144,6 +167,78 @@ func (in *CostInfo) DeepCopy() *CostInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionRecord) DeepCopyInto(out *DecisionRecord) {
+	*out = *in
+	in.DecidedAt.DeepCopyInto(&out.DecidedAt)
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]CandidateEvaluation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionRecord.
+func (in *DecisionRecord) DeepCopy() *DecisionRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementConstraints) DeepCopyInto(out *PlacementConstraints) {
+	*out = *in
+	if in.Required != nil {
+		in, out := &in.Required, &out.Required
+		*out = make([]v1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Preferred != nil {
+		in, out := &in.Preferred, &out.Preferred
+		*out = make([]PreferredPlacementTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementConstraints.
+func (in *PlacementConstraints) DeepCopy() *PlacementConstraints {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementConstraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredPlacementTerm) DeepCopyInto(out *PreferredPlacementTerm) {
+	*out = *in
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = make([]v1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredPlacementTerm.
+func (in *PreferredPlacementTerm) DeepCopy() *PreferredPlacementTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredPlacementTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequestedResourceQuantities) DeepCopyInto(out *RequestedResourceQuantities) {
 	*out = *in
@@ -159,6 +254,13 @@ func (in *RequestedResourceQuantities) DeepCopyInto(out *RequestedResourceQuanti
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Extended != nil {
+		in, out := &in.Extended, &out.Extended
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestedResourceQuantities.
@@ -239,6 +341,15 @@ func (in *ReservationSpec) DeepCopyInto(out *ReservationSpec) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementConstraints)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationSpec.
@@ -262,7 +373,16 @@ func (in *ReservationStatus) DeepCopyInto(out *ReservationStatus) {
 		in, out := &in.ExpiresAt, &out.ExpiresAt
 		*out = (*in).DeepCopy()
 	}
+	if in.LastHeartbeatTime != nil {
+		in, out := &in.LastHeartbeatTime, &out.LastHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
 	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Decision != nil {
+		in, out := &in.Decision, &out.Decision
+		*out = new(DecisionRecord)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -321,6 +441,13 @@ func (in *ResourceQuantities) DeepCopyInto(out *ResourceQuantities) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Extended != nil {
+		in, out := &in.Extended, &out.Extended
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuantities.
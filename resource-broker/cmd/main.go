@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -40,6 +44,9 @@ import (
 	"github.com/mehdiazizian/liqo-resource-broker/internal/api/handlers"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/controller"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	transportgrpc "github.com/mehdiazizian/liqo-resource-broker/internal/transport/grpc"
+	transportmqtt "github.com/mehdiazizian/liqo-resource-broker/internal/transport/mqtt"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -66,17 +73,70 @@ func main() {
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 	var brokerInterface string
+	var enableHTTP, enableGRPC, enableMQTT, enableKubernetes bool
 	var httpPort string
+	var grpcPort string
+	var mqttServerURL string
 	var httpCertPath string
 	var httpNamespace string
+	var scoringStrategy string
+	var enablePreemption bool
+	var maxReservationLifetime time.Duration
+	var heartbeatTimeout time.Duration
+	var orphanGracePeriod time.Duration
+	var requireProviderAck bool
+	var reservedAuditInterval time.Duration
+	var terminalReservationTTL time.Duration
+	var keepTerminalReservations int
+	var reservationArchivePath string
+	var instructionFeedSize int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
-	flag.StringVar(&brokerInterface, "broker-interface", "kubernetes",
-		"Communication interface for agents: 'http' (REST API with mTLS) or 'kubernetes' (CRD-based via K8s API). "+
-			"Only ONE interface is active at a time. Agents must use matching transport.")
-	flag.StringVar(&httpPort, "http-port", "8443", "HTTP REST API server port (only used when broker-interface=http)")
-	flag.StringVar(&httpCertPath, "http-cert-path", "/etc/broker/certs", "Path to TLS certificates for HTTP API (only used when broker-interface=http)")
+	flag.BoolVar(&enableHTTP, "enable-http", false,
+		"Serve agents over the HTTP REST API with mTLS (agents use --broker-transport=http).")
+	flag.BoolVar(&enableGRPC, "enable-grpc", false,
+		"Serve agents over gRPC with mTLS (agents use --broker-transport=grpc).")
+	flag.BoolVar(&enableMQTT, "enable-mqtt", false,
+		"Serve agents over MQTT with mTLS (agents use --broker-transport=mqtt).")
+	flag.BoolVar(&enableKubernetes, "enable-kubernetes", true,
+		"Decide Reservations that agents create directly through the Kubernetes API "+
+			"(agents use --broker-transport=kubernetes). When disabled they are failed.")
+	flag.StringVar(&brokerInterface, "broker-interface", "",
+		"Deprecated: use the --enable-* flags. Enables one more interface: 'http', 'grpc', 'mqtt' or 'kubernetes'.")
+	flag.StringVar(&httpPort, "http-port", "8443", "HTTP REST API server port (only used with --enable-http)")
+	flag.StringVar(&grpcPort, "grpc-port", "9443", "gRPC server port (only used with --enable-grpc)")
+	flag.StringVar(&mqttServerURL, "mqtt-server-url", "",
+		"MQTT server the broker and agents connect to, e.g. tls://mosquitto:8883 (required with --enable-mqtt)")
+	flag.StringVar(&httpCertPath, "http-cert-path", "/etc/broker/certs",
+		"Path to TLS certificates for the HTTP API, the gRPC server and MQTT")
 	flag.StringVar(&httpNamespace, "http-namespace", "default", "Namespace for ClusterAdvertisements and Reservations")
+	flag.StringVar(&scoringStrategy, "scoring-strategy", string(broker.DefaultScoringStrategy),
+		"Default strategy for ranking candidate clusters: LeastAllocated (spread), MostAllocated (bin-pack), "+
+			"LowestCost or BalancedResource. Reservations may override it per request.")
+	flag.BoolVar(&enablePreemption, "enable-preemption", false,
+		"Let reservations that fit nowhere evict lower-priority Reserved (not yet Active) reservations.")
+	flag.DurationVar(&maxReservationLifetime, "max-reservation-lifetime", 0,
+		"Maximum total lifetime of a reservation with a duration, counted from when it was reserved and "+
+			"including renewals (0 = unlimited).")
+	flag.DurationVar(&heartbeatTimeout, "heartbeat-timeout", 0,
+		"Mark Active reservations Orphaned when their requester sent no heartbeat for this long (0 = disabled).")
+	flag.DurationVar(&orphanGracePeriod, "orphan-grace-period", 5*time.Minute,
+		"How long an Orphaned reservation keeps its resources before they are released.")
+	flag.BoolVar(&requireProviderAck, "require-provider-ack", false,
+		"Keep reservations Reserved until their provider accepted them, even once the requester activated them.")
+	flag.DurationVar(&reservedAuditInterval, "reserved-audit-interval", 5*time.Minute,
+		"How often to recompute each cluster's Reserved resources from its reservations and correct drift (0 = disabled).")
+	flag.DurationVar(&terminalReservationTTL, "terminal-reservation-ttl", 0,
+		"Delete Released, Failed and Preempted reservations this long after they finished, except those "+
+			"created with an idempotency key and parts of unfinished groups (0 = no age limit).")
+	flag.IntVar(&keepTerminalReservations, "keep-terminal-reservations", 0,
+		"Always keep this many of the most recently finished reservations, whatever their age "+
+			"(0 = none; with --terminal-reservation-ttl=0 too, the default, nothing is deleted).")
+	flag.StringVar(&reservationArchivePath, "reservation-archive-path", "",
+		"File to which deleted terminal reservations are appended as JSON lines (empty = no archive).")
+	flag.IntVar(&instructionFeedSize, "instruction-feed-size", 1024,
+		"Number of recent reservation changes kept so instruction streams can resume after a reconnect "+
+			"(0 = no streaming, agents poll).")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
@@ -100,6 +160,32 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// --broker-interface predates the --enable-* flags; the CRD interface was always on
+	switch brokerInterface {
+	case "":
+	case "http":
+		enableHTTP = true
+	case "grpc":
+		enableGRPC = true
+	case "mqtt":
+		enableMQTT = true
+	case "kubernetes":
+		enableKubernetes = true
+	default:
+		setupLog.Error(nil, "Invalid broker-interface value",
+			"value", brokerInterface,
+			"valid", "http, grpc, mqtt, kubernetes")
+		os.Exit(1)
+	}
+	if brokerInterface != "" {
+		setupLog.Info("--broker-interface is deprecated, use the --enable-* flags", "value", brokerInterface)
+	}
+	if !enableHTTP && !enableGRPC && !enableMQTT && !enableKubernetes {
+		setupLog.Error(nil, "no broker interface enabled",
+			"flags", "--enable-http, --enable-grpc, --enable-mqtt, --enable-kubernetes")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -199,78 +285,163 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize decision engine for reservation controller
+	// One decision engine is shared by the reservation controller and every interface
+	strategy, err := broker.NewScoringStrategy(brokerv1alpha1.ScoringStrategyType(scoringStrategy))
+	if err != nil {
+		setupLog.Error(err, "invalid scoring strategy")
+		os.Exit(1)
+	}
+	setupLog.Info("Using scoring strategy", "strategy", strategy.Name())
+
+	// Decisions list only the Scheduled reservations, through this index
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &brokerv1alpha1.Reservation{},
+		broker.ReservationPhaseField, broker.IndexReservationPhase); err != nil {
+		setupLog.Error(err, "unable to index reservations by phase")
+		os.Exit(1)
+	}
+
 	decisionEngine := &broker.DecisionEngine{
-		Client: mgr.GetClient(),
+		Client:     mgr.GetClient(),
+		Strategy:   strategy,
+		Preemption: enablePreemption,
 	}
 
 	if err := (&controller.ReservationReconciler{
-		Client:         mgr.GetClient(),
-		Scheme:         mgr.GetScheme(),
-		DecisionEngine: decisionEngine,
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		DecisionEngine:     decisionEngine,
+		HeartbeatTimeout:   heartbeatTimeout,
+		OrphanGracePeriod:  orphanGracePeriod,
+		RequireProviderAck: requireProviderAck,
+		// Agents without the CRD interface cannot see decisions on Reservations they create
+		RejectDirectReservations: !enableKubernetes,
+		MaxLifetime:              maxReservationLifetime,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Reservation")
 		os.Exit(1)
 	}
 
+	if reservedAuditInterval > 0 {
+		if err := mgr.Add(&controller.ReservedAuditor{
+			Client:   mgr.GetClient(),
+			Interval: reservedAuditInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up reserved resources auditor")
+			os.Exit(1)
+		}
+	}
+
+	retention := broker.RetentionPolicy{TTL: terminalReservationTTL, KeepLast: keepTerminalReservations}
+	if retention.Enabled() {
+		collector := &controller.ReservationCollector{
+			Client: mgr.GetClient(),
+			Policy: retention,
+		}
+		if reservationArchivePath != "" {
+			collector.Archive = &broker.FileArchive{Path: reservationArchivePath}
+		}
+		if err := mgr.Add(collector); err != nil {
+			setupLog.Error(err, "unable to set up reservation collector")
+			os.Exit(1)
+		}
+	}
+
 	// =============================================================================
-	// BROKER INTERFACE SELECTION
+	// BROKER INTERFACES
 	// =============================================================================
-	// The broker runs ONE communication interface at a time, selected by --broker-interface flag.
-	// All agents connecting to this broker must use the matching transport.
-	//
-	// HINT: To enable MULTIPLE interfaces simultaneously (e.g., for migration scenarios),
-	// remove the switch statement and start each interface conditionally:
-	//
-	//   if httpEnabled {
-	//       startHTTPServer(...)
-	//   }
-	//   if mqttEnabled {
-	//       startMQTTServer(...)
-	//   }
-	//   // Kubernetes CRD interface is always available via K8s API
+	// The broker serves any combination of interfaces at once, so agents can be
+	// moved from one transport to another gradually. Each agent must use a
+	// transport whose interface is enabled here.
 	//
+	// HTTP, gRPC and MQTT call one service, and the service and the Reservation
+	// controller share one decision engine: every interface decides and locks
+	// reservations the same way, against the same capacity.
 	// =============================================================================
 
-	switch brokerInterface {
-	case "http":
-		// HTTP REST API with mTLS - agents use --broker-transport=http
-		setupLog.Info("Starting broker with HTTP interface",
-			"port", httpPort,
-			"certPath", httpCertPath,
-			"namespace", httpNamespace)
-
-		// Create handlers with k8s client and decision engine
-		handler := handlers.NewHandler(mgr.GetClient(), httpNamespace, decisionEngine)
-
-		// Create and start HTTP server
-		server, err := api.NewServer(httpPort, httpCertPath, handler)
-		if err != nil {
-			setupLog.Error(err, "failed to create HTTP server")
+	if enableHTTP || enableGRPC || enableMQTT {
+		// One feed serves the instruction streams of every interface
+		feed := newInstructionFeed(mgr, instructionFeedSize)
+		if enableMQTT && feed == nil {
+			// Instructions are pushed to agents, so the feed is required
+			setupLog.Error(nil, "enable-mqtt requires instruction-feed-size > 0")
 			os.Exit(1)
 		}
+		svc := service.New(mgr.GetClient(), httpNamespace, decisionEngine, maxReservationLifetime, feed)
+
+		if enableHTTP {
+			// HTTP REST API with mTLS - agents use --broker-transport=http
+			setupLog.Info("Starting broker HTTP interface",
+				"port", httpPort,
+				"certPath", httpCertPath,
+				"namespace", httpNamespace)
+
+			server, err := api.NewServer(httpPort, httpCertPath, handlers.NewHandler(svc))
+			if err != nil {
+				setupLog.Error(err, "failed to create HTTP server")
+				os.Exit(1)
+			}
+
+			// Start server in background goroutine
+			go func() {
+				setupLog.Info("HTTP REST API server listening", "port", httpPort)
+				if err := server.Start(); err != nil {
+					setupLog.Error(err, "HTTP server failed")
+					os.Exit(1)
+				}
+			}()
+		}
 
-		// Start server in background goroutine
-		go func() {
-			setupLog.Info("HTTP REST API server listening", "port", httpPort)
-			if err := server.Start(); err != nil {
-				setupLog.Error(err, "HTTP server failed")
+		if enableGRPC {
+			// gRPC with mTLS - agents use --broker-transport=grpc
+			setupLog.Info("Starting broker gRPC interface",
+				"port", grpcPort,
+				"certPath", httpCertPath,
+				"namespace", httpNamespace)
+
+			// Every RPC calls the same service as the HTTP interface
+			server, err := transportgrpc.NewServer(grpcPort, httpCertPath, svc)
+			if err != nil {
+				setupLog.Error(err, "failed to create gRPC server")
 				os.Exit(1)
 			}
-		}()
 
-	case "kubernetes":
+			// Start server in background goroutine
+			go func() {
+				setupLog.Info("gRPC server listening", "port", grpcPort)
+				if err := server.Start(); err != nil {
+					setupLog.Error(err, "gRPC server failed")
+					os.Exit(1)
+				}
+			}()
+		}
+
+		if enableMQTT {
+			// MQTT with mTLS - agents use --broker-transport=mqtt
+			setupLog.Info("Starting broker MQTT interface",
+				"serverURL", mqttServerURL,
+				"certPath", httpCertPath,
+				"namespace", httpNamespace)
+
+			bridge, err := newMQTTBridge(svc, mqttServerURL, httpCertPath)
+			if err != nil {
+				setupLog.Error(err, "failed to set up MQTT")
+				os.Exit(1)
+			}
+
+			// The bridge serves agent messages with the same service as the HTTP interface
+			if err := mgr.Add(bridge); err != nil {
+				setupLog.Error(err, "unable to add MQTT bridge to manager")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if enableKubernetes {
 		// Kubernetes CRD-based - agents use --broker-transport=kubernetes
 		// No additional server needed; agents create CRDs directly via K8s API
-		setupLog.Info("Starting broker with Kubernetes interface",
+		setupLog.Info("Starting broker Kubernetes interface",
 			"namespace", httpNamespace,
 			"note", "Agents must create ClusterAdvertisement CRDs directly via Kubernetes API")
-
-	default:
-		setupLog.Error(nil, "Invalid broker-interface value",
-			"value", brokerInterface,
-			"valid", "http, kubernetes")
-		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -289,3 +460,46 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newInstructionFeed feeds instruction streams from the informer behind the
+// manager's cache. Returns nil (polling only) when size is 0.
+func newInstructionFeed(mgr ctrl.Manager, size int) *broker.ReservationFeed {
+	if size <= 0 {
+		return nil
+	}
+
+	reservationFeed := broker.NewReservationFeed(size)
+	reservationInformer, err := mgr.GetCache().GetInformer(context.Background(), &brokerv1alpha1.Reservation{})
+	if err != nil {
+		setupLog.Error(err, "unable to get Reservation informer")
+		os.Exit(1)
+	}
+	if _, err := reservationInformer.AddEventHandler(reservationFeed); err != nil {
+		setupLog.Error(err, "unable to watch Reservations for instruction streams")
+		os.Exit(1)
+	}
+	return reservationFeed
+}
+
+// newMQTTBridge connects to the external MQTT server at serverURL. Agents'
+// messages are accepted when signed with a certificate for client auth from
+// the CA in certPath; the broker signs its own with its certificate.
+func newMQTTBridge(svc *service.Service, serverURL, certPath string) (*transportmqtt.Bridge, error) {
+	if serverURL == "" {
+		return nil, errors.New("--mqtt-server-url is required with --enable-mqtt")
+	}
+
+	clientOpts, err := transportmqtt.ClientOptions(serverURL, certPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := transportmqtt.LoadSigner(certPath)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := transportmqtt.LoadVerifier(certPath, x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	return transportmqtt.NewBridge(svc, clientOpts, signer, verifier), nil
+}
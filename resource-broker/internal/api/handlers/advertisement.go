@@ -2,21 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
 
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
 )
 
 // PostAdvertisement handles POST /api/v1/advertisements
-// CRITICAL: Preserves Reserved field from existing advertisement
+// See service.PublishAdvertisement.
 func (h *Handler) PostAdvertisement(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := log.FromContext(ctx).WithName("advertisement-handler")
@@ -25,191 +23,58 @@ func (h *Handler) PostAdvertisement(w http.ResponseWriter, r *http.Request) {
 	var incomingAdv dto.AdvertisementDTO
 	if err := json.NewDecoder(r.Body).Decode(&incomingAdv); err != nil {
 		logger.Error(err, "Failed to decode request body")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Validate cluster ID matches certificate
-	certClusterID, _ := middleware.GetClusterID(ctx)
-	if incomingAdv.ClusterID != certClusterID {
-		logger.Error(nil, "Cluster ID mismatch",
-			"advertised", incomingAdv.ClusterID,
-			"certificate", certClusterID)
-		http.Error(w, "Cluster ID does not match certificate", http.StatusForbidden)
-		return
-	}
-
-	// CRITICAL: Fetch existing advertisement to preserve Reserved field
-	existing := &brokerv1alpha1.ClusterAdvertisement{}
-	advName := incomingAdv.ClusterID + "-adv"
-	err := h.k8sClient.Get(ctx,
-		types.NamespacedName{Name: advName, Namespace: h.namespace},
-		existing)
-
-	// Convert DTO to k8s ClusterAdvertisement
-	clusterAdv, err2 := dto.ToClusterAdvertisement(&incomingAdv, h.namespace)
-	if err2 != nil {
-		logger.Error(err2, "Failed to convert advertisement")
-		http.Error(w, "Failed to process advertisement", http.StatusInternalServerError)
-		return
-	}
-
-	if err == nil {
-		// Advertisement exists - CRITICAL: Preserve Reserved field
-		if existing.Spec.Resources.Reserved != nil {
-			logger.Info("Preserving Reserved field from existing advertisement",
-				"cpu", existing.Spec.Resources.Reserved.CPU.String(),
-				"memory", existing.Spec.Resources.Reserved.Memory.String())
-			clusterAdv.Spec.Resources.Reserved = existing.Spec.Resources.Reserved
-		}
-
-		// Update existing advertisement
-		clusterAdv.ResourceVersion = existing.ResourceVersion
-		if err := h.k8sClient.Update(ctx, clusterAdv); err != nil {
-			logger.Error(err, "Failed to update advertisement")
-			http.Error(w, fmt.Sprintf("Failed to update advertisement: %v", err),
-				http.StatusInternalServerError)
-			return
-		}
-
-		logger.Info("Updated advertisement",
-			"clusterID", incomingAdv.ClusterID,
-			"availableCPU", incomingAdv.Resources.Available.CPU,
-			"availableMemory", incomingAdv.Resources.Available.Memory)
-
-	} else if apierrors.IsNotFound(err) {
-		// Advertisement doesn't exist - create new
-		if err := h.k8sClient.Create(ctx, clusterAdv); err != nil {
-			logger.Error(err, "Failed to create advertisement")
-			http.Error(w, fmt.Sprintf("Failed to create advertisement: %v", err),
-				http.StatusInternalServerError)
-			return
-		}
-
-		logger.Info("Created new advertisement",
-			"clusterID", incomingAdv.ClusterID,
-			"availableCPU", incomingAdv.Resources.Available.CPU,
-			"availableMemory", incomingAdv.Resources.Available.Memory)
-
-	} else {
-		// Unexpected error
-		logger.Error(err, "Failed to check existing advertisement")
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	clusterID, _ := middleware.GetClusterID(ctx)
+	response, err := h.service.PublishAdvertisement(ctx, clusterID, &incomingAdv)
+	if err != nil {
+		respondWithServiceError(w, err)
 		return
 	}
-
-	// Build response with updated advertisement
-	responseDTO := dto.FromClusterAdvertisement(clusterAdv)
-
-	// Piggyback provider instructions: include any Reserved-phase reservations
-	// where this cluster is the provider. This eliminates the need for polling.
-	var providerInstructions []*dto.ReservationDTO
-	reservationList := &brokerv1alpha1.ReservationList{}
-	if err := h.k8sClient.List(ctx, reservationList); err != nil {
-		logger.Error(err, "Failed to list reservations for provider instructions")
-	} else {
-		for i := range reservationList.Items {
-			rsv := &reservationList.Items[i]
-			if rsv.Status.Phase == brokerv1alpha1.ReservationPhaseReserved &&
-				rsv.Spec.TargetClusterID == incomingAdv.ClusterID {
-				providerInstructions = append(providerInstructions, dto.FromReservation(rsv))
-			}
-		}
-		if len(providerInstructions) > 0 {
-			logger.Info("Including provider instructions in advertisement response",
-				"clusterID", incomingAdv.ClusterID,
-				"count", len(providerInstructions))
-		}
-	}
-
-	response := &dto.AdvertisementResponseDTO{
-		Advertisement:        responseDTO,
-		ProviderInstructions: providerInstructions,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logger.Error(err, "Failed to encode response")
-	}
+	respondWithJSON(w, r, http.StatusOK, response)
 }
 
 // GetAdvertisement handles GET /api/v1/advertisements/{clusterID}
 func (h *Handler) GetAdvertisement(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	logger := log.FromContext(ctx).WithName("advertisement-handler")
-
-	clusterID := r.PathValue("clusterID")
-	if clusterID == "" {
-		http.Error(w, "Missing clusterID parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Fetch advertisement
-	existing := &brokerv1alpha1.ClusterAdvertisement{}
-	err := h.k8sClient.Get(ctx,
-		types.NamespacedName{Name: clusterID + "-adv", Namespace: h.namespace},
-		existing)
-
+	advertisement, err := h.service.GetAdvertisement(r.Context(), r.PathValue("clusterID"))
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			http.Error(w, "Advertisement not found", http.StatusNotFound)
-		} else {
-			logger.Error(err, "Failed to fetch advertisement")
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+		respondWithServiceError(w, err)
 		return
 	}
-
-	// Convert to DTO (includes Reserved field if present)
-	responseDTO := dto.FromClusterAdvertisement(existing)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(responseDTO); err != nil {
-		logger.Error(err, "Failed to encode response")
-	}
+	respondWithJSON(w, r, http.StatusOK, advertisement)
 }
 
 // GetInstructions handles GET /api/v1/instructions
-// Returns pending provider instructions for the calling cluster.
+// Returns pending provider instructions for the calling cluster, plus
+// preemption notices for reservations it requested or provides and release
+// notices for reservations it provides (see service.Instructions).
 // Agents poll this endpoint every few seconds for near-instant instruction delivery,
-// instead of waiting for the next advertisement cycle (30s).
+// instead of waiting for the next advertisement cycle (30s). With ?watch=true
+// the instructions are streamed as they change instead (see watchInstructions).
 func (h *Handler) GetInstructions(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	logger := log.FromContext(ctx).WithName("instructions-handler")
+	clusterID, _ := middleware.GetClusterID(r.Context())
 
-	// Get cluster ID from mTLS certificate
-	clusterID, ok := middleware.GetClusterID(ctx)
-	if !ok || clusterID == "" {
-		respondWithError(w, http.StatusForbidden, "Could not determine cluster ID from certificate")
+	if r.URL.Query().Get("watch") == "true" {
+		h.watchInstructions(w, r, clusterID)
 		return
 	}
 
-	// Find all Reserved-phase reservations where this cluster is the provider
-	reservationList := &brokerv1alpha1.ReservationList{}
-	if err := h.k8sClient.List(ctx, reservationList); err != nil {
-		logger.Error(err, "Failed to list reservations")
-		respondWithError(w, http.StatusInternalServerError, "Failed to list reservations")
+	instructions, err := h.service.Instructions(r.Context(), clusterID)
+	if err != nil {
+		respondWithServiceError(w, err)
 		return
 	}
+	respondWithJSON(w, r, http.StatusOK, instructions)
+}
 
-	var instructions []*dto.ReservationDTO
-	for i := range reservationList.Items {
-		rsv := &reservationList.Items[i]
-		if rsv.Status.Phase == brokerv1alpha1.ReservationPhaseReserved &&
-			rsv.Spec.TargetClusterID == clusterID {
-			instructions = append(instructions, dto.FromReservation(rsv))
-		}
-	}
-
-	logger.V(1).Info("Returning provider instructions",
-		"clusterID", clusterID,
-		"count", len(instructions))
-
+// respondWithJSON sends a JSON response
+func respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(instructions); err != nil {
-		logger.Error(err, "Failed to encode response")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to encode response")
 	}
 }
 
@@ -222,6 +87,57 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	})
 }
 
+// respondWithServiceError sends the error response for a failed service
+// call, with the decision behind it if there is one
+func respondWithServiceError(w http.ResponseWriter, err error) {
+	serviceErr := service.AsError(err)
+	code := httpStatus(serviceErr.Code)
+	if serviceErr.Code == service.CodeInProgress {
+		w.Header().Set("Retry-After", "1")
+	}
+
+	if serviceErr.Decision == nil {
+		respondWithError(w, code, serviceErr.Message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(dto.ErrorDTO{
+		Error:    serviceErr.Message,
+		Decision: dto.FromDecisionRecord(serviceErr.Decision),
+	})
+}
+
+// httpStatus is the status code of a service error code
+func httpStatus(code service.Code) int {
+	switch code {
+	case service.CodeInvalid:
+		return http.StatusBadRequest
+	case service.CodeForbidden:
+		return http.StatusForbidden
+	case service.CodeNotFound:
+		return http.StatusNotFound
+	case service.CodeConflict:
+		return http.StatusConflict
+	case service.CodeKeyReused:
+		return http.StatusUnprocessableEntity
+	case service.CodeInProgress:
+		return http.StatusServiceUnavailable
+	case service.CodeUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// decodeOptionalBody decodes a JSON request body that may be empty
+func decodeOptionalBody(r *http.Request, out any) error {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
 // readBody safely reads and limits request body
 func readBody(r *http.Request) ([]byte, error) {
 	const maxBodySize = 1 << 20 // 1MB
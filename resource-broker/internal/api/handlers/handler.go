@@ -1,22 +1,16 @@
 package handlers
 
 import (
-	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
 )
 
-// Handler contains dependencies for HTTP handlers
+// Handler serves the REST API: it decodes requests, calls the broker
+// service as the cluster of the client certificate and encodes its results
 type Handler struct {
-	k8sClient      client.Client
-	namespace      string // Default namespace for resources
-	decisionEngine *broker.DecisionEngine
+	service *service.Service
 }
 
-// NewHandler creates a new handler with k8s client and decision engine
-func NewHandler(k8sClient client.Client, namespace string, decisionEngine *broker.DecisionEngine) *Handler {
-	return &Handler{
-		k8sClient:      k8sClient,
-		namespace:      namespace,
-		decisionEngine: decisionEngine,
-	}
+// NewHandler creates a new handler on the broker service
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{service: svc}
 }
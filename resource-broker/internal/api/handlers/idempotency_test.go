@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Helper to build an active provider cluster with the given capacity
+func makeProvider(clusterID, cpu, memory string) *brokerv1alpha1.ClusterAdvertisement {
+	return &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterID + "-adv", Namespace: "default"},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: clusterID,
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)},
+				Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)},
+			},
+		},
+		Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+	}
+}
+
+// Helper to create a handler on a fake broker cluster
+func newFakeHandler(funcs *interceptor.Funcs, objects ...client.Object) (*Handler, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	builder := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}, &brokerv1alpha1.ClusterAdvertisement{}).
+		WithIndex(&brokerv1alpha1.Reservation{}, broker.ReservationPhaseField, broker.IndexReservationPhase)
+	if funcs != nil {
+		builder = builder.WithInterceptorFuncs(*funcs)
+	}
+	k8sClient := builder.Build()
+	return NewHandler(service.New(k8sClient, "default", &broker.DecisionEngine{Client: k8sClient}, 0, nil)), k8sClient
+}
+
+// Helper to send POST /api/v1/reservations as the requester
+func postReservation(h *Handler, requesterID, key string, body dto.ReservationRequestDTO) *httptest.ResponseRecorder {
+	encoded, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/reservations", bytes.NewReader(encoded))
+	r = r.WithContext(middleware.WithClusterID(r.Context(), requesterID))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.PostReservation(w, r)
+	return w
+}
+
+// Helper to read the CPU reserved on a cluster
+func reservedCPU(t *testing.T, k8sClient client.Client, name string) string {
+	t.Helper()
+	cluster := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, cluster); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	if cluster.Spec.Resources.Reserved == nil {
+		return "0"
+	}
+	return cluster.Spec.Resources.Reserved.CPU.String()
+}
+
+var smallRequest = dto.ReservationRequestDTO{
+	RequestedResources: dto.ResourceQuantitiesDTO{CPU: "2", Memory: "2Gi"},
+}
+
+// Test: A replay with the same key and body returns the first reservation and locks nothing more
+func TestPostReservation_ReplaysSameRequest(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"))
+
+	first := postReservation(h, "cluster-1", "key-1", smallRequest)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	var created dto.ReservationDTO
+	_ = json.Unmarshal(first.Body.Bytes(), &created)
+
+	replay := postReservation(h, "cluster-1", "key-1", smallRequest)
+	if replay.Code != http.StatusOK {
+		t.Fatalf("expected 200 on replay, got %d: %s", replay.Code, replay.Body.String())
+	}
+	var replayed dto.ReservationDTO
+	_ = json.Unmarshal(replay.Body.Bytes(), &replayed)
+	if replayed.ID != created.ID {
+		t.Errorf("expected replay to return %s, got %s", created.ID, replayed.ID)
+	}
+
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "2" {
+		t.Errorf("expected 2 CPU reserved once, got %s", got)
+	}
+
+	// The same key from another requester is a different reservation
+	other := postReservation(h, "cluster-3", "key-1", smallRequest)
+	if other.Code != http.StatusCreated {
+		t.Errorf("expected 201 for another requester, got %d", other.Code)
+	}
+}
+
+// Test: Reusing a key for a different request is rejected with 422
+func TestPostReservation_RejectsKeyReuseWithDifferentBody(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"))
+
+	if w := postReservation(h, "cluster-1", "key-1", smallRequest); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	larger := smallRequest
+	larger.RequestedResources.CPU = "4"
+	w := postReservation(h, "cluster-1", "key-1", larger)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "2" {
+		t.Errorf("expected only the first request locked, got %s CPU reserved", got)
+	}
+}
+
+// Test: A concurrent duplicate that loses the create replays the winner's reservation instead of locking
+func TestPostReservation_ConcurrentDuplicateReplays(t *testing.T) {
+	funcs := &interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			reservation, ok := obj.(*brokerv1alpha1.Reservation)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+
+			// The other request creates and reserves first
+			winner := reservation.DeepCopy()
+			if err := c.Create(ctx, winner, opts...); err != nil {
+				return err
+			}
+			winner.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+			if err := c.Status().Update(ctx, winner); err != nil {
+				return err
+			}
+			return apierrors.NewAlreadyExists(schema.GroupResource{Group: "broker.fluidos.eu", Resource: "reservations"}, obj.GetName())
+		},
+	}
+	h, k8sClient := newFakeHandler(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	w := postReservation(h, "cluster-1", "key-1", smallRequest)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 replay of the winner, got %d: %s", w.Code, w.Body.String())
+	}
+	var replayed dto.ReservationDTO
+	_ = json.Unmarshal(w.Body.Bytes(), &replayed)
+	if replayed.Status.Phase != string(brokerv1alpha1.ReservationPhaseReserved) {
+		t.Errorf("expected the winner's Reserved reservation, got %+v", replayed)
+	}
+
+	// Locking is the winner's job
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "0" {
+		t.Errorf("expected the loser to lock nothing, got %s CPU reserved", got)
+	}
+}
+
+// Test: A request whose key is still being served answers 503 with Retry-After
+func TestRespondWithServiceError_InProgress(t *testing.T) {
+	w := httptest.NewRecorder()
+	respondWithServiceError(w, &service.Error{Code: service.CodeInProgress, Message: "Request in progress"})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+}
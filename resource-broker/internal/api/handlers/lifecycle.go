@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// DeleteReservation handles DELETE /api/v1/reservations/{id}
+// Releases a reservation, or every part of a split or gang group when given
+// the group ID (see service.Release). Answers 202: the reservation
+// controller frees the locked capacity.
+func (h *Handler) DeleteReservation(w http.ResponseWriter, r *http.Request) {
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Release(r.Context(), clusterID, r.PathValue("id"))
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusAccepted, reservation)
+}
+
+// PostReservationActivation handles POST /api/v1/reservations/{id}/activate
+// The requester reports that it started using a Reserved reservation (see
+// service.Activate). Answers 202: the reservation controller promotes it to
+// Active.
+func (h *Handler) PostReservationActivation(w http.ResponseWriter, r *http.Request) {
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Activate(r.Context(), clusterID, r.PathValue("id"))
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusAccepted, reservation)
+}
+
+// PostReservationAcknowledgement handles POST /api/v1/reservations/{id}/acknowledge
+// The provider accepts or rejects a reservation it was instructed to hold
+// (see service.Acknowledge). Only the provider may answer.
+func (h *Handler) PostReservationAcknowledgement(w http.ResponseWriter, r *http.Request) {
+	var reqDTO dto.AcknowledgeRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&reqDTO); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Acknowledge(r.Context(), clusterID, r.PathValue("id"), &reqDTO)
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, reservation)
+}
+
+// PostReservationHeartbeat handles POST /api/v1/reservations/{id}/heartbeat
+// The requester reports that it still uses a reservation (see
+// service.Heartbeat). Finished reservations answer 409 so the requester
+// learns it lost them.
+func (h *Handler) PostReservationHeartbeat(w http.ResponseWriter, r *http.Request) {
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Heartbeat(r.Context(), clusterID, r.PathValue("id"))
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, reservation)
+}
+
+// PostReservationRenewal handles POST /api/v1/reservations/{id}/renew
+// Extends the expiry of a Reserved or Active reservation (see service.Renew).
+// The body is optional.
+func (h *Handler) PostReservationRenewal(w http.ResponseWriter, r *http.Request) {
+	var reqDTO dto.RenewRequestDTO
+	if err := decodeOptionalBody(r, &reqDTO); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Renew(r.Context(), clusterID, r.PathValue("id"), &reqDTO)
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, reservation)
+}
+
+// PostReservationResize handles POST /api/v1/reservations/{id}/resize
+// Grows or shrinks the CPU and memory of a Reserved or Active reservation in
+// place (see service.Resize). The provider picks up the new size through
+// GET /api/v1/instructions.
+func (h *Handler) PostReservationResize(w http.ResponseWriter, r *http.Request) {
+	var reqDTO dto.ResizeRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&reqDTO); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.Resize(r.Context(), clusterID, r.PathValue("id"), &reqDTO)
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, reservation)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/controller"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Helper to send a request on one reservation as clusterID through handle
+func sendReservationRequest(
+	handle http.HandlerFunc,
+	method, clusterID, id string,
+	body any,
+) *httptest.ResponseRecorder {
+	var encoded []byte
+	if body != nil {
+		encoded, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, "/api/v1/reservations/"+id, bytes.NewReader(encoded))
+	r = r.WithContext(middleware.WithClusterID(r.Context(), clusterID))
+	r.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	handle(w, r)
+	return w
+}
+
+// Helper to reserve smallRequest for the requester and return the reservation
+func mustReserve(t *testing.T, h *Handler, requesterID string) dto.ReservationDTO {
+	t.Helper()
+	w := postReservation(h, requesterID, "", smallRequest)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created dto.ReservationDTO
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	return created
+}
+
+// Helper to get a reservation from a fake broker cluster
+func getReservation(t *testing.T, k8sClient client.Client, name string) *brokerv1alpha1.Reservation {
+	t.Helper()
+	reservation := &brokerv1alpha1.Reservation{}
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, reservation); err != nil {
+		t.Fatalf("failed to get reservation %s: %v", name, err)
+	}
+	return reservation
+}
+
+// Helper to build a reservation of cluster-1 on cluster-2 in the given phase
+func makeReservation(name string, phase brokerv1alpha1.ReservationPhase) *brokerv1alpha1.Reservation {
+	return &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: brokerv1alpha1.ReservationSpec{
+			RequesterID:     "cluster-1",
+			TargetClusterID: "cluster-2",
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU:    resource.MustParse("2"),
+				Memory: resource.MustParse("2Gi"),
+			},
+		},
+		Status: brokerv1alpha1.ReservationStatus{Phase: phase},
+	}
+}
+
+// Helper to run the reservation controller once on a reservation
+func reconcileReservation(t *testing.T, k8sClient client.Client, name string) {
+	t.Helper()
+	reconciler := &controller.ReservationReconciler{
+		Client:         k8sClient,
+		Scheme:         k8sClient.Scheme(),
+		DecisionEngine: &broker.DecisionEngine{Client: k8sClient},
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("failed to reconcile %s: %v", name, err)
+	}
+}
+
+// Test: Only the requester can release a reservation; the controller then frees its lock
+func TestDeleteReservation_RequesterReleasesLock(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"))
+	created := mustReserve(t, h, "cluster-1")
+
+	// The provider and unrelated clusters do not learn the reservation exists
+	for _, clusterID := range []string{"cluster-2", "cluster-3"} {
+		if w := sendReservationRequest(h.DeleteReservation, http.MethodDelete, clusterID, created.ID, nil); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d: %s", clusterID, w.Code, w.Body.String())
+		}
+	}
+	if reservation := getReservation(t, k8sClient, created.ID); reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved ||
+		meta.IsStatusConditionTrue(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		t.Fatalf("expected the reservation untouched, got %s with %v", reservation.Status.Phase, reservation.Status.Conditions)
+	}
+
+	w := sendReservationRequest(h.DeleteReservation, http.MethodDelete, "cluster-1", created.ID, nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "2" {
+		t.Errorf("expected the lock held until the controller frees it, got %s CPU reserved", got)
+	}
+
+	reconcileReservation(t, k8sClient, created.ID)
+	if reservation := getReservation(t, k8sClient, created.ID); reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReleased {
+		t.Errorf("expected Released, got %s", reservation.Status.Phase)
+	}
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "0" {
+		t.Errorf("expected the lock released, got %s CPU reserved", got)
+	}
+}
+
+// Test: Releasing a reservation that already ended is accepted and changes nothing
+func TestDeleteReservation_AlreadyTerminal(t *testing.T) {
+	failed := makeReservation("failed", brokerv1alpha1.ReservationPhaseFailed)
+	failed.Status.Message = "Provider rejected"
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"), failed)
+
+	w := sendReservationRequest(h.DeleteReservation, http.MethodDelete, "cluster-1", "failed", nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reservation := getReservation(t, k8sClient, "failed")
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseFailed || reservation.Status.Message != "Provider rejected" {
+		t.Errorf("expected the reservation to stay Failed, got %s: %s", reservation.Status.Phase, reservation.Status.Message)
+	}
+	if len(reservation.Status.Conditions) != 0 {
+		t.Errorf("expected no release condition on a finished reservation, got %v", reservation.Status.Conditions)
+	}
+}
+
+// Test: The requester activates a Reserved reservation, which the controller promotes to Active; repeating is a no-op
+func TestPostReservationActivation_PromotesReserved(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"))
+	created := mustReserve(t, h, "cluster-1")
+
+	for _, clusterID := range []string{"cluster-2", "cluster-3"} {
+		if w := sendReservationRequest(h.PostReservationActivation, http.MethodPost, clusterID, created.ID, nil); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d: %s", clusterID, w.Code, w.Body.String())
+		}
+	}
+	if reservation := getReservation(t, k8sClient, created.ID); len(reservation.Status.Conditions) != 0 {
+		t.Fatalf("expected no activation by other clusters, got %v", reservation.Status.Conditions)
+	}
+
+	if w := sendReservationRequest(h.PostReservationActivation, http.MethodPost, "cluster-1", created.ID, nil); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	reconcileReservation(t, k8sClient, created.ID)
+	activated := getReservation(t, k8sClient, created.ID)
+	if activated.Status.Phase != brokerv1alpha1.ReservationPhaseActive {
+		t.Fatalf("expected Active, got %s", activated.Status.Phase)
+	}
+
+	// Repeating while Active, e.g. after a lost response, changes nothing
+	if w := sendReservationRequest(h.PostReservationActivation, http.MethodPost, "cluster-1", created.ID, nil); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 on repeat, got %d: %s", w.Code, w.Body.String())
+	}
+	if repeated := getReservation(t, k8sClient, created.ID); repeated.ResourceVersion != activated.ResourceVersion {
+		t.Errorf("expected the repeat to leave the reservation unchanged, got %+v", repeated.Status)
+	}
+}
+
+// Test: Reservations that are not Reserved or Active cannot be activated
+func TestPostReservationActivation_RejectsOtherPhases(t *testing.T) {
+	phases := []brokerv1alpha1.ReservationPhase{
+		brokerv1alpha1.ReservationPhasePending,
+		brokerv1alpha1.ReservationPhaseScheduled,
+		brokerv1alpha1.ReservationPhaseFailed,
+		brokerv1alpha1.ReservationPhaseReleased,
+		brokerv1alpha1.ReservationPhasePreempted,
+	}
+	for _, phase := range phases {
+		t.Run(string(phase), func(t *testing.T) {
+			h, k8sClient := newFakeHandler(nil, makeReservation("rsv", phase))
+
+			w := sendReservationRequest(h.PostReservationActivation, http.MethodPost, "cluster-1", "rsv", nil)
+			if w.Code != http.StatusConflict {
+				t.Errorf("expected 409, got %d: %s", w.Code, w.Body.String())
+			}
+			if reservation := getReservation(t, k8sClient, "rsv"); reservation.Status.Phase != phase || len(reservation.Status.Conditions) != 0 {
+				t.Errorf("expected the reservation to stay %s, got %s with %v", phase, reservation.Status.Phase, reservation.Status.Conditions)
+			}
+		})
+	}
+}
+
+// Test: Only the provider can acknowledge; its rejection fails the reservation and frees the lock
+func TestPostReservationAcknowledgement_OnlyProvider(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeProvider("cluster-2", "8", "16Gi"))
+	created := mustReserve(t, h, "cluster-1")
+	accept := dto.AcknowledgeRequestDTO{Accepted: true}
+
+	// The requester and unrelated clusters cannot answer for the provider
+	for _, clusterID := range []string{"cluster-1", "cluster-3"} {
+		w := sendReservationRequest(h.PostReservationAcknowledgement, http.MethodPost, clusterID, created.ID, accept)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403 for %s, got %d: %s", clusterID, w.Code, w.Body.String())
+		}
+	}
+	if reservation := getReservation(t, k8sClient, created.ID); len(reservation.Status.Conditions) != 0 {
+		t.Fatalf("expected no answer recorded, got %v", reservation.Status.Conditions)
+	}
+
+	w := sendReservationRequest(h.PostReservationAcknowledgement, http.MethodPost, "cluster-2", created.ID, accept)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if reservation := getReservation(t, k8sClient, created.ID); !meta.IsStatusConditionTrue(reservation.Status.Conditions,
+		brokerv1alpha1.ReservationConditionProviderAccepted) {
+		t.Errorf("expected the provider's acceptance recorded, got %v", reservation.Status.Conditions)
+	}
+
+	reject := dto.AcknowledgeRequestDTO{Accepted: false, Reason: "local policy"}
+	w = sendReservationRequest(h.PostReservationAcknowledgement, http.MethodPost, "cluster-2", created.ID, reject)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the rejection, got %d: %s", w.Code, w.Body.String())
+	}
+	reconcileReservation(t, k8sClient, created.ID)
+	if reservation := getReservation(t, k8sClient, created.ID); reservation.Status.Phase != brokerv1alpha1.ReservationPhaseFailed {
+		t.Errorf("expected Failed after the rejection, got %s", reservation.Status.Phase)
+	}
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "0" {
+		t.Errorf("expected the lock released, got %s CPU reserved", got)
+	}
+}
+
+// Test: Acknowledging a released reservation is a conflict and records nothing
+func TestPostReservationAcknowledgement_Released(t *testing.T) {
+	h, k8sClient := newFakeHandler(nil, makeReservation("released", brokerv1alpha1.ReservationPhaseReleased))
+
+	w := sendReservationRequest(h.PostReservationAcknowledgement, http.MethodPost, "cluster-2", "released",
+		dto.AcknowledgeRequestDTO{Accepted: true})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if reservation := getReservation(t, k8sClient, "released"); len(reservation.Status.Conditions) != 0 {
+		t.Errorf("expected no answer recorded, got %v", reservation.Status.Conditions)
+	}
+}
@@ -2,28 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"time"
-
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
-	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
 )
 
+// IdempotencyKeyHeader lets requesters retry reservation requests safely.
+// Requests with the same key from the same requester return the reservation
+// created by the first one instead of reserving again.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // PostReservation handles POST /api/v1/reservations
 // This is a synchronous endpoint: the agent sends a reservation request,
 // the broker decides and reserves resources, and returns the instruction
-// in the response. No polling needed.
+// in the response (201). No polling needed.
+// When the request opts into queueing and no cluster has capacity, the
+// reservation is kept Pending and 202 Accepted is returned instead of 409;
+// the reservation controller places it once capacity frees up.
+// With an Idempotency-Key header, a replay returns the reservation created
+// by the first attempt (200, or 202 while queued) instead of reserving again.
 func (h *Handler) PostReservation(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := log.FromContext(ctx).WithName("reservation-handler")
@@ -37,155 +38,95 @@ func (h *Handler) PostReservation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get requester ID from mTLS certificate (prevents spoofing)
-	requesterID, ok := middleware.GetClusterID(ctx)
-	if !ok || requesterID == "" {
-		respondWithError(w, http.StatusForbidden, "Could not determine cluster ID from certificate")
-		return
-	}
-
-	// Validate requested resources
-	if reqDTO.RequestedResources.CPU == "" || reqDTO.RequestedResources.Memory == "" {
-		respondWithError(w, http.StatusBadRequest, "requestedResources.cpu and requestedResources.memory are required")
-		return
-	}
+	requesterID, _ := middleware.GetClusterID(ctx)
+	result, err := h.service.Reserve(ctx, requesterID, &reqDTO, r.Header.Get(IdempotencyKeyHeader))
+	respondWithReservationResult(w, r, result, err)
+}
 
-	requestedCPU, err := resource.ParseQuantity(reqDTO.RequestedResources.CPU)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid CPU quantity: %v", err))
-		return
-	}
-	requestedMemory, err := resource.ParseQuantity(reqDTO.RequestedResources.Memory)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid memory quantity: %v", err))
-		return
-	}
+// PostReservationDryRun handles POST /api/v1/reservations:dryRun
+// Runs the same decision as POST /api/v1/reservations without reserving
+// anything (see service.DryRun). Always 200 for a valid request; whether it
+// would succeed is in the result.
+func (h *Handler) PostReservationDryRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.FromContext(ctx).WithName("reservation-handler")
 
-	if requestedCPU.Sign() <= 0 || requestedMemory.Sign() <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Requested CPU and memory must be greater than zero")
+	var reqDTO dto.ReservationRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&reqDTO); err != nil {
+		logger.Error(err, "Failed to decode request body")
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Run decision engine synchronously
-	bestCluster, err := h.decisionEngine.SelectBestCluster(
-		ctx, requesterID, requestedCPU, requestedMemory, reqDTO.Priority,
-	)
+	requesterID, _ := middleware.GetClusterID(ctx)
+	result, err := h.service.DryRun(ctx, requesterID, &reqDTO)
 	if err != nil {
-		logger.Error(err, "No suitable cluster found",
-			"requesterID", requesterID,
-			"requestedCPU", requestedCPU.String(),
-			"requestedMemory", requestedMemory.String())
-		respondWithError(w, http.StatusConflict,
-			fmt.Sprintf("No suitable cluster found: %v", err))
+		respondWithServiceError(w, err)
 		return
 	}
+	respondWithJSON(w, r, http.StatusOK, result)
+}
 
-	// Generate reservation name
-	reservationName := fmt.Sprintf("rsv-%s-%d", requesterID, time.Now().UnixMilli())
-
-	// Create Reservation CRD for record-keeping and lifecycle management
-	reservation := &brokerv1alpha1.Reservation{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      reservationName,
-			Namespace: h.namespace,
-		},
-		Spec: brokerv1alpha1.ReservationSpec{
-			RequesterID:     requesterID,
-			TargetClusterID: bestCluster.Spec.ClusterID,
-			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
-				CPU:    requestedCPU,
-				Memory: requestedMemory,
-			},
-			Priority: reqDTO.Priority,
-		},
-	}
-
-	// Parse duration if provided
-	if reqDTO.Duration != "" {
-		d, err := time.ParseDuration(reqDTO.Duration)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid duration: %v", err))
-			return
-		}
-		reservation.Spec.Duration = &metav1.Duration{Duration: d}
-	}
+// PostGangReservation handles POST /api/v1/reservations:gang
+// Reserves several differently-shaped blocks all-or-nothing (see
+// service.ReserveGang). Honours the Idempotency-Key header like
+// POST /api/v1/reservations.
+func (h *Handler) PostGangReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := log.FromContext(ctx).WithName("reservation-handler")
 
-	// Create the reservation CRD
-	if err := h.k8sClient.Create(ctx, reservation); err != nil {
-		logger.Error(err, "Failed to create reservation CRD")
-		respondWithError(w, http.StatusInternalServerError, "Failed to create reservation")
+	var reqDTO dto.GangReservationRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&reqDTO); err != nil {
+		logger.Error(err, "Failed to decode request body")
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Add finalizer
-	controllerutil.AddFinalizer(reservation, brokerv1alpha1.ReservationFinalizer)
-	if err := h.k8sClient.Update(ctx, reservation); err != nil {
-		logger.Error(err, "Failed to add finalizer to reservation")
-	}
+	requesterID, _ := middleware.GetClusterID(ctx)
+	result, err := h.service.ReserveGang(ctx, requesterID, &reqDTO, r.Header.Get(IdempotencyKeyHeader))
+	respondWithReservationResult(w, r, result, err)
+}
 
-	// Lock resources in the target cluster using retry for conflict resolution
-	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		clusterAdv := &brokerv1alpha1.ClusterAdvertisement{}
-		if err := h.k8sClient.Get(ctx,
-			types.NamespacedName{Name: bestCluster.Name, Namespace: bestCluster.Namespace},
-			clusterAdv); err != nil {
-			if apierrors.IsNotFound(err) {
-				return fmt.Errorf("target cluster not found: %s", bestCluster.Spec.ClusterID)
-			}
-			return err
-		}
-
-		if !resourceutil.CanReserve(clusterAdv, requestedCPU, requestedMemory) {
-			return fmt.Errorf("insufficient resources in cluster %s", bestCluster.Spec.ClusterID)
-		}
-
-		if err := resourceutil.AddReservation(clusterAdv, requestedCPU, requestedMemory); err != nil {
-			return err
-		}
-
-		return h.k8sClient.Update(ctx, clusterAdv)
-	})
-
-	if lockErr != nil {
-		logger.Error(lockErr, "Failed to lock resources")
-		// Mark reservation as failed
-		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
-		reservation.Status.Message = fmt.Sprintf("Failed to lock resources: %v", lockErr)
-		reservation.Status.LastUpdateTime = metav1.Now()
-		_ = h.k8sClient.Status().Update(ctx, reservation)
-
-		respondWithError(w, http.StatusConflict, fmt.Sprintf("Failed to reserve resources: %v", lockErr))
+// GetReservation handles GET /api/v1/reservations/{id}
+// Lets a requester follow a queued reservation until it is placed.
+// Only the requester and the provider of the reservation may read it.
+func (h *Handler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	reservation, err := h.service.GetReservation(r.Context(), clusterID, r.PathValue("id"))
+	if err != nil {
+		respondWithServiceError(w, err)
 		return
 	}
+	respondWithJSON(w, r, http.StatusOK, reservation)
+}
 
-	// Mark reservation as Reserved
-	now := metav1.Now()
-	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
-	reservation.Status.Message = fmt.Sprintf("Resources locked in cluster %s", bestCluster.Spec.ClusterID)
-	reservation.Status.ReservedAt = &now
-	reservation.Status.LastUpdateTime = now
-
-	if reservation.Spec.Duration != nil {
-		expiresAt := metav1.NewTime(now.Add(reservation.Spec.Duration.Duration))
-		reservation.Status.ExpiresAt = &expiresAt
+// GetReservationDecision handles GET /api/v1/reservations/{id}/decision
+// Explains how the broker placed (or failed to place) a reservation.
+// Only the requester may read it, since it describes other clusters.
+func (h *Handler) GetReservationDecision(w http.ResponseWriter, r *http.Request) {
+	clusterID, _ := middleware.GetClusterID(r.Context())
+	decision, err := h.service.GetDecision(r.Context(), clusterID, r.PathValue("id"))
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
 	}
+	respondWithJSON(w, r, http.StatusOK, decision)
+}
 
-	if err := h.k8sClient.Status().Update(ctx, reservation); err != nil {
-		logger.Error(err, "Failed to update reservation status")
+// respondWithReservationResult sends a served reservation request: 201 for a
+// new reservation, 202 while queued, 200 for a replay
+func respondWithReservationResult(w http.ResponseWriter, r *http.Request, result *service.ReservationResult, err error) {
+	if err != nil {
+		respondWithServiceError(w, err)
+		return
 	}
 
-	logger.Info("Reservation created synchronously",
-		"reservation", reservationName,
-		"requester", requesterID,
-		"targetCluster", bestCluster.Spec.ClusterID,
-		"cpu", requestedCPU.String(),
-		"memory", requestedMemory.String())
-
-	// Return the instruction in the response
-	response := dto.FromReservation(reservation)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logger.Error(err, "Failed to encode response")
+	code := http.StatusCreated
+	switch result.Outcome {
+	case service.OutcomeQueued:
+		code = http.StatusAccepted
+	case service.OutcomeExisting:
+		code = http.StatusOK
 	}
+	respondWithJSON(w, r, code, result.Reservation)
 }
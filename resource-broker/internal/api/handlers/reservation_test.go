@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Helper to list the reservations on a fake broker cluster
+func listReservations(t *testing.T, k8sClient client.Client) []brokerv1alpha1.Reservation {
+	t.Helper()
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := k8sClient.List(context.Background(), reservationList); err != nil {
+		t.Fatalf("failed to list reservations: %v", err)
+	}
+	return reservationList.Items
+}
+
+// Test: A conflicting status write is retried and the reservation ends up Reserved and marked locked
+func TestPostReservation_RetriesConflictingStatusWrite(t *testing.T) {
+	conflicts := 0
+	funcs := &interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			if conflicts == 0 {
+				conflicts++
+				return apierrors.NewConflict(schema.GroupResource{Group: "broker.fluidos.eu", Resource: "reservations"}, obj.GetName(), errors.New("modified"))
+			}
+			return c.Status().Update(ctx, obj, opts...)
+		},
+	}
+	h, k8sClient := newFakeHandler(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	w := postReservation(h, "cluster-1", "", smallRequest)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reservations := listReservations(t, k8sClient)
+	if len(reservations) != 1 {
+		t.Fatalf("expected 1 reservation, got %d", len(reservations))
+	}
+	reservation := reservations[0]
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+		t.Errorf("expected Reserved, got %q", reservation.Status.Phase)
+	}
+	if _, ok := reservation.Annotations[brokerv1alpha1.ReservationLockedByAPIAnnotation]; !ok {
+		t.Error("expected the lock to be marked on the reservation")
+	}
+	if len(reservation.Finalizers) != 1 {
+		t.Errorf("expected the finalizer to be set at creation, got %v", reservation.Finalizers)
+	}
+}
+
+// Test: A reservation that cannot be marked Reserved gives its lock back and the request fails with 500
+func TestPostReservation_RollsBackUnrecordedLock(t *testing.T) {
+	funcs := &interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			if reservation, ok := obj.(*brokerv1alpha1.Reservation); ok &&
+				reservation.Status.Phase == brokerv1alpha1.ReservationPhaseReserved {
+				return errors.New("etcd unavailable")
+			}
+			return c.Status().Update(ctx, obj, opts...)
+		},
+	}
+	h, k8sClient := newFakeHandler(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	w := postReservation(h, "cluster-1", "", smallRequest)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := reservedCPU(t, k8sClient, "cluster-2-adv"); got != "0" {
+		t.Errorf("expected the lock to be released, got %s CPU reserved", got)
+	}
+	reservation := listReservations(t, k8sClient)[0]
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseFailed {
+		t.Errorf("expected Failed, got %q", reservation.Status.Phase)
+	}
+	if _, ok := reservation.Annotations[brokerv1alpha1.ReservationLockedByAPIAnnotation]; ok {
+		t.Error("expected the lock mark to be removed")
+	}
+}
+
+// Test: A lock that cannot be rolled back still fails the reservation, so a replay does not report it Reserved
+func TestPostReservation_FailsReservationWhenRollbackFails(t *testing.T) {
+	funcs := &interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			// The lock is taken; giving it back fails
+			if cluster, ok := obj.(*brokerv1alpha1.ClusterAdvertisement); ok &&
+				cluster.Spec.Resources.Reserved != nil && cluster.Spec.Resources.Reserved.CPU.IsZero() {
+				return errors.New("etcd unavailable")
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			if reservation, ok := obj.(*brokerv1alpha1.Reservation); ok &&
+				reservation.Status.Phase == brokerv1alpha1.ReservationPhaseReserved {
+				return errors.New("etcd unavailable")
+			}
+			return c.Status().Update(ctx, obj, opts...)
+		},
+	}
+	h, k8sClient := newFakeHandler(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	w := postReservation(h, "cluster-1", "key-1", smallRequest)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reservation := listReservations(t, k8sClient)[0]
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseFailed {
+		t.Errorf("expected Failed, got %q", reservation.Status.Phase)
+	}
+	if !strings.Contains(reservation.Status.Message, "could not be released") {
+		t.Errorf("expected the message to report the leaked lock, got %q", reservation.Status.Message)
+	}
+
+	// The replay learns the reservation failed instead of waiting on it
+	if replay := postReservation(h, "cluster-1", "key-1", smallRequest); replay.Code == http.StatusOK {
+		t.Errorf("expected the replay not to return a reservation, got %d: %s", replay.Code, replay.Body.String())
+	}
+}
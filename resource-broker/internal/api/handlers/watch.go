@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// watchInstructions handles GET /api/v1/instructions?watch=true
+// Streams the calling cluster's instructions as Server-Sent Events:
+//   - "instruction" events carry a ReservationDTO, as returned by the poll;
+//   - "bookmark" events carry no instruction and only advance the resume token.
+//
+// Every event's id is a resume token. A reconnect with the last one in the
+// Last-Event-ID header (or ?resumeToken=) continues right after it.
+func (h *Handler) watchInstructions(w http.ResponseWriter, r *http.Request, clusterID string) {
+	token := r.Header.Get("Last-Event-ID")
+	if token == "" {
+		token = r.URL.Query().Get("resumeToken")
+	}
+
+	if clusterID == "" {
+		respondWithError(w, http.StatusForbidden, "Could not determine cluster ID from certificate")
+		return
+	}
+	if !h.service.StreamingEnabled() {
+		respondWithServiceError(w, service.ErrStreamingDisabled)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	h.service.StreamInstructions(r.Context(), clusterID, token, func(token string, instruction *dto.ReservationDTO) error {
+		var err error
+		if instruction != nil {
+			err = writeEvent(w, "instruction", token, instruction)
+		} else {
+			err = writeEvent(w, "bookmark", token, struct{}{})
+		}
+		if err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+}
+
+// writeEvent writes one Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, event, id string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", event, id, data)
+	return err
+}
@@ -39,8 +39,7 @@ func ValidateClientCertificate(next http.Handler) http.Handler {
 		}
 
 		// Store cluster ID in request context for downstream handlers
-		ctx := context.WithValue(r.Context(), ClusterIDKey, clusterID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithClusterID(r.Context(), clusterID)))
 	})
 }
 
@@ -50,6 +49,17 @@ func extractClusterID(cert *x509.Certificate) string {
 	return cert.Subject.CommonName
 }
 
+// ClusterIDFromCertificate returns the cluster ID of a verified client
+// certificate, for servers that authenticate clients outside this middleware
+func ClusterIDFromCertificate(cert *x509.Certificate) string {
+	return extractClusterID(cert)
+}
+
+// WithClusterID returns a context carrying the cluster ID, as read by GetClusterID
+func WithClusterID(ctx context.Context, clusterID string) context.Context {
+	return context.WithValue(ctx, ClusterIDKey, clusterID)
+}
+
 // GetClusterID retrieves cluster ID from request context
 func GetClusterID(ctx context.Context) (string, bool) {
 	clusterID, ok := ctx.Value(ClusterIDKey).(string)
@@ -23,6 +23,32 @@ type Server struct {
 
 // NewServer creates a new HTTP REST API server with mTLS
 func NewServer(port string, certPath string, handler *handlers.Handler) (*Server, error) {
+	tlsConfig, err := LoadTLSConfig(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply middleware chain
+	handlerWithMiddleware := middleware.Chain(
+		NewRouter(handler),
+		middleware.ValidateClientCertificate,
+		middleware.Logging,
+	)
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:      ":" + port,
+			Handler:   handlerWithMiddleware,
+			TLSConfig: tlsConfig,
+		},
+		handlers: handler,
+	}, nil
+}
+
+// LoadTLSConfig loads the server certificate (tls.crt, tls.key) and the CA
+// (ca.crt) from certPath into an mTLS configuration that requires and
+// verifies client certificates
+func LoadTLSConfig(certPath string) (*tls.Config, error) {
 	// Load server certificate
 	cert, err := tls.LoadX509KeyPair(
 		filepath.Join(certPath, "tls.crt"),
@@ -44,41 +70,40 @@ func NewServer(port string, certPath string, handler *handlers.Handler) (*Server
 	}
 
 	// mTLS configuration - require and verify client certificates
-	tlsConfig := &tls.Config{
+	return &tls.Config{
 		Certificates: []tls.Certificate{cert},
 		ClientAuth:   tls.RequireAndVerifyClientCert,
 		ClientCAs:    caCertPool,
 		MinVersion:   tls.VersionTLS12,
-	}
+	}, nil
+}
 
-	// Create router
+// NewRouter registers the REST API routes, without middleware. Handlers read
+// the calling cluster's ID from the request context (see middleware.GetClusterID).
+func NewRouter(handler *handlers.Handler) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Register routes
 	mux.HandleFunc("POST /api/v1/advertisements", handler.PostAdvertisement)
 	mux.HandleFunc("GET /api/v1/advertisements/{clusterID}", handler.GetAdvertisement)
 	mux.HandleFunc("POST /api/v1/reservations", handler.PostReservation)
+	mux.HandleFunc("POST /api/v1/reservations:gang", handler.PostGangReservation)
+	mux.HandleFunc("POST /api/v1/reservations:dryRun", handler.PostReservationDryRun)
+	mux.HandleFunc("GET /api/v1/reservations/{id}", handler.GetReservation)
+	mux.HandleFunc("DELETE /api/v1/reservations/{id}", handler.DeleteReservation)
+	mux.HandleFunc("POST /api/v1/reservations/{id}/activate", handler.PostReservationActivation)
+	mux.HandleFunc("POST /api/v1/reservations/{id}/renew", handler.PostReservationRenewal)
+	mux.HandleFunc("POST /api/v1/reservations/{id}/acknowledge", handler.PostReservationAcknowledgement)
+	mux.HandleFunc("POST /api/v1/reservations/{id}/heartbeat", handler.PostReservationHeartbeat)
+	mux.HandleFunc("POST /api/v1/reservations/{id}/resize", handler.PostReservationResize)
+	mux.HandleFunc("GET /api/v1/reservations/{id}/decision", handler.GetReservationDecision)
 	mux.HandleFunc("GET /api/v1/instructions", handler.GetInstructions)
 	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
 
-	// Apply middleware chain
-	handlerWithMiddleware := middleware.Chain(
-		mux,
-		middleware.ValidateClientCertificate,
-		middleware.Logging,
-	)
-
-	return &Server{
-		httpServer: &http.Server{
-			Addr:      ":" + port,
-			Handler:   handlerWithMiddleware,
-			TLSConfig: tlsConfig,
-		},
-		handlers: handler,
-	}, nil
+	return mux
 }
 
 // Start begins serving HTTP requests
@@ -0,0 +1,85 @@
+package broker
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// assumeTimeout bounds how long an assumed Scheduled reservation is counted
+// without the cache showing it
+const assumeTimeout = 1 * time.Minute
+
+// assumedReservation is a reservation admitted as Scheduled that the cache
+// may not show yet
+type assumedReservation struct {
+	reservation *brokerv1alpha1.Reservation
+	assumedAt   time.Time
+}
+
+// BeginAdmission starts admitting a reservation: deciding where it goes and
+// taking its lock or scheduled slot. Admissions run one at a time across all
+// interfaces and the reservation controller, since a scheduled slot is held by
+// the reservation itself and no cluster update would catch two admissions
+// taking the same slice. Call the returned function once the admission is
+// recorded.
+func (d *DecisionEngine) BeginAdmission() (end func()) {
+	d.admission.Lock()
+	return d.admission.Unlock
+}
+
+// AssumeScheduled counts a reservation just marked Scheduled in later
+// decisions until the cache shows it, so the next admission does not miss
+// its slot
+func (d *DecisionEngine) AssumeScheduled(reservation *brokerv1alpha1.Reservation) {
+	d.assumedMu.Lock()
+	defer d.assumedMu.Unlock()
+
+	if d.assumed == nil {
+		d.assumed = make(map[types.NamespacedName]assumedReservation)
+	}
+	key := types.NamespacedName{Namespace: reservation.Namespace, Name: reservation.Name}
+	d.assumed[key] = assumedReservation{reservation: reservation.DeepCopy(), assumedAt: time.Now()}
+}
+
+// withAssumed returns the listed Scheduled reservations together with every
+// assumed one the cache does not show as Scheduled yet. Assumptions the cache
+// caught up with (in any phase past Pending), or that timed out, are forgotten.
+func (d *DecisionEngine) withAssumed(
+	ctx context.Context,
+	scheduled []brokerv1alpha1.Reservation,
+) []brokerv1alpha1.Reservation {
+	d.assumedMu.Lock()
+	defer d.assumedMu.Unlock()
+
+	if len(d.assumed) == 0 {
+		return scheduled
+	}
+
+	listed := make(map[types.NamespacedName]bool, len(scheduled))
+	for i := range scheduled {
+		listed[types.NamespacedName{Namespace: scheduled[i].Namespace, Name: scheduled[i].Name}] = true
+	}
+
+	for key, assumed := range d.assumed {
+		if listed[key] || time.Since(assumed.assumedAt) > assumeTimeout {
+			delete(d.assumed, key)
+			continue
+		}
+
+		// Not listed as Scheduled: either the cache is behind, or the
+		// reservation already moved on
+		current := &brokerv1alpha1.Reservation{}
+		if err := d.Client.Get(ctx, key, current); err == nil {
+			if phase := current.Status.Phase; phase != "" && phase != brokerv1alpha1.ReservationPhasePending {
+				delete(d.assumed, key)
+				continue
+			}
+		}
+		scheduled = append(scheduled, *assumed.reservation)
+	}
+	return scheduled
+}
@@ -0,0 +1,47 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Archive is an append-only history of the reservations the broker deletes
+type Archive interface {
+	Append(reservation *brokerv1alpha1.Reservation) error
+}
+
+// FileArchive appends reservations to a file, one JSON document per line
+type FileArchive struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Append writes the reservation at the end of the archive file
+func (a *FileArchive) Append(reservation *brokerv1alpha1.Reservation) error {
+	data, err := json.Marshal(reservation)
+	if err != nil {
+		return fmt.Errorf("failed to encode reservation %s: %w", reservation.Name, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.OpenFile(a.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to sync archive: %w", err)
+	}
+	return file.Close()
+}
@@ -3,119 +3,216 @@ package broker
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // DecisionEngine selects the best cluster for resource allocation
 type DecisionEngine struct {
 	Client client.Client
+
+	// Strategy ranks candidate clusters when the request does not select one.
+	// Defaults to DefaultScoringStrategy when nil.
+	Strategy ScoringStrategy
+
+	// Preemption lets requests that fit nowhere evict lower-priority
+	// Reserved reservations (see PlanPreemption)
+	Preemption bool
+
+	// admission serializes admissions (see BeginAdmission); assumed holds
+	// the Scheduled reservations admitted since (see AssumeScheduled)
+	admission sync.Mutex
+	assumedMu sync.Mutex
+	assumed   map[types.NamespacedName]assumedReservation
+}
+
+// PlacementRequest describes the resources a requester needs from a provider
+type PlacementRequest struct {
+	// RequesterID is the requesting cluster, which is never selected as provider
+	RequesterID string
+
+	// Resources are the quantities the provider must have available
+	Resources brokerv1alpha1.RequestedResourceQuantities
+
+	// Strategy overrides the engine's scoring strategy (optional)
+	Strategy brokerv1alpha1.ScoringStrategyType
+
+	// Placement restricts and ranks clusters by their labels (optional)
+	Placement *brokerv1alpha1.PlacementConstraints
+
+	// Window is when the resources are needed. The zero value means from
+	// now until released, or for Duration if set.
+	Window Window
+
+	// Duration is how long an immediate request holds the resources
+	// (optional, 0 = until released). Ignored when Window is set.
+	Duration time.Duration
+
+	// ClusterID restricts the selection to the cluster with this ID (optional)
+	ClusterID string
+}
+
+// window returns the request's window, starting now unless scheduled and
+// ending after Duration if one is given
+func (r PlacementRequest) window() Window {
+	if !r.Window.Start.IsZero() {
+		return r.Window
+	}
+	window := Window{Start: time.Now()}
+	if r.Duration > 0 {
+		window.End = window.Start.Add(r.Duration)
+	}
+	return window
 }
 
 // SelectBestCluster finds the most suitable cluster based on requested resources
 func (d *DecisionEngine) SelectBestCluster(
 	ctx context.Context,
-	requesterID string,
-	requestedCPU, requestedMemory resource.Quantity,
-	priority int32,
+	request PlacementRequest,
 ) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	cluster, _, err := d.SelectBestClusterWithRecord(ctx, request)
+	return cluster, err
+}
+
+// SelectBestClusterWithRecord is SelectBestCluster that also returns a record
+// of every candidate's evaluation. The record is nil only if the request
+// itself is invalid or the clusters cannot be listed.
+func (d *DecisionEngine) SelectBestClusterWithRecord(
+	ctx context.Context,
+	request PlacementRequest,
+) (*brokerv1alpha1.ClusterAdvertisement, *brokerv1alpha1.DecisionRecord, error) {
+
+	// List all cluster advertisements, with the slots scheduled in the request's window
+	clusters, err := d.listClusters(ctx, request.window())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if request.ClusterID != "" {
+		clusters = slices.DeleteFunc(clusters, func(cluster brokerv1alpha1.ClusterAdvertisement) bool {
+			return cluster.Spec.ClusterID != request.ClusterID
+		})
+		if len(clusters) == 0 {
+			record := &brokerv1alpha1.DecisionRecord{DecidedAt: metav1.Now(),
+				Message: fmt.Sprintf("Cluster %s not found", request.ClusterID)}
+			return nil, record, fmt.Errorf("%w: %s", ErrClusterNotFound, request.ClusterID)
+		}
+	}
+
+	if len(clusters) == 0 {
+		record := &brokerv1alpha1.DecisionRecord{DecidedAt: metav1.Now(), Message: "No clusters available"}
+		return nil, record, fmt.Errorf("no clusters available")
+	}
+
+	return d.bestCluster(clusters, request)
+}
 
-	// List all cluster advertisements
-	advList := &brokerv1alpha1.ClusterAdvertisementList{}
-	if err := d.Client.List(ctx, advList); err != nil {
-		return nil, fmt.Errorf("failed to list cluster advertisements: %w", err)
+// bestCluster filters and scores the given clusters for the request and
+// returns the highest-scoring one, with a record of every evaluation.
+// The returned cluster points into clusters.
+func (d *DecisionEngine) bestCluster(
+	clusters []brokerv1alpha1.ClusterAdvertisement,
+	request PlacementRequest,
+) (*brokerv1alpha1.ClusterAdvertisement, *brokerv1alpha1.DecisionRecord, error) {
+
+	strategy, err := d.strategyFor(request)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if len(advList.Items) == 0 {
-		return nil, fmt.Errorf("no clusters available")
+	placement, err := compilePlacement(request.Placement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := &brokerv1alpha1.DecisionRecord{
+		DecidedAt:  metav1.Now(),
+		Strategy:   strategy.Name(),
+		Candidates: make([]brokerv1alpha1.CandidateEvaluation, 0, len(clusters)),
 	}
 
 	var bestCluster *brokerv1alpha1.ClusterAdvertisement
 	var bestScore float64 = -1
 
-	for i := range advList.Items {
-		cluster := &advList.Items[i]
+	for i := range clusters {
+		cluster := &clusters[i]
+		evaluation := brokerv1alpha1.CandidateEvaluation{ClusterID: cluster.Spec.ClusterID}
 
+		switch {
 		// Skip if it's the requester's own cluster
-		if cluster.Spec.ClusterID == requesterID {
-			continue
-		}
+		case cluster.Spec.ClusterID == request.RequesterID:
+			evaluation.Reason = brokerv1alpha1.CandidateRejectedSelf
 
 		// Skip inactive clusters
-		if !cluster.Status.Active {
-			continue
-		}
+		case !cluster.Status.Active:
+			evaluation.Reason = brokerv1alpha1.CandidateRejectedStale
+
+		// Skip clusters that violate required placement constraints
+		case !placement.matches(cluster):
+			evaluation.Reason = brokerv1alpha1.CandidateRejectedPlacement
+			evaluation.Detail = "cluster labels do not match the required placement"
 
 		// Check if cluster has enough resources
-		if !d.hasEnoughResources(cluster, requestedCPU, requestedMemory) {
-			continue
+		case !d.hasEnoughResources(cluster, request.Resources):
+			evaluation.Reason, evaluation.Detail = insufficientResource(cluster, request.Resources)
+
+		default:
+			// Calculate score, favouring clusters that match preferred placement
+			strategyScore := strategy.Score(cluster, request.Resources)
+			placementBonus := placement.preferenceScore(cluster)
+			score := strategyScore + placementBonus
+
+			evaluation.Eligible = true
+			evaluation.Score = strconv.FormatFloat(score, 'f', 2, 64)
+			evaluation.StrategyScore = strconv.FormatFloat(strategyScore, 'f', 2, 64)
+			evaluation.PlacementBonus = strconv.FormatFloat(placementBonus, 'f', 2, 64)
+
+			if score > bestScore {
+				bestScore = score
+				bestCluster = cluster
+			}
 		}
 
-		// Calculate score
-		score := d.calculateScore(cluster, requestedCPU, requestedMemory, priority)
-
-		if score > bestScore {
-			bestScore = score
-			bestCluster = cluster
-		}
+		record.Candidates = append(record.Candidates, evaluation)
 	}
 
 	if bestCluster == nil {
-		return nil, fmt.Errorf("no suitable cluster found for requested resources")
+		record.Message = "No cluster passed every filter"
+		return nil, record, fmt.Errorf("no suitable cluster found for requested resources")
 	}
 
-	return bestCluster, nil
+	record.SelectedClusterID = bestCluster.Spec.ClusterID
+	return bestCluster, record, nil
 }
 
-// hasEnoughResources checks if cluster has sufficient available resources
-func (d *DecisionEngine) hasEnoughResources(
-	cluster *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
-) bool {
-	availableCPU := cluster.Spec.Resources.Available.CPU
-	availableMemory := cluster.Spec.Resources.Available.Memory
-
-	return availableCPU.Cmp(requestedCPU) >= 0 && availableMemory.Cmp(requestedMemory) >= 0
+// strategyFor resolves the scoring strategy for a request:
+// request override first, then the engine default, then DefaultScoringStrategy
+func (d *DecisionEngine) strategyFor(request PlacementRequest) (ScoringStrategy, error) {
+	if request.Strategy != "" {
+		return NewScoringStrategy(request.Strategy)
+	}
+	if d.Strategy != nil {
+		return d.Strategy, nil
+	}
+	return NewScoringStrategy(DefaultScoringStrategy)
 }
 
-// calculateScore computes a score for the cluster based on availability
-// Higher score = better choice
-func (d *DecisionEngine) calculateScore(
+// hasEnoughResources checks if cluster has sufficient available resources.
+// Uses the same check as resource locking so filtering and locking never disagree.
+func (d *DecisionEngine) hasEnoughResources(
 	cluster *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
-	priority int32,
-) float64 {
-	// Calculate CPU utilization after reservation (0-1)
-	allocatableCPU := cluster.Spec.Resources.Allocatable.CPU.AsApproximateFloat64()
-	availableCPU := cluster.Spec.Resources.Available.CPU.AsApproximateFloat64()
-	requestedCPUFloat := requestedCPU.AsApproximateFloat64()
-
-	cpuUtilization := 1.0 - ((availableCPU - requestedCPUFloat) / allocatableCPU)
-
-	// Calculate Memory utilization after reservation (0-1)
-	allocatableMemory := cluster.Spec.Resources.Allocatable.Memory.AsApproximateFloat64()
-	availableMemory := cluster.Spec.Resources.Available.Memory.AsApproximateFloat64()
-	requestedMemoryFloat := requestedMemory.AsApproximateFloat64()
-
-	memoryUtilization := 1.0 - ((availableMemory - requestedMemoryFloat) / allocatableMemory)
-
-	// Balanced score: prefer clusters with lower utilization (more headroom)
-	// Score is higher when utilization is lower
-	score := (1.0 - cpuUtilization*0.5) + (1.0 - memoryUtilization*0.5)
-
-	// If cost info is available, factor it in (lower cost = higher score)
-	if cluster.Spec.Cost != nil {
-		// For now, we just ensure cost doesn't make score negative
-		// In production, you'd normalize cost and subtract it
-		score = score * 0.9 // Slight penalty if cost exists but not optimized
-	}
-
-	priorityBonus := float64(priority) * 0.01
-
-	return score + priorityBonus
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) bool {
+	return resourceutil.CanReserve(cluster, requested)
 }
 
 // UpdateClusterScore updates the score field in the cluster advertisement status
@@ -42,7 +42,8 @@ func makeClusterAdvertisement(name, clusterID string, allocatableCPU, allocatabl
 func createFakeClient(objects ...runtime.Object) client.Client {
 	scheme := runtime.NewScheme()
 	_ = brokerv1alpha1.AddToScheme(scheme)
-	return fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+	return fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).
+		WithIndex(&brokerv1alpha1.Reservation{}, ReservationPhaseField, IndexReservationPhase).Build()
 }
 
 // Test: When two clusters exist, pick the one with more available resources
@@ -55,13 +56,13 @@ func TestSelectBestCluster_PicksClusterWithMoreResources(t *testing.T) {
 	engine := &DecisionEngine{Client: fakeClient}
 
 	// Request 500m CPU, 1Gi memory from requester "cluster-0"
-	result, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-0", // requester (not cluster-1 or cluster-2)
-		resource.MustParse("500m"),
-		resource.MustParse("1Gi"),
-		0, // priority
-	)
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0", // requester (not cluster-1 or cluster-2)
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("500m"),
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: should pick cluster-2 (more headroom = higher score)
 	if err != nil {
@@ -82,13 +83,13 @@ func TestSelectBestCluster_SkipsRequesterOwnCluster(t *testing.T) {
 	engine := &DecisionEngine{Client: fakeClient}
 
 	// cluster-1 requests resources (should not pick itself even though it has more)
-	result, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-1", // requester IS cluster-1
-		resource.MustParse("500m"),
-		resource.MustParse("1Gi"),
-		0,
-	)
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-1", // requester IS cluster-1
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("500m"),
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: must pick cluster-2, not cluster-1
 	if err != nil {
@@ -105,13 +106,13 @@ func TestSelectBestCluster_NoClusterAvailable(t *testing.T) {
 	fakeClient := createFakeClient() // empty
 	engine := &DecisionEngine{Client: fakeClient}
 
-	_, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-0",
-		resource.MustParse("500m"),
-		resource.MustParse("1Gi"),
-		0,
-	)
+	_, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("500m"),
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: should return error
 	if err == nil {
@@ -128,13 +129,13 @@ func TestSelectBestCluster_SkipsInactiveClusters(t *testing.T) {
 	fakeClient := createFakeClient(cluster1, cluster2)
 	engine := &DecisionEngine{Client: fakeClient}
 
-	result, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-0",
-		resource.MustParse("500m"),
-		resource.MustParse("1Gi"),
-		0,
-	)
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("500m"),
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: should pick cluster-2 (active), not cluster-1 (inactive)
 	if err != nil {
@@ -155,13 +156,13 @@ func TestSelectBestCluster_RequestExceedsAllClusters(t *testing.T) {
 	engine := &DecisionEngine{Client: fakeClient}
 
 	// Request 10000m CPU - more than any cluster has
-	_, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-0",
-		resource.MustParse("10000m"), // 10 cores - too much
-		resource.MustParse("1Gi"),
-		0,
-	)
+	_, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("10000m"), // 10 cores - too much
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: should return error
 	if err == nil {
@@ -184,13 +185,13 @@ func TestSelectBestCluster_PrefersHigherAvailableRatio(t *testing.T) {
 	fakeClient := createFakeClient(cluster1, cluster2)
 	engine := &DecisionEngine{Client: fakeClient}
 
-	result, err := engine.SelectBestCluster(
-		context.Background(),
-		"cluster-0",
-		resource.MustParse("500m"),
-		resource.MustParse("1Gi"),
-		0,
-	)
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("500m"),
+			Memory: resource.MustParse("1Gi"),
+		},
+	})
 
 	// Verify: should pick cluster-1 (higher available/allocatable ratio = better score)
 	if err != nil {
@@ -250,7 +251,7 @@ func TestHasEnoughResources_ChecksBothCPUAndMemory(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cluster := makeClusterAdvertisement("test", "test-cluster", "4000m", "8Gi", tt.availableCPU, tt.availableMemory, true)
-			result := engine.hasEnoughResources(cluster, resource.MustParse(tt.requestedCPU), resource.MustParse(tt.requestedMemory))
+			result := engine.hasEnoughResources(cluster, makeRequest(tt.requestedCPU, tt.requestedMemory))
 			if result != tt.expected {
 				t.Errorf("expected %v, got %v", tt.expected, result)
 			}
@@ -258,6 +259,44 @@ func TestHasEnoughResources_ChecksBothCPUAndMemory(t *testing.T) {
 	}
 }
 
+// Test: GPU requests skip clusters without enough GPUs
+func TestSelectBestCluster_GPURequestSkipsClustersWithoutGPU(t *testing.T) {
+	// cpu-only has more headroom but advertises no GPUs
+	cpuOnly := makeClusterAdvertisement("cpu-only-adv", "cpu-only", "8000m", "16Gi", "8000m", "16Gi", true)
+	gpuCluster := makeClusterAdvertisement("gpu-adv", "gpu", "4000m", "8Gi", "2000m", "4Gi", true)
+	gpus := resource.MustParse("2")
+	gpuCluster.Spec.Resources.Allocatable.GPU = &gpus
+	availableGPUs := gpus.DeepCopy()
+	gpuCluster.Spec.Resources.Available.GPU = &availableGPUs
+
+	engine := &DecisionEngine{Client: createFakeClient(cpuOnly, gpuCluster)}
+
+	request := makeRequest("500m", "1Gi")
+	requestedGPU := resource.MustParse("1")
+	request.GPU = &requestedGPU
+
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   request,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Spec.ClusterID != "gpu" {
+		t.Errorf("expected gpu cluster, got %s", result.Spec.ClusterID)
+	}
+
+	// Asking for more GPUs than any cluster has fails
+	tooMany := resource.MustParse("4")
+	request.GPU = &tooMany
+	if _, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   request,
+	}); err == nil {
+		t.Error("expected error when no cluster has enough GPUs, got nil")
+	}
+}
+
 // Test: calculateBaseScore returns 0 when allocatable is 0
 func TestCalculateBaseScore_ZeroAllocatable(t *testing.T) {
 	engine := &DecisionEngine{}
@@ -0,0 +1,59 @@
+package broker
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// insufficientResource names the first requested resource the cluster cannot
+// provide, in the order CanReserve checks them (extended resources by name,
+// so the record is the same every time), with requested vs. available
+func insufficientResource(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) (brokerv1alpha1.CandidateRejectionReason, string) {
+	available := cluster.Spec.Resources.Available
+
+	if available.CPU.Cmp(requested.CPU) < 0 {
+		return brokerv1alpha1.CandidateRejectedCPU, shortage("CPU", requested.CPU, &available.CPU)
+	}
+
+	if available.Memory.Cmp(requested.Memory) < 0 {
+		return brokerv1alpha1.CandidateRejectedMemory, shortage("memory", requested.Memory, &available.Memory)
+	}
+
+	if requested.GPU != nil && requested.GPU.Sign() > 0 &&
+		(available.GPU == nil || available.GPU.Cmp(*requested.GPU) < 0) {
+		return brokerv1alpha1.CandidateRejectedGPU, shortage("GPU", *requested.GPU, available.GPU)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(requested.Extended)) {
+		requestedQty := requested.Extended[name]
+		if requestedQty.Sign() <= 0 {
+			continue
+		}
+		availableQty, ok := available.Extended[name]
+		if !ok {
+			return brokerv1alpha1.CandidateRejectedExtended, shortage(name, requestedQty, nil)
+		}
+		if availableQty.Cmp(requestedQty) < 0 {
+			return brokerv1alpha1.CandidateRejectedExtended, shortage(name, requestedQty, &availableQty)
+		}
+	}
+
+	// CanReserve and this check disagree; should not happen
+	return "", ""
+}
+
+// shortage formats requested vs. available for a decision record
+func shortage(name string, requested resource.Quantity, available *resource.Quantity) string {
+	if available == nil {
+		return fmt.Sprintf("requested %s %s, none advertised", requested.String(), name)
+	}
+	return fmt.Sprintf("requested %s %s, available %s", requested.String(), name, available.String())
+}
@@ -0,0 +1,114 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test: The decision record explains why each candidate was filtered out or how it scored
+func TestSelectBestClusterWithRecord_ExplainsEveryCandidate(t *testing.T) {
+	self := makeClusterAdvertisement("self-adv", "cluster-0", "8000m", "16Gi", "8000m", "16Gi", true)
+	stale := makeClusterAdvertisement("stale-adv", "stale", "8000m", "16Gi", "8000m", "16Gi", false)
+	lowCPU := makeClusterAdvertisement("low-cpu-adv", "low-cpu", "8000m", "16Gi", "500m", "16Gi", true)
+	lowMemory := makeClusterAdvertisement("low-memory-adv", "low-memory", "8000m", "16Gi", "8000m", "512Mi", true)
+	wrongRegion := makeLabeledCluster("us-adv", "us", "4000m", map[string]string{"region": "us-east-1"})
+	fits := makeLabeledCluster("eu-adv", "eu", "4000m", map[string]string{"region": "eu-west-1"})
+
+	engine := &DecisionEngine{Client: createFakeClient(self, stale, lowCPU, lowMemory, wrongRegion, fits)}
+
+	cluster, record, err := engine.SelectBestClusterWithRecord(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("1000m", "1Gi"),
+		Placement: &brokerv1alpha1.PlacementConstraints{
+			Required: []metav1.LabelSelectorRequirement{inRequirement("region", "eu-west-1")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cluster.Spec.ClusterID != "eu" || record.SelectedClusterID != "eu" {
+		t.Fatalf("expected eu to be selected, got %s (record: %s)", cluster.Spec.ClusterID, record.SelectedClusterID)
+	}
+
+	expected := map[string]brokerv1alpha1.CandidateRejectionReason{
+		"cluster-0": brokerv1alpha1.CandidateRejectedSelf,
+		"stale":     brokerv1alpha1.CandidateRejectedStale,
+		"us":        brokerv1alpha1.CandidateRejectedPlacement,
+		"eu":        "",
+	}
+	if len(record.Candidates) != 6 {
+		t.Fatalf("expected 6 candidates in the record, got %d", len(record.Candidates))
+	}
+	for _, candidate := range record.Candidates {
+		reason, ok := expected[candidate.ClusterID]
+		if !ok {
+			continue
+		}
+		if candidate.Reason != reason {
+			t.Errorf("%s: expected reason %q, got %q", candidate.ClusterID, reason, candidate.Reason)
+		}
+		if candidate.Eligible != (reason == "") {
+			t.Errorf("%s: expected eligible=%v", candidate.ClusterID, reason == "")
+		}
+		if candidate.Eligible && candidate.Score == "" {
+			t.Errorf("%s: expected a score for an eligible cluster", candidate.ClusterID)
+		}
+	}
+}
+
+// Test: Insufficient resources name the resource that ran out with the numbers behind it
+func TestSelectBestClusterWithRecord_NamesMissingResource(t *testing.T) {
+	lowCPU := makeClusterAdvertisement("low-cpu-adv", "low-cpu", "8000m", "16Gi", "500m", "16Gi", true)
+	lowMemory := makeClusterAdvertisement("low-memory-adv", "low-memory", "8000m", "16Gi", "8000m", "512Mi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(lowCPU, lowMemory)}
+
+	_, record, err := engine.SelectBestClusterWithRecord(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("1000m", "1Gi"),
+	})
+	if err == nil {
+		t.Fatal("expected error when nothing fits, got nil")
+	}
+	if record == nil || record.SelectedClusterID != "" {
+		t.Fatalf("expected a record without selection, got %+v", record)
+	}
+
+	for _, candidate := range record.Candidates {
+		switch candidate.ClusterID {
+		case "low-cpu":
+			if candidate.Reason != brokerv1alpha1.CandidateRejectedCPU || candidate.Detail != "requested 1 CPU, available 500m" {
+				t.Errorf("low-cpu: got %q (%s)", candidate.Reason, candidate.Detail)
+			}
+		case "low-memory":
+			if candidate.Reason != brokerv1alpha1.CandidateRejectedMemory {
+				t.Errorf("low-memory: got %q (%s)", candidate.Reason, candidate.Detail)
+			}
+		}
+	}
+}
+
+// Test: With several extended resources short, the first by name is reported, every time
+func TestInsufficientResource_ExtendedByName(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "8000m", "16Gi", "8000m", "16Gi", true)
+	cluster.Spec.Resources.Available.Extended = map[string]resource.Quantity{
+		"example.com/fpga": resource.MustParse("1"),
+		"example.com/tpu":  resource.MustParse("1"),
+	}
+	requested := makeRequest("1000m", "1Gi")
+	requested.Extended = map[string]resource.Quantity{
+		"example.com/tpu":  resource.MustParse("2"),
+		"example.com/fpga": resource.MustParse("2"),
+	}
+
+	for range 20 {
+		reason, detail := insufficientResource(cluster, requested)
+		if reason != brokerv1alpha1.CandidateRejectedExtended || detail != "requested 2 example.com/fpga, available 1" {
+			t.Fatalf("expected the fpga shortage, got %q (%s)", reason, detail)
+		}
+	}
+}
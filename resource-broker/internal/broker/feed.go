@@ -0,0 +1,134 @@
+package broker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// FeedChange is one reservation change in a ReservationFeed
+type FeedChange struct {
+	// Token resumes a stream right after this change
+	Token       string
+	Reservation *brokerv1alpha1.Reservation
+}
+
+// ReservationFeed keeps the most recent reservation changes, in order, so
+// instruction streams can follow them and resume after a reconnect. It is fed
+// by the informer behind the manager's cache (it implements its event handler).
+// Tokens are only valid for the process that issued them; a token from
+// another process, or older than the kept changes, needs a resync.
+type ReservationFeed struct {
+	mu       sync.Mutex
+	epoch    string
+	capacity int
+	next     uint64       // Sequence number of the next change
+	changes  []FeedChange // Last changes, oldest first, at most capacity
+	changed  chan struct{}
+}
+
+// NewReservationFeed creates a feed keeping the last capacity changes
+func NewReservationFeed(capacity int) *ReservationFeed {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ReservationFeed{
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		capacity: capacity,
+		next:     1,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Publish appends a change of the reservation and wakes up waiting streams
+func (f *ReservationFeed) Publish(reservation *brokerv1alpha1.Reservation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.changes = append(f.changes, FeedChange{
+		Token:       f.token(f.next),
+		Reservation: reservation.DeepCopy(),
+	})
+	f.next++
+	if len(f.changes) > f.capacity {
+		f.changes = f.changes[len(f.changes)-f.capacity:]
+	}
+
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Head returns the token of the latest change
+func (f *ReservationFeed) Head() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.token(f.next - 1)
+}
+
+// Changed returns a channel closed by the next Publish. Take it before
+// calling Since, so a change in between is not missed.
+func (f *ReservationFeed) Changed() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.changed
+}
+
+// Since returns the changes after token and the token of the latest one.
+// Returns false if the changes after token are no longer all kept, or the
+// token was issued by another process.
+func (f *ReservationFeed) Since(token string) ([]FeedChange, string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	head := f.token(f.next - 1)
+	seq, err := f.sequence(token)
+	if err != nil || seq >= f.next {
+		return nil, head, false
+	}
+
+	// The change right after token must still be kept
+	first := f.next - uint64(len(f.changes))
+	if seq+1 < first {
+		return nil, head, false
+	}
+
+	kept := f.changes[seq+1-first:]
+	changes := make([]FeedChange, len(kept))
+	copy(changes, kept)
+	return changes, head, true
+}
+
+// OnAdd publishes reservations created after the informer's initial list
+func (f *ReservationFeed) OnAdd(obj interface{}, isInInitialList bool) {
+	if reservation, ok := obj.(*brokerv1alpha1.Reservation); ok && !isInInitialList {
+		f.Publish(reservation)
+	}
+}
+
+// OnUpdate publishes the new state of an updated reservation
+func (f *ReservationFeed) OnUpdate(_, newObj interface{}) {
+	if reservation, ok := newObj.(*brokerv1alpha1.Reservation); ok {
+		f.Publish(reservation)
+	}
+}
+
+// OnDelete ignores deletions: deleted reservations carry no instructions
+func (f *ReservationFeed) OnDelete(interface{}) {}
+
+// token formats the sequence number of a change of this process
+func (f *ReservationFeed) token(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// sequence parses a token of this process
+func (f *ReservationFeed) sequence(token string) (uint64, error) {
+	epoch, seq, found := strings.Cut(token, "-")
+	if !found || epoch != f.epoch {
+		return 0, fmt.Errorf("token %q was not issued by this feed", token)
+	}
+	return strconv.ParseUint(seq, 10, 64)
+}
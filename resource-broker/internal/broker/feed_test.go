@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Test: Streams resume after their last change, and need a resync once it fell out of the feed
+func TestReservationFeed_ResumeAndResync(t *testing.T) {
+	feed := NewReservationFeed(2)
+	start := feed.Head()
+
+	changed := feed.Changed()
+	feed.Publish(makeHeldReservation("rsv-1", "cluster-1", 0, "1000m", "1Gi", brokerv1alpha1.ReservationPhaseReserved))
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected Publish to wake up waiting streams")
+	}
+
+	changes, head, ok := feed.Since(start)
+	if !ok || len(changes) != 1 || changes[0].Reservation.Name != "rsv-1" || head != changes[0].Token {
+		t.Fatalf("Expected rsv-1 as the only change, got ok=%v changes=%d", ok, len(changes))
+	}
+	afterFirst := head
+
+	feed.Publish(makeHeldReservation("rsv-2", "cluster-1", 0, "1000m", "1Gi", brokerv1alpha1.ReservationPhaseReserved))
+	feed.Publish(makeHeldReservation("rsv-3", "cluster-1", 0, "1000m", "1Gi", brokerv1alpha1.ReservationPhaseReserved))
+
+	changes, _, ok = feed.Since(afterFirst)
+	if !ok || len(changes) != 2 || changes[0].Reservation.Name != "rsv-2" || changes[1].Reservation.Name != "rsv-3" {
+		t.Fatalf("Expected rsv-2 and rsv-3 after resuming, got ok=%v changes=%d", ok, len(changes))
+	}
+
+	// rsv-1 was dropped to keep 2 changes, so the start token cannot resume
+	if _, _, ok := feed.Since(start); ok {
+		t.Error("Expected a resync for a token older than the kept changes")
+	}
+	if _, _, ok := NewReservationFeed(2).Since(afterFirst); ok {
+		t.Error("Expected a resync for a token of another feed")
+	}
+
+	changes, _, ok = feed.Since(feed.Head())
+	if !ok || len(changes) != 0 {
+		t.Errorf("Expected no changes after the head, got ok=%v changes=%d", ok, len(changes))
+	}
+}
@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// ErrEmptyGang is returned for a gang request without members
+var ErrEmptyGang = errors.New("gang request has no members")
+
+// PlanGang places every member of a gang request, or none of them. Members
+// are placed one after another against a simulated view of the clusters, so
+// members landing on the same cluster never count the same capacity twice.
+// The hardest members (GPUs, extended resources, then the largest) are placed
+// first; the placement is greedy and does not backtrack.
+// The returned parts are in the order of members.
+func (d *DecisionEngine) PlanGang(ctx context.Context, members []PlacementRequest) ([]GroupPart, error) {
+	if len(members) == 0 {
+		return nil, ErrEmptyGang
+	}
+
+	// Locks taken for earlier members are applied to these copies only
+	simulated, err := d.listClusters(ctx, members[0].window())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(simulated) == 0 {
+		return nil, fmt.Errorf("no clusters available")
+	}
+
+	order := make([]int, len(members))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return placedBefore(members[order[i]].Resources, members[order[j]].Resources)
+	})
+
+	parts := make([]GroupPart, len(members))
+	for _, i := range order {
+		cluster, _, err := d.bestCluster(simulated, members[i])
+		if err != nil {
+			return nil, fmt.Errorf("member %d: %w", i+1, err)
+		}
+
+		if err := resourceutil.AddReservation(cluster, members[i].Resources); err != nil {
+			return nil, fmt.Errorf("member %d: %w", i+1, err)
+		}
+
+		parts[i] = GroupPart{Cluster: cluster, Resources: members[i].Resources}
+	}
+
+	return parts, nil
+}
+
+// placedBefore orders gang members by how hard they are to place
+func placedBefore(a, b brokerv1alpha1.RequestedResourceQuantities) bool {
+	if gpuA, gpuB := gpuCount(a), gpuCount(b); gpuA != gpuB {
+		return gpuA > gpuB
+	}
+	if len(a.Extended) != len(b.Extended) {
+		return len(a.Extended) > len(b.Extended)
+	}
+	if cmp := a.CPU.Cmp(b.CPU); cmp != 0 {
+		return cmp > 0
+	}
+	return a.Memory.Cmp(b.Memory) > 0
+}
+
+func gpuCount(resources brokerv1alpha1.RequestedResourceQuantities) int64 {
+	if resources.GPU == nil {
+		return 0
+	}
+	return resources.GPU.Value()
+}
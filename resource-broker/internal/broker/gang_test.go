@@ -0,0 +1,56 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Test: GPU members are placed first so CPU members don't take the GPU cluster's capacity
+func TestPlanGang_PlacesHardestMembersFirst(t *testing.T) {
+	cpuOnly := makeClusterAdvertisement("cpu-only-adv", "cpu-only", "8000m", "16Gi", "8000m", "16Gi", true)
+	gpuCluster := makeClusterAdvertisement("gpu-adv", "gpu", "4000m", "8Gi", "4000m", "8Gi", true)
+	gpus := resource.MustParse("2")
+	gpuCluster.Spec.Resources.Allocatable.GPU = &gpus
+	availableGPUs := gpus.DeepCopy()
+	gpuCluster.Spec.Resources.Available.GPU = &availableGPUs
+
+	engine := &DecisionEngine{Client: createFakeClient(cpuOnly, gpuCluster)}
+
+	gpuBlock := makeRequest("2000m", "2Gi")
+	requestedGPU := resource.MustParse("1")
+	gpuBlock.GPU = &requestedGPU
+
+	// Bin-packing would put the CPU block on the fuller GPU cluster if placed first
+	parts, err := engine.PlanGang(context.Background(), []PlacementRequest{
+		{RequesterID: "cluster-0", Resources: makeRequest("3000m", "4Gi"), Strategy: brokerv1alpha1.ScoringStrategyMostAllocated},
+		{RequesterID: "cluster-0", Resources: gpuBlock, Strategy: brokerv1alpha1.ScoringStrategyMostAllocated},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if parts[0].Cluster.Spec.ClusterID != "cpu-only" {
+		t.Errorf("expected CPU block on cpu-only, got %s", parts[0].Cluster.Spec.ClusterID)
+	}
+	if parts[1].Cluster.Spec.ClusterID != "gpu" {
+		t.Errorf("expected GPU block on gpu, got %s", parts[1].Cluster.Spec.ClusterID)
+	}
+}
+
+// Test: Members that only fit one at a time fail the whole gang
+func TestPlanGang_MembersShareCapacity(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster)}
+
+	_, err := engine.PlanGang(context.Background(), []PlacementRequest{
+		{RequesterID: "cluster-0", Resources: makeRequest("3000m", "2Gi")},
+		{RequesterID: "cluster-0", Resources: makeRequest("3000m", "2Gi")},
+	})
+	if err == nil {
+		t.Fatal("expected error when members don't fit together, got nil")
+	}
+}
@@ -0,0 +1,80 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// GroupPart is one block of a reservation group (a split request or a gang)
+// and the provider cluster it is placed on
+type GroupPart struct {
+	Cluster   *brokerv1alpha1.ClusterAdvertisement
+	Resources brokerv1alpha1.RequestedResourceQuantities
+}
+
+// LockGroup locks every part of a reservation group, all-or-nothing: if one
+// part cannot be locked, the parts locked before it are released again.
+func (d *DecisionEngine) LockGroup(ctx context.Context, parts []GroupPart) error {
+	logger := log.FromContext(ctx)
+
+	for i, part := range parts {
+		if err := d.updateClusterLock(ctx, part.Cluster, part.Resources, true); err != nil {
+			for _, locked := range parts[:i] {
+				if rollbackErr := d.updateClusterLock(ctx, locked.Cluster, locked.Resources, false); rollbackErr != nil {
+					logger.Error(rollbackErr, "Failed to roll back group lock",
+						"cluster", locked.Cluster.Spec.ClusterID)
+				}
+			}
+			return fmt.Errorf("failed to lock part on cluster %s: %w", part.Cluster.Spec.ClusterID, err)
+		}
+	}
+	return nil
+}
+
+// CheckGroupLock runs the checks LockGroup would run against the current
+// ClusterAdvertisements, without locking anything. Parts on the same cluster
+// are checked together.
+func (d *DecisionEngine) CheckGroupLock(ctx context.Context, parts []GroupPart) error {
+	current := make(map[types.NamespacedName]*brokerv1alpha1.ClusterAdvertisement)
+
+	for _, part := range parts {
+		key := types.NamespacedName{Name: part.Cluster.Name, Namespace: part.Cluster.Namespace}
+		cluster, ok := current[key]
+		if !ok {
+			cluster = &brokerv1alpha1.ClusterAdvertisement{}
+			if err := d.Client.Get(ctx, key, cluster); err != nil {
+				return fmt.Errorf("failed to get cluster %s: %w", part.Cluster.Spec.ClusterID, err)
+			}
+			current[key] = cluster
+		}
+
+		if !resourceutil.CanReserve(cluster, part.Resources) {
+			return fmt.Errorf("insufficient resources in cluster %s", part.Cluster.Spec.ClusterID)
+		}
+		if err := resourceutil.AddReservation(cluster, part.Resources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateClusterLock adds (lock=true) or removes the resources from the
+// cluster's Reserved total
+func (d *DecisionEngine) updateClusterLock(
+	ctx context.Context,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	resources brokerv1alpha1.RequestedResourceQuantities,
+	lock bool,
+) error {
+	if lock {
+		_, err := d.LockResources(ctx, cluster.Spec.ClusterID, resources)
+		return err
+	}
+	return d.ReleaseResources(ctx, cluster.Spec.ClusterID, resources)
+}
@@ -0,0 +1,65 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test: A part that fails to lock releases the parts locked before it
+func TestLockGroup_AllOrNothing(t *testing.T) {
+	cluster1 := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "8000m", "16Gi", "8000m", "16Gi", true)
+	cluster2 := makeClusterAdvertisement("cluster-2-adv", "cluster-2", "2000m", "4Gi", "2000m", "4Gi", true)
+
+	fakeClient := createFakeClient(cluster1, cluster2)
+	engine := &DecisionEngine{Client: fakeClient}
+
+	// The second part no longer fits cluster-2
+	parts := []GroupPart{
+		{Cluster: cluster1, Resources: makeRequest("2000m", "4Gi")},
+		{Cluster: cluster2, Resources: makeRequest("3000m", "4Gi")},
+	}
+	if err := engine.LockGroup(context.Background(), parts); err == nil {
+		t.Fatal("expected lock to fail")
+	}
+
+	current := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "cluster-1-adv", Namespace: "default"}, current); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	if current.Spec.Resources.Reserved != nil && !current.Spec.Resources.Reserved.CPU.IsZero() {
+		t.Errorf("expected cluster-1 lock to be rolled back, got %s CPU reserved", current.Spec.Resources.Reserved.CPU.String())
+	}
+}
+
+// Test: Checking a group lock sums parts on the same cluster and changes nothing
+func TestCheckGroupLock_SumsPartsPerCluster(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+
+	fakeClient := createFakeClient(cluster)
+	engine := &DecisionEngine{Client: fakeClient}
+
+	fits := []GroupPart{{Cluster: cluster, Resources: makeRequest("2000m", "2Gi")}}
+	if err := engine.CheckGroupLock(context.Background(), fits); err != nil {
+		t.Fatalf("expected a single part to fit, got %v", err)
+	}
+
+	// Each part fits alone, but not both together
+	tooMuch := []GroupPart{
+		{Cluster: cluster, Resources: makeRequest("3000m", "2Gi")},
+		{Cluster: cluster, Resources: makeRequest("3000m", "2Gi")},
+	}
+	if err := engine.CheckGroupLock(context.Background(), tooMuch); err == nil {
+		t.Error("expected parts on the same cluster to be checked together")
+	}
+
+	current := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "cluster-1-adv", Namespace: "default"}, current); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	if current.Spec.Resources.Reserved != nil {
+		t.Errorf("expected nothing to be reserved, got %s CPU", current.Spec.Resources.Reserved.CPU.String())
+	}
+}
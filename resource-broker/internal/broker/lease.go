@@ -0,0 +1,69 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// ErrMaxLifetimeReached is returned when a reservation cannot be renewed any further
+var ErrMaxLifetimeReached = errors.New("reservation reached its maximum lifetime")
+
+// RenewedExpiry returns the expiry of a reservation renewed at now: now plus
+// the extension, but never earlier than the current expiry. With a positive
+// maxLifetime the expiry is capped at reservedAt plus maxLifetime, and
+// ErrMaxLifetimeReached is returned if the cap leaves nothing to extend.
+func RenewedExpiry(reservedAt, expiresAt, now time.Time, extension, maxLifetime time.Duration) (time.Time, error) {
+	renewed := now.Add(extension)
+
+	if maxLifetime > 0 {
+		limit := reservedAt.Add(maxLifetime)
+		if !limit.After(expiresAt) {
+			return expiresAt, ErrMaxLifetimeReached
+		}
+		if renewed.After(limit) {
+			renewed = limit
+		}
+	}
+
+	if renewed.Before(expiresAt) {
+		return expiresAt, nil
+	}
+	return renewed, nil
+}
+
+// RenewedReservationExpiry returns the expiry a Reserved or Active reservation
+// gets if renewed at now (see RenewedExpiry). An extension of zero uses the
+// reservation's own duration. Every interface renews through it, so the
+// maximum lifetime applies to all of them.
+func RenewedReservationExpiry(
+	reservation *brokerv1alpha1.Reservation,
+	extension time.Duration,
+	now time.Time,
+	maxLifetime time.Duration,
+) (time.Time, error) {
+	switch reservation.Status.Phase {
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive:
+	default:
+		return time.Time{}, fmt.Errorf("reservation is %s", brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+	}
+	if reservation.Status.ExpiresAt == nil {
+		return time.Time{}, errors.New("reservation does not expire")
+	}
+
+	if extension == 0 {
+		if reservation.Spec.Duration == nil {
+			return time.Time{}, errors.New("no duration given and the reservation has none")
+		}
+		extension = reservation.Spec.Duration.Duration
+	}
+
+	reservedAt := reservation.CreationTimestamp.Time
+	if reservation.Status.ReservedAt != nil {
+		reservedAt = reservation.Status.ReservedAt.Time
+	}
+
+	return RenewedExpiry(reservedAt, reservation.Status.ExpiresAt.Time, now, extension, maxLifetime)
+}
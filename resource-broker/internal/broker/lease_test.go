@@ -0,0 +1,77 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Test: Renewal extends from now and is capped by the maximum lifetime
+func TestRenewedExpiry_CappedByMaxLifetime(t *testing.T) {
+	reservedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := reservedAt.Add(1 * time.Hour)
+	now := reservedAt.Add(50 * time.Minute)
+
+	renewed, err := RenewedExpiry(reservedAt, expiresAt, now, 1*time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := now.Add(1 * time.Hour); !renewed.Equal(want) {
+		t.Errorf("Expected expiry %v without limit, got %v", want, renewed)
+	}
+
+	renewed, err = RenewedExpiry(reservedAt, expiresAt, now, 1*time.Hour, 90*time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := reservedAt.Add(90 * time.Minute); !renewed.Equal(want) {
+		t.Errorf("Expected expiry capped at %v, got %v", want, renewed)
+	}
+
+	// A short extension never brings the expiry forward
+	renewed, err = RenewedExpiry(reservedAt, expiresAt, now, 1*time.Minute, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !renewed.Equal(expiresAt) {
+		t.Errorf("Expected expiry to stay at %v, got %v", expiresAt, renewed)
+	}
+}
+
+// Test: A reservation already at its maximum lifetime cannot be renewed
+func TestRenewedExpiry_MaxLifetimeReached(t *testing.T) {
+	reservedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := reservedAt.Add(2 * time.Hour)
+
+	_, err := RenewedExpiry(reservedAt, expiresAt, expiresAt.Add(-time.Minute), 1*time.Hour, 2*time.Hour)
+	if !errors.Is(err, ErrMaxLifetimeReached) {
+		t.Errorf("Expected ErrMaxLifetimeReached, got %v", err)
+	}
+}
+
+// Test: A reservation renews by its own duration by default, and only while Reserved or Active
+func TestRenewedReservationExpiry_UsesOwnDuration(t *testing.T) {
+	reservedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := reservedAt.Add(50 * time.Minute)
+	reservation := makeHeldReservation("job", "cluster-1", 0, "1", "1Gi", brokerv1alpha1.ReservationPhaseActive)
+	reservation.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+	reservation.Status.ReservedAt = &metav1.Time{Time: reservedAt}
+	reservation.Status.ExpiresAt = &metav1.Time{Time: reservedAt.Add(time.Hour)}
+
+	renewed, err := RenewedReservationExpiry(reservation, 0, now, 90*time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := reservedAt.Add(90 * time.Minute); !renewed.Equal(want) {
+		t.Errorf("Expected expiry capped at %v, got %v", want, renewed)
+	}
+
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+	if _, err := RenewedReservationExpiry(reservation, 0, now, 0); err == nil {
+		t.Error("Expected a Scheduled reservation to be rejected")
+	}
+}
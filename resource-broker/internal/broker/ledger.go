@@ -0,0 +1,86 @@
+package broker
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// HoldsLock reports whether a reservation in the given phase has its
+// resources counted in the target cluster's Reserved total
+func HoldsLock(phase brokerv1alpha1.ReservationPhase) bool {
+	switch phase {
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+		brokerv1alpha1.ReservationPhaseOrphaned:
+		return true
+	}
+	return false
+}
+
+// ExpectedReserved returns, per target cluster ID, the Reserved total implied
+// by the reservations that hold a lock. Clusters without any are absent.
+func ExpectedReserved(reservations []brokerv1alpha1.Reservation) (map[string]*brokerv1alpha1.ResourceQuantities, error) {
+	// Locks are added to scratch advertisements exactly as the broker adds them
+	scratch := make(map[string]*brokerv1alpha1.ClusterAdvertisement)
+	for i := range reservations {
+		rsv := &reservations[i]
+		if !HoldsLock(rsv.Status.Phase) || rsv.Spec.TargetClusterID == "" {
+			continue
+		}
+		cluster, ok := scratch[rsv.Spec.TargetClusterID]
+		if !ok {
+			cluster = &brokerv1alpha1.ClusterAdvertisement{}
+			scratch[rsv.Spec.TargetClusterID] = cluster
+		}
+		if err := resourceutil.AddReservation(cluster, rsv.Spec.RequestedResources); err != nil {
+			return nil, err
+		}
+	}
+
+	expected := make(map[string]*brokerv1alpha1.ResourceQuantities, len(scratch))
+	for clusterID, cluster := range scratch {
+		expected[clusterID] = cluster.Spec.Resources.Reserved
+	}
+	return expected, nil
+}
+
+// ReservedEqual reports whether two Reserved totals hold the same amounts.
+// Missing totals and missing quantities count as zero.
+func ReservedEqual(a, b *brokerv1alpha1.ResourceQuantities) bool {
+	if a == nil {
+		a = &brokerv1alpha1.ResourceQuantities{}
+	}
+	if b == nil {
+		b = &brokerv1alpha1.ResourceQuantities{}
+	}
+
+	if a.CPU.Cmp(b.CPU) != 0 || a.Memory.Cmp(b.Memory) != 0 || !quantityEqual(a.GPU, b.GPU) {
+		return false
+	}
+	for name, qty := range a.Extended {
+		other := b.Extended[name]
+		if qty.Cmp(other) != 0 {
+			return false
+		}
+	}
+	for name, qty := range b.Extended {
+		other := a.Extended[name]
+		if qty.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// quantityEqual compares optional quantities, treating nil as zero
+func quantityEqual(a, b *resource.Quantity) bool {
+	var zero resource.Quantity
+	if a == nil {
+		a = &zero
+	}
+	if b == nil {
+		b = &zero
+	}
+	return a.Cmp(*b) == 0
+}
@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Test: Only reservations holding a lock count towards their target cluster's Reserved total
+func TestExpectedReserved_SumsLockedReservationsPerCluster(t *testing.T) {
+	reservations := []brokerv1alpha1.Reservation{
+		*makeHeldReservation("active", "cluster-1", 0, "2000m", "4Gi", brokerv1alpha1.ReservationPhaseActive),
+		*makeHeldReservation("reserved", "cluster-1", 0, "1000m", "2Gi", brokerv1alpha1.ReservationPhaseReserved),
+		*makeHeldReservation("orphaned", "cluster-2", 0, "500m", "1Gi", brokerv1alpha1.ReservationPhaseOrphaned),
+		*makeHeldReservation("released", "cluster-2", 0, "4000m", "8Gi", brokerv1alpha1.ReservationPhaseReleased),
+		*makeHeldReservation("failed", "cluster-3", 0, "4000m", "8Gi", brokerv1alpha1.ReservationPhaseFailed),
+	}
+
+	expected, err := ExpectedReserved(reservations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := expected["cluster-1"]; got == nil || got.CPU.Cmp(resource.MustParse("3")) != 0 ||
+		got.Memory.Cmp(resource.MustParse("6Gi")) != 0 {
+		t.Errorf("Expected 3 CPU and 6Gi reserved on cluster-1, got %v", got)
+	}
+	if got := expected["cluster-2"]; got == nil || got.CPU.Cmp(resource.MustParse("500m")) != 0 {
+		t.Errorf("Expected 500m CPU reserved on cluster-2, got %v", got)
+	}
+	if _, ok := expected["cluster-3"]; ok {
+		t.Error("Expected no Reserved total for a cluster without locks")
+	}
+}
+
+// Test: Missing totals and quantities compare as zero
+func TestReservedEqual_NilIsZero(t *testing.T) {
+	zero := &brokerv1alpha1.ResourceQuantities{
+		CPU:      resource.MustParse("0"),
+		Memory:   resource.MustParse("0"),
+		Extended: map[string]resource.Quantity{"amd.com/gpu": resource.MustParse("0")},
+	}
+	if !ReservedEqual(nil, zero) {
+		t.Error("Expected a nil total to equal a zero total")
+	}
+
+	gpu := resource.MustParse("1")
+	withGPU := zero.DeepCopy()
+	withGPU.GPU = &gpu
+	if ReservedEqual(zero, withGPU) {
+		t.Error("Expected totals differing in GPUs to differ")
+	}
+}
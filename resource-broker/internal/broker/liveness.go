@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Liveness tells whether the requester of an Active reservation is still around
+type Liveness string
+
+const (
+	// LivenessAlive means the requester sent a heartbeat within the timeout
+	LivenessAlive Liveness = "Alive"
+
+	// LivenessOrphaned means the requester missed its heartbeats and the
+	// grace period has not ended yet
+	LivenessOrphaned Liveness = "Orphaned"
+
+	// LivenessAbandoned means the grace period ended without a heartbeat
+	LivenessAbandoned Liveness = "Abandoned"
+)
+
+// LastSeen returns the last sign of life of a reservation's requester: its
+// latest heartbeat or, before the first one, the activation of the reservation
+func LastSeen(reservation *brokerv1alpha1.Reservation) time.Time {
+	lastSeen := reservation.CreationTimestamp.Time
+	if reservation.Status.ReservedAt != nil {
+		lastSeen = reservation.Status.ReservedAt.Time
+	}
+	if active := meta.FindStatusCondition(reservation.Status.Conditions,
+		brokerv1alpha1.ReservationConditionRequesterActive); active != nil && active.LastTransitionTime.After(lastSeen) {
+		lastSeen = active.LastTransitionTime.Time
+	}
+	if heartbeat := reservation.Status.LastHeartbeatTime; heartbeat != nil && heartbeat.After(lastSeen) {
+		lastSeen = heartbeat.Time
+	}
+	return lastSeen
+}
+
+// CheckLiveness returns the liveness of an Active or Orphaned reservation at
+// now. The reservation is orphaned once no heartbeat arrived for timeout, and
+// abandoned once it stayed orphaned for the grace period.
+func CheckLiveness(
+	reservation *brokerv1alpha1.Reservation,
+	now time.Time,
+	timeout, grace time.Duration,
+) Liveness {
+	lastSeen := LastSeen(reservation)
+	if !now.After(lastSeen.Add(timeout)) {
+		return LivenessAlive
+	}
+
+	orphanedAt := lastSeen.Add(timeout)
+	if orphaned := meta.FindStatusCondition(reservation.Status.Conditions,
+		brokerv1alpha1.ReservationConditionOrphaned); orphaned != nil && orphaned.Status == metav1.ConditionTrue &&
+		orphaned.LastTransitionTime.After(orphanedAt) {
+		orphanedAt = orphaned.LastTransitionTime.Time
+	}
+	if now.After(orphanedAt.Add(grace)) {
+		return LivenessAbandoned
+	}
+	return LivenessOrphaned
+}
@@ -0,0 +1,60 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test: A reservation is orphaned after the heartbeat timeout and abandoned after the grace period
+func TestCheckLiveness_TimeoutThenGrace(t *testing.T) {
+	reservedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	rsv := &brokerv1alpha1.Reservation{
+		Status: brokerv1alpha1.ReservationStatus{
+			Phase:             brokerv1alpha1.ReservationPhaseActive,
+			ReservedAt:        &metav1.Time{Time: reservedAt},
+			LastHeartbeatTime: &metav1.Time{Time: reservedAt.Add(10 * time.Minute)},
+		},
+	}
+
+	cases := []struct {
+		at   time.Duration
+		want Liveness
+	}{
+		{at: 12 * time.Minute, want: LivenessAlive},
+		{at: 16 * time.Minute, want: LivenessOrphaned},
+		{at: 26 * time.Minute, want: LivenessAbandoned},
+	}
+	for _, c := range cases {
+		if got := CheckLiveness(rsv, reservedAt.Add(c.at), 5*time.Minute, 10*time.Minute); got != c.want {
+			t.Errorf("At +%v: expected %s, got %s", c.at, c.want, got)
+		}
+	}
+}
+
+// Test: The grace period runs from when the reservation was marked Orphaned
+func TestCheckLiveness_GraceFromOrphanedCondition(t *testing.T) {
+	reservedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	// The broker was down and only noticed the missed heartbeats an hour later
+	orphanedAt := reservedAt.Add(1 * time.Hour)
+	rsv := &brokerv1alpha1.Reservation{
+		Status: brokerv1alpha1.ReservationStatus{
+			Phase:      brokerv1alpha1.ReservationPhaseOrphaned,
+			ReservedAt: &metav1.Time{Time: reservedAt},
+			Conditions: []metav1.Condition{{
+				Type:               brokerv1alpha1.ReservationConditionOrphaned,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: orphanedAt},
+			}},
+		},
+	}
+
+	if got := CheckLiveness(rsv, orphanedAt.Add(5*time.Minute), 5*time.Minute, 10*time.Minute); got != LivenessOrphaned {
+		t.Errorf("Expected %s within the grace period, got %s", LivenessOrphaned, got)
+	}
+	if got := CheckLiveness(rsv, orphanedAt.Add(11*time.Minute), 5*time.Minute, 10*time.Minute); got != LivenessAbandoned {
+		t.Errorf("Expected %s after the grace period, got %s", LivenessAbandoned, got)
+	}
+}
@@ -0,0 +1,69 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/util/retry"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// ErrInsufficientResources is returned when a cluster has not enough resources left to lock
+var ErrInsufficientResources = errors.New("insufficient resources")
+
+// LockResources adds the resources to the Reserved total of the cluster with
+// the given ID, retrying on conflicts. Every interface locks through it, so a
+// reservation decided over HTTP, gRPC, MQTT or a Reservation CRD competes for
+// the same capacity. Returns the updated cluster.
+func (d *DecisionEngine) LockResources(
+	ctx context.Context,
+	clusterID string,
+	resources brokerv1alpha1.RequestedResourceQuantities,
+) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	var locked *brokerv1alpha1.ClusterAdvertisement
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster, err := d.clusterByID(ctx, clusterID)
+		if err != nil {
+			return err
+		}
+
+		if !resourceutil.CanReserve(cluster, resources) {
+			return fmt.Errorf("%w in cluster %s", ErrInsufficientResources, clusterID)
+		}
+		if err := resourceutil.AddReservation(cluster, resources); err != nil {
+			return err
+		}
+
+		locked = cluster
+		return d.Client.Update(ctx, cluster)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// ReleaseResources removes the resources from the Reserved total of the
+// cluster with the given ID, retrying on conflicts
+func (d *DecisionEngine) ReleaseResources(
+	ctx context.Context,
+	clusterID string,
+	resources brokerv1alpha1.RequestedResourceQuantities,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster, err := d.clusterByID(ctx, clusterID)
+		if err != nil {
+			return err
+		}
+
+		if err := resourceutil.RemoveReservation(cluster, resources); err != nil {
+			return err
+		}
+
+		return d.Client.Update(ctx, cluster)
+	})
+}
@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Test: Locking adds to Reserved until the cluster is full, and releasing gives the capacity back
+func TestLockResources_LocksAndReleases(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster)}
+	ctx := context.Background()
+
+	locked, err := engine.LockResources(ctx, "cluster-1", makeRequest("3000m", "4Gi"))
+	if err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	if locked.Spec.ClusterID != "cluster-1" {
+		t.Errorf("expected the locked cluster to be returned, got %s", locked.Spec.ClusterID)
+	}
+	assertReservedCPU(t, engine, "3")
+
+	// Only 1 CPU is left
+	if _, err := engine.LockResources(ctx, "cluster-1", makeRequest("2000m", "1Gi")); !errors.Is(err, ErrInsufficientResources) {
+		t.Errorf("expected ErrInsufficientResources, got %v", err)
+	}
+	assertReservedCPU(t, engine, "3")
+
+	if err := engine.ReleaseResources(ctx, "cluster-1", makeRequest("3000m", "4Gi")); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	assertReservedCPU(t, engine, "0")
+}
+
+// Test: Locking and releasing on an unknown cluster fail
+func TestLockResources_UnknownCluster(t *testing.T) {
+	engine := &DecisionEngine{Client: createFakeClient()}
+	ctx := context.Background()
+
+	if _, err := engine.LockResources(ctx, "missing", makeRequest("1", "1Gi")); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound on lock, got %v", err)
+	}
+	if err := engine.ReleaseResources(ctx, "missing", makeRequest("1", "1Gi")); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound on release, got %v", err)
+	}
+}
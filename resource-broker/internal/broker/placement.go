@@ -0,0 +1,85 @@
+package broker
+
+import (
+	"fmt"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// compiledPlacement holds PlacementConstraints parsed into label selectors
+type compiledPlacement struct {
+	required    labels.Selector
+	preferred   []weightedSelector
+	totalWeight int32
+}
+
+type weightedSelector struct {
+	weight   int32
+	selector labels.Selector
+}
+
+// ValidatePlacement checks that placement constraints can be evaluated.
+// A nil placement is valid and matches every cluster.
+func ValidatePlacement(placement *brokerv1alpha1.PlacementConstraints) error {
+	_, err := compilePlacement(placement)
+	return err
+}
+
+func compilePlacement(placement *brokerv1alpha1.PlacementConstraints) (*compiledPlacement, error) {
+	compiled := &compiledPlacement{required: labels.Everything()}
+	if placement == nil {
+		return compiled, nil
+	}
+
+	if len(placement.Required) > 0 {
+		selector, err := requirementsAsSelector(placement.Required)
+		if err != nil {
+			return nil, fmt.Errorf("invalid required placement: %w", err)
+		}
+		compiled.required = selector
+	}
+
+	for i, term := range placement.Preferred {
+		if term.Weight < 1 || term.Weight > 100 {
+			return nil, fmt.Errorf("invalid preferred placement %d: weight must be between 1 and 100, got %d",
+				i, term.Weight)
+		}
+		selector, err := requirementsAsSelector(term.Requirements)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preferred placement %d: %w", i, err)
+		}
+		compiled.preferred = append(compiled.preferred, weightedSelector{weight: term.Weight, selector: selector})
+		compiled.totalWeight += term.Weight
+	}
+
+	return compiled, nil
+}
+
+func requirementsAsSelector(requirements []metav1.LabelSelectorRequirement) (labels.Selector, error) {
+	return metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: requirements})
+}
+
+// matches reports whether the cluster's labels satisfy every required constraint
+func (p *compiledPlacement) matches(cluster *brokerv1alpha1.ClusterAdvertisement) bool {
+	return p.required.Matches(labels.Set(cluster.Spec.Labels))
+}
+
+// preferenceScore is the share of preferred weight the cluster matches (0-1).
+// It is added to the strategy score, so a full match is worth as much as the
+// whole strategy range. Returns 0 when there are no preferences.
+func (p *compiledPlacement) preferenceScore(cluster *brokerv1alpha1.ClusterAdvertisement) float64 {
+	if p.totalWeight == 0 {
+		return 0
+	}
+
+	clusterLabels := labels.Set(cluster.Spec.Labels)
+	var matched int32
+	for _, term := range p.preferred {
+		if term.selector.Matches(clusterLabels) {
+			matched += term.weight
+		}
+	}
+	return float64(matched) / float64(p.totalWeight)
+}
@@ -0,0 +1,132 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const regionLabel = "topology.kubernetes.io/region"
+
+// Helper to build a cluster advertisement with labels
+func makeLabeledCluster(name, clusterID, availableCPU string, clusterLabels map[string]string) *brokerv1alpha1.ClusterAdvertisement {
+	cluster := makeClusterAdvertisement(name, clusterID, "4000m", "8Gi", availableCPU, "4Gi", true)
+	cluster.Spec.Labels = clusterLabels
+	return cluster
+}
+
+// Helper to build an In requirement
+func inRequirement(key string, values ...string) metav1.LabelSelectorRequirement {
+	return metav1.LabelSelectorRequirement{Key: key, Operator: metav1.LabelSelectorOpIn, Values: values}
+}
+
+// Test: Required constraints exclude clusters even when they score higher
+func TestSelectBestCluster_RequiredPlacementFiltersClusters(t *testing.T) {
+	// us-east has more headroom but violates the EU-only rule
+	us := makeLabeledCluster("us-adv", "us", "3500m", map[string]string{regionLabel: "us-east-1"})
+	eu := makeLabeledCluster("eu-adv", "eu", "1500m", map[string]string{regionLabel: "eu-west-1"})
+	unlabeled := makeLabeledCluster("unlabeled-adv", "unlabeled", "3800m", nil)
+
+	engine := &DecisionEngine{Client: createFakeClient(us, eu, unlabeled)}
+
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("500m", "1Gi"),
+		Placement: &brokerv1alpha1.PlacementConstraints{
+			Required: []metav1.LabelSelectorRequirement{inRequirement(regionLabel, "eu-west-1", "eu-central-1")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Spec.ClusterID != "eu" {
+		t.Errorf("expected eu cluster, got %s", result.Spec.ClusterID)
+	}
+}
+
+// Test: No cluster is selected when none satisfies the required constraints
+func TestSelectBestCluster_RequiredPlacementNoMatch(t *testing.T) {
+	us := makeLabeledCluster("us-adv", "us", "3500m", map[string]string{regionLabel: "us-east-1"})
+
+	engine := &DecisionEngine{Client: createFakeClient(us)}
+
+	_, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("500m", "1Gi"),
+		Placement: &brokerv1alpha1.PlacementConstraints{
+			Required: []metav1.LabelSelectorRequirement{inRequirement(regionLabel, "eu-west-1")},
+		},
+	})
+	if err == nil {
+		t.Error("expected error when no cluster satisfies placement, got nil")
+	}
+}
+
+// Test: Preferred constraints outrank a better strategy score without excluding others
+func TestSelectBestCluster_PreferredPlacementRanksClusters(t *testing.T) {
+	roomy := makeLabeledCluster("roomy-adv", "roomy", "3500m", map[string]string{"compliance.fluidos.eu/gdpr": "false"})
+	compliant := makeLabeledCluster("compliant-adv", "compliant", "2000m", map[string]string{"compliance.fluidos.eu/gdpr": "true"})
+
+	engine := &DecisionEngine{Client: createFakeClient(roomy, compliant)}
+
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("500m", "1Gi"),
+		Placement: &brokerv1alpha1.PlacementConstraints{
+			Preferred: []brokerv1alpha1.PreferredPlacementTerm{{
+				Weight:       100,
+				Requirements: []metav1.LabelSelectorRequirement{inRequirement("compliance.fluidos.eu/gdpr", "true")},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Spec.ClusterID != "compliant" {
+		t.Errorf("expected preferred compliant cluster, got %s", result.Spec.ClusterID)
+	}
+}
+
+// Test: Invalid constraints are rejected
+func TestValidatePlacement_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement *brokerv1alpha1.PlacementConstraints
+	}{
+		{
+			name: "unknown operator",
+			placement: &brokerv1alpha1.PlacementConstraints{
+				Required: []metav1.LabelSelectorRequirement{{Key: regionLabel, Operator: "Near", Values: []string{"eu"}}},
+			},
+		},
+		{
+			name: "In without values",
+			placement: &brokerv1alpha1.PlacementConstraints{
+				Required: []metav1.LabelSelectorRequirement{{Key: regionLabel, Operator: metav1.LabelSelectorOpIn}},
+			},
+		},
+		{
+			name: "weight out of range",
+			placement: &brokerv1alpha1.PlacementConstraints{
+				Preferred: []brokerv1alpha1.PreferredPlacementTerm{{
+					Weight:       0,
+					Requirements: []metav1.LabelSelectorRequirement{inRequirement(regionLabel, "eu-west-1")},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePlacement(tt.placement); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	if err := ValidatePlacement(nil); err != nil {
+		t.Errorf("expected nil placement to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// ErrPreemptionNotPossible is returned when no cluster can fit the request
+// even after evicting every lower-priority reservation
+var ErrPreemptionNotPossible = errors.New("no cluster can fit the request by preempting lower-priority reservations")
+
+// PreemptionPlan names the cluster to place a request on and the Reserved
+// reservations that must be evicted from it first
+type PreemptionPlan struct {
+	Cluster *brokerv1alpha1.ClusterAdvertisement
+	Victims []*brokerv1alpha1.Reservation
+}
+
+// PlanPreemption finds a cluster where evicting lower-priority Reserved (not
+// yet Active) reservations frees enough capacity for the request.
+// Clusters needing fewer victims win, then clusters whose most important
+// victim has the lowest priority, then the usual strategy score.
+func (d *DecisionEngine) PlanPreemption(
+	ctx context.Context,
+	request PlacementRequest,
+	priority int32,
+) (*PreemptionPlan, error) {
+
+	strategy, err := d.strategyFor(request)
+	if err != nil {
+		return nil, err
+	}
+
+	placement, err := compilePlacement(request.Placement)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := d.listClusters(ctx, request.window())
+	if err != nil {
+		return nil, err
+	}
+
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := d.Client.List(ctx, reservationList); err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	// Only Reserved reservations of lower priority can be evicted
+	candidatesByCluster := make(map[string][]*brokerv1alpha1.Reservation)
+	for i := range reservationList.Items {
+		rsv := &reservationList.Items[i]
+		if rsv.Status.Phase != brokerv1alpha1.ReservationPhaseReserved || rsv.Spec.Priority >= priority {
+			continue
+		}
+		candidatesByCluster[rsv.Spec.TargetClusterID] = append(candidatesByCluster[rsv.Spec.TargetClusterID], rsv)
+	}
+
+	var best *PreemptionPlan
+	var bestScore float64
+
+	for i := range clusters {
+		cluster := &clusters[i]
+
+		if cluster.Spec.ClusterID == request.RequesterID || !cluster.Status.Active || !placement.matches(cluster) {
+			continue
+		}
+
+		victims, simulated := selectVictims(cluster, candidatesByCluster[cluster.Spec.ClusterID], request.Resources)
+		if simulated == nil {
+			continue
+		}
+
+		plan := &PreemptionPlan{Cluster: cluster, Victims: victims}
+		score := strategy.Score(simulated, request.Resources) + placement.preferenceScore(cluster)
+
+		if best == nil || betterPlan(plan, score, best, bestScore) {
+			best = plan
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil, ErrPreemptionNotPossible
+	}
+
+	return best, nil
+}
+
+// selectVictims evicts candidates from a copy of the cluster, least important
+// first, until the request fits. Victims that turn out not to be needed are
+// then reprieved, most important first. Returns nil if the request cannot fit.
+func selectVictims(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	candidates []*brokerv1alpha1.Reservation,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) ([]*brokerv1alpha1.Reservation, *brokerv1alpha1.ClusterAdvertisement) {
+
+	// Lowest priority first; among equals, the most recent reservation goes first
+	sorted := append([]*brokerv1alpha1.Reservation(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Spec.Priority != sorted[j].Spec.Priority {
+			return sorted[i].Spec.Priority < sorted[j].Spec.Priority
+		}
+		return sorted[j].CreationTimestamp.Before(&sorted[i].CreationTimestamp)
+	})
+
+	simulated := cluster.DeepCopy()
+	var evicted []*brokerv1alpha1.Reservation
+	for _, candidate := range sorted {
+		if resourceutil.CanReserve(simulated, requested) {
+			break
+		}
+		if err := resourceutil.RemoveReservation(simulated, candidate.Spec.RequestedResources); err != nil {
+			return nil, nil
+		}
+		evicted = append(evicted, candidate)
+	}
+
+	if !resourceutil.CanReserve(simulated, requested) {
+		return nil, nil
+	}
+
+	// Reprieve victims whose resources are not actually needed
+	var victims []*brokerv1alpha1.Reservation
+	for i := len(evicted) - 1; i >= 0; i-- {
+		if err := resourceutil.AddReservation(simulated, evicted[i].Spec.RequestedResources); err != nil {
+			return nil, nil
+		}
+		if resourceutil.CanReserve(simulated, requested) {
+			continue
+		}
+		if err := resourceutil.RemoveReservation(simulated, evicted[i].Spec.RequestedResources); err != nil {
+			return nil, nil
+		}
+		victims = append(victims, evicted[i])
+	}
+
+	return victims, simulated
+}
+
+// betterPlan reports whether plan (with score) disrupts less than best
+func betterPlan(plan *PreemptionPlan, score float64, best *PreemptionPlan, bestScore float64) bool {
+	if len(plan.Victims) != len(best.Victims) {
+		return len(plan.Victims) < len(best.Victims)
+	}
+	if highest, bestHighest := highestPriority(plan.Victims), highestPriority(best.Victims); highest != bestHighest {
+		return highest < bestHighest
+	}
+	return score > bestScore
+}
+
+func highestPriority(reservations []*brokerv1alpha1.Reservation) int32 {
+	var highest int32
+	for i, rsv := range reservations {
+		if i == 0 || rsv.Spec.Priority > highest {
+			highest = rsv.Spec.Priority
+		}
+	}
+	return highest
+}
+
+// errNoLongerReserved is returned by markPreempted for a victim that was
+// activated, released or deleted since it was planned for eviction
+var errNoLongerReserved = errors.New("no longer Reserved")
+
+// ExecutePreemption marks the victims Preempted, then releases their locks and
+// locks the preemptor's resources in a single ClusterAdvertisement update.
+// Returns the updated cluster. A victim that left the Reserved phase after
+// planning is skipped: its lock is the controller's to release, so only the
+// locks of the victims actually marked are released here, and plan.Victims is
+// left with those. If the preemptor does not fit even so, the marked victims'
+// locks are still released and an error wrapping ErrInsufficientResources is
+// returned. The preemptor's own status is left to the caller.
+func (d *DecisionEngine) ExecutePreemption(
+	ctx context.Context,
+	plan *PreemptionPlan,
+	preemptor *brokerv1alpha1.Reservation,
+) (*brokerv1alpha1.ClusterAdvertisement, error) {
+
+	// Victims that were activated or released since planning must not be evicted
+	for _, victim := range plan.Victims {
+		current := &brokerv1alpha1.Reservation{}
+		if err := d.Client.Get(ctx, types.NamespacedName{Name: victim.Name, Namespace: victim.Namespace}, current); err != nil {
+			return nil, fmt.Errorf("failed to re-check preemption victim %s: %w", victim.Name, err)
+		}
+		if current.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+			return nil, fmt.Errorf("preemption victim %s is no longer Reserved", victim.Name)
+		}
+	}
+
+	// Victims are marked first, each only while it is still Reserved, so a
+	// lock is never released twice by a victim that changed in the meantime
+	logger := log.FromContext(ctx)
+	var evicted []*brokerv1alpha1.Reservation
+	for _, victim := range plan.Victims {
+		if err := d.markPreempted(ctx, victim, preemptor, plan.Cluster.Spec.ClusterID); err != nil {
+			if errors.Is(err, errNoLongerReserved) {
+				logger.Info("Preemption victim changed since planning, skipping it",
+					"reservation", victim.Name,
+					"preemptor", preemptor.Name)
+			} else {
+				logger.Error(err, "Failed to mark reservation as preempted",
+					"reservation", victim.Name,
+					"preemptor", preemptor.Name)
+			}
+			continue
+		}
+		evicted = append(evicted, victim)
+		logger.Info("Reservation preempted",
+			"reservation", victim.Name,
+			"priority", victim.Spec.Priority,
+			"preemptor", preemptor.Name,
+			"preemptorPriority", preemptor.Spec.Priority,
+			"cluster", plan.Cluster.Spec.ClusterID)
+	}
+	plan.Victims = evicted
+
+	var lockedCluster *brokerv1alpha1.ClusterAdvertisement
+	var fits bool
+	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &brokerv1alpha1.ClusterAdvertisement{}
+		if err := d.Client.Get(ctx,
+			types.NamespacedName{Name: plan.Cluster.Name, Namespace: plan.Cluster.Namespace},
+			cluster); err != nil {
+			return err
+		}
+
+		for _, victim := range evicted {
+			if err := resourceutil.RemoveReservation(cluster, victim.Spec.RequestedResources); err != nil {
+				return err
+			}
+		}
+
+		// Evicted victims are gone either way, so their locks are released even if the preemptor does not fit
+		fits = resourceutil.CanReserve(cluster, preemptor.Spec.RequestedResources)
+		if fits {
+			if err := resourceutil.AddReservation(cluster, preemptor.Spec.RequestedResources); err != nil {
+				return err
+			}
+		}
+
+		lockedCluster = cluster
+		return d.Client.Update(ctx, cluster)
+	})
+	if lockErr != nil {
+		return nil, lockErr
+	}
+	if !fits {
+		return nil, fmt.Errorf("%w in cluster %s even after preemption", ErrInsufficientResources, plan.Cluster.Spec.ClusterID)
+	}
+	return lockedCluster, nil
+}
+
+// markPreempted moves a victim that is still Reserved to the terminal
+// Preempted phase with the reason. Returns errNoLongerReserved otherwise.
+func (d *DecisionEngine) markPreempted(
+	ctx context.Context,
+	victim *brokerv1alpha1.Reservation,
+	preemptor *brokerv1alpha1.Reservation,
+	clusterID string,
+) error {
+	message := fmt.Sprintf("Preempted by reservation %s (priority %d > %d) on cluster %s",
+		preemptor.Name, preemptor.Spec.Priority, victim.Spec.Priority, clusterID)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &brokerv1alpha1.Reservation{}
+		if err := d.Client.Get(ctx, types.NamespacedName{Name: victim.Name, Namespace: victim.Namespace}, current); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%w: %s was deleted", errNoLongerReserved, victim.Name)
+			}
+			return err
+		}
+		if current.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+			return fmt.Errorf("%w: %s is %s", errNoLongerReserved, victim.Name, current.Status.Phase)
+		}
+
+		now := metav1.Now()
+		current.Status.Phase = brokerv1alpha1.ReservationPhasePreempted
+		current.Status.Message = message
+		current.Status.LastUpdateTime = now
+		meta.SetStatusCondition(&current.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionPreempted,
+			Status:  metav1.ConditionTrue,
+			Reason:  "HigherPriorityReservation",
+			Message: message,
+		})
+
+		return d.Client.Status().Update(ctx, current)
+	})
+}
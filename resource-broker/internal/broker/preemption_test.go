@@ -0,0 +1,229 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// Helper to build a reservation holding resources on a cluster
+func makeHeldReservation(name, clusterID string, priority int32, cpu, memory string, phase brokerv1alpha1.ReservationPhase) *brokerv1alpha1.Reservation {
+	return &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+		Spec: brokerv1alpha1.ReservationSpec{
+			RequesterID:        "cluster-9",
+			TargetClusterID:    clusterID,
+			RequestedResources: makeRequest(cpu, memory),
+			Priority:           priority,
+		},
+		Status: brokerv1alpha1.ReservationStatus{
+			Phase: phase,
+		},
+	}
+}
+
+// Helper to lock the reservations' resources in the cluster, as the broker would have
+func lockReservations(t *testing.T, cluster *brokerv1alpha1.ClusterAdvertisement, reservations ...*brokerv1alpha1.Reservation) {
+	t.Helper()
+	for _, rsv := range reservations {
+		if err := resourceutil.AddReservation(cluster, rsv.Spec.RequestedResources); err != nil {
+			t.Fatalf("failed to lock %s: %v", rsv.Name, err)
+		}
+	}
+}
+
+// Create a fake client that serves the status subresource
+func createFakeClientWithStatus(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}, &brokerv1alpha1.ClusterAdvertisement{}).
+		WithIndex(&brokerv1alpha1.Reservation{}, ReservationPhaseField, IndexReservationPhase).
+		Build()
+}
+
+// Test: Lowest-priority victims are chosen and unnecessary evictions are reprieved
+func TestPlanPreemption_EvictsOnlyWhatIsNeeded(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "3500m", "8Gi", "3500m", "8Gi", true)
+	tiny := makeHeldReservation("tiny", "cluster-1", 0, "500m", "1Gi", brokerv1alpha1.ReservationPhaseReserved)
+	big := makeHeldReservation("big", "cluster-1", 1, "2000m", "1Gi", brokerv1alpha1.ReservationPhaseReserved)
+	lockReservations(t, cluster, tiny, big) // 1000m CPU left
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster, tiny, big)}
+
+	plan, err := engine.PlanPreemption(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("2500m", "1Gi"),
+	}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Evicting tiny first is not enough; once big is evicted tiny can stay
+	if len(plan.Victims) != 1 || plan.Victims[0].Name != "big" {
+		names := []string{}
+		for _, victim := range plan.Victims {
+			names = append(names, victim.Name)
+		}
+		t.Fatalf("expected only 'big' to be preempted, got %v", names)
+	}
+}
+
+// Test: Active and equal-priority reservations are never evicted
+func TestPlanPreemption_RespectsPriorityAndPhase(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	active := makeHeldReservation("active", "cluster-1", 1, "2000m", "2Gi", brokerv1alpha1.ReservationPhaseActive)
+	equal := makeHeldReservation("equal", "cluster-1", 10, "2000m", "2Gi", brokerv1alpha1.ReservationPhaseReserved)
+	lockReservations(t, cluster, active, equal) // nothing left
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster, active, equal)}
+
+	_, err := engine.PlanPreemption(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("1000m", "1Gi"),
+	}, 10)
+	if !errors.Is(err, ErrPreemptionNotPossible) {
+		t.Fatalf("expected ErrPreemptionNotPossible, got %v", err)
+	}
+}
+
+// Test: Executing a plan moves the lock to the preemptor and marks victims Preempted
+func TestExecutePreemption_ReleasesVictimsAndLocksPreemptor(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	batch := makeHeldReservation("batch", "cluster-1", 0, "3000m", "4Gi", brokerv1alpha1.ReservationPhaseReserved)
+	lockReservations(t, cluster, batch)
+
+	urgent := makeHeldReservation("urgent", "cluster-1", 100, "2000m", "2Gi", brokerv1alpha1.ReservationPhasePending)
+
+	fakeClient := createFakeClientWithStatus(cluster, batch, urgent)
+	engine := &DecisionEngine{Client: fakeClient, Preemption: true}
+
+	plan, err := engine.PlanPreemption(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   urgent.Spec.RequestedResources,
+	}, urgent.Spec.Priority)
+	if err != nil {
+		t.Fatalf("unexpected planning error: %v", err)
+	}
+
+	locked, err := engine.ExecutePreemption(context.Background(), plan, urgent)
+	if err != nil {
+		t.Fatalf("unexpected execution error: %v", err)
+	}
+
+	// Reserved now only holds the preemptor
+	if locked.Spec.Resources.Reserved.CPU.String() != "2" {
+		t.Errorf("expected 2 CPU reserved after preemption, got %s", locked.Spec.Resources.Reserved.CPU.String())
+	}
+
+	victim := &brokerv1alpha1.Reservation{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "batch", Namespace: "default"}, victim); err != nil {
+		t.Fatalf("failed to get victim: %v", err)
+	}
+	if victim.Status.Phase != brokerv1alpha1.ReservationPhasePreempted {
+		t.Errorf("expected victim phase Preempted, got %s", victim.Status.Phase)
+	}
+	if !meta.IsStatusConditionTrue(victim.Status.Conditions, brokerv1alpha1.ReservationConditionPreempted) {
+		t.Error("expected victim to carry the Preempted condition")
+	}
+}
+
+// Test: A victim released between planning and execution is neither overwritten nor released twice
+func TestExecutePreemption_SkipsVictimReleasedMeanwhile(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	batch := makeHeldReservation("batch", "cluster-1", 0, "3000m", "4Gi", brokerv1alpha1.ReservationPhaseReserved)
+	lockReservations(t, cluster, batch)
+
+	urgent := makeHeldReservation("urgent", "cluster-1", 100, "2000m", "2Gi", brokerv1alpha1.ReservationPhasePending)
+
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	batchGets := 0
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cluster, batch, urgent).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}, &brokerv1alpha1.ClusterAdvertisement{}).
+		WithIndex(&brokerv1alpha1.Reservation{}, ReservationPhaseField, IndexReservationPhase).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				// The requester releases the victim right after the re-check, and the controller frees its lock
+				if key.Name == "batch" {
+					batchGets++
+					if batchGets == 2 {
+						releaseForTest(t, ctx, c, "batch")
+					}
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+	engine := &DecisionEngine{Client: fakeClient, Preemption: true}
+
+	plan, err := engine.PlanPreemption(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   urgent.Spec.RequestedResources,
+	}, urgent.Spec.Priority)
+	if err != nil {
+		t.Fatalf("unexpected planning error: %v", err)
+	}
+
+	locked, err := engine.ExecutePreemption(context.Background(), plan, urgent)
+	if err != nil {
+		t.Fatalf("unexpected execution error: %v", err)
+	}
+
+	// The released victim's lock is not subtracted a second time
+	if locked.Spec.Resources.Reserved.CPU.String() != "2" {
+		t.Errorf("expected 2 CPU reserved after preemption, got %s", locked.Spec.Resources.Reserved.CPU.String())
+	}
+	if len(plan.Victims) != 0 {
+		t.Errorf("expected no victim to be evicted, got %d", len(plan.Victims))
+	}
+
+	victim := &brokerv1alpha1.Reservation{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "batch", Namespace: "default"}, victim); err != nil {
+		t.Fatalf("failed to get victim: %v", err)
+	}
+	if victim.Status.Phase != brokerv1alpha1.ReservationPhaseReleased {
+		t.Errorf("expected victim to stay Released, got %s", victim.Status.Phase)
+	}
+}
+
+// releaseForTest moves a reservation to Released and frees its lock, as the reservation controller would
+func releaseForTest(t *testing.T, ctx context.Context, c client.Client, name string) {
+	t.Helper()
+
+	rsv := &brokerv1alpha1.Reservation{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, rsv); err != nil {
+		t.Fatalf("failed to get %s: %v", name, err)
+	}
+	if rsv.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+		return
+	}
+	rsv.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
+	if err := c.Status().Update(ctx, rsv); err != nil {
+		t.Fatalf("failed to release %s: %v", name, err)
+	}
+
+	engine := &DecisionEngine{Client: c}
+	if err := engine.ReleaseResources(ctx, rsv.Spec.TargetClusterID, rsv.Spec.RequestedResources); err != nil {
+		t.Fatalf("failed to free the lock of %s: %v", name, err)
+	}
+}
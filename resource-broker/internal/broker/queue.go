@@ -0,0 +1,118 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// IsQueued reports whether the reservation is waiting in the capacity queue:
+// it opted into queueing and has not been placed yet.
+func IsQueued(reservation *brokerv1alpha1.Reservation) bool {
+	if !reservation.Spec.Queue || reservation.DeletionTimestamp != nil {
+		return false
+	}
+	return reservation.Status.Phase == "" || reservation.Status.Phase == brokerv1alpha1.ReservationPhasePending
+}
+
+// QueuedReservations returns the queued reservations of the list in queue order:
+// higher Priority first, then oldest first. Name breaks remaining ties so the
+// order is stable across reconciles.
+func QueuedReservations(list *brokerv1alpha1.ReservationList) []*brokerv1alpha1.Reservation {
+	var queued []*brokerv1alpha1.Reservation
+	for i := range list.Items {
+		if IsQueued(&list.Items[i]) {
+			queued = append(queued, &list.Items[i])
+		}
+	}
+
+	sort.SliceStable(queued, func(i, j int) bool {
+		return queuedBefore(queued[i], queued[j])
+	})
+	return queued
+}
+
+// queuedBefore reports whether a ranks ahead of b in the queue
+func queuedBefore(a, b *brokerv1alpha1.Reservation) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// QueuedAhead returns the first queued reservation ranked ahead of the given
+// one that fits the cluster right now, or nil. The cluster is left to such a
+// reservation, so freed capacity goes to the head of the queue whatever order
+// reservations are reconciled in, and new requests do not overtake the queue.
+// Queued reservations that fit nowhere do not hold back the ones behind them.
+// A request not created yet ranks behind every queued reservation of the same
+// priority, given a CreationTimestamp of now.
+func (d *DecisionEngine) QueuedAhead(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+) (*brokerv1alpha1.Reservation, error) {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := d.Client.List(ctx, reservationList); err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	for _, queued := range QueuedReservations(reservationList) {
+		// Everything from the reservation itself on ranks behind it
+		if (queued.Namespace == reservation.Namespace && queued.Name == reservation.Name) ||
+			!queuedBefore(queued, reservation) {
+			break
+		}
+
+		// Waiting for a future window, not for capacity now
+		if queued.Spec.StartTime != nil && time.Now().Before(queued.Spec.StartTime.Time) {
+			continue
+		}
+		if queued.Spec.RequesterID == cluster.Spec.ClusterID ||
+			!resourceutil.CanReserve(cluster, queued.Spec.RequestedResources) {
+			continue
+		}
+		placement, err := compilePlacement(queued.Spec.Placement)
+		if err != nil || !placement.matches(cluster) {
+			continue
+		}
+		return queued, nil
+	}
+	return nil, nil
+}
+
+// LeaveToQueue records in the decision that the selected cluster is left to
+// a queued reservation ranked ahead (see QueuedAhead) and returns the error
+// the caller reports instead of placing the request
+func LeaveToQueue(
+	record *brokerv1alpha1.DecisionRecord,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	ahead *brokerv1alpha1.Reservation,
+) error {
+	message := fmt.Sprintf("Cluster %s is left to queued reservation %s, which is ahead in the queue",
+		cluster.Spec.ClusterID, ahead.Name)
+	if record != nil {
+		record.SelectedClusterID = ""
+		record.Message = message
+	}
+	return errors.New(message)
+}
+
+// QueuePosition returns the 1-based position of the named reservation among
+// the queued reservations, or 0 if it is not queued.
+func QueuePosition(queued []*brokerv1alpha1.Reservation, namespace, name string) int32 {
+	for i, reservation := range queued {
+		if reservation.Namespace == namespace && reservation.Name == name {
+			return int32(i + 1)
+		}
+	}
+	return 0
+}
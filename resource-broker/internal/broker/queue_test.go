@@ -0,0 +1,121 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Helper to build a reservation for queue tests
+func makeQueuedReservation(name string, priority int32, age time.Duration, phase brokerv1alpha1.ReservationPhase) brokerv1alpha1.Reservation {
+	return brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Spec: brokerv1alpha1.ReservationSpec{
+			Queue:    true,
+			Priority: priority,
+		},
+		Status: brokerv1alpha1.ReservationStatus{
+			Phase: phase,
+		},
+	}
+}
+
+// Test: Queue is ordered by priority first, then by age
+func TestQueuedReservations_OrderedByPriorityThenAge(t *testing.T) {
+	list := &brokerv1alpha1.ReservationList{Items: []brokerv1alpha1.Reservation{
+		makeQueuedReservation("low-old", 1, 10*time.Minute, brokerv1alpha1.ReservationPhasePending),
+		makeQueuedReservation("high-new", 10, 1*time.Minute, brokerv1alpha1.ReservationPhasePending),
+		makeQueuedReservation("high-old", 10, 5*time.Minute, brokerv1alpha1.ReservationPhasePending),
+		makeQueuedReservation("low-new", 1, 2*time.Minute, ""),
+	}}
+
+	queued := QueuedReservations(list)
+
+	expected := []string{"high-old", "high-new", "low-old", "low-new"}
+	if len(queued) != len(expected) {
+		t.Fatalf("expected %d queued reservations, got %d", len(expected), len(queued))
+	}
+	for i, name := range expected {
+		if queued[i].Name != name {
+			t.Errorf("position %d: expected %s, got %s", i+1, name, queued[i].Name)
+		}
+	}
+
+	if pos := QueuePosition(queued, "default", "low-old"); pos != 3 {
+		t.Errorf("expected low-old at position 3, got %d", pos)
+	}
+}
+
+// Test: Placed, failed and non-queueing reservations are not part of the queue
+func TestQueuedReservations_SkipsNonQueued(t *testing.T) {
+	notQueueing := makeQueuedReservation("not-queueing", 5, time.Minute, brokerv1alpha1.ReservationPhasePending)
+	notQueueing.Spec.Queue = false
+
+	list := &brokerv1alpha1.ReservationList{Items: []brokerv1alpha1.Reservation{
+		makeQueuedReservation("reserved", 5, time.Minute, brokerv1alpha1.ReservationPhaseReserved),
+		makeQueuedReservation("failed", 5, time.Minute, brokerv1alpha1.ReservationPhaseFailed),
+		notQueueing,
+		makeQueuedReservation("waiting", 0, time.Minute, brokerv1alpha1.ReservationPhasePending),
+	}}
+
+	queued := QueuedReservations(list)
+	if len(queued) != 1 || queued[0].Name != "waiting" {
+		t.Fatalf("expected only 'waiting' to be queued, got %d entries", len(queued))
+	}
+
+	if pos := QueuePosition(queued, "default", "reserved"); pos != 0 {
+		t.Errorf("expected position 0 for a non-queued reservation, got %d", pos)
+	}
+}
+
+// Test: A lower-priority reservation waits while a queued reservation ahead of it fits the freed cluster
+func TestQueuedAhead_LowerPriorityWaits(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+
+	high := makeQueuedReservation("high", 10, 1*time.Minute, brokerv1alpha1.ReservationPhasePending)
+	high.Spec.RequestedResources = makeRequest("3000m", "4Gi")
+	low := makeQueuedReservation("low", 1, 5*time.Minute, brokerv1alpha1.ReservationPhasePending)
+	low.Spec.RequestedResources = makeRequest("2000m", "2Gi")
+	huge := makeQueuedReservation("huge", 20, 10*time.Minute, brokerv1alpha1.ReservationPhasePending)
+	huge.Spec.RequestedResources = makeRequest("16000m", "4Gi")
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster, &high, &low, &huge)}
+	ctx := context.Background()
+
+	// "huge" is ahead of both but fits nowhere, so it holds back neither
+	ahead, err := engine.QueuedAhead(ctx, &low, cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ahead == nil || ahead.Name != "high" {
+		t.Fatalf("expected low to wait for high, got %v", ahead)
+	}
+
+	ahead, err = engine.QueuedAhead(ctx, &high, cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ahead != nil {
+		t.Errorf("expected high to be placed, got it left to %s", ahead.Name)
+	}
+
+	// A new request of the same priority ranks behind the queued one
+	request := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+		Spec:       brokerv1alpha1.ReservationSpec{Priority: 10, RequestedResources: makeRequest("1000m", "1Gi")},
+	}
+	ahead, err = engine.QueuedAhead(ctx, request, cluster)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ahead == nil || ahead.Name != "high" {
+		t.Errorf("expected the new request to wait for high, got %v", ahead)
+	}
+}
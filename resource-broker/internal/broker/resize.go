@@ -0,0 +1,109 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/util/retry"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+var (
+	// ErrResizeNotPossible is returned when the target cluster has no headroom left for a grown reservation
+	ErrResizeNotPossible = errors.New("target cluster has not enough headroom for the resize")
+
+	// ErrClusterNotFound is returned when no ClusterAdvertisement has the given cluster ID
+	ErrClusterNotFound = errors.New("cluster not found")
+)
+
+// ResizedResources returns the reservation's resources with CPU and memory
+// replaced. GPUs and extended resources are kept as they are.
+func ResizedResources(
+	current brokerv1alpha1.RequestedResourceQuantities,
+	cpu, memory resource.Quantity,
+) brokerv1alpha1.RequestedResourceQuantities {
+	resized := *current.DeepCopy()
+	resized.CPU = cpu
+	resized.Memory = memory
+	return resized
+}
+
+// ResizeLock changes the resources locked for a reservation on the cluster
+// with the given ID from current to resized, adjusting Reserved by the
+// difference in a single ClusterAdvertisement update. Growing checks the
+// headroom the cluster has besides the reservation's own lock, counting the
+// Scheduled reservations overlapping window, and must run inside an
+// admission (see BeginAdmission); shrinking always succeeds.
+func (d *DecisionEngine) ResizeLock(
+	ctx context.Context,
+	clusterID string,
+	window Window,
+	current, resized brokerv1alpha1.RequestedResourceQuantities,
+) error {
+	grows := resized.CPU.Cmp(current.CPU) > 0 || resized.Memory.Cmp(current.Memory) > 0
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster, err := d.clusterByID(ctx, clusterID)
+		if err != nil {
+			return err
+		}
+
+		if grows {
+			view, err := d.clusterView(ctx, clusterID, window)
+			if err != nil {
+				return err
+			}
+			if err := resourceutil.RemoveReservation(view, current); err != nil {
+				return err
+			}
+			if !resourceutil.CanReserve(view, resized) {
+				return fmt.Errorf("%w: cluster %s", ErrResizeNotPossible, clusterID)
+			}
+		}
+
+		if err := resourceutil.RemoveReservation(cluster, current); err != nil {
+			return err
+		}
+		if err := resourceutil.AddReservation(cluster, resized); err != nil {
+			return err
+		}
+
+		return d.Client.Update(ctx, cluster)
+	})
+}
+
+// clusterView returns a cluster as seen by a request for window (see listClusters)
+func (d *DecisionEngine) clusterView(
+	ctx context.Context,
+	clusterID string,
+	window Window,
+) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	clusters, err := d.listClusters(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		if clusters[i].Spec.ClusterID == clusterID {
+			return &clusters[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, clusterID)
+}
+
+// clusterByID returns the current ClusterAdvertisement of a cluster
+func (d *DecisionEngine) clusterByID(ctx context.Context, clusterID string) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := d.Client.List(ctx, clusterList); err != nil {
+		return nil, err
+	}
+	for i := range clusterList.Items {
+		if clusterList.Items[i].Spec.ClusterID == clusterID {
+			return &clusterList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, clusterID)
+}
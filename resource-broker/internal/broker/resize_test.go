@@ -0,0 +1,96 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Test: Resizing adjusts Reserved by the difference and checks headroom besides the reservation's own lock
+func TestResizeLock_AdjustsReservedByDifference(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+
+	fakeClient := createFakeClient(cluster)
+	engine := &DecisionEngine{Client: fakeClient}
+	ctx := context.Background()
+
+	current := makeRequest("2000m", "4Gi")
+	if err := engine.updateClusterLock(ctx, cluster, current, true); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+
+	// 2 CPUs are locked and 2 are free, so growing to 4 fits
+	grown := ResizedResources(current, resource.MustParse("4000m"), resource.MustParse("4Gi"))
+	if err := engine.ResizeLock(ctx, "cluster-1", Window{Start: time.Now()}, current, grown); err != nil {
+		t.Fatalf("expected grow to fit, got %v", err)
+	}
+	assertReservedCPU(t, engine, "4")
+
+	tooLarge := ResizedResources(grown, resource.MustParse("5000m"), resource.MustParse("4Gi"))
+	if err := engine.ResizeLock(ctx, "cluster-1", Window{Start: time.Now()}, grown, tooLarge); !errors.Is(err, ErrResizeNotPossible) {
+		t.Errorf("expected ErrResizeNotPossible, got %v", err)
+	}
+	assertReservedCPU(t, engine, "4")
+
+	shrunk := ResizedResources(grown, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	if err := engine.ResizeLock(ctx, "cluster-1", Window{Start: time.Now()}, grown, shrunk); err != nil {
+		t.Fatalf("expected shrink to succeed, got %v", err)
+	}
+	assertReservedCPU(t, engine, "1")
+}
+
+// Test: Growing leaves room for the Scheduled slots overlapping the reservation's window
+func TestResizeLock_RespectsScheduledSlots(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	slot := makeScheduledReservation("nightly", "cluster-1", "2000m", time.Now().Add(2*time.Hour), 4*time.Hour)
+
+	fakeClient := createFakeClient(cluster, &slot)
+	engine := &DecisionEngine{Client: fakeClient}
+	ctx := context.Background()
+
+	current := makeRequest("1000m", "2Gi")
+	if err := engine.updateClusterLock(ctx, cluster, current, true); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	grown := ResizedResources(current, resource.MustParse("3000m"), resource.MustParse("2Gi"))
+
+	// 3 CPUs and the slot's 2 do not fit in 4, unless the reservation expires before the slot starts
+	untilSlot := Window{Start: time.Now(), End: time.Now().Add(time.Hour)}
+	overlapping := Window{Start: time.Now(), End: time.Now().Add(3 * time.Hour)}
+	if err := engine.ResizeLock(ctx, "cluster-1", overlapping, current, grown); !errors.Is(err, ErrResizeNotPossible) {
+		t.Fatalf("expected ErrResizeNotPossible over the slot, got %v", err)
+	}
+	assertReservedCPU(t, engine, "1")
+
+	if err := engine.ResizeLock(ctx, "cluster-1", untilSlot, current, grown); err != nil {
+		t.Fatalf("expected grow to fit before the slot, got %v", err)
+	}
+	assertReservedCPU(t, engine, "3")
+}
+
+// Test: Resizing on an unknown cluster fails
+func TestResizeLock_UnknownCluster(t *testing.T) {
+	engine := &DecisionEngine{Client: createFakeClient()}
+
+	err := engine.ResizeLock(context.Background(), "missing", Window{Start: time.Now()}, makeRequest("1", "1Gi"), makeRequest("2", "2Gi"))
+	if !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func assertReservedCPU(t *testing.T, engine *DecisionEngine, want string) {
+	t.Helper()
+
+	current := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := engine.Client.Get(context.Background(), types.NamespacedName{Name: "cluster-1-adv", Namespace: "default"}, current); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	if current.Spec.Resources.Reserved == nil || current.Spec.Resources.Reserved.CPU.Cmp(resource.MustParse(want)) != 0 {
+		t.Errorf("expected %s CPU reserved, got %v", want, current.Spec.Resources.Reserved)
+	}
+}
@@ -0,0 +1,95 @@
+package broker
+
+import (
+	"sort"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// RetentionPolicy bounds how many terminal reservations the broker keeps.
+// A terminal reservation is collected once it is both older than TTL and not
+// among the KeepLast most recently finished ones. A zero limit retains
+// nothing on its own; with both limits zero nothing is collected.
+type RetentionPolicy struct {
+	// TTL is how long a reservation is kept after it finished
+	TTL time.Duration
+
+	// KeepLast is how many of the most recently finished reservations are kept
+	KeepLast int
+}
+
+// Enabled reports whether the policy collects anything at all
+func (p RetentionPolicy) Enabled() bool {
+	return p.TTL > 0 || p.KeepLast > 0
+}
+
+// IsTerminal reports whether a reservation in the given phase is finished for good
+func IsTerminal(phase brokerv1alpha1.ReservationPhase) bool {
+	switch phase {
+	case brokerv1alpha1.ReservationPhaseReleased, brokerv1alpha1.ReservationPhaseFailed,
+		brokerv1alpha1.ReservationPhasePreempted:
+		return true
+	}
+	return false
+}
+
+// FinishedAt returns when a terminal reservation reached its phase. The
+// status is not written again after that, so its last update time is used.
+func FinishedAt(reservation *brokerv1alpha1.Reservation) time.Time {
+	if !reservation.Status.LastUpdateTime.IsZero() {
+		return reservation.Status.LastUpdateTime.Time
+	}
+	return reservation.CreationTimestamp.Time
+}
+
+// Collectable returns the terminal reservations the policy no longer retains
+// at now, oldest first. Whatever the policy, it never returns reservations
+// created with an idempotency key, since deleting one frees the key and a
+// late retry would reserve again, nor the parts of a group that still has
+// unfinished parts, since the group would no longer be seen whole.
+func (p RetentionPolicy) Collectable(reservations []brokerv1alpha1.Reservation, now time.Time) []*brokerv1alpha1.Reservation {
+	if !p.Enabled() {
+		return nil
+	}
+
+	liveGroups := map[string]bool{}
+	for i := range reservations {
+		if groupID := reservations[i].Spec.GroupID; groupID != "" && !IsTerminal(reservations[i].Status.Phase) {
+			liveGroups[groupID] = true
+		}
+	}
+
+	var terminal []*brokerv1alpha1.Reservation
+	for i := range reservations {
+		reservation := &reservations[i]
+		if !IsTerminal(reservation.Status.Phase) ||
+			reservation.Annotations[brokerv1alpha1.ReservationIdempotencyKeyAnnotation] != "" ||
+			liveGroups[reservation.Spec.GroupID] {
+			continue
+		}
+		terminal = append(terminal, reservation)
+	}
+
+	// Most recently finished first, so the first KeepLast are retained
+	sort.SliceStable(terminal, func(i, j int) bool {
+		return FinishedAt(terminal[i]).After(FinishedAt(terminal[j]))
+	})
+
+	var collectable []*brokerv1alpha1.Reservation
+	for i, reservation := range terminal {
+		if i < p.KeepLast {
+			continue
+		}
+		if p.TTL > 0 && !now.After(FinishedAt(reservation).Add(p.TTL)) {
+			continue
+		}
+		collectable = append(collectable, reservation)
+	}
+
+	// Oldest first, so an interrupted collection removes the oldest history
+	for i, j := 0, len(collectable)-1; i < j; i, j = i+1, j-1 {
+		collectable[i], collectable[j] = collectable[j], collectable[i]
+	}
+	return collectable
+}
@@ -0,0 +1,117 @@
+package broker
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Helper to build a reservation that reached the given phase at finishedAt
+func makeFinishedReservation(name string, phase brokerv1alpha1.ReservationPhase, finishedAt time.Time) brokerv1alpha1.Reservation {
+	rsv := makeHeldReservation(name, "cluster-1", 0, "1000m", "1Gi", phase)
+	rsv.Status.LastUpdateTime = metav1.NewTime(finishedAt)
+	return *rsv
+}
+
+// Test: Terminal reservations are collected once past the TTL and outside the last N
+func TestRetentionPolicy_TTLAndKeepLast(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	reservations := []brokerv1alpha1.Reservation{
+		makeFinishedReservation("released-old", brokerv1alpha1.ReservationPhaseReleased, now.Add(-48*time.Hour)),
+		makeFinishedReservation("failed-old", brokerv1alpha1.ReservationPhaseFailed, now.Add(-30*time.Hour)),
+		makeFinishedReservation("preempted-old", brokerv1alpha1.ReservationPhasePreempted, now.Add(-26*time.Hour)),
+		makeFinishedReservation("released-recent", brokerv1alpha1.ReservationPhaseReleased, now.Add(-1*time.Hour)),
+		makeFinishedReservation("active-old", brokerv1alpha1.ReservationPhaseActive, now.Add(-72*time.Hour)),
+	}
+
+	cases := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{name: "disabled", policy: RetentionPolicy{}},
+		{name: "ttl", policy: RetentionPolicy{TTL: 24 * time.Hour},
+			want: []string{"released-old", "failed-old", "preempted-old"}},
+		{name: "keep last", policy: RetentionPolicy{KeepLast: 2},
+			want: []string{"released-old", "failed-old"}},
+		{name: "both", policy: RetentionPolicy{TTL: 24 * time.Hour, KeepLast: 3},
+			want: []string{"released-old"}},
+	}
+	for _, c := range cases {
+		got := c.policy.Collectable(reservations, now)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: expected %d collectable reservations, got %d", c.name, len(c.want), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i].Name != c.want[i] {
+				t.Errorf("%s: expected %s at position %d, got %s", c.name, c.want[i], i, got[i].Name)
+			}
+		}
+	}
+}
+
+// Test: Reservations created with an idempotency key and parts of unfinished groups are never collected
+func TestRetentionPolicy_KeepsKeyedReservationsAndLiveGroups(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+
+	keyed := makeFinishedReservation("keyed", brokerv1alpha1.ReservationPhaseReleased, old)
+	keyed.Annotations = map[string]string{brokerv1alpha1.ReservationIdempotencyKeyAnnotation: "key-1"}
+	livePart := makeFinishedReservation("live-group-0", brokerv1alpha1.ReservationPhaseFailed, old)
+	livePart.Spec.GroupID = "live-group"
+	liveSibling := makeFinishedReservation("live-group-1", brokerv1alpha1.ReservationPhaseActive, old)
+	liveSibling.Spec.GroupID = "live-group"
+	deadPart := makeFinishedReservation("dead-group-0", brokerv1alpha1.ReservationPhaseReleased, old)
+	deadPart.Spec.GroupID = "dead-group"
+	reservations := []brokerv1alpha1.Reservation{
+		keyed, livePart, liveSibling, deadPart,
+		makeFinishedReservation("plain", brokerv1alpha1.ReservationPhaseReleased, old.Add(-time.Hour)),
+	}
+
+	got := RetentionPolicy{TTL: 24 * time.Hour}.Collectable(reservations, now)
+	if len(got) != 2 || got[0].Name != "plain" || got[1].Name != "dead-group-0" {
+		var names []string
+		for _, reservation := range got {
+			names = append(names, reservation.Name)
+		}
+		t.Errorf("Expected only plain and dead-group-0 to be collected, got %v", names)
+	}
+}
+
+// Test: The file archive appends one JSON reservation per line
+func TestFileArchive_AppendsJSONLines(t *testing.T) {
+	archive := &FileArchive{Path: filepath.Join(t.TempDir(), "reservations.jsonl")}
+
+	for _, name := range []string{"rsv-1", "rsv-2"} {
+		rsv := makeFinishedReservation(name, brokerv1alpha1.ReservationPhaseReleased, time.Now())
+		if err := archive.Append(&rsv); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	file, err := os.Open(archive.Path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rsv brokerv1alpha1.Reservation
+		if err := json.Unmarshal(scanner.Bytes(), &rsv); err != nil {
+			t.Fatalf("failed to decode archived reservation: %v", err)
+		}
+		names = append(names, rsv.Name)
+	}
+	if len(names) != 2 || names[0] != "rsv-1" || names[1] != "rsv-2" {
+		t.Errorf("Expected rsv-1 and rsv-2 in order, got %v", names)
+	}
+}
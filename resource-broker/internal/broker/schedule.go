@@ -0,0 +1,168 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Window is the time span [Start, End) in which a reservation holds capacity.
+// A zero End means until the reservation is released.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// contains reports whether t falls inside the window
+func (w Window) contains(t time.Time) bool {
+	return !t.Before(w.Start) && (w.End.IsZero() || t.Before(w.End))
+}
+
+// overlaps reports whether the two windows share any instant
+func (w Window) overlaps(other Window) bool {
+	return (w.End.IsZero() || other.Start.Before(w.End)) &&
+		(other.End.IsZero() || w.Start.Before(other.End))
+}
+
+// ScheduledWindow returns the future window of a Scheduled reservation.
+// Returns false for reservations in any other phase.
+func ScheduledWindow(reservation *brokerv1alpha1.Reservation) (Window, bool) {
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseScheduled || reservation.Spec.StartTime == nil {
+		return Window{}, false
+	}
+	window := Window{Start: reservation.Spec.StartTime.Time}
+	if reservation.Spec.Duration != nil {
+		window.End = window.Start.Add(reservation.Spec.Duration.Duration)
+	}
+	return window, true
+}
+
+// WithScheduled returns copies of the clusters whose Reserved totals also
+// count the Scheduled reservations overlapping window. Their load is sampled
+// at every slice boundary inside the window, and each resource counts at its
+// busiest slice, so a request that fits a copy fits in every slice.
+// Locks held now count for the whole window, since they may be renewed.
+func WithScheduled(
+	clusters []brokerv1alpha1.ClusterAdvertisement,
+	reservations []brokerv1alpha1.Reservation,
+	window Window,
+) ([]brokerv1alpha1.ClusterAdvertisement, error) {
+
+	type slot struct {
+		window    Window
+		resources brokerv1alpha1.RequestedResourceQuantities
+	}
+	slotsByCluster := make(map[string][]slot)
+	for i := range reservations {
+		scheduled, ok := ScheduledWindow(&reservations[i])
+		if !ok || !scheduled.overlaps(window) {
+			continue
+		}
+		clusterID := reservations[i].Spec.TargetClusterID
+		slotsByCluster[clusterID] = append(slotsByCluster[clusterID],
+			slot{window: scheduled, resources: reservations[i].Spec.RequestedResources})
+	}
+
+	views := make([]brokerv1alpha1.ClusterAdvertisement, len(clusters))
+	for i := range clusters {
+		clusters[i].DeepCopyInto(&views[i])
+
+		slots := slotsByCluster[clusters[i].Spec.ClusterID]
+		if len(slots) == 0 {
+			continue
+		}
+
+		// Load only grows when a slot starts, so sampling the start of the
+		// window and of every slot inside it covers every slice
+		var peak brokerv1alpha1.RequestedResourceQuantities
+		for _, boundary := range slots {
+			at := boundary.window.Start
+			if at.Before(window.Start) {
+				at = window.Start
+			}
+
+			slice := &brokerv1alpha1.ClusterAdvertisement{}
+			for _, s := range slots {
+				if !s.window.contains(at) {
+					continue
+				}
+				if err := resourceutil.AddReservation(slice, s.resources); err != nil {
+					return nil, fmt.Errorf("failed to sum scheduled reservations: %w", err)
+				}
+			}
+			if slice.Spec.Resources.Reserved != nil {
+				raiseTo(&peak, slice.Spec.Resources.Reserved)
+			}
+		}
+
+		if err := resourceutil.AddReservation(&views[i], peak); err != nil {
+			return nil, fmt.Errorf("failed to apply scheduled reservations: %w", err)
+		}
+	}
+	return views, nil
+}
+
+// raiseTo raises every resource of peak to at least its amount in load
+func raiseTo(peak *brokerv1alpha1.RequestedResourceQuantities, load *brokerv1alpha1.ResourceQuantities) {
+	if load.CPU.Cmp(peak.CPU) > 0 {
+		peak.CPU = load.CPU.DeepCopy()
+	}
+	if load.Memory.Cmp(peak.Memory) > 0 {
+		peak.Memory = load.Memory.DeepCopy()
+	}
+	if load.GPU != nil && (peak.GPU == nil || load.GPU.Cmp(*peak.GPU) > 0) {
+		gpu := load.GPU.DeepCopy()
+		peak.GPU = &gpu
+	}
+	for name, qty := range load.Extended {
+		if current, ok := peak.Extended[name]; ok && current.Cmp(qty) >= 0 {
+			continue
+		}
+		if peak.Extended == nil {
+			peak.Extended = make(map[string]resource.Quantity)
+		}
+		peak.Extended[name] = qty.DeepCopy()
+	}
+}
+
+// ReservationPhaseField is the field index of Reservations by status.phase,
+// which lets decisions list only the Scheduled ones. The manager's cache must
+// register it (see IndexReservationPhase).
+const ReservationPhaseField = "status.phase"
+
+// IndexReservationPhase indexes a Reservation under its phase
+func IndexReservationPhase(obj client.Object) []string {
+	reservation, ok := obj.(*brokerv1alpha1.Reservation)
+	if !ok {
+		return nil
+	}
+	return []string{string(reservation.Status.Phase)}
+}
+
+// listClusters returns the cluster advertisements as seen by a request for
+// window: Scheduled reservations overlapping it count as reserved, including
+// those the cache does not show yet (see AssumeScheduled)
+func (d *DecisionEngine) listClusters(
+	ctx context.Context,
+	window Window,
+) ([]brokerv1alpha1.ClusterAdvertisement, error) {
+	advList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := d.Client.List(ctx, advList); err != nil {
+		return nil, fmt.Errorf("failed to list cluster advertisements: %w", err)
+	}
+
+	// Only Scheduled reservations hold future slots
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := d.Client.List(ctx, reservationList,
+		client.MatchingFields{ReservationPhaseField: string(brokerv1alpha1.ReservationPhaseScheduled)}); err != nil {
+		return nil, fmt.Errorf("failed to list scheduled reservations: %w", err)
+	}
+
+	return WithScheduled(advList.Items, d.withAssumed(ctx, reservationList.Items), window)
+}
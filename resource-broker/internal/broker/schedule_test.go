@@ -0,0 +1,171 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Helper to build a Scheduled reservation holding a slot in a future window
+func makeScheduledReservation(name, clusterID, cpu string, start time.Time, duration time.Duration) brokerv1alpha1.Reservation {
+	rsv := makeHeldReservation(name, clusterID, 0, cpu, "1Gi", brokerv1alpha1.ReservationPhaseScheduled)
+	rsv.Spec.StartTime = &metav1.Time{Time: start}
+	rsv.Spec.Duration = &metav1.Duration{Duration: duration}
+	return *rsv
+}
+
+// Test: A window sees the busiest slice of the Scheduled reservations it overlaps
+func TestWithScheduled_BusiestOverlappingSlice(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "16Gi", "4000m", "16Gi", true)
+	reservations := []brokerv1alpha1.Reservation{
+		makeScheduledReservation("a", "cluster-1", "2000m", day.Add(10*time.Hour), 2*time.Hour),
+		makeScheduledReservation("b", "cluster-1", "1000m", day.Add(11*time.Hour), 2*time.Hour),
+		makeScheduledReservation("c", "cluster-1", "3000m", day.Add(14*time.Hour), 1*time.Hour),
+		makeScheduledReservation("elsewhere", "cluster-2", "4000m", day.Add(10*time.Hour), 8*time.Hour),
+	}
+
+	cases := []struct {
+		name   string
+		window Window
+		want   string
+	}{
+		{name: "a and b overlap", window: Window{Start: day.Add(9 * time.Hour), End: day.Add(11*time.Hour + 30*time.Minute)}, want: "1"},
+		{name: "only b", window: Window{Start: day.Add(12*time.Hour + 30*time.Minute), End: day.Add(13*time.Hour + 30*time.Minute)}, want: "3"},
+		{name: "between slots", window: Window{Start: day.Add(13 * time.Hour), End: day.Add(14 * time.Hour)}, want: "4"},
+		{name: "open-ended", window: Window{Start: day.Add(9 * time.Hour)}, want: "1"},
+	}
+	for _, c := range cases {
+		views, err := WithScheduled([]brokerv1alpha1.ClusterAdvertisement{*cluster}, reservations, c.window)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if got := views[0].Spec.Resources.Available.CPU; got.Cmp(resource.MustParse(c.want)) != 0 {
+			t.Errorf("%s: expected %s CPU available, got %s", c.name, c.want, got.String())
+		}
+	}
+
+	if cluster.Spec.Resources.Reserved != nil {
+		t.Error("Expected the original cluster to be left untouched")
+	}
+}
+
+// Test: Immediate requests cannot take capacity promised to a future window
+func TestSelectBestCluster_RespectsScheduledSlots(t *testing.T) {
+	cluster1 := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	cluster2 := makeClusterAdvertisement("cluster-2-adv", "cluster-2", "2000m", "8Gi", "2000m", "8Gi", true)
+	slot := makeScheduledReservation("nightly", "cluster-1", "3000m", time.Now().Add(24*time.Hour), 4*time.Hour)
+
+	engine := &DecisionEngine{Client: createFakeClientWithStatus(cluster1, cluster2, &slot)}
+
+	best, err := engine.SelectBestCluster(context.Background(), PlacementRequest{Resources: makeRequest("2000m", "1Gi")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if best.Spec.ClusterID != "cluster-2" {
+		t.Errorf("Expected cluster-2, since cluster-1 promised 3 of its 4 CPUs, got %s", best.Spec.ClusterID)
+	}
+
+	// After the slot ends, cluster-1 has room again
+	later := time.Now().Add(30 * time.Hour)
+	best, err = engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("3000m", "1Gi"),
+		Window:    Window{Start: later, End: later.Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if best.Spec.ClusterID != "cluster-1" {
+		t.Errorf("Expected cluster-1 after the slot, got %s", best.Spec.ClusterID)
+	}
+}
+
+// Test: A given cluster is only selected if it has room in the window
+func TestSelectBestCluster_RestrictedToCluster(t *testing.T) {
+	cluster1 := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	cluster2 := makeClusterAdvertisement("cluster-2-adv", "cluster-2", "8000m", "8Gi", "8000m", "8Gi", true)
+	start := time.Now().Add(24 * time.Hour)
+	slot := makeScheduledReservation("nightly", "cluster-1", "3000m", start, 4*time.Hour)
+
+	engine := &DecisionEngine{Client: createFakeClientWithStatus(cluster1, cluster2, &slot)}
+	window := Window{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)}
+
+	if _, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("2000m", "1Gi"),
+		Window:    window,
+		ClusterID: "cluster-1",
+	}); err == nil {
+		t.Error("Expected cluster-1 to be rejected, since 3 of its 4 CPUs are promised in the window")
+	}
+
+	best, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("1000m", "1Gi"),
+		Window:    window,
+		ClusterID: "cluster-1",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if best.Spec.ClusterID != "cluster-1" {
+		t.Errorf("Expected cluster-1, got %s", best.Spec.ClusterID)
+	}
+
+	if _, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("1000m", "1Gi"),
+		ClusterID: "missing",
+	}); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("Expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+// Test: A reservation just admitted as Scheduled counts before the cache shows it
+func TestSelectBestCluster_CountsAssumedScheduled(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	engine := &DecisionEngine{Client: createFakeClientWithStatus(cluster)}
+
+	start := time.Now().Add(24 * time.Hour)
+	slot := makeScheduledReservation("nightly", "cluster-1", "3000m", start, 4*time.Hour)
+	engine.AssumeScheduled(&slot)
+
+	request := PlacementRequest{
+		Resources: makeRequest("2000m", "1Gi"),
+		Window:    Window{Start: start, End: start.Add(time.Hour)},
+	}
+	if _, err := engine.SelectBestCluster(context.Background(), request); err == nil {
+		t.Fatal("Expected the assumed slot to leave no room")
+	}
+
+	// Once the cache shows the reservation in another phase, it counts as listed
+	slot.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+	if err := engine.Client.Create(context.Background(), &slot); err != nil {
+		t.Fatalf("Failed to create reservation: %v", err)
+	}
+	if _, err := engine.SelectBestCluster(context.Background(), request); err != nil {
+		t.Errorf("Expected room once the slot failed, got %v", err)
+	}
+}
+
+// Test: An immediate request with a duration is not held back by slots starting after it ends
+func TestSelectBestCluster_DurationEndsBeforeSlot(t *testing.T) {
+	cluster := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "4000m", "8Gi", "4000m", "8Gi", true)
+	slot := makeScheduledReservation("nightly", "cluster-1", "3000m", time.Now().Add(2*time.Hour), 4*time.Hour)
+	engine := &DecisionEngine{Client: createFakeClientWithStatus(cluster, &slot)}
+
+	if _, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("2000m", "1Gi"),
+		Duration:  time.Hour,
+	}); err != nil {
+		t.Errorf("Expected a one-hour request to fit before the slot, got %v", err)
+	}
+
+	if _, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		Resources: makeRequest("2000m", "1Gi"),
+	}); err == nil {
+		t.Error("Expected a request without duration to see the slot")
+	}
+}
@@ -0,0 +1,174 @@
+package broker
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// DefaultScoringStrategy is used when neither the engine nor the reservation selects one
+const DefaultScoringStrategy = brokerv1alpha1.ScoringStrategyLeastAllocated
+
+// ScoringStrategy ranks clusters that already passed filtering.
+// Higher score = better choice. Scores are only compared within one strategy.
+type ScoringStrategy interface {
+	// Name returns the strategy identifier used in flags and Reservation specs
+	Name() brokerv1alpha1.ScoringStrategyType
+
+	// Score rates the cluster for the requested resources
+	Score(cluster *brokerv1alpha1.ClusterAdvertisement, requested brokerv1alpha1.RequestedResourceQuantities) float64
+}
+
+// NewScoringStrategy returns the built-in strategy with the given name.
+// An empty name selects DefaultScoringStrategy.
+func NewScoringStrategy(name brokerv1alpha1.ScoringStrategyType) (ScoringStrategy, error) {
+	switch name {
+	case "", brokerv1alpha1.ScoringStrategyLeastAllocated:
+		return LeastAllocated{}, nil
+	case brokerv1alpha1.ScoringStrategyMostAllocated:
+		return MostAllocated{}, nil
+	case brokerv1alpha1.ScoringStrategyLowestCost:
+		return LowestCost{}, nil
+	case brokerv1alpha1.ScoringStrategyBalancedResource:
+		return BalancedResource{}, nil
+	default:
+		return nil, fmt.Errorf("unknown scoring strategy %q (supported: %s, %s, %s, %s)", name,
+			brokerv1alpha1.ScoringStrategyLeastAllocated,
+			brokerv1alpha1.ScoringStrategyMostAllocated,
+			brokerv1alpha1.ScoringStrategyLowestCost,
+			brokerv1alpha1.ScoringStrategyBalancedResource)
+	}
+}
+
+// LeastAllocated spreads load by preferring the cluster with the most
+// headroom left after fulfilling the request.
+type LeastAllocated struct{}
+
+// Name implements ScoringStrategy
+func (LeastAllocated) Name() brokerv1alpha1.ScoringStrategyType {
+	return brokerv1alpha1.ScoringStrategyLeastAllocated
+}
+
+// Score is the average free fraction of CPU and memory after the reservation (0-1)
+func (LeastAllocated) Score(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) float64 {
+	cpuFree, memoryFree := freeFractionsAfter(cluster, requested)
+	return (cpuFree + memoryFree) / 2
+}
+
+// MostAllocated bin-packs by preferring the cluster with the least headroom
+// left after fulfilling the request, keeping other providers free.
+type MostAllocated struct{}
+
+// Name implements ScoringStrategy
+func (MostAllocated) Name() brokerv1alpha1.ScoringStrategyType {
+	return brokerv1alpha1.ScoringStrategyMostAllocated
+}
+
+// Score is the average used fraction of CPU and memory after the reservation (0-1)
+func (MostAllocated) Score(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) float64 {
+	cpuFree, memoryFree := freeFractionsAfter(cluster, requested)
+	return ((1 - cpuFree) + (1 - memoryFree)) / 2
+}
+
+// LowestCost prefers the cluster with the lowest advertised hourly price for
+// the request. Clusters that publish no usable cost information rank last.
+// Currencies are not converted; providers are expected to agree on one.
+type LowestCost struct{}
+
+// Name implements ScoringStrategy
+func (LowestCost) Name() brokerv1alpha1.ScoringStrategyType {
+	return brokerv1alpha1.ScoringStrategyLowestCost
+}
+
+// Score maps the estimated hourly cost into (0-1], cheaper = higher
+func (LowestCost) Score(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) float64 {
+	cost, ok := estimateHourlyCost(cluster.Spec.Cost, requested)
+	if !ok {
+		return 0
+	}
+	return 1 / (1 + cost)
+}
+
+// BalancedResource prefers the cluster whose CPU and memory usage stay
+// closest to each other after the reservation, avoiding stranded capacity.
+type BalancedResource struct{}
+
+// Name implements ScoringStrategy
+func (BalancedResource) Name() brokerv1alpha1.ScoringStrategyType {
+	return brokerv1alpha1.ScoringStrategyBalancedResource
+}
+
+// Score is 1 minus the difference between CPU and memory free fractions (0-1)
+func (BalancedResource) Score(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) float64 {
+	cpuFree, memoryFree := freeFractionsAfter(cluster, requested)
+	return 1 - math.Abs(cpuFree-memoryFree)
+}
+
+// freeFractionsAfter returns the fraction of allocatable CPU and memory that
+// would still be available after the reservation, clamped to 0-1.
+func freeFractionsAfter(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) (cpuFree, memoryFree float64) {
+	resources := cluster.Spec.Resources
+
+	cpuFree = freeFraction(
+		resources.Allocatable.CPU.AsApproximateFloat64(),
+		resources.Available.CPU.AsApproximateFloat64(),
+		requested.CPU.AsApproximateFloat64(),
+	)
+	memoryFree = freeFraction(
+		resources.Allocatable.Memory.AsApproximateFloat64(),
+		resources.Available.Memory.AsApproximateFloat64(),
+		requested.Memory.AsApproximateFloat64(),
+	)
+	return cpuFree, memoryFree
+}
+
+func freeFraction(allocatable, available, requested float64) float64 {
+	if allocatable <= 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (available-requested)/allocatable))
+}
+
+// estimateHourlyCost prices the request using the cluster's per-core-hour and
+// per-GB-hour rates. Returns false when the cluster publishes no usable rates.
+func estimateHourlyCost(
+	cost *brokerv1alpha1.CostInfo,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) (float64, bool) {
+	if cost == nil {
+		return 0, false
+	}
+
+	cpuRate, cpuErr := strconv.ParseFloat(cost.CPUCost, 64)
+	memoryRate, memoryErr := strconv.ParseFloat(cost.MemoryCost, 64)
+	if cpuErr != nil && memoryErr != nil {
+		return 0, false
+	}
+
+	total := 0.0
+	if cpuErr == nil {
+		total += cpuRate * requested.CPU.AsApproximateFloat64()
+	}
+	if memoryErr == nil {
+		const bytesPerGB = 1 << 30
+		total += memoryRate * requested.Memory.AsApproximateFloat64() / bytesPerGB
+	}
+	return total, true
+}
@@ -0,0 +1,149 @@
+package broker
+
+import (
+	"context"
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Helper to build a request for scoring tests
+func makeRequest(cpu, memory string) brokerv1alpha1.RequestedResourceQuantities {
+	return brokerv1alpha1.RequestedResourceQuantities{
+		CPU:    resource.MustParse(cpu),
+		Memory: resource.MustParse(memory),
+	}
+}
+
+// Test: Every built-in strategy name resolves, empty name selects the default
+func TestNewScoringStrategy_BuiltIns(t *testing.T) {
+	names := []brokerv1alpha1.ScoringStrategyType{
+		brokerv1alpha1.ScoringStrategyLeastAllocated,
+		brokerv1alpha1.ScoringStrategyMostAllocated,
+		brokerv1alpha1.ScoringStrategyLowestCost,
+		brokerv1alpha1.ScoringStrategyBalancedResource,
+	}
+
+	for _, name := range names {
+		strategy, err := NewScoringStrategy(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", name, err)
+		}
+		if strategy.Name() != name {
+			t.Errorf("expected strategy %s, got %s", name, strategy.Name())
+		}
+	}
+
+	strategy, err := NewScoringStrategy("")
+	if err != nil {
+		t.Fatalf("unexpected error for empty name: %v", err)
+	}
+	if strategy.Name() != DefaultScoringStrategy {
+		t.Errorf("expected default strategy %s, got %s", DefaultScoringStrategy, strategy.Name())
+	}
+}
+
+// Test: Unknown strategy names are rejected
+func TestNewScoringStrategy_Unknown(t *testing.T) {
+	if _, err := NewScoringStrategy("Random"); err == nil {
+		t.Error("expected error for unknown strategy, got nil")
+	}
+}
+
+// Test: LeastAllocated and MostAllocated rank the same clusters in opposite order
+func TestScoringStrategies_SpreadVersusBinPack(t *testing.T) {
+	// 25% of allocatable available vs 75% of allocatable available
+	full := makeClusterAdvertisement("full", "full", "4000m", "8Gi", "1000m", "2Gi", true)
+	empty := makeClusterAdvertisement("empty", "empty", "4000m", "8Gi", "3000m", "6Gi", true)
+	request := makeRequest("500m", "1Gi")
+
+	spread := LeastAllocated{}
+	if spread.Score(empty, request) <= spread.Score(full, request) {
+		t.Error("expected LeastAllocated to prefer the emptier cluster")
+	}
+
+	binPack := MostAllocated{}
+	if binPack.Score(full, request) <= binPack.Score(empty, request) {
+		t.Error("expected MostAllocated to prefer the fuller cluster")
+	}
+}
+
+// Test: LowestCost prefers the cheaper cluster and ranks unpriced clusters last
+func TestLowestCost_PrefersCheaperCluster(t *testing.T) {
+	cheap := makeClusterAdvertisement("cheap", "cheap", "4000m", "8Gi", "2000m", "4Gi", true)
+	cheap.Spec.Cost = &brokerv1alpha1.CostInfo{CPUCost: "0.02", MemoryCost: "0.005", Currency: "EUR"}
+
+	expensive := makeClusterAdvertisement("expensive", "expensive", "4000m", "8Gi", "2000m", "4Gi", true)
+	expensive.Spec.Cost = &brokerv1alpha1.CostInfo{CPUCost: "0.10", MemoryCost: "0.01", Currency: "EUR"}
+
+	unpriced := makeClusterAdvertisement("unpriced", "unpriced", "4000m", "8Gi", "2000m", "4Gi", true)
+
+	request := makeRequest("1000m", "2Gi")
+	strategy := LowestCost{}
+
+	cheapScore := strategy.Score(cheap, request)
+	expensiveScore := strategy.Score(expensive, request)
+	unpricedScore := strategy.Score(unpriced, request)
+
+	if cheapScore <= expensiveScore {
+		t.Errorf("expected cheap cluster to score higher, got cheap=%f, expensive=%f", cheapScore, expensiveScore)
+	}
+	if unpricedScore >= expensiveScore {
+		t.Errorf("expected unpriced cluster to rank last, got unpriced=%f, expensive=%f", unpricedScore, expensiveScore)
+	}
+}
+
+// Test: BalancedResource prefers the cluster whose CPU and memory stay even
+func TestBalancedResource_PrefersEvenUsage(t *testing.T) {
+	// balanced: 50% CPU and 50% memory available
+	balanced := makeClusterAdvertisement("balanced", "balanced", "4000m", "8Gi", "2000m", "4Gi", true)
+	// skewed: 90% CPU but only 20% memory available
+	skewed := makeClusterAdvertisement("skewed", "skewed", "10000m", "10Gi", "9000m", "2Gi", true)
+
+	request := makeRequest("500m", "1Gi")
+	strategy := BalancedResource{}
+
+	if strategy.Score(balanced, request) <= strategy.Score(skewed, request) {
+		t.Error("expected BalancedResource to prefer the cluster with even CPU/memory usage")
+	}
+}
+
+// Test: A per-request strategy overrides the engine default
+func TestSelectBestCluster_RequestStrategyOverridesDefault(t *testing.T) {
+	full := makeClusterAdvertisement("full-adv", "full", "4000m", "8Gi", "1000m", "2Gi", true)
+	empty := makeClusterAdvertisement("empty-adv", "empty", "4000m", "8Gi", "3000m", "6Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(full, empty), Strategy: LeastAllocated{}}
+
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("500m", "1Gi"),
+		Strategy:    brokerv1alpha1.ScoringStrategyMostAllocated,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Spec.ClusterID != "full" {
+		t.Errorf("expected MostAllocated override to pick full, got %s", result.Spec.ClusterID)
+	}
+}
+
+// Test: The engine strategy is used when the request does not override it
+func TestSelectBestCluster_UsesEngineStrategy(t *testing.T) {
+	full := makeClusterAdvertisement("full-adv", "full", "4000m", "8Gi", "1000m", "2Gi", true)
+	empty := makeClusterAdvertisement("empty-adv", "empty", "4000m", "8Gi", "3000m", "6Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(full, empty), Strategy: MostAllocated{}}
+
+	result, err := engine.SelectBestCluster(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("500m", "1Gi"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Spec.ClusterID != "full" {
+		t.Errorf("expected engine MostAllocated strategy to pick full, got %s", result.Spec.ClusterID)
+	}
+}
@@ -0,0 +1,158 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	resourceutil "github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+var (
+	// ErrSplitNotPossible is returned when the providers together cannot fit the request
+	ErrSplitNotPossible = errors.New("request cannot be split across the available clusters")
+
+	// ErrSplitUnsupported is returned for requests that cannot be divided
+	ErrSplitUnsupported = errors.New("only CPU and memory requests can be split; GPUs and extended resources cannot")
+)
+
+// ValidateMinChunk checks that the minimum chunk fits inside the request
+func ValidateMinChunk(requested, minChunk brokerv1alpha1.RequestedResourceQuantities) error {
+	if minChunk.CPU.Sign() < 0 || minChunk.Memory.Sign() < 0 {
+		return errors.New("minimum chunk must not be negative")
+	}
+	if minChunk.CPU.Cmp(requested.CPU) > 0 || minChunk.Memory.Cmp(requested.Memory) > 0 {
+		return errors.New("minimum chunk must not exceed the requested resources")
+	}
+	return nil
+}
+
+// PlanSplit divides a CPU/memory request across several providers. Every part
+// keeps the request's CPU-to-memory ratio and is at least minChunk in size.
+// Clusters that can take the largest share go first to keep the number of
+// parts low; the scoring strategy breaks ties.
+func (d *DecisionEngine) PlanSplit(
+	ctx context.Context,
+	request PlacementRequest,
+	minChunk brokerv1alpha1.RequestedResourceQuantities,
+) ([]GroupPart, error) {
+
+	if request.Resources.GPU != nil && request.Resources.GPU.Sign() > 0 || len(request.Resources.Extended) > 0 {
+		return nil, ErrSplitUnsupported
+	}
+
+	strategy, err := d.strategyFor(request)
+	if err != nil {
+		return nil, err
+	}
+
+	placement, err := compilePlacement(request.Placement)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := d.listClusters(ctx, request.window())
+	if err != nil {
+		return nil, err
+	}
+
+	requestedCPU := request.Resources.CPU.MilliValue()
+	requestedMemory := request.Resources.Memory.Value()
+	if requestedCPU <= 0 || requestedMemory <= 0 {
+		return nil, ErrSplitNotPossible
+	}
+
+	// Smallest share of the request a part may hold
+	minFraction := math.Max(
+		float64(minChunk.CPU.MilliValue())/float64(requestedCPU),
+		float64(minChunk.Memory.Value())/float64(requestedMemory),
+	)
+
+	type candidate struct {
+		cluster  *brokerv1alpha1.ClusterAdvertisement
+		fraction float64
+		score    float64
+	}
+
+	var candidates []candidate
+	for i := range clusters {
+		cluster := &clusters[i]
+
+		if cluster.Spec.ClusterID == request.RequesterID || !cluster.Status.Active || !placement.matches(cluster) {
+			continue
+		}
+
+		available := cluster.Spec.Resources.Available
+		fraction := math.Min(
+			float64(available.CPU.MilliValue())/float64(requestedCPU),
+			float64(available.Memory.Value())/float64(requestedMemory),
+		)
+		if fraction <= 0 || fraction < minFraction {
+			continue
+		}
+
+		candidates = append(candidates, candidate{
+			cluster:  cluster,
+			fraction: fraction,
+			score:    strategy.Score(cluster, minChunk) + placement.preferenceScore(cluster),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].fraction != candidates[j].fraction {
+			return candidates[i].fraction > candidates[j].fraction
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	var parts []GroupPart
+	remainingCPU, remainingMemory := requestedCPU, requestedMemory
+	remainingFraction := 1.0
+
+	for _, c := range candidates {
+		var cpu, memory int64
+		if c.fraction >= remainingFraction {
+			// Last part takes exactly what is left
+			cpu, memory = remainingCPU, remainingMemory
+		} else {
+			// Leave at least one minimum chunk for the remaining providers
+			share := math.Min(c.fraction, remainingFraction-minFraction)
+			if share <= 0 || share < minFraction {
+				continue
+			}
+			cpu = int64(math.Floor(share * float64(requestedCPU)))
+			memory = int64(math.Floor(share * float64(requestedMemory)))
+		}
+		if cpu <= 0 || memory <= 0 {
+			continue
+		}
+
+		part := GroupPart{
+			Cluster: c.cluster,
+			Resources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU:    *resource.NewMilliQuantity(cpu, resource.DecimalSI),
+				Memory: *resource.NewQuantity(memory, resource.BinarySI),
+			},
+		}
+		if !resourceutil.CanReserve(c.cluster, part.Resources) {
+			continue
+		}
+
+		parts = append(parts, part)
+		remainingCPU -= cpu
+		remainingMemory -= memory
+		remainingFraction = math.Max(
+			float64(remainingCPU)/float64(requestedCPU),
+			float64(remainingMemory)/float64(requestedMemory),
+		)
+		if remainingCPU <= 0 && remainingMemory <= 0 {
+			return parts, nil
+		}
+	}
+
+	return nil, ErrSplitNotPossible
+}
@@ -0,0 +1,57 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Test: A request too large for any single cluster is split and the parts add up to it
+func TestPlanSplit_DividesAcrossClusters(t *testing.T) {
+	cluster1 := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "8000m", "16Gi", "3000m", "6Gi", true)
+	cluster2 := makeClusterAdvertisement("cluster-2-adv", "cluster-2", "8000m", "16Gi", "2000m", "4Gi", true)
+	cluster3 := makeClusterAdvertisement("cluster-3-adv", "cluster-3", "8000m", "16Gi", "1000m", "2Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster1, cluster2, cluster3)}
+
+	parts, err := engine.PlanSplit(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("4000m", "8Gi"),
+	}, makeRequest("500m", "1Gi"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The two largest clusters are enough
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	if parts[0].Cluster.Spec.ClusterID != "cluster-1" || parts[1].Cluster.Spec.ClusterID != "cluster-2" {
+		t.Errorf("expected parts on cluster-1 and cluster-2, got %s and %s",
+			parts[0].Cluster.Spec.ClusterID, parts[1].Cluster.Spec.ClusterID)
+	}
+
+	totalCPU := parts[0].Resources.CPU.DeepCopy()
+	totalCPU.Add(parts[1].Resources.CPU)
+	totalMemory := parts[0].Resources.Memory.DeepCopy()
+	totalMemory.Add(parts[1].Resources.Memory)
+	if totalCPU.MilliValue() != 4000 || totalMemory.Cmp(makeRequest("0", "8Gi").Memory) != 0 {
+		t.Errorf("expected parts to add up to 4 CPU / 8Gi, got %s / %s", totalCPU.String(), totalMemory.String())
+	}
+}
+
+// Test: Clusters that can't hold a minimum chunk are not used
+func TestPlanSplit_RespectsMinChunk(t *testing.T) {
+	cluster1 := makeClusterAdvertisement("cluster-1-adv", "cluster-1", "8000m", "16Gi", "3000m", "6Gi", true)
+	cluster2 := makeClusterAdvertisement("cluster-2-adv", "cluster-2", "8000m", "16Gi", "1000m", "2Gi", true)
+
+	engine := &DecisionEngine{Client: createFakeClient(cluster1, cluster2)}
+
+	_, err := engine.PlanSplit(context.Background(), PlacementRequest{
+		RequesterID: "cluster-0",
+		Resources:   makeRequest("4000m", "8Gi"),
+	}, makeRequest("2000m", "4Gi"))
+	if !errors.Is(err, ErrSplitNotPossible) {
+		t.Fatalf("expected ErrSplitNotPossible, got %v", err)
+	}
+}
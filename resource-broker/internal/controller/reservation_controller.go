@@ -23,19 +23,20 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
-	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
 )
 
 // ReservationReconciler reconciles a Reservation object
@@ -43,12 +44,37 @@ type ReservationReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	DecisionEngine *broker.DecisionEngine
+
+	// HeartbeatTimeout orphans Active reservations whose requester sent no
+	// heartbeat for this long (0 disables heartbeat checks)
+	HeartbeatTimeout time.Duration
+
+	// OrphanGracePeriod is how long an Orphaned reservation keeps its
+	// resources before it is released
+	OrphanGracePeriod time.Duration
+
+	// RequireProviderAck keeps activated reservations Reserved until their
+	// provider accepted them
+	RequireProviderAck bool
+
+	// RejectDirectReservations fails reservations created directly through
+	// the Kubernetes API instead of deciding them, for brokers that serve
+	// agents only through the API (HTTP, gRPC or MQTT)
+	RejectDirectReservations bool
+
+	// MaxLifetime caps the total lifetime, across renewals, of reservations
+	// created or renewed through the Kubernetes API (0 = unlimited)
+	MaxLifetime time.Duration
 }
 
-var (
-	errTargetClusterNotFound = errors.New("target cluster not found")
-	errInsufficientResources = errors.New("insufficient resources")
-)
+// queueRetryInterval is the fallback retry for queued reservations in case
+// no ClusterAdvertisement change arrives to trigger one
+const queueRetryInterval = 1 * time.Minute
+
+// apiDecisionTimeout is how long a reservation created by the broker's API is
+// left to the API to decide. One still in the initial phase after that was
+// abandoned (e.g. the broker restarted mid-request) and is decided here.
+const apiDecisionTimeout = 1 * time.Minute
 
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations/status,verbs=get;update;patch
@@ -112,21 +138,32 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	if _, ok := reservation.Annotations[brokerv1alpha1.ReservationRenewalRequestAnnotation]; ok {
+		return r.handleRenewalRequest(ctx, reservation, logger)
+	}
+
 	// Handle different phases
 	switch reservation.Status.Phase {
 	case "": // New reservation
-		return r.handlePendingReservation(ctx, reservation, logger)
+		return r.handleNewReservation(ctx, reservation, logger)
 
 	case brokerv1alpha1.ReservationPhasePending:
 		return r.handlePendingReservation(ctx, reservation, logger)
 
+	case brokerv1alpha1.ReservationPhaseScheduled:
+		return r.handleScheduledReservation(ctx, reservation, logger)
+
 	case brokerv1alpha1.ReservationPhaseReserved:
 		return r.handleReservedReservation(ctx, reservation, logger)
 
 	case brokerv1alpha1.ReservationPhaseActive:
 		return r.handleActiveReservation(ctx, reservation, logger)
 
-	case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhaseReleased:
+	case brokerv1alpha1.ReservationPhaseOrphaned:
+		return r.handleOrphanedReservation(ctx, reservation, logger)
+
+	case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhaseReleased,
+		brokerv1alpha1.ReservationPhasePreempted:
 		// Terminal states - no action needed
 		return ctrl.Result{}, nil
 	}
@@ -134,6 +171,112 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{}, nil
 }
 
+// handleRenewalRequest applies a renewal requested through the Kubernetes API
+// with the renewal-request annotation, exactly as the broker's API renews,
+// records the outcome in the Renewed condition and removes the annotation
+func (r *ReservationReconciler) handleRenewalRequest(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	// An empty request renews by the reservation's own duration
+	var extension time.Duration
+	var renewErr error
+	if requested := reservation.Annotations[brokerv1alpha1.ReservationRenewalRequestAnnotation]; requested != "" {
+		d, err := time.ParseDuration(requested)
+		if err != nil || d <= 0 {
+			renewErr = fmt.Errorf("invalid duration: %s", requested)
+		}
+		extension = d
+	}
+
+	var expiresAt time.Time
+	if renewErr == nil {
+		expiresAt, renewErr = broker.RenewedReservationExpiry(reservation, extension, time.Now(), r.MaxLifetime)
+	}
+
+	condition := metav1.Condition{
+		Type:   brokerv1alpha1.ReservationConditionRenewed,
+		Status: metav1.ConditionTrue,
+		Reason: "RenewedByRequester",
+	}
+	if renewErr != nil {
+		logger.Info("Renewal rejected", "reservation", reservation.Name, "reason", renewErr.Error())
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "RenewalRejected"
+		condition.Message = fmt.Sprintf("Reservation cannot be renewed: %v", renewErr)
+	} else {
+		logger.Info("Requester renewed reservation", "reservation", reservation.Name, "expiresAt", expiresAt)
+		renewed := metav1.NewTime(expiresAt)
+		reservation.Status.ExpiresAt = &renewed
+		condition.Message = fmt.Sprintf("Renewed until %s", expiresAt.UTC().Format(time.RFC3339))
+	}
+
+	// The previous outcome is replaced even if it had the same status
+	meta.RemoveStatusCondition(&reservation.Status.Conditions, brokerv1alpha1.ReservationConditionRenewed)
+	meta.SetStatusCondition(&reservation.Status.Conditions, condition)
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Removing the request tells the requester the outcome is recorded
+	delete(reservation.Annotations, brokerv1alpha1.ReservationRenewalRequestAnnotation)
+	if err := r.Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// handleNewReservation decides a reservation created directly through the
+// Kubernetes API. Reservations created by the broker's API are decided and
+// locked by it; they are only taken over once the API abandoned them, and
+// are not locked again if the API marked them locked.
+func (r *ReservationReconciler) handleNewReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	if _, ok := reservation.Annotations[brokerv1alpha1.ReservationDecidedByAPIAnnotation]; ok {
+		if wait := time.Until(reservation.CreationTimestamp.Add(apiDecisionTimeout)); wait > 0 {
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		if _, locked := reservation.Annotations[brokerv1alpha1.ReservationLockedByAPIAnnotation]; locked {
+			logger.Info("Reservation locked but left unmarked by the API, marking it Reserved",
+				"reservation", reservation.Name)
+			return r.markReserved(ctx, reservation, nil, logger)
+		}
+		logger.Info("Reservation left undecided by the API, deciding it", "reservation", reservation.Name)
+		return r.handlePendingReservation(ctx, reservation, logger)
+	}
+
+	if duration := reservation.Spec.Duration; duration != nil && r.MaxLifetime > 0 && duration.Duration > r.MaxLifetime {
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = fmt.Sprintf("Duration %s exceeds the maximum reservation lifetime of %s",
+			duration.Duration, r.MaxLifetime)
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if r.RejectDirectReservations {
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = "Reservations cannot be created through the Kubernetes API on this broker. " +
+			"Use the broker's HTTP, gRPC or MQTT interface."
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	return r.handlePendingReservation(ctx, reservation, logger)
+}
+
 // handlePendingReservation processes a new reservation request
 func (r *ReservationReconciler) handlePendingReservation(
 	ctx context.Context,
@@ -141,21 +284,65 @@ func (r *ReservationReconciler) handlePendingReservation(
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 
+	// Released while still queued: nothing is locked, just leave the queue
+	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		return r.releaseForRequester(ctx, reservation, logger)
+	}
+
+	defer r.DecisionEngine.BeginAdmission()()
+
+	// A future window only holds a slot until it opens
+	if reservation.Spec.StartTime != nil && time.Now().Before(reservation.Spec.StartTime.Time) {
+		return r.scheduleReservation(ctx, reservation, logger)
+	}
+
 	// If TargetClusterID is already specified, use it
 	if reservation.Spec.TargetClusterID != "" {
 		return r.reserveInTargetCluster(ctx, reservation, logger)
 	}
 
 	// Otherwise, select best cluster based on decision engine
-	bestCluster, err := r.DecisionEngine.SelectBestCluster(
-		ctx,
-		reservation.Spec.RequesterID,
-		reservation.Spec.RequestedResources.CPU,
-		reservation.Spec.RequestedResources.Memory,
-		reservation.Spec.Priority,
-	)
+	placementRequest := broker.PlacementRequest{
+		RequesterID: reservation.Spec.RequesterID,
+		Resources:   reservation.Spec.RequestedResources,
+		Strategy:    reservation.Spec.ScoringStrategy,
+		Placement:   reservation.Spec.Placement,
+	}
+	if reservation.Spec.Duration != nil {
+		placementRequest.Duration = reservation.Spec.Duration.Duration
+	}
+	bestCluster, decision, err := r.DecisionEngine.SelectBestClusterWithRecord(ctx, placementRequest)
+
+	// Capacity a queued reservation ranked ahead fits in is left to it
+	var ahead *brokerv1alpha1.Reservation
+	if err == nil {
+		if ahead, err = r.DecisionEngine.QueuedAhead(ctx, reservation, bestCluster); err != nil {
+			return ctrl.Result{}, err
+		}
+		if ahead != nil {
+			err = broker.LeaveToQueue(decision, bestCluster, ahead)
+		}
+	}
+	decisionChanged := recordDecision(reservation, decision)
 
 	if err != nil {
+		if r.DecisionEngine.Preemption && ahead == nil {
+			plan, planErr := r.DecisionEngine.PlanPreemption(ctx, placementRequest, reservation.Spec.Priority)
+			if planErr == nil {
+				if decision != nil {
+					decision.SelectedClusterID = plan.Cluster.Spec.ClusterID
+					decision.Message = fmt.Sprintf("No cluster fits; placed on %s by preempting %d reservation(s)",
+						plan.Cluster.Spec.ClusterID, len(plan.Victims))
+				}
+				return r.reserveByPreemption(ctx, reservation, plan, logger)
+			}
+			logger.Info("Preemption not possible", "reservation", reservation.Name, "reason", planErr.Error())
+		}
+
+		if reservation.Spec.Queue {
+			return r.queueReservation(ctx, reservation, decisionChanged, logger)
+		}
+
 		logger.Error(err, "failed to select cluster",
 			"requesterID", reservation.Spec.RequesterID,
 			"requestedCPU", reservation.Spec.RequestedResources.CPU.String(),
@@ -165,6 +352,9 @@ func (r *ReservationReconciler) handlePendingReservation(
 			"Ensure clusters are registered, active, and have sufficient available resources.",
 			reservation.Spec.RequestedResources.CPU.String(),
 			reservation.Spec.RequestedResources.Memory.String())
+		if ahead != nil {
+			reservation.Status.Message = fmt.Sprintf("%v. Set spec.queue to wait for capacity.", err)
+		}
 		reservation.Status.LastUpdateTime = metav1.Now()
 
 		if err := r.Status().Update(ctx, reservation); err != nil {
@@ -173,12 +363,14 @@ func (r *ReservationReconciler) handlePendingReservation(
 		return ctrl.Result{}, nil
 	}
 
-	// Update reservation with selected cluster
+	// Update reservation with selected cluster; the spec update returns the
+	// stored status, so the decision is restored for the next status write
 	reservation.Spec.TargetClusterID = bestCluster.Spec.ClusterID
 	if err := r.Update(ctx, reservation); err != nil {
 		logger.Error(err, "Failed to update reservation with target cluster")
 		return ctrl.Result{}, err
 	}
+	reservation.Status.Decision = decision
 
 	return r.reserveInTargetCluster(ctx, reservation, logger)
 }
@@ -190,36 +382,11 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 
-	var lockedCluster *brokerv1alpha1.ClusterAdvertisement
-
-	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		clusterAdv, err := r.findClusterByID(ctx, reservation.Spec.TargetClusterID)
-		if err != nil {
-			return err
-		}
-
-		if !resource.CanReserve(
-			clusterAdv,
-			reservation.Spec.RequestedResources.CPU,
-			reservation.Spec.RequestedResources.Memory,
-		) {
-			return errInsufficientResources
-		}
-
-		if err := resource.AddReservation(
-			clusterAdv,
-			reservation.Spec.RequestedResources.CPU,
-			reservation.Spec.RequestedResources.Memory,
-		); err != nil {
-			return err
-		}
-
-		lockedCluster = clusterAdv
-		return r.Update(ctx, clusterAdv)
-	})
+	lockedCluster, lockErr := r.DecisionEngine.LockResources(ctx,
+		reservation.Spec.TargetClusterID, reservation.Spec.RequestedResources)
 
 	switch {
-	case errors.Is(lockErr, errTargetClusterNotFound):
+	case errors.Is(lockErr, broker.ErrClusterNotFound):
 		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
 		reservation.Status.Message = fmt.Sprintf("Target cluster '%s' not found. "+
 			"The cluster may have been removed or is not registered with the broker.",
@@ -229,7 +396,10 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
-	case errors.Is(lockErr, errInsufficientResources):
+	case errors.Is(lockErr, broker.ErrInsufficientResources):
+		if reservation.Spec.Queue {
+			return r.queueReservation(ctx, reservation, false, logger)
+		}
 		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
 		reservation.Status.Message = fmt.Sprintf("Insufficient resources in cluster '%s'. "+
 			"Requested: %s CPU, %s Memory. "+
@@ -250,15 +420,63 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 		return ctrl.Result{}, lockErr
 	}
 
+	return r.markReserved(ctx, reservation, lockedCluster, logger)
+}
+
+// reserveByPreemption evicts the plan's lower-priority reservations and locks
+// the reservation's resources in their place
+func (r *ReservationReconciler) reserveByPreemption(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	plan *broker.PreemptionPlan,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	lockedCluster, err := r.DecisionEngine.ExecutePreemption(ctx, plan, reservation)
+	if err != nil {
+		logger.Error(err, "failed to preempt reservations",
+			"targetClusterID", plan.Cluster.Spec.ClusterID,
+			"victims", len(plan.Victims))
+		return ctrl.Result{}, err
+	}
+
+	// Resources are locked; record where
+	decision := reservation.Status.Decision
+	reservation.Spec.TargetClusterID = plan.Cluster.Spec.ClusterID
+	if err := r.Update(ctx, reservation); err != nil {
+		logger.Error(err, "Failed to update reservation with target cluster after preemption")
+		return ctrl.Result{}, err
+	}
+	reservation.Status.Decision = decision
+
+	return r.markReserved(ctx, reservation, lockedCluster, logger)
+}
+
+// markReserved records that the reservation's resources are locked.
+// lockedCluster is the cluster as the lock left it, or nil if the resources
+// were locked earlier (by the API).
+func (r *ReservationReconciler) markReserved(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	lockedCluster *brokerv1alpha1.ClusterAdvertisement,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
 	// Mark as reserved
 	now := metav1.Now()
 	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
 	reservation.Status.Message = fmt.Sprintf("Resources locked in cluster %s", reservation.Spec.TargetClusterID)
 	reservation.Status.ReservedAt = &now
+	reservation.Status.QueuePosition = 0
 
-	// Set expiration if duration is specified
+	// Set expiration if duration is specified; a scheduled window ends
+	// at its planned end even when it opened late
 	if reservation.Spec.Duration != nil {
-		expiresAt := metav1.NewTime(now.Add(reservation.Spec.Duration.Duration))
+		from := now.Time
+		if reservation.Spec.StartTime != nil {
+			from = reservation.Spec.StartTime.Time
+		}
+		expiresAt := metav1.NewTime(from.Add(reservation.Spec.Duration.Duration))
 		reservation.Status.ExpiresAt = &expiresAt
 	}
 
@@ -268,6 +486,11 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 		return ctrl.Result{}, err
 	}
 
+	if lockedCluster == nil {
+		logger.Info("Reservation marked Reserved", "targetCluster", reservation.Spec.TargetClusterID)
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+
 	logger.Info(fmt.Sprintf("✅ Resources Locked Successfully\n"+
 		"  └─ Reservation: %s\n"+
 		"  └─ Target Cluster: %s\n"+
@@ -283,6 +506,162 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 }
 
+// scheduleReservation marks a reservation for a future window Scheduled once
+// a cluster has room in every slice of the window: the given target cluster,
+// or the best one if none was given. Nothing is locked until the window opens.
+func (r *ReservationReconciler) scheduleReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	start := reservation.Spec.StartTime.Time
+	end := start.Add(reservation.Spec.Duration.Duration)
+
+	bestCluster, decision, err := r.DecisionEngine.SelectBestClusterWithRecord(ctx, broker.PlacementRequest{
+		RequesterID: reservation.Spec.RequesterID,
+		Resources:   reservation.Spec.RequestedResources,
+		Strategy:    reservation.Spec.ScoringStrategy,
+		Placement:   reservation.Spec.Placement,
+		Window:      broker.Window{Start: start, End: end},
+		ClusterID:   reservation.Spec.TargetClusterID,
+	})
+	recordDecision(reservation, decision)
+
+	if err != nil {
+		logger.Info("No cluster has room for the scheduled window", "reason", err.Error())
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = fmt.Sprintf("No suitable cluster found for the window from %s to %s: %v",
+			start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), err)
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if reservation.Spec.TargetClusterID == "" {
+		reservation.Spec.TargetClusterID = bestCluster.Spec.ClusterID
+		if err := r.Update(ctx, reservation); err != nil {
+			logger.Error(err, "Failed to update scheduled reservation with target cluster")
+			return ctrl.Result{}, err
+		}
+		reservation.Status.Decision = decision
+	}
+
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+	reservation.Status.Message = fmt.Sprintf("Scheduled in cluster %s from %s to %s",
+		reservation.Spec.TargetClusterID,
+		start.UTC().Format(time.RFC3339),
+		end.UTC().Format(time.RFC3339))
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.DecisionEngine.AssumeScheduled(reservation)
+
+	logger.Info("Reservation scheduled",
+		"targetCluster", reservation.Spec.TargetClusterID,
+		"startTime", start)
+	return ctrl.Result{RequeueAfter: time.Until(start)}, nil
+}
+
+// queueReservation keeps a reservation that opted into queueing Pending and
+// records its position. The status is only written when it changed, since
+// every status update triggers another reconcile of the reservation.
+func (r *ReservationReconciler) queueReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	decisionChanged bool,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := r.List(ctx, reservationList); err != nil {
+		return ctrl.Result{}, err
+	}
+	queued := broker.QueuedReservations(reservationList)
+	position := broker.QueuePosition(queued, reservation.Namespace, reservation.Name)
+
+	message := fmt.Sprintf("Queued: waiting for capacity (position %d of %d). Requested: %s CPU, %s Memory.",
+		position, len(queued),
+		reservation.Spec.RequestedResources.CPU.String(),
+		reservation.Spec.RequestedResources.Memory.String())
+
+	if decisionChanged ||
+		reservation.Status.Phase != brokerv1alpha1.ReservationPhasePending ||
+		reservation.Status.QueuePosition != position ||
+		reservation.Status.Message != message {
+		logger.Info("No capacity available, reservation queued",
+			"reservation", reservation.Name,
+			"priority", reservation.Spec.Priority,
+			"position", position)
+
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhasePending
+		reservation.Status.QueuePosition = position
+		reservation.Status.Message = message
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: queueRetryInterval}, nil
+}
+
+// recordDecision stores the decision record on the reservation status and
+// reports whether it changed. A record with the same outcome as the stored one
+// is not considered a change, so queued reservations don't rewrite their
+// status on every retry just because the decision time moved.
+func recordDecision(reservation *brokerv1alpha1.Reservation, decision *brokerv1alpha1.DecisionRecord) bool {
+	if decision == nil {
+		return false
+	}
+	if previous := reservation.Status.Decision; previous != nil &&
+		previous.SelectedClusterID == decision.SelectedClusterID &&
+		previous.Message == decision.Message &&
+		equality.Semantic.DeepEqual(previous.Candidates, decision.Candidates) {
+		return false
+	}
+	reservation.Status.Decision = decision
+	return true
+}
+
+// handleScheduledReservation waits for a scheduled window to open and then
+// locks the reservation's resources in its target cluster
+func (r *ReservationReconciler) handleScheduledReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	// Released before the window opened: nothing is locked
+	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		return r.releaseForRequester(ctx, reservation, logger)
+	}
+
+	now := time.Now()
+	start := reservation.Spec.StartTime.Time
+	if now.Before(start) {
+		return ctrl.Result{RequeueAfter: start.Sub(now)}, nil
+	}
+
+	if end := start.Add(reservation.Spec.Duration.Duration); !now.Before(end) {
+		logger.Info("Scheduled window ended before it could open", "end", end)
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = fmt.Sprintf("Scheduled window ended at %s before its resources could be locked",
+			end.UTC().Format(time.RFC3339))
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Scheduled window opened, locking resources", "targetCluster", reservation.Spec.TargetClusterID)
+	defer r.DecisionEngine.BeginAdmission()()
+	return r.reserveInTargetCluster(ctx, reservation, logger)
+}
+
 // handleReservedReservation manages a reserved reservation
 func (r *ReservationReconciler) handleReservedReservation(
 	ctx context.Context,
@@ -290,7 +669,16 @@ func (r *ReservationReconciler) handleReservedReservation(
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 
-	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterActive) {
+	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		return r.releaseForRequester(ctx, reservation, logger)
+	}
+
+	if meta.IsStatusConditionFalse(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted) {
+		return r.failForProvider(ctx, reservation, logger)
+	}
+
+	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterActive) &&
+		(!r.RequireProviderAck || reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionProviderAccepted)) {
 		logger.Info("Requester confirmed activation, promoting reservation to Active")
 		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseActive
 		reservation.Status.Message = "Requester confirmed activation"
@@ -333,17 +721,11 @@ func (r *ReservationReconciler) handleActiveReservation(
 ) (ctrl.Result, error) {
 
 	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased) {
-		logger.Info("Requester signaled release, freeing resources")
-		if err := r.releaseResources(ctx, reservation, logger); err != nil {
-			return ctrl.Result{}, err
-		}
-		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
-		reservation.Status.Message = "Requester released reservation"
-		reservation.Status.LastUpdateTime = metav1.Now()
-		if err := r.Status().Update(ctx, reservation); err != nil {
-			return ctrl.Result{}, err
-		}
-		return ctrl.Result{}, nil
+		return r.releaseForRequester(ctx, reservation, logger)
+	}
+
+	if meta.IsStatusConditionFalse(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted) {
+		return r.failForProvider(ctx, reservation, logger)
 	}
 
 	// Check if expired
@@ -366,90 +748,166 @@ func (r *ReservationReconciler) handleActiveReservation(
 		return ctrl.Result{}, nil
 	}
 
+	if r.HeartbeatTimeout > 0 &&
+		broker.CheckLiveness(reservation, time.Now(), r.HeartbeatTimeout, r.OrphanGracePeriod) != broker.LivenessAlive {
+		lastSeen := broker.LastSeen(reservation)
+		logger.Info("Requester missed its heartbeats, orphaning reservation", "lastSeen", lastSeen)
+
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseOrphaned
+		reservation.Status.Message = fmt.Sprintf("No heartbeat from requester since %s",
+			lastSeen.UTC().Format(time.RFC3339))
+		meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionOrphaned,
+			Status:  metav1.ConditionTrue,
+			Reason:  "HeartbeatMissed",
+			Message: reservation.Status.Message,
+		})
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+
 	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 }
 
-// releaseResources releases reserved resources when reservation is deleted
-func (r *ReservationReconciler) releaseResources(
+// handleOrphanedReservation manages an Active reservation whose requester
+// stopped sending heartbeats. A new heartbeat makes it Active again; after the
+// grace period its resources are released.
+func (r *ReservationReconciler) handleOrphanedReservation(
 	ctx context.Context,
 	reservation *brokerv1alpha1.Reservation,
 	logger logr.Logger,
-) error {
-	// Only release if reservation was actually reserved
-	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved &&
-		reservation.Status.Phase != brokerv1alpha1.ReservationPhaseActive {
-		return nil
-	}
+) (ctrl.Result, error) {
 
-	// Find the cluster advertisement
-	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
-	if err := r.List(ctx, clusterList); err != nil {
-		return err
+	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased) {
+		return r.releaseForRequester(ctx, reservation, logger)
 	}
 
-	var targetCluster *brokerv1alpha1.ClusterAdvertisement
-	for i := range clusterList.Items {
-		if clusterList.Items[i].Spec.ClusterID == reservation.Spec.TargetClusterID {
-			targetCluster = &clusterList.Items[i]
-			break
-		}
+	if meta.IsStatusConditionFalse(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted) {
+		return r.failForProvider(ctx, reservation, logger)
 	}
 
-	if targetCluster == nil {
-		logger.Info("Target cluster not found, skipping resource release")
-		return nil
-	}
+	now := time.Now()
+	expired := reservation.Status.ExpiresAt != nil && now.After(reservation.Status.ExpiresAt.Time)
 
-	// Release the resources
-	err := resource.RemoveReservation(
-		targetCluster,
-		reservation.Spec.RequestedResources.CPU,
-		reservation.Spec.RequestedResources.Memory,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to remove reservation: %w", err)
+	liveness := broker.LivenessAlive
+	if r.HeartbeatTimeout > 0 {
+		liveness = broker.CheckLiveness(reservation, now, r.HeartbeatTimeout, r.OrphanGracePeriod)
 	}
 
-	// Update the cluster advertisement
-	if err := r.Update(ctx, targetCluster); err != nil {
-		return fmt.Errorf("failed to update cluster after releasing resources: %w", err)
-	}
+	switch {
+	case expired || liveness == broker.LivenessAbandoned:
+		logger.Info("Releasing orphaned reservation", "expired", expired)
+		if err := r.releaseResources(ctx, reservation, logger); err != nil {
+			logger.Error(err, "Failed to release resources of orphaned reservation")
+			return ctrl.Result{}, err
+		}
 
-	logger.Info("Successfully released resources",
-		"cluster", reservation.Spec.TargetClusterID,
-		"cpu", reservation.Spec.RequestedResources.CPU.String(),
-		"memory", reservation.Spec.RequestedResources.Memory.String())
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
+		reservation.Status.Message = "Reservation expired and released"
+		if !expired {
+			reservation.Status.Message = fmt.Sprintf("Released after no heartbeat from requester for %s",
+				r.OrphanGracePeriod)
+		}
 
-	return nil
+	case liveness == broker.LivenessAlive:
+		logger.Info("Requester heartbeat resumed, reactivating reservation")
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseActive
+		reservation.Status.Message = "Requester heartbeat resumed"
+		meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionOrphaned,
+			Status:  metav1.ConditionFalse,
+			Reason:  "HeartbeatResumed",
+			Message: reservation.Status.Message,
+		})
+
+	default:
+		// Still within the grace period; a heartbeat triggers a reconcile
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	if reservation.Status.Phase == brokerv1alpha1.ReservationPhaseActive {
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+	return ctrl.Result{}, nil
 }
 
-func (r *ReservationReconciler) findClusterByID(
+// releaseForRequester frees the resources of a reservation its requester
+// released and marks it Released
+func (r *ReservationReconciler) releaseForRequester(
 	ctx context.Context,
-	clusterID string,
-) (*brokerv1alpha1.ClusterAdvertisement, error) {
-	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
-	if err := r.List(ctx, clusterList); err != nil {
-		return nil, err
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+	logger.Info("Requester signaled release, freeing resources", "phase", reservation.Status.Phase)
+	if err := r.releaseResources(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
 	}
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
+	reservation.Status.Message = "Requester released reservation"
+	reservation.Status.QueuePosition = 0
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
 
-	for i := range clusterList.Items {
-		item := clusterList.Items[i]
-		if item.Spec.ClusterID != clusterID {
-			continue
-		}
+// failForProvider frees the resources of a reservation its provider rejected
+// and marks it Failed with the provider's reason
+func (r *ReservationReconciler) failForProvider(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+	rejection := meta.FindStatusCondition(reservation.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted)
+	logger.Info("Provider rejected reservation, freeing resources",
+		"phase", reservation.Status.Phase,
+		"reason", rejection.Message)
+	if err := r.releaseResources(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+	reservation.Status.Message = rejection.Message
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
 
-		cluster := &brokerv1alpha1.ClusterAdvertisement{}
-		if err := r.Get(ctx, types.NamespacedName{Name: item.Name, Namespace: item.Namespace}, cluster); err != nil {
-			if apierrors.IsNotFound(err) {
-				return nil, fmt.Errorf("%w: %s", errTargetClusterNotFound, clusterID)
-			}
-			return nil, err
-		}
+// releaseResources releases reserved resources when reservation is deleted
+func (r *ReservationReconciler) releaseResources(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) error {
+	// Only release if reservation was actually reserved
+	if !broker.HoldsLock(reservation.Status.Phase) {
+		return nil
+	}
 
-		return cluster, nil
+	err := r.DecisionEngine.ReleaseResources(ctx, reservation.Spec.TargetClusterID, reservation.Spec.RequestedResources)
+	if errors.Is(err, broker.ErrClusterNotFound) {
+		logger.Info("Target cluster not found, skipping resource release")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release resources: %w", err)
 	}
 
-	return nil, fmt.Errorf("%w: %s", errTargetClusterNotFound, clusterID)
+	logger.Info("Successfully released resources",
+		"cluster", reservation.Spec.TargetClusterID,
+		"cpu", reservation.Spec.RequestedResources.CPU.String(),
+		"memory", reservation.Spec.RequestedResources.Memory.String())
+
+	return nil
 }
 
 func reservationHasCondition(reservation *brokerv1alpha1.Reservation, conditionType string) bool {
@@ -471,6 +929,9 @@ func validateReservationSpec(reservation *brokerv1alpha1.Reservation) error {
 	if reservation.Spec.RequestedResources.Memory.Sign() <= 0 {
 		return errors.New("requested memory must be greater than zero")
 	}
+	if reservation.Spec.StartTime != nil && reservation.Spec.Duration == nil {
+		return errors.New("spec.startTime requires spec.duration")
+	}
 	return nil
 }
 
@@ -485,6 +946,31 @@ func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&brokerv1alpha1.Reservation{}).
+		Watches(
+			&brokerv1alpha1.ClusterAdvertisement{},
+			handler.EnqueueRequestsFromMapFunc(r.findQueuedReservations),
+		).
 		Named("reservation").
 		Complete(r)
 }
+
+// findQueuedReservations retries queued reservations when a cluster's capacity
+// may have changed. The work queue does not keep their order; placement
+// checks the queue itself (see broker.QueuedAhead), so freed capacity goes to
+// higher-priority and older reservations whichever is reconciled first.
+func (r *ReservationReconciler) findQueuedReservations(ctx context.Context, _ client.Object) []reconcile.Request {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := r.List(ctx, reservationList); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list reservations for queue retry")
+		return nil
+	}
+
+	queued := broker.QueuedReservations(reservationList)
+	requests := make([]reconcile.Request, 0, len(queued))
+	for _, reservation := range queued {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: reservation.Name, Namespace: reservation.Namespace},
+		})
+	}
+	return requests
+}
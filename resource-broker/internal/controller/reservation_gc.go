@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+// ReservationCollector periodically deletes the terminal reservations the
+// retention policy no longer keeps, archiving them first when an archive is set
+type ReservationCollector struct {
+	client.Client
+	Policy   broker.RetentionPolicy
+	Archive  broker.Archive // Optional append-only history
+	Interval time.Duration  // Defaults to 5 minutes
+}
+
+// Start runs a collection every Interval until the context is cancelled
+func (c *ReservationCollector) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("reservation-collector")
+
+	interval := c.Interval
+	if interval == 0 {
+		interval = 5 * time.Minute
+	}
+	logger.Info("Starting reservation collector",
+		"ttl", c.Policy.TTL,
+		"keepLast", c.Policy.KeepLast,
+		"archive", c.Archive != nil,
+		"interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.collect(ctx, logger); err != nil {
+				logger.Error(err, "Failed to collect terminal reservations")
+			}
+		}
+	}
+}
+
+// collect archives and deletes every reservation the policy no longer retains
+func (c *ReservationCollector) collect(ctx context.Context, logger logr.Logger) error {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := c.List(ctx, reservationList); err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	collected := 0
+	for _, reservation := range c.Policy.Collectable(reservationList.Items, time.Now()) {
+		if c.Archive != nil {
+			if err := c.Archive.Append(reservation); err != nil {
+				// Stop rather than delete history that could not be archived
+				return fmt.Errorf("failed to archive reservation %s: %w", reservation.Name, err)
+			}
+		}
+
+		if err := c.Delete(ctx, reservation); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete terminal reservation", "reservation", reservation.Name)
+			continue
+		}
+		collected++
+	}
+
+	if collected > 0 {
+		logger.Info("Collected terminal reservations", "count", collected)
+	}
+	return nil
+}
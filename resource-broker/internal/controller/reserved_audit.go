@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// errDriftChanged aborts a correction when Reserved moved since the audit
+var errDriftChanged = errors.New("reserved changed since the audit")
+
+// reservedDrift is a mismatch between a cluster's Reserved total and the one
+// implied by its reservations
+type reservedDrift struct {
+	actual   *brokerv1alpha1.ResourceQuantities
+	expected *brokerv1alpha1.ResourceQuantities
+}
+
+// ReservedAuditor periodically recomputes each cluster's Reserved total from
+// the Reserved, Active and Orphaned reservations targeting it, and corrects
+// the advertisement when the two drifted apart
+type ReservedAuditor struct {
+	client.Client
+	Interval time.Duration
+
+	// suspected holds the drift found by the previous audit. A lock is taken
+	// just before its reservation turns Reserved, so only drift seen twice in
+	// a row is corrected.
+	suspected map[types.NamespacedName]reservedDrift
+}
+
+// Start runs the audit every Interval until the context is cancelled
+func (a *ReservedAuditor) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("reserved-auditor")
+	logger.Info("Starting Reserved auditor", "interval", a.Interval)
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := a.audit(ctx, logger); err != nil {
+				logger.Error(err, "Failed to audit reserved resources")
+			}
+		}
+	}
+}
+
+// audit compares every cluster's Reserved total with its reservations
+func (a *ReservedAuditor) audit(ctx context.Context, logger logr.Logger) error {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := a.List(ctx, reservationList); err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := a.List(ctx, clusterList); err != nil {
+		return fmt.Errorf("failed to list cluster advertisements: %w", err)
+	}
+
+	expected, err := broker.ExpectedReserved(reservationList.Items)
+	if err != nil {
+		return fmt.Errorf("failed to compute expected reserved resources: %w", err)
+	}
+
+	suspected := make(map[types.NamespacedName]reservedDrift)
+	for i := range clusterList.Items {
+		cluster := &clusterList.Items[i]
+		key := client.ObjectKeyFromObject(cluster)
+		drift := reservedDrift{
+			actual:   cluster.Spec.Resources.Reserved,
+			expected: expected[cluster.Spec.ClusterID],
+		}
+
+		if broker.ReservedEqual(drift.actual, drift.expected) {
+			if err := a.setDriftCondition(ctx, key, metav1.ConditionFalse, "ReservedInSync",
+				"Reserved matches the reservations holding resources"); err != nil {
+				logger.Error(err, "Failed to update ReservedDrift condition", "cluster", cluster.Spec.ClusterID)
+			}
+			continue
+		}
+
+		previous, seen := a.suspected[key]
+		if !seen || !broker.ReservedEqual(previous.actual, drift.actual) ||
+			!broker.ReservedEqual(previous.expected, drift.expected) {
+			logger.Info("Reserved differs from reservations, checking again at next audit",
+				"cluster", cluster.Spec.ClusterID,
+				"reserved", formatReserved(drift.actual),
+				"expected", formatReserved(drift.expected))
+			suspected[key] = drift
+			continue
+		}
+
+		if err := a.correct(ctx, key, drift); err != nil {
+			if !errors.Is(err, errDriftChanged) {
+				logger.Error(err, "Failed to correct reserved resources", "cluster", cluster.Spec.ClusterID)
+			}
+			continue
+		}
+		logger.Info("Corrected reserved resources drift",
+			"cluster", cluster.Spec.ClusterID,
+			"from", formatReserved(drift.actual),
+			"to", formatReserved(drift.expected))
+
+		message := fmt.Sprintf("Reserved corrected from %s to %s",
+			formatReserved(drift.actual), formatReserved(drift.expected))
+		if err := a.setDriftCondition(ctx, key, metav1.ConditionTrue, "ReservedCorrected", message); err != nil {
+			logger.Error(err, "Failed to update ReservedDrift condition", "cluster", cluster.Spec.ClusterID)
+		}
+	}
+	a.suspected = suspected
+
+	return nil
+}
+
+// correct replaces the cluster's Reserved total with the expected one, as
+// long as it still holds the drifted value
+func (a *ReservedAuditor) correct(ctx context.Context, key types.NamespacedName, drift reservedDrift) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &brokerv1alpha1.ClusterAdvertisement{}
+		if err := a.Get(ctx, key, current); err != nil {
+			return err
+		}
+		if !broker.ReservedEqual(current.Spec.Resources.Reserved, drift.actual) {
+			return errDriftChanged
+		}
+
+		if drift.expected != nil {
+			current.Spec.Resources.Reserved = drift.expected.DeepCopy()
+		} else {
+			current.Spec.Resources.Reserved = &brokerv1alpha1.ResourceQuantities{
+				CPU:    *k8sresource.NewQuantity(0, k8sresource.DecimalSI),
+				Memory: *k8sresource.NewQuantity(0, k8sresource.BinarySI),
+			}
+		}
+		resource.UpdateAvailableResources(&current.Spec.Resources)
+		return a.Update(ctx, current)
+	})
+}
+
+// setDriftCondition sets the ReservedDrift condition, writing the status only
+// when the condition changed
+func (a *ReservedAuditor) setDriftCondition(
+	ctx context.Context,
+	key types.NamespacedName,
+	status metav1.ConditionStatus,
+	reason, message string,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &brokerv1alpha1.ClusterAdvertisement{}
+		if err := a.Get(ctx, key, current); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !meta.SetStatusCondition(&current.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ClusterAdvertisementConditionReservedDrift,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		}) {
+			return nil
+		}
+		return a.Status().Update(ctx, current)
+	})
+}
+
+// formatReserved renders a Reserved total for logs and condition messages
+func formatReserved(reserved *brokerv1alpha1.ResourceQuantities) string {
+	if reserved == nil {
+		return "cpu=0 memory=0"
+	}
+	text := fmt.Sprintf("cpu=%s memory=%s", reserved.CPU.String(), reserved.Memory.String())
+	if reserved.GPU != nil {
+		text += fmt.Sprintf(" gpu=%s", reserved.GPU.String())
+	}
+	return text
+}
@@ -50,11 +50,30 @@ func UpdateAvailableResources(resources *brokerv1alpha1.ResourceMetrics) {
 		if resources.Reserved != nil && resources.Reserved.GPU != nil {
 			reservedGPU = resources.Reserved.GPU
 		}
+		allocatedGPU := resource.NewQuantity(0, resource.DecimalSI)
+		if resources.Allocated.GPU != nil {
+			allocatedGPU = resources.Allocated.GPU
+		}
 		availableGPU := CalculateAvailable(
 			*resources.Allocatable.GPU,
-			*resources.Allocated.GPU,
+			*allocatedGPU,
 			reservedGPU,
 		)
 		resources.Available.GPU = &availableGPU
 	}
+
+	// Calculate extended resources advertised by the cluster
+	if len(resources.Allocatable.Extended) > 0 {
+		available := make(map[string]resource.Quantity, len(resources.Allocatable.Extended))
+		for name, allocatable := range resources.Allocatable.Extended {
+			var reserved *resource.Quantity
+			if resources.Reserved != nil {
+				if reservedQty, ok := resources.Reserved.Extended[name]; ok {
+					reserved = &reservedQty
+				}
+			}
+			available[name] = CalculateAvailable(allocatable, resources.Allocated.Extended[name], reserved)
+		}
+		resources.Available.Extended = available
+	}
 }
@@ -11,27 +11,45 @@ import (
 // CanReserve checks if a cluster has enough resources for a reservation
 func CanReserve(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
+	requested brokerv1alpha1.RequestedResourceQuantities,
 ) bool {
 	available := clusterAdv.Spec.Resources.Available
 
 	// Check CPU
-	if available.CPU.Cmp(requestedCPU) < 0 {
+	if available.CPU.Cmp(requested.CPU) < 0 {
 		return false
 	}
 
 	// Check Memory
-	if available.Memory.Cmp(requestedMemory) < 0 {
+	if available.Memory.Cmp(requested.Memory) < 0 {
 		return false
 	}
 
+	// Check GPU (clusters that advertise no GPUs have none available)
+	if requestsGPU(requested) {
+		if available.GPU == nil || available.GPU.Cmp(*requested.GPU) < 0 {
+			return false
+		}
+	}
+
+	// Check extended resources (not advertised = none available)
+	for name, requestedQty := range requested.Extended {
+		if requestedQty.Sign() <= 0 {
+			continue
+		}
+		availableQty, ok := available.Extended[name]
+		if !ok || availableQty.Cmp(requestedQty) < 0 {
+			return false
+		}
+	}
+
 	return true
 }
 
 // AddReservation adds reserved resources to a cluster advertisement
 func AddReservation(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	cpuToReserve, memoryToReserve resource.Quantity,
+	requested brokerv1alpha1.RequestedResourceQuantities,
 ) error {
 	// Initialize Reserved if nil
 	if clusterAdv.Spec.Resources.Reserved == nil {
@@ -40,10 +58,28 @@ func AddReservation(
 			Memory: *resource.NewQuantity(0, resource.BinarySI),
 		}
 	}
+	reserved := clusterAdv.Spec.Resources.Reserved
 
 	// Add to reserved
-	clusterAdv.Spec.Resources.Reserved.CPU.Add(cpuToReserve)
-	clusterAdv.Spec.Resources.Reserved.Memory.Add(memoryToReserve)
+	reserved.CPU.Add(requested.CPU)
+	reserved.Memory.Add(requested.Memory)
+	if requestsGPU(requested) {
+		if reserved.GPU == nil {
+			reserved.GPU = resource.NewQuantity(0, resource.DecimalSI)
+		}
+		reserved.GPU.Add(*requested.GPU)
+	}
+	for name, qty := range requested.Extended {
+		if qty.Sign() <= 0 {
+			continue
+		}
+		if reserved.Extended == nil {
+			reserved.Extended = make(map[string]resource.Quantity)
+		}
+		total := reserved.Extended[name]
+		total.Add(qty)
+		reserved.Extended[name] = total
+	}
 
 	// Recalculate available using single source of truth
 	UpdateAvailableResources(&clusterAdv.Spec.Resources)
@@ -54,18 +90,33 @@ func AddReservation(
 // RemoveReservation removes reserved resources from a cluster advertisement
 func RemoveReservation(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	cpuToRelease, memoryToRelease resource.Quantity,
+	requested brokerv1alpha1.RequestedResourceQuantities,
 ) error {
 	if clusterAdv.Spec.Resources.Reserved == nil {
 		return fmt.Errorf("no reserved resources to release")
 	}
+	reserved := clusterAdv.Spec.Resources.Reserved
 
 	// Subtract from reserved
-	clusterAdv.Spec.Resources.Reserved.CPU.Sub(cpuToRelease)
-	clusterAdv.Spec.Resources.Reserved.Memory.Sub(memoryToRelease)
+	reserved.CPU.Sub(requested.CPU)
+	reserved.Memory.Sub(requested.Memory)
+	if requestsGPU(requested) && reserved.GPU != nil {
+		reserved.GPU.Sub(*requested.GPU)
+	}
+	for name, qty := range requested.Extended {
+		if total, ok := reserved.Extended[name]; ok {
+			total.Sub(qty)
+			reserved.Extended[name] = total
+		}
+	}
 
 	// Recalculate available using single source of truth
 	UpdateAvailableResources(&clusterAdv.Spec.Resources)
 
 	return nil
 }
+
+// requestsGPU reports whether the request asks for at least one GPU
+func requestsGPU(requested brokerv1alpha1.RequestedResourceQuantities) bool {
+	return requested.GPU != nil && requested.GPU.Sign() > 0
+}
@@ -36,11 +36,36 @@ func makeClusterAdvertisement(allocatableCPU, allocatableMemory, allocatedCPU, a
 	}
 }
 
+// Helper to build a CPU/memory request for testing
+func makeRequest(cpu, memory string) brokerv1alpha1.RequestedResourceQuantities {
+	return brokerv1alpha1.RequestedResourceQuantities{
+		CPU:    resource.MustParse(cpu),
+		Memory: resource.MustParse(memory),
+	}
+}
+
+// Helper to build a request that includes GPUs
+func makeGPURequest(cpu, memory, gpu string) brokerv1alpha1.RequestedResourceQuantities {
+	request := makeRequest(cpu, memory)
+	gpuQty := resource.MustParse(gpu)
+	request.GPU = &gpuQty
+	return request
+}
+
+// Helper to give a test cluster GPUs (allocatable and available)
+func withGPU(cluster *brokerv1alpha1.ClusterAdvertisement, allocatable, available string) *brokerv1alpha1.ClusterAdvertisement {
+	allocatableGPU := resource.MustParse(allocatable)
+	availableGPU := resource.MustParse(available)
+	cluster.Spec.Resources.Allocatable.GPU = &allocatableGPU
+	cluster.Spec.Resources.Available.GPU = &availableGPU
+	return cluster
+}
+
 // Test: CanReserve returns true when enough resources available
 func TestCanReserve_EnoughResources(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
-	result := CanReserve(cluster, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	result := CanReserve(cluster, makeRequest("1000m", "2Gi"))
 
 	if !result {
 		t.Error("expected CanReserve to return true when enough resources available")
@@ -52,7 +77,7 @@ func TestCanReserve_InsufficientCPU(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "1000m", "6Gi")
 
 	// Request more CPU than available
-	result := CanReserve(cluster, resource.MustParse("2000m"), resource.MustParse("1Gi"))
+	result := CanReserve(cluster, makeRequest("2000m", "1Gi"))
 
 	if result {
 		t.Error("expected CanReserve to return false when CPU is insufficient")
@@ -64,7 +89,7 @@ func TestCanReserve_InsufficientMemory(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "1Gi")
 
 	// Request more memory than available
-	result := CanReserve(cluster, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	result := CanReserve(cluster, makeRequest("1000m", "2Gi"))
 
 	if result {
 		t.Error("expected CanReserve to return false when memory is insufficient")
@@ -76,7 +101,7 @@ func TestCanReserve_ExactMatch(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
 	// Request exactly what's available
-	result := CanReserve(cluster, resource.MustParse("3000m"), resource.MustParse("6Gi"))
+	result := CanReserve(cluster, makeRequest("3000m", "6Gi"))
 
 	if !result {
 		t.Error("expected CanReserve to return true when request exactly matches available")
@@ -88,7 +113,7 @@ func TestAddReservation_IncreasesReserved(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
 	// Add a reservation
-	err := AddReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	err := AddReservation(cluster, makeRequest("500m", "1Gi"))
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -117,7 +142,7 @@ func TestAddReservation_DecreasesAvailable(t *testing.T) {
 	originalAvailableCPU := cluster.Spec.Resources.Available.CPU.DeepCopy()
 
 	// Add a reservation of 500m CPU
-	err := AddReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	err := AddReservation(cluster, makeRequest("500m", "1Gi"))
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -140,10 +165,10 @@ func TestAddReservation_MultipleReservationsAccumulate(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
 	// Add first reservation
-	_ = AddReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	_ = AddReservation(cluster, makeRequest("500m", "1Gi"))
 
 	// Add second reservation
-	_ = AddReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	_ = AddReservation(cluster, makeRequest("500m", "1Gi"))
 
 	// Total reserved should be 1000m CPU, 2Gi memory
 	expectedCPU := resource.MustParse("1000m")
@@ -168,10 +193,10 @@ func TestRemoveReservation_DecreasesReserved(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
 	// First add a reservation
-	_ = AddReservation(cluster, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	_ = AddReservation(cluster, makeRequest("1000m", "2Gi"))
 
 	// Then remove part of it
-	err := RemoveReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	err := RemoveReservation(cluster, makeRequest("500m", "1Gi"))
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -194,10 +219,10 @@ func TestRemoveReservation_IncreasesAvailable(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 
 	// Add then remove reservation
-	_ = AddReservation(cluster, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	_ = AddReservation(cluster, makeRequest("1000m", "2Gi"))
 	// Available is now 2000m
 
-	err := RemoveReservation(cluster, resource.MustParse("1000m"), resource.MustParse("2Gi"))
+	err := RemoveReservation(cluster, makeRequest("1000m", "2Gi"))
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -216,9 +241,118 @@ func TestRemoveReservation_ErrorWhenNoReserved(t *testing.T) {
 	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
 	// Reserved is nil by default
 
-	err := RemoveReservation(cluster, resource.MustParse("500m"), resource.MustParse("1Gi"))
+	err := RemoveReservation(cluster, makeRequest("500m", "1Gi"))
 
 	if err == nil {
 		t.Error("expected error when removing reservation from nil Reserved, got nil")
 	}
 }
+
+// Test: CanReserve rejects GPU requests on clusters that advertise no GPUs
+func TestCanReserve_GPURequestOnClusterWithoutGPU(t *testing.T) {
+	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
+
+	if CanReserve(cluster, makeGPURequest("1000m", "1Gi", "1")) {
+		t.Error("expected CanReserve to return false when cluster has no GPUs")
+	}
+}
+
+// Test: CanReserve checks GPU availability
+func TestCanReserve_GPU(t *testing.T) {
+	cluster := withGPU(makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi"), "2", "1")
+
+	if !CanReserve(cluster, makeGPURequest("1000m", "1Gi", "1")) {
+		t.Error("expected CanReserve to return true when enough GPUs available")
+	}
+	if CanReserve(cluster, makeGPURequest("1000m", "1Gi", "2")) {
+		t.Error("expected CanReserve to return false when GPUs are insufficient")
+	}
+}
+
+// Test: AddReservation and RemoveReservation track GPUs
+func TestAddRemoveReservation_GPU(t *testing.T) {
+	cluster := withGPU(makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi"), "2", "2")
+	request := makeGPURequest("500m", "1Gi", "1")
+
+	if err := AddReservation(cluster, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Allocated GPU is nil (no GPU pods), so Available = 2 - 0 - 1 = 1
+	expectedGPU := resource.MustParse("1")
+	if cluster.Spec.Resources.Reserved.GPU == nil || cluster.Spec.Resources.Reserved.GPU.Cmp(expectedGPU) != 0 {
+		t.Errorf("expected reserved GPU %s, got %v", expectedGPU.String(), cluster.Spec.Resources.Reserved.GPU)
+	}
+	if cluster.Spec.Resources.Available.GPU.Cmp(expectedGPU) != 0 {
+		t.Errorf("expected available GPU %s, got %s", expectedGPU.String(), cluster.Spec.Resources.Available.GPU.String())
+	}
+
+	if err := RemoveReservation(cluster, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedGPU = resource.MustParse("2")
+	if cluster.Spec.Resources.Available.GPU.Cmp(expectedGPU) != 0 {
+		t.Errorf("expected available GPU %s after release, got %s", expectedGPU.String(), cluster.Spec.Resources.Available.GPU.String())
+	}
+}
+
+// Test: CanReserve matches requests against advertised extended resources
+func TestCanReserve_ExtendedResources(t *testing.T) {
+	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
+	cluster.Spec.Resources.Available.Extended = map[string]resource.Quantity{
+		"amd.com/gpu":   resource.MustParse("2"),
+		"hugepages-2Mi": resource.MustParse("512Mi"),
+	}
+
+	request := makeRequest("1000m", "1Gi")
+	request.Extended = map[string]resource.Quantity{
+		"amd.com/gpu":   resource.MustParse("2"),
+		"hugepages-2Mi": resource.MustParse("256Mi"),
+	}
+	if !CanReserve(cluster, request) {
+		t.Error("expected CanReserve to return true when extended resources are available")
+	}
+
+	request.Extended["amd.com/gpu"] = resource.MustParse("3")
+	if CanReserve(cluster, request) {
+		t.Error("expected CanReserve to return false when an extended resource is insufficient")
+	}
+
+	request.Extended = map[string]resource.Quantity{"example.com/fpga": resource.MustParse("1")}
+	if CanReserve(cluster, request) {
+		t.Error("expected CanReserve to return false for an extended resource the cluster does not advertise")
+	}
+}
+
+// Test: AddReservation and RemoveReservation track extended resources
+func TestAddRemoveReservation_ExtendedResources(t *testing.T) {
+	cluster := makeClusterAdvertisement("4000m", "8Gi", "1000m", "2Gi", "3000m", "6Gi")
+	cluster.Spec.Resources.Allocatable.Extended = map[string]resource.Quantity{"amd.com/gpu": resource.MustParse("4")}
+	cluster.Spec.Resources.Allocated.Extended = map[string]resource.Quantity{"amd.com/gpu": resource.MustParse("1")}
+
+	request := makeRequest("500m", "1Gi")
+	request.Extended = map[string]resource.Quantity{"amd.com/gpu": resource.MustParse("2")}
+
+	if err := AddReservation(cluster, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Available = 4 - 1 - 2 = 1
+	expected := resource.MustParse("1")
+	available := cluster.Spec.Resources.Available.Extended["amd.com/gpu"]
+	if available.Cmp(expected) != 0 {
+		t.Errorf("expected available amd.com/gpu %s, got %s", expected.String(), available.String())
+	}
+
+	if err := RemoveReservation(cluster, request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Available = 4 - 1 - 0 = 3
+	expected = resource.MustParse("3")
+	available = cluster.Spec.Resources.Available.Extended["amd.com/gpu"]
+	if available.Cmp(expected) != 0 {
+		t.Errorf("expected available amd.com/gpu %s after release, got %s", expected.String(), available.String())
+	}
+}
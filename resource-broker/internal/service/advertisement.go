@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// PublishAdvertisement stores the calling cluster's advertisement and returns
+// it with the cluster's pending provider instructions
+// CRITICAL: Preserves Reserved field from existing advertisement
+func (s *Service) PublishAdvertisement(
+	ctx context.Context,
+	clusterID string,
+	incomingAdv *dto.AdvertisementDTO,
+) (*dto.AdvertisementResponseDTO, error) {
+	logger := log.FromContext(ctx).WithName("advertisement-service")
+
+	// Validate cluster ID matches certificate
+	if incomingAdv.ClusterID != clusterID {
+		logger.Error(nil, "Cluster ID mismatch",
+			"advertised", incomingAdv.ClusterID,
+			"certificate", clusterID)
+		return nil, errorf(CodeForbidden, "Cluster ID does not match certificate")
+	}
+
+	// CRITICAL: Fetch existing advertisement to preserve Reserved field
+	existing := &brokerv1alpha1.ClusterAdvertisement{}
+	advName := incomingAdv.ClusterID + "-adv"
+	err := s.k8sClient.Get(ctx,
+		types.NamespacedName{Name: advName, Namespace: s.namespace},
+		existing)
+
+	// Convert DTO to k8s ClusterAdvertisement
+	clusterAdv, err2 := dto.ToClusterAdvertisement(incomingAdv, s.namespace)
+	if err2 != nil {
+		logger.Error(err2, "Failed to convert advertisement")
+		return nil, errorf(CodeInternal, "Failed to process advertisement")
+	}
+
+	if err == nil {
+		// Advertisement exists - CRITICAL: Preserve Reserved field
+		if existing.Spec.Resources.Reserved != nil {
+			logger.Info("Preserving Reserved field from existing advertisement",
+				"cpu", existing.Spec.Resources.Reserved.CPU.String(),
+				"memory", existing.Spec.Resources.Reserved.Memory.String())
+			clusterAdv.Spec.Resources.Reserved = existing.Spec.Resources.Reserved
+		}
+
+		// Update existing advertisement
+		clusterAdv.ResourceVersion = existing.ResourceVersion
+		if err := s.k8sClient.Update(ctx, clusterAdv); err != nil {
+			logger.Error(err, "Failed to update advertisement")
+			return nil, errorf(CodeInternal, "Failed to update advertisement: %v", err)
+		}
+
+		logger.Info("Updated advertisement",
+			"clusterID", incomingAdv.ClusterID,
+			"availableCPU", incomingAdv.Resources.Available.CPU,
+			"availableMemory", incomingAdv.Resources.Available.Memory)
+
+	} else if apierrors.IsNotFound(err) {
+		// Advertisement doesn't exist - create new
+		if err := s.k8sClient.Create(ctx, clusterAdv); err != nil {
+			logger.Error(err, "Failed to create advertisement")
+			return nil, errorf(CodeInternal, "Failed to create advertisement: %v", err)
+		}
+
+		logger.Info("Created new advertisement",
+			"clusterID", incomingAdv.ClusterID,
+			"availableCPU", incomingAdv.Resources.Available.CPU,
+			"availableMemory", incomingAdv.Resources.Available.Memory)
+
+	} else {
+		// Unexpected error
+		logger.Error(err, "Failed to check existing advertisement")
+		return nil, errorf(CodeInternal, "Internal server error")
+	}
+
+	// Piggyback provider instructions: include any Reserved-phase reservations
+	// where this cluster is the provider. This eliminates the need for polling.
+	var providerInstructions []*dto.ReservationDTO
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, reservationList); err != nil {
+		logger.Error(err, "Failed to list reservations for provider instructions")
+	} else {
+		for i := range reservationList.Items {
+			rsv := &reservationList.Items[i]
+			if rsv.Status.Phase == brokerv1alpha1.ReservationPhaseReserved &&
+				rsv.Spec.TargetClusterID == incomingAdv.ClusterID {
+				providerInstructions = append(providerInstructions, dto.FromReservation(rsv))
+			}
+		}
+		if len(providerInstructions) > 0 {
+			logger.Info("Including provider instructions in advertisement response",
+				"clusterID", incomingAdv.ClusterID,
+				"count", len(providerInstructions))
+		}
+	}
+
+	return &dto.AdvertisementResponseDTO{
+		Advertisement:        dto.FromClusterAdvertisement(clusterAdv),
+		ProviderInstructions: providerInstructions,
+	}, nil
+}
+
+// GetAdvertisement returns the advertisement of a cluster
+func (s *Service) GetAdvertisement(ctx context.Context, clusterID string) (*dto.AdvertisementDTO, error) {
+	logger := log.FromContext(ctx).WithName("advertisement-service")
+
+	if clusterID == "" {
+		return nil, errorf(CodeInvalid, "Missing clusterID parameter")
+	}
+
+	existing := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := s.k8sClient.Get(ctx,
+		types.NamespacedName{Name: clusterID + "-adv", Namespace: s.namespace},
+		existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errorf(CodeNotFound, "Advertisement not found")
+		}
+		logger.Error(err, "Failed to fetch advertisement")
+		return nil, errorf(CodeInternal, "Internal server error")
+	}
+
+	// Includes the Reserved field if present
+	return dto.FromClusterAdvertisement(existing), nil
+}
+
+// Instructions returns the calling cluster's current instructions: the
+// reservations it provides, plus preemption notices for reservations it
+// requested or provides and release notices for reservations it provides
+func (s *Service) Instructions(ctx context.Context, clusterID string) ([]*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("instructions-service")
+
+	if clusterID == "" {
+		return nil, errNoClusterID
+	}
+
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, reservationList); err != nil {
+		logger.Error(err, "Failed to list reservations")
+		return nil, errorf(CodeInternal, "Failed to list reservations")
+	}
+
+	var instructions []*dto.ReservationDTO
+	for i := range reservationList.Items {
+		if isInstructionFor(&reservationList.Items[i], clusterID) {
+			instructions = append(instructions, dto.FromReservation(&reservationList.Items[i]))
+		}
+	}
+
+	logger.V(1).Info("Returning provider instructions",
+		"clusterID", clusterID,
+		"count", len(instructions))
+	return instructions, nil
+}
+
+// isInstructionFor reports whether the reservation is an instruction or a
+// notice for the given cluster
+func isInstructionFor(rsv *brokerv1alpha1.Reservation, clusterID string) bool {
+	switch rsv.Status.Phase {
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive:
+		// Active ones are repeated so renewed expiries reach the provider
+		return rsv.Spec.TargetClusterID == clusterID
+	case brokerv1alpha1.ReservationPhasePreempted:
+		// Both sides must drop their local instruction for the evicted reservation
+		return rsv.Spec.TargetClusterID == clusterID || rsv.Spec.RequesterID == clusterID
+	case brokerv1alpha1.ReservationPhaseFailed:
+		// The requester learns that the provider rejected its reservation
+		return (rsv.Spec.TargetClusterID == clusterID || rsv.Spec.RequesterID == clusterID) &&
+			meta.IsStatusConditionFalse(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionProviderAccepted)
+	case brokerv1alpha1.ReservationPhaseReleased:
+		// The provider stops holding capacity the requester gave back or abandoned
+		return rsv.Spec.TargetClusterID == clusterID &&
+			(meta.IsStatusConditionTrue(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterReleased) ||
+				meta.IsStatusConditionTrue(rsv.Status.Conditions, brokerv1alpha1.ReservationConditionOrphaned))
+	}
+	return false
+}
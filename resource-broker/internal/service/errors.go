@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Code classifies why an operation failed. Each interface maps it to its own
+// status codes (HTTP status, gRPC code).
+type Code int
+
+const (
+	// CodeInternal is a broker-side failure; the request may be retried
+	CodeInternal Code = iota
+	// CodeInvalid is a malformed or unsupported request
+	CodeInvalid
+	// CodeForbidden is a request without a cluster identity, or by a cluster
+	// not allowed to make it
+	CodeForbidden
+	// CodeNotFound is an unknown reservation or advertisement, or one of
+	// another cluster
+	CodeNotFound
+	// CodeConflict is a request the current state does not allow, e.g. no
+	// cluster has capacity or the reservation already ended
+	CodeConflict
+	// CodeKeyReused is an idempotency key used before for a different request
+	CodeKeyReused
+	// CodeInProgress is a request whose idempotency key is still being
+	// served; the requester retries shortly
+	CodeInProgress
+	// CodeUnimplemented is a feature this broker does not run
+	CodeUnimplemented
+)
+
+// Error is a failed operation
+type Error struct {
+	Code    Code
+	Message string
+	// Decision explains why no cluster was chosen, when the request was decided
+	Decision *brokerv1alpha1.DecisionRecord
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errorf builds an Error
+func errorf(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsError returns err as an Error; errors that are not are internal failures
+func AsError(err error) *Error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr
+	}
+	return &Error{Code: CodeInternal, Message: err.Error()}
+}
+
+// errNoClusterID rejects calls without the calling cluster's identity
+var errNoClusterID = errorf(CodeForbidden, "Could not determine cluster ID from certificate")
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// newReservationName names a new reservation or reservation group. Requests
+// with an idempotency key get a name derived from it, so a replay finds the
+// reservation of the first attempt and concurrent replays cannot both create one.
+func newReservationName(idempotencyKey, requesterID string) string {
+	if idempotencyKey == "" {
+		return fmt.Sprintf("rsv-%s-%d", requesterID, time.Now().UnixMilli())
+	}
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	return fmt.Sprintf("rsv-%s-%s", requesterID, hex.EncodeToString(sum[:8]))
+}
+
+// requestHash hashes a decoded request body. The body is encoded again first,
+// so requests that differ only in formatting or field order hash the same.
+func requestHash(body any) string {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// reservationAnnotations marks the reservations a request creates as decided
+// by the API and records the request's idempotency key and hash on them
+func reservationAnnotations(idempotencyKey, hash string) map[string]string {
+	annotations := map[string]string{brokerv1alpha1.ReservationDecidedByAPIAnnotation: "true"}
+	if idempotencyKey != "" {
+		annotations[brokerv1alpha1.ReservationIdempotencyKeyAnnotation] = idempotencyKey
+		annotations[brokerv1alpha1.ReservationRequestHashAnnotation] = hash
+	}
+	return annotations
+}
+
+// attemptName names the attempt-th reservation or group created for an
+// idempotency key: the first is named after the key alone
+func attemptName(keyName string, attempt int) string {
+	if attempt == 1 {
+		return keyName
+	}
+	return fmt.Sprintf("%s-attempt%d", keyName, attempt)
+}
+
+// replayReservation looks up what a request's idempotency key already
+// created. It returns the current state of that reservation or group, or
+// CodeKeyReused if the key was used for a request with a different body
+// (hash). Otherwise it returns the name for the reservation or group the
+// request creates. A key whose reservations all failed or were preempted is
+// admitted again, under the next attempt's name, since the requester holds
+// nothing with it and retries to get resources.
+func (s *Service) replayReservation(
+	ctx context.Context,
+	idempotencyKey, requesterID, hash string,
+) (*ReservationResult, string, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	if idempotencyKey == "" {
+		return nil, newReservationName("", requesterID), nil
+	}
+
+	// The name includes the requester ID, so it cannot be another cluster's
+	keyName := newReservationName(idempotencyKey, requesterID)
+	for attempt := 1; ; attempt++ {
+		id := attemptName(keyName, attempt)
+		reservations, err := s.reservationsByID(ctx, id)
+		if err != nil {
+			logger.Error(err, "Failed to look up reservation for idempotency key")
+			return nil, "", errorf(CodeInternal, "Failed to fetch reservation")
+		}
+		if len(reservations) == 0 {
+			return nil, id, nil
+		}
+
+		// Reservations created before hashes were recorded carry none
+		if recorded := reservations[0].Annotations[brokerv1alpha1.ReservationRequestHashAnnotation]; recorded != "" && recorded != hash {
+			return nil, "", errorf(CodeKeyReused,
+				"Idempotency key was already used for a different request (reservation %s)", id)
+		}
+
+		if lostAll(reservations) {
+			continue
+		}
+
+		logger.Info("Replaying reservation request",
+			"reservation", id,
+			"requester", requesterID,
+			"phase", reservations[0].Status.Phase)
+		return replayResult(id, reservations)
+	}
+}
+
+// lostAll reports whether every part of an attempt failed or was preempted
+func lostAll(reservations []brokerv1alpha1.Reservation) bool {
+	for i := range reservations {
+		switch reservations[i].Status.Phase {
+		case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhasePreempted:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// replayResult answers a replay with the reservation or group id an earlier
+// attempt created
+func replayResult(id string, reservations []brokerv1alpha1.Reservation) (*ReservationResult, string, error) {
+	// The parts of a group are locked together and share their phase
+	phase := reservations[0].Status.Phase
+	switch phase {
+	case "":
+		// The first attempt is still deciding or locking; the requester retries
+		return nil, "", errorf(CodeInProgress, "Reservation request still in progress")
+	case brokerv1alpha1.ReservationPhasePending:
+		return &ReservationResult{
+			Reservation: reservationsDTO(id, reservations, "Queued: waiting for capacity"),
+			Outcome:     OutcomeQueued,
+		}, "", nil
+	case brokerv1alpha1.ReservationPhaseScheduled:
+		return &ReservationResult{
+			Reservation: reservationsDTO(id, reservations, reservations[0].Status.Message),
+			Outcome:     OutcomeExisting,
+		}, "", nil
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+		brokerv1alpha1.ReservationPhaseOrphaned:
+		return &ReservationResult{
+			Reservation: reservationsDTO(id, reservations, fmt.Sprintf("Resources locked in %d parts", len(reservations))),
+			Outcome:     OutcomeExisting,
+		}, "", nil
+	default:
+		return nil, "", errorf(CodeConflict, "Reservation %s already ended as %s: %s",
+			id, phase, reservations[0].Status.Message)
+	}
+}
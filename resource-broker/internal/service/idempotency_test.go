@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Helper to create a service on a fake broker cluster
+func newFakeService(funcs *interceptor.Funcs, objects ...client.Object) (*Service, client.Client) {
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	builder := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}, &brokerv1alpha1.ClusterAdvertisement{}).
+		WithIndex(&brokerv1alpha1.Reservation{}, broker.ReservationPhaseField, broker.IndexReservationPhase)
+	if funcs != nil {
+		builder = builder.WithInterceptorFuncs(*funcs)
+	}
+	k8sClient := builder.Build()
+	return New(k8sClient, "default", &broker.DecisionEngine{Client: k8sClient}, 0, nil), k8sClient
+}
+
+// Helper to build an active provider cluster with the given capacity
+func makeProvider(clusterID, cpu, memory string) *brokerv1alpha1.ClusterAdvertisement {
+	return &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterID + "-adv", Namespace: "default"},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: clusterID,
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)},
+				Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)},
+			},
+		},
+		Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+	}
+}
+
+var smallRequest = dto.ReservationRequestDTO{
+	RequestedResources: dto.ResourceQuantitiesDTO{CPU: "2", Memory: "2Gi"},
+}
+
+// Test: A replay while the first attempt is still deciding is reported as in progress
+func TestReserve_ReplayInProgress(t *testing.T) {
+	inProgress := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        newReservationName("key-1", "cluster-1"),
+			Namespace:   "default",
+			Annotations: reservationAnnotations("key-1", requestHash(&smallRequest)),
+		},
+		Spec: brokerv1alpha1.ReservationSpec{RequesterID: "cluster-1", TargetClusterID: "cluster-2"},
+	}
+	s, _ := newFakeService(nil, makeProvider("cluster-2", "8", "16Gi"), inProgress)
+
+	request := smallRequest
+	_, err := s.Reserve(context.Background(), "cluster-1", &request, "key-1")
+	if err == nil || AsError(err).Code != CodeInProgress {
+		t.Fatalf("expected CodeInProgress, got %v", err)
+	}
+}
+
+// Test: A key whose reservation failed is admitted again under the next attempt's name, and replays return that attempt
+func TestReserve_ReadmitsKeyAfterFailure(t *testing.T) {
+	failed := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        newReservationName("key-1", "cluster-1"),
+			Namespace:   "default",
+			Annotations: reservationAnnotations("key-1", requestHash(&smallRequest)),
+		},
+		Spec:   brokerv1alpha1.ReservationSpec{RequesterID: "cluster-1", TargetClusterID: "cluster-2"},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseFailed, Message: "Rejected by provider"},
+	}
+	s, _ := newFakeService(nil, makeProvider("cluster-2", "8", "16Gi"), failed)
+
+	request := smallRequest
+	result, err := s.Reserve(context.Background(), "cluster-1", &request, "key-1")
+	if err != nil {
+		t.Fatalf("expected the key to be admitted again, got %v", err)
+	}
+	secondAttempt := attemptName(failed.Name, 2)
+	if result.Outcome != OutcomeCreated || result.Reservation.ID != secondAttempt {
+		t.Fatalf("expected %s to be created, got %s (outcome %v)", secondAttempt, result.Reservation.ID, result.Outcome)
+	}
+
+	result, err = s.Reserve(context.Background(), "cluster-1", &request, "key-1")
+	if err != nil || result.Outcome != OutcomeExisting || result.Reservation.ID != secondAttempt {
+		t.Errorf("expected a replay to return %s, got %+v, %v", secondAttempt, result, err)
+	}
+}
+
+// Test: A key whose reservation was released stays used
+func TestReserve_ReplayReleased(t *testing.T) {
+	released := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        newReservationName("key-1", "cluster-1"),
+			Namespace:   "default",
+			Annotations: reservationAnnotations("key-1", requestHash(&smallRequest)),
+		},
+		Spec:   brokerv1alpha1.ReservationSpec{RequesterID: "cluster-1", TargetClusterID: "cluster-2"},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReleased},
+	}
+	s, _ := newFakeService(nil, makeProvider("cluster-2", "8", "16Gi"), released)
+
+	request := smallRequest
+	_, err := s.Reserve(context.Background(), "cluster-1", &request, "key-1")
+	if err == nil || AsError(err).Code != CodeConflict {
+		t.Fatalf("expected CodeConflict, got %v", err)
+	}
+}
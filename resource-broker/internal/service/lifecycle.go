@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Release releases a reservation, or every part of a split or gang group when
+// given the group ID. The reservation controller frees the locked capacity;
+// queued reservations simply leave the queue. Only the requester may release.
+// Releasing an already finished reservation is a no-op.
+func (s *Service) Release(ctx context.Context, clusterID, reservationID string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	reservations, err := s.requesterReservations(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		err := s.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) bool {
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseReleased, brokerv1alpha1.ReservationPhaseFailed,
+				brokerv1alpha1.ReservationPhasePreempted:
+				return false
+			}
+			return brokerv1alpha1.SetRequesterCondition(reservation, brokerv1alpha1.ReservationConditionRequesterReleased,
+				"ReleasedByRequester", "Requester released the reservation")
+		})
+		if err != nil {
+			logger.Error(err, "Failed to release reservation", "reservation", reservation.Name)
+			return nil, errorf(CodeInternal, "Failed to release reservation")
+		}
+		logger.Info("Requester released reservation",
+			"reservation", reservation.Name,
+			"requester", reservation.Spec.RequesterID,
+			"phase", reservation.Status.Phase)
+	}
+
+	return reservationsDTO(reservationID, reservations,
+		fmt.Sprintf("Release requested for %d parts", len(reservations))), nil
+}
+
+// Activate records that the requester started using a Reserved reservation
+// (e.g. Liqo peering with the provider is established). The reservation
+// controller then promotes it to Active. Only the requester may activate;
+// activating an Active reservation is a no-op, any other phase is a conflict.
+func (s *Service) Activate(ctx context.Context, clusterID, reservationID string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	reservations, err := s.requesterReservations(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		switch reservations[i].Status.Phase {
+		case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive:
+		default:
+			return nil, errorf(CodeConflict, "Reservation %s is %s and cannot be activated",
+				reservations[i].Name, brokerv1alpha1.PhaseOrPending(reservations[i].Status.Phase))
+		}
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		var conflict bool
+		err := s.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) bool {
+			conflict = false
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseActive:
+				return false
+			case brokerv1alpha1.ReservationPhaseReserved:
+				return brokerv1alpha1.SetRequesterCondition(reservation, brokerv1alpha1.ReservationConditionRequesterActive,
+					"ActivatedByRequester", "Requester started using the reservation")
+			default:
+				// Changed since the check above, e.g. expired or preempted
+				conflict = true
+				return false
+			}
+		})
+		if err != nil {
+			logger.Error(err, "Failed to activate reservation", "reservation", reservation.Name)
+			return nil, errorf(CodeInternal, "Failed to activate reservation")
+		}
+		if conflict {
+			return nil, errorf(CodeConflict, "Reservation %s is %s and cannot be activated",
+				reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+		}
+		logger.Info("Requester activated reservation",
+			"reservation", reservation.Name,
+			"requester", reservation.Spec.RequesterID,
+			"targetCluster", reservation.Spec.TargetClusterID)
+	}
+
+	return reservationsDTO(reservationID, reservations,
+		fmt.Sprintf("Activation requested for %d parts", len(reservations))), nil
+}
+
+// Acknowledge records the provider's answer to a reservation it was
+// instructed to hold, in the ProviderAccepted condition. On rejection the
+// reservation controller frees the locked capacity and fails the reservation
+// with the provider's reason. A provider may reject a reservation it accepted
+// earlier, e.g. when its capacity changed. Only the provider may answer;
+// other clusters are forbidden.
+func (s *Service) Acknowledge(
+	ctx context.Context,
+	clusterID, reservationID string,
+	reqDTO *dto.AcknowledgeRequestDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	reservation, err := s.getReservation(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Spec.TargetClusterID != clusterID {
+		return nil, errorf(CodeForbidden, "Only the provider of reservation %s may acknowledge it", reservation.Name)
+	}
+
+	status, reason, message := metav1.ConditionTrue, "AcceptedByProvider", "Provider holds the capacity"
+	if !reqDTO.Accepted {
+		status, reason, message = metav1.ConditionFalse, "RejectedByProvider", "Provider rejected the reservation"
+		if reqDTO.Reason != "" {
+			message = fmt.Sprintf("%s: %s", message, reqDTO.Reason)
+		}
+	}
+
+	var finished bool
+	err = s.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) bool {
+		finished = false
+		// A repeated answer is a no-op, even if the reservation ended because of it
+		if cond := meta.FindStatusCondition(reservation.Status.Conditions,
+			brokerv1alpha1.ReservationConditionProviderAccepted); cond != nil && cond.Status == status {
+			return false
+		}
+		switch reservation.Status.Phase {
+		case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+			brokerv1alpha1.ReservationPhaseOrphaned:
+		default:
+			finished = true
+			return false
+		}
+		return meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionProviderAccepted,
+			Status:  status,
+			Reason:  reason,
+			Message: message,
+		})
+	})
+	if err != nil {
+		logger.Error(err, "Failed to record provider answer", "reservation", reservation.Name)
+		return nil, errorf(CodeInternal, "Failed to record provider answer")
+	}
+	if finished {
+		return nil, errorf(CodeConflict, "Reservation %s is %s",
+			reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+	}
+
+	logger.Info("Provider answered reservation",
+		"reservation", reservation.Name,
+		"provider", clusterID,
+		"accepted", reqDTO.Accepted,
+		"reason", reqDTO.Reason)
+
+	return dto.FromReservation(reservation), nil
+}
+
+// Heartbeat records that the requester still uses a reservation (or every
+// part of a group). With --heartbeat-timeout, Active reservations without
+// heartbeats become Orphaned and are released after a grace period; a
+// heartbeat on an Orphaned reservation makes it Active again. Finished
+// reservations are a conflict, so the requester learns it lost them. Only
+// the requester may heartbeat.
+func (s *Service) Heartbeat(ctx context.Context, clusterID, reservationID string) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	reservations, err := s.requesterReservations(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+	for i := range reservations {
+		reservation := &reservations[i]
+		var finished bool
+		err := s.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) bool {
+			switch reservation.Status.Phase {
+			case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive,
+				brokerv1alpha1.ReservationPhaseOrphaned:
+				finished = false
+				reservation.Status.LastHeartbeatTime = &now
+				return true
+			default:
+				finished = true
+				return false
+			}
+		})
+		if err != nil {
+			logger.Error(err, "Failed to record heartbeat", "reservation", reservation.Name)
+			return nil, errorf(CodeInternal, "Failed to record heartbeat")
+		}
+		if finished {
+			return nil, errorf(CodeConflict, "Reservation %s is %s",
+				reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+		}
+		logger.V(1).Info("Requester heartbeat",
+			"reservation", reservation.Name,
+			"requester", reservation.Spec.RequesterID,
+			"phase", reservation.Status.Phase)
+	}
+
+	return reservationsDTO(reservationID, reservations,
+		fmt.Sprintf("Heartbeat recorded for %d parts", len(reservations))), nil
+}
+
+// Renew extends the expiry of a Reserved or Active reservation (or of every
+// part of a group) to now plus the requested duration, by default the
+// reservation's own duration. With --max-reservation-lifetime the expiry is
+// capped at that long after the reservation was reserved; a reservation
+// already at the cap cannot be renewed. Only the requester may renew.
+func (s *Service) Renew(
+	ctx context.Context,
+	clusterID, reservationID string,
+	reqDTO *dto.RenewRequestDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	var extension time.Duration
+	if reqDTO.Duration != "" {
+		d, err := time.ParseDuration(reqDTO.Duration)
+		if err != nil || d <= 0 {
+			return nil, errorf(CodeInvalid, "Invalid duration: %s", reqDTO.Duration)
+		}
+		extension = d
+	}
+
+	reservations, err := s.requesterReservations(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every part first so a group is renewed all together or not at all
+	now := time.Now()
+	for i := range reservations {
+		if _, err := broker.RenewedReservationExpiry(&reservations[i], extension, now, s.maxLifetime); err != nil {
+			return nil, errorf(CodeConflict, "Reservation %s cannot be renewed: %v", reservations[i].Name, err)
+		}
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		var renewErr error
+		err := s.updateReservationStatus(ctx, reservation, func(reservation *brokerv1alpha1.Reservation) bool {
+			// Recomputed in case the reservation changed since the check above
+			expiresAt, err := broker.RenewedReservationExpiry(reservation, extension, now, s.maxLifetime)
+			renewErr = err
+			if err != nil || expiresAt.Equal(reservation.Status.ExpiresAt.Time) {
+				return false
+			}
+			renewed := metav1.NewTime(expiresAt)
+			reservation.Status.ExpiresAt = &renewed
+			return true
+		})
+		if err != nil {
+			logger.Error(err, "Failed to renew reservation", "reservation", reservation.Name)
+			return nil, errorf(CodeInternal, "Failed to renew reservation")
+		}
+		if renewErr != nil {
+			return nil, errorf(CodeConflict, "Reservation %s cannot be renewed: %v", reservation.Name, renewErr)
+		}
+		logger.Info("Requester renewed reservation",
+			"reservation", reservation.Name,
+			"requester", reservation.Spec.RequesterID,
+			"expiresAt", reservation.Status.ExpiresAt.Time)
+	}
+
+	return reservationsDTO(reservationID, reservations, fmt.Sprintf("Renewed %d parts", len(reservations))), nil
+}
+
+// Resize grows or shrinks the CPU and memory of a Reserved or Active
+// reservation on its current target cluster, without releasing it. Reserved
+// is adjusted by the difference; growing needs headroom on the target
+// cluster. The provider picks up the new size with its instructions. Only
+// the requester may resize, and group parts are resized one by one by their
+// own name.
+func (s *Service) Resize(
+	ctx context.Context,
+	clusterID, reservationID string,
+	reqDTO *dto.ResizeRequestDTO,
+) (*dto.ReservationDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	cpu, memory, err := parseResize(reqDTO)
+	if err != nil {
+		return nil, errorf(CodeInvalid, "%v", err)
+	}
+
+	reservations, err := s.requesterReservations(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservations[0].Name != reservationID {
+		return nil, errorf(CodeInvalid, "Resize the parts of a reservation group individually")
+	}
+	reservation := &reservations[0]
+
+	switch reservation.Status.Phase {
+	case brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive:
+	default:
+		return nil, errorf(CodeConflict, "Reservation %s is %s and cannot be resized",
+			reservation.Name, brokerv1alpha1.PhaseOrPending(reservation.Status.Phase))
+	}
+
+	current := reservation.Spec.RequestedResources
+	resized := broker.ResizedResources(current, cpu, memory)
+	if resized.CPU.Cmp(current.CPU) == 0 && resized.Memory.Cmp(current.Memory) == 0 {
+		return dto.FromReservation(reservation), nil
+	}
+
+	// Growing takes capacity like an admission: it must not race one for the
+	// same headroom, and must leave room for the slots Scheduled while the
+	// reservation still holds its lock
+	window := broker.Window{Start: time.Now()}
+	if reservation.Status.ExpiresAt != nil {
+		window.End = reservation.Status.ExpiresAt.Time
+	}
+	endAdmission := func() {}
+	if resized.CPU.Cmp(current.CPU) > 0 || resized.Memory.Cmp(current.Memory) > 0 {
+		endAdmission = s.decisionEngine.BeginAdmission()
+	}
+
+	// Lock first so the spec never claims more than the cluster holds for it
+	err = s.decisionEngine.ResizeLock(ctx, reservation.Spec.TargetClusterID, window, current, resized)
+	endAdmission()
+	if err != nil {
+		if errors.Is(err, broker.ErrResizeNotPossible) {
+			return nil, errorf(CodeConflict, "Reservation %s cannot be resized: %v", reservation.Name, err)
+		}
+		logger.Error(err, "Failed to resize reservation lock", "reservation", reservation.Name)
+		return nil, errorf(CodeInternal, "Failed to resize reservation")
+	}
+
+	var changed bool
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.k8sClient.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+			return err
+		}
+		// Changed since the lock was moved, e.g. released or resized concurrently
+		changed = (reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved &&
+			reservation.Status.Phase != brokerv1alpha1.ReservationPhaseActive) ||
+			!equality.Semantic.DeepEqual(reservation.Spec.RequestedResources, current)
+		if changed {
+			return nil
+		}
+		reservation.Spec.RequestedResources = resized
+		return s.k8sClient.Update(ctx, reservation)
+	})
+	if err != nil || changed {
+		if rollbackErr := s.decisionEngine.ResizeLock(ctx, reservation.Spec.TargetClusterID, window, resized, current); rollbackErr != nil {
+			logger.Error(rollbackErr, "Failed to roll back resized lock",
+				"reservation", reservation.Name,
+				"targetCluster", reservation.Spec.TargetClusterID)
+		}
+		if err != nil {
+			logger.Error(err, "Failed to update reservation size", "reservation", reservation.Name)
+			return nil, errorf(CodeInternal, "Failed to resize reservation")
+		}
+		return nil, errorf(CodeConflict, "Reservation %s changed during the resize", reservation.Name)
+	}
+
+	logger.Info("Requester resized reservation",
+		"reservation", reservation.Name,
+		"targetCluster", reservation.Spec.TargetClusterID,
+		"cpu", resized.CPU.String(),
+		"memory", resized.Memory.String())
+
+	return dto.FromReservation(reservation), nil
+}
+
+// parseResize reads the new CPU and memory of a resize request
+func parseResize(reqDTO *dto.ResizeRequestDTO) (cpu, memory resource.Quantity, err error) {
+	requested := reqDTO.RequestedResources
+	if requested.GPU != "" || len(requested.Extended) > 0 {
+		return cpu, memory, errors.New("only CPU and memory can be resized")
+	}
+	if requested.CPU == "" || requested.Memory == "" {
+		return cpu, memory, errors.New("requestedResources.cpu and requestedResources.memory are required")
+	}
+
+	if cpu, err = resource.ParseQuantity(requested.CPU); err != nil {
+		return cpu, memory, fmt.Errorf("invalid CPU quantity: %v", err)
+	}
+	if memory, err = resource.ParseQuantity(requested.Memory); err != nil {
+		return cpu, memory, fmt.Errorf("invalid memory quantity: %v", err)
+	}
+	if cpu.Sign() <= 0 || memory.Sign() <= 0 {
+		return cpu, memory, errors.New("requested CPU and memory must be greater than zero")
+	}
+	return cpu, memory, nil
+}
+
+// requesterReservations resolves a reservation or group ID for the calling
+// cluster. Reservations of other requesters are reported as not found.
+func (s *Service) requesterReservations(
+	ctx context.Context,
+	clusterID, reservationID string,
+) ([]brokerv1alpha1.Reservation, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	if clusterID == "" {
+		return nil, errNoClusterID
+	}
+
+	reservations, err := s.reservationsByID(ctx, reservationID)
+	if err != nil {
+		logger.Error(err, "Failed to fetch reservation")
+		return nil, errorf(CodeInternal, "Failed to fetch reservation")
+	}
+	if len(reservations) == 0 {
+		return nil, errReservationNotFound
+	}
+
+	// Don't reveal other clusters' reservations
+	for i := range reservations {
+		if reservations[i].Spec.RequesterID != clusterID {
+			return nil, errReservationNotFound
+		}
+	}
+
+	return reservations, nil
+}
+
+// reservationsByID returns the reservation with the given name or, if there is
+// none, all parts of the reservation group with that ID
+func (s *Service) reservationsByID(ctx context.Context, id string) ([]brokerv1alpha1.Reservation, error) {
+	reservation := &brokerv1alpha1.Reservation{}
+	err := s.k8sClient.Get(ctx, types.NamespacedName{Name: id, Namespace: s.namespace}, reservation)
+	if err == nil {
+		return []brokerv1alpha1.Reservation{*reservation}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	group := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, group,
+		client.InNamespace(s.namespace),
+		client.MatchingLabels{brokerv1alpha1.ReservationGroupLabel: id}); err != nil {
+		return nil, err
+	}
+	sort.Slice(group.Items, func(i, j int) bool {
+		return group.Items[i].Name < group.Items[j].Name
+	})
+	return group.Items, nil
+}
+
+// updateReservationStatus re-reads the reservation and writes its status if
+// mutate changed it, retrying on conflicts with the reservation controller
+func (s *Service) updateReservationStatus(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	mutate func(*brokerv1alpha1.Reservation) bool,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.k8sClient.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+			return err
+		}
+		if !mutate(reservation) {
+			return nil
+		}
+		reservation.Status.LastUpdateTime = metav1.Now()
+		return s.k8sClient.Status().Update(ctx, reservation)
+	})
+}
+
+// reservationsDTO is a single reservation, or the parts of a group when id is
+// a group ID
+func reservationsDTO(id string, reservations []brokerv1alpha1.Reservation, groupMessage string) *dto.ReservationDTO {
+	if reservations[0].Name == id {
+		return dto.FromReservation(&reservations[0])
+	}
+
+	response := &dto.ReservationDTO{
+		ID:          id,
+		RequesterID: reservations[0].Spec.RequesterID,
+		Status: dto.ReservationStatusDTO{
+			Message: groupMessage,
+		},
+		GroupID: id,
+	}
+	for i := range reservations {
+		response.Parts = append(response.Parts, dto.FromReservation(&reservations[i]))
+	}
+	return response
+}
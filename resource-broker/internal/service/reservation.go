@@ -0,0 +1,980 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Outcome is how a reservation request was served
+type Outcome int
+
+const (
+	// OutcomeCreated is a new reservation or group, Reserved or Scheduled
+	OutcomeCreated Outcome = iota
+	// OutcomeQueued is a reservation kept Pending until capacity frees up
+	OutcomeQueued
+	// OutcomeExisting is the reservation an earlier request with the same
+	// idempotency key created
+	OutcomeExisting
+)
+
+// ReservationResult is a served reservation request
+type ReservationResult struct {
+	Reservation *dto.ReservationDTO
+	Outcome     Outcome
+}
+
+// Reserve serves a reservation request synchronously: it decides where the
+// reservation goes, reserves the resources and returns the instruction.
+// When the request opts into queueing and no cluster has capacity, the
+// reservation is kept Pending (OutcomeQueued) instead of failing; the
+// reservation controller places it once capacity frees up.
+// With an idempotency key, a replay returns the reservation created by the
+// first attempt instead of reserving again.
+func (s *Service) Reserve(
+	ctx context.Context,
+	requesterID string,
+	reqDTO *dto.ReservationRequestDTO,
+	idempotencyKey string,
+) (*ReservationResult, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	// The requester is the authenticated cluster, never the request (prevents spoofing)
+	if requesterID == "" {
+		return nil, errNoClusterID
+	}
+
+	// A retry of a request that already reserved gets the same reservation back
+	hash := requestHash(reqDTO)
+	result, reservationName, err := s.replayReservation(ctx, idempotencyKey, requesterID, hash)
+	if result != nil || err != nil {
+		return result, err
+	}
+
+	spec, err := s.parseReservationRequest(reqDTO, requesterID)
+	if err != nil {
+		return nil, errorf(CodeInvalid, "%v", err)
+	}
+	requestedResources := spec.RequestedResources
+	requestedCPU, requestedMemory := requestedResources.CPU, requestedResources.Memory
+
+	minChunk, err := parseSplitRequest(reqDTO, requestedResources)
+	if err != nil {
+		return nil, errorf(CodeInvalid, "%v", err)
+	}
+
+	// Admissions run one at a time only until the lock or scheduled slot is
+	// recorded; marking the reservation and answering run concurrently
+	endAdmission := sync.OnceFunc(s.decisionEngine.BeginAdmission())
+	defer endAdmission()
+
+	// Run decision engine synchronously
+	placementRequest := placementRequestFor(spec)
+	bestCluster, decision, err := s.decisionEngine.SelectBestClusterWithRecord(ctx, placementRequest)
+
+	// Capacity a queued reservation ranked ahead fits in is left to it
+	var ahead *brokerv1alpha1.Reservation
+	if err == nil && spec.StartTime == nil {
+		if ahead, err = s.queuedAhead(ctx, spec, bestCluster); err != nil {
+			logger.Error(err, "Failed to check the reservation queue")
+			return nil, errorf(CodeInternal, "Failed to check the reservation queue")
+		}
+		if ahead != nil {
+			err = broker.LeaveToQueue(decision, bestCluster, ahead)
+			bestCluster = nil
+		}
+	}
+
+	// No single cluster fits: divide the request across several if allowed
+	if err != nil && reqDTO.Splittable && ahead == nil {
+		parts, splitErr := s.decisionEngine.PlanSplit(ctx, placementRequest, minChunk)
+		if splitErr == nil {
+			specs := make([]brokerv1alpha1.ReservationSpec, len(parts))
+			for i := range specs {
+				specs[i] = *spec
+			}
+			return s.reserveGroup(ctx, reservationName, idempotencyKey, hash, specs, parts, endAdmission)
+		}
+		logger.Info("Split not possible", "requesterID", requesterID, "reason", splitErr.Error())
+	}
+
+	// Nothing fits: try evicting lower-priority Reserved reservations if enabled
+	var preemption *broker.PreemptionPlan
+	if err != nil && s.decisionEngine.Preemption && spec.StartTime == nil && ahead == nil {
+		plan, planErr := s.decisionEngine.PlanPreemption(ctx, placementRequest, reqDTO.Priority)
+		if planErr == nil {
+			preemption = plan
+			bestCluster = plan.Cluster
+			err = nil
+			if decision != nil {
+				decision.SelectedClusterID = plan.Cluster.Spec.ClusterID
+				decision.Message = fmt.Sprintf("No cluster fits; placed on %s by preempting %d reservation(s)",
+					plan.Cluster.Spec.ClusterID, len(plan.Victims))
+			}
+		} else {
+			logger.Info("Preemption not possible", "requesterID", requesterID, "reason", planErr.Error())
+		}
+	}
+
+	if err != nil {
+		logger.Error(err, "No suitable cluster found",
+			"requesterID", requesterID,
+			"requestedCPU", requestedCPU.String(),
+			"requestedMemory", requestedMemory.String(),
+			"queue", reqDTO.Queue)
+		if !reqDTO.Queue {
+			// No reservation is kept, so the decision goes back with the error
+			return nil, &Error{
+				Code:     CodeConflict,
+				Message:  fmt.Sprintf("No suitable cluster found: %v", err),
+				Decision: decision,
+			}
+		}
+	}
+
+	// Create Reservation CRD for record-keeping and lifecycle management
+	reservation := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        reservationName,
+			Namespace:   s.namespace,
+			Annotations: reservationAnnotations(idempotencyKey, hash),
+		},
+		Spec: *spec,
+	}
+	if bestCluster != nil {
+		reservation.Spec.TargetClusterID = bestCluster.Spec.ClusterID
+	}
+	controllerutil.AddFinalizer(reservation, brokerv1alpha1.ReservationFinalizer)
+
+	// Create the reservation CRD
+	if err := s.k8sClient.Create(ctx, reservation); err != nil {
+		endAdmission()
+		// A concurrent replay of the same request created it first
+		if apierrors.IsAlreadyExists(err) {
+			if result, _, replayErr := s.replayReservation(ctx, idempotencyKey, requesterID, hash); result != nil || replayErr != nil {
+				return result, replayErr
+			}
+		}
+		logger.Error(err, "Failed to create reservation CRD")
+		return nil, errorf(CodeInternal, "Failed to create reservation")
+	}
+
+	// Written with the next status update
+	reservation.Status.Decision = decision
+
+	// Nothing fits right now: leave the reservation queued for the controller
+	if bestCluster == nil {
+		endAdmission()
+		return s.queue(ctx, reservation), nil
+	}
+
+	// A future window locks nothing yet; the controller locks it when the window opens
+	if reservation.Spec.StartTime != nil {
+		return s.schedule(ctx, reservation)
+	}
+
+	// Lock resources in the target cluster through the engine every interface shares
+	var lockErr error
+	if preemption != nil {
+		// Victims' locks are released and ours taken in the same update
+		_, lockErr = s.decisionEngine.ExecutePreemption(ctx, preemption, reservation)
+	} else {
+		_, lockErr = s.decisionEngine.LockResources(ctx, bestCluster.Spec.ClusterID, requestedResources)
+	}
+	endAdmission()
+
+	if lockErr != nil && reservation.Spec.Queue {
+		// Capacity was taken concurrently; let the controller pick another cluster
+		logger.Info("Failed to lock resources, queueing reservation", "reason", lockErr.Error())
+		reservation.Spec.TargetClusterID = ""
+		if err := s.k8sClient.Update(ctx, reservation); err != nil {
+			logger.Error(err, "Failed to clear target cluster of queued reservation")
+		}
+		return s.queue(ctx, reservation), nil
+	}
+
+	if lockErr != nil {
+		logger.Error(lockErr, "Failed to lock resources")
+		// Mark reservation as failed
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = fmt.Sprintf("Failed to lock resources: %v", lockErr)
+		reservation.Status.LastUpdateTime = metav1.Now()
+		_ = s.k8sClient.Status().Update(ctx, reservation)
+
+		return nil, errorf(CodeConflict, "Failed to reserve resources: %v", lockErr)
+	}
+
+	// Mark reservation as Reserved; a reservation that cannot be marked
+	// gives its resources back rather than holding them unrecorded
+	if err := s.markReserved(ctx, reservation); err != nil {
+		logger.Error(err, "Failed to mark reservation Reserved", "reservation", reservationName)
+		return nil, s.recordFailed(ctx, []*brokerv1alpha1.Reservation{reservation}, err)
+	}
+
+	logger.Info("Reservation created synchronously",
+		"reservation", reservationName,
+		"requester", requesterID,
+		"targetCluster", bestCluster.Spec.ClusterID,
+		"preempted", preemptedCount(preemption),
+		"cpu", requestedCPU.String(),
+		"memory", requestedMemory.String())
+
+	// Return the instruction in the response
+	return &ReservationResult{Reservation: dto.FromReservation(reservation), Outcome: OutcomeCreated}, nil
+}
+
+// DryRun runs the same decision as Reserve (selection, split, preemption,
+// queueing) and the lock checks against current capacity, but creates no
+// Reservation and leaves Reserved untouched. Whether the request would
+// succeed is in the result.
+func (s *Service) DryRun(
+	ctx context.Context,
+	requesterID string,
+	reqDTO *dto.ReservationRequestDTO,
+) (*dto.DryRunResultDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	if requesterID == "" {
+		return nil, errNoClusterID
+	}
+
+	spec, err := s.parseReservationRequest(reqDTO, requesterID)
+	if err != nil {
+		return nil, errorf(CodeInvalid, "%v", err)
+	}
+	minChunk, err := parseSplitRequest(reqDTO, spec.RequestedResources)
+	if err != nil {
+		return nil, errorf(CodeInvalid, "%v", err)
+	}
+
+	result, err := s.dryRun(ctx, spec, reqDTO.Splittable, minChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Reservation dry run",
+		"requesterID", requesterID,
+		"outcome", result.Outcome,
+		"targetCluster", result.TargetClusterID)
+	return result, nil
+}
+
+// dryRun evaluates a reservation request in the order Reserve tries the
+// alternatives: a single cluster, a split, preemption, then the queue. Only
+// failing to evaluate it is an error.
+func (s *Service) dryRun(
+	ctx context.Context,
+	spec *brokerv1alpha1.ReservationSpec,
+	splittable bool,
+	minChunk brokerv1alpha1.RequestedResourceQuantities,
+) (*dto.DryRunResultDTO, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+	placementRequest := placementRequestFor(spec)
+	result := &dto.DryRunResultDTO{}
+
+	bestCluster, decision, err := s.decisionEngine.SelectBestClusterWithRecord(ctx, placementRequest)
+
+	// Capacity a queued reservation ranked ahead fits in is left to it
+	var ahead *brokerv1alpha1.Reservation
+	if err == nil && spec.StartTime == nil {
+		if ahead, err = s.queuedAhead(ctx, spec, bestCluster); err != nil {
+			logger.Error(err, "Failed to check the reservation queue")
+			return nil, errorf(CodeInternal, "Failed to check the reservation queue")
+		}
+		if ahead != nil {
+			err = broker.LeaveToQueue(decision, bestCluster, ahead)
+		}
+	}
+	if decision != nil {
+		result.Decision = dto.FromDecisionRecord(decision)
+	}
+
+	if err == nil && spec.StartTime != nil {
+		result.Feasible = true
+		result.Outcome = "Scheduled"
+		result.TargetClusterID = bestCluster.Spec.ClusterID
+		result.Message = fmt.Sprintf("Would hold a slot in cluster %s from %s",
+			bestCluster.Spec.ClusterID, spec.StartTime.UTC().Format(time.RFC3339))
+		return result, nil
+	}
+
+	if err == nil {
+		result.TargetClusterID = bestCluster.Spec.ClusterID
+		lockErr := s.decisionEngine.CheckGroupLock(ctx, []broker.GroupPart{
+			{Cluster: bestCluster, Resources: spec.RequestedResources},
+		})
+		if lockErr == nil {
+			result.Feasible = true
+			result.Outcome = "Reserved"
+			result.Message = fmt.Sprintf("Would lock resources in cluster %s", bestCluster.Spec.ClusterID)
+			return result, nil
+		}
+		err = lockErr
+	}
+
+	if splittable && ahead == nil {
+		parts, splitErr := s.decisionEngine.PlanSplit(ctx, placementRequest, minChunk)
+		if splitErr == nil && s.decisionEngine.CheckGroupLock(ctx, parts) == nil {
+			result.Feasible = true
+			result.Outcome = "Split"
+			result.TargetClusterID = ""
+			result.Message = fmt.Sprintf("Would split the request across %d clusters", len(parts))
+			for _, part := range parts {
+				result.Parts = append(result.Parts, dto.DryRunPartDTO{
+					TargetClusterID:    part.Cluster.Spec.ClusterID,
+					RequestedResources: dto.FromRequestedResources(part.Resources),
+				})
+			}
+			return result, nil
+		}
+	}
+
+	if s.decisionEngine.Preemption && spec.StartTime == nil && ahead == nil {
+		plan, planErr := s.decisionEngine.PlanPreemption(ctx, placementRequest, spec.Priority)
+		if planErr == nil {
+			result.Feasible = true
+			result.Outcome = "Preemption"
+			result.TargetClusterID = plan.Cluster.Spec.ClusterID
+			result.Message = fmt.Sprintf("Would lock resources in cluster %s by preempting %d reservation(s)",
+				plan.Cluster.Spec.ClusterID, len(plan.Victims))
+			for _, victim := range plan.Victims {
+				result.Preempted = append(result.Preempted, victim.Name)
+			}
+			return result, nil
+		}
+	}
+
+	result.TargetClusterID = ""
+	if spec.Queue {
+		result.Outcome = "Queued"
+		result.Message = fmt.Sprintf("Would be queued until capacity frees up: %v", err)
+		return result, nil
+	}
+	result.Outcome = "Rejected"
+	result.Message = fmt.Sprintf("No suitable cluster found: %v", err)
+	return result, nil
+}
+
+// ReserveGang reserves several differently-shaped blocks in one call (e.g. a
+// GPU block and a CPU block), possibly on different clusters. Either every
+// member is locked or none is: members are planned together and locked via
+// LockGroup, which releases earlier locks if a later one fails. Members cannot
+// be queued or split. Honours the idempotency key like Reserve.
+func (s *Service) ReserveGang(
+	ctx context.Context,
+	requesterID string,
+	reqDTO *dto.GangReservationRequestDTO,
+	idempotencyKey string,
+) (*ReservationResult, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	if requesterID == "" {
+		return nil, errNoClusterID
+	}
+
+	if len(reqDTO.Members) == 0 {
+		return nil, errorf(CodeInvalid, "members must not be empty")
+	}
+
+	hash := requestHash(reqDTO)
+	result, groupID, err := s.replayReservation(ctx, idempotencyKey, requesterID, hash)
+	if result != nil || err != nil {
+		return result, err
+	}
+
+	specs := make([]brokerv1alpha1.ReservationSpec, 0, len(reqDTO.Members))
+	members := make([]broker.PlacementRequest, 0, len(reqDTO.Members))
+	for i := range reqDTO.Members {
+		member := &reqDTO.Members[i]
+		if member.Queue || member.Splittable || member.StartTime != nil {
+			return nil, errorf(CodeInvalid, "Invalid member %d: gang members cannot be queued, split or scheduled", i+1)
+		}
+
+		spec, err := s.parseReservationRequest(member, requesterID)
+		if err != nil {
+			return nil, errorf(CodeInvalid, "Invalid member %d: %v", i+1, err)
+		}
+		specs = append(specs, *spec)
+		members = append(members, placementRequestFor(spec))
+	}
+
+	endAdmission := sync.OnceFunc(s.decisionEngine.BeginAdmission())
+	defer endAdmission()
+
+	parts, err := s.decisionEngine.PlanGang(ctx, members)
+	if err != nil {
+		logger.Error(err, "No placement found for gang",
+			"requesterID", requesterID,
+			"members", len(members))
+		return nil, errorf(CodeConflict, "No placement found for all members: %v", err)
+	}
+
+	return s.reserveGroup(ctx, groupID, idempotencyKey, hash, specs, parts, endAdmission)
+}
+
+// markReserved records that the reservation's resources are locked in its
+// target cluster: first with the locked-by-api annotation, so a takeover by
+// the reservation controller never locks them again, then by marking it
+// Reserved. Both writes are retried on conflicts.
+func (s *Service) markReserved(ctx context.Context, reservation *brokerv1alpha1.Reservation) error {
+	decision := reservation.Status.Decision
+
+	if err := s.setLockedAnnotation(ctx, reservation, true); err != nil {
+		return fmt.Errorf("failed to mark the lock: %w", err)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.k8sClient.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+			return err
+		}
+
+		now := metav1.Now()
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+		reservation.Status.Message = fmt.Sprintf("Resources locked in cluster %s", reservation.Spec.TargetClusterID)
+		reservation.Status.ReservedAt = &now
+		reservation.Status.LastUpdateTime = now
+		if decision != nil {
+			reservation.Status.Decision = decision
+		}
+
+		if reservation.Spec.Duration != nil {
+			expiresAt := metav1.NewTime(now.Add(reservation.Spec.Duration.Duration))
+			reservation.Status.ExpiresAt = &expiresAt
+		}
+
+		return s.k8sClient.Status().Update(ctx, reservation)
+	})
+}
+
+// setLockedAnnotation adds or removes the locked-by-api annotation
+func (s *Service) setLockedAnnotation(ctx context.Context, reservation *brokerv1alpha1.Reservation, locked bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := s.k8sClient.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+			return err
+		}
+		_, marked := reservation.Annotations[brokerv1alpha1.ReservationLockedByAPIAnnotation]
+		switch {
+		case locked == marked:
+			return nil
+		case locked:
+			if reservation.Annotations == nil {
+				reservation.Annotations = make(map[string]string)
+			}
+			reservation.Annotations[brokerv1alpha1.ReservationLockedByAPIAnnotation] = "true"
+		default:
+			delete(reservation.Annotations, brokerv1alpha1.ReservationLockedByAPIAnnotation)
+		}
+		return s.k8sClient.Update(ctx, reservation)
+	})
+}
+
+// abandonLocks gives back the resources locked for reservations that could
+// not be marked Reserved and fails them, so a replay of the request is not
+// answered with a reservation that holds nothing. The lock mark is only
+// removed once the resources are back; a lock that could not be released is
+// kept marked and left for the Reserved audit to correct. Returns an error if
+// any lock could not be released or any reservation could not be failed.
+func (s *Service) abandonLocks(ctx context.Context, reservations []*brokerv1alpha1.Reservation, cause error) error {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	var errs []error
+	for _, reservation := range reservations {
+		message := fmt.Sprintf("Failed to record the reservation: %v", cause)
+		if err := s.decisionEngine.ReleaseResources(ctx,
+			reservation.Spec.TargetClusterID, reservation.Spec.RequestedResources); err != nil {
+			logger.Error(err, "Failed to release resources of unrecorded reservation",
+				"reservation", reservation.Name)
+			errs = append(errs, fmt.Errorf("failed to release %s: %w", reservation.Name, err))
+			message += "; its resources could not be released"
+		} else if err := s.setLockedAnnotation(ctx, reservation, false); err != nil {
+			logger.Error(err, "Failed to remove lock mark of unrecorded reservation",
+				"reservation", reservation.Name)
+		}
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := s.k8sClient.Get(ctx, client.ObjectKeyFromObject(reservation), reservation); err != nil {
+				return err
+			}
+			reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+			reservation.Status.Message = message
+			reservation.Status.LastUpdateTime = metav1.Now()
+			return s.k8sClient.Status().Update(ctx, reservation)
+		})
+		if err != nil {
+			logger.Error(err, "Failed to mark unrecorded reservation Failed", "reservation", reservation.Name)
+			errs = append(errs, fmt.Errorf("failed to fail %s: %w", reservation.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordFailed abandons the locks of reservations that could not be marked
+// Reserved and returns the error for the requester
+func (s *Service) recordFailed(ctx context.Context, reservations []*brokerv1alpha1.Reservation, cause error) error {
+	if err := s.abandonLocks(ctx, reservations, cause); err != nil {
+		return errorf(CodeInternal, "Failed to record reservation, and to roll back its lock: %v", err)
+	}
+	return errorf(CodeInternal, "Failed to record reservation")
+}
+
+// reserveGroup creates one Reservation per part of a group (the parts of a
+// split request or the members of a gang), linked by a GroupID, and locks all
+// parts together. specs[i] describes the reservation for parts[i]. If any part
+// cannot be locked none are, and every part is marked Failed. The result
+// lists one entry per part so the requester can create an instruction for each.
+// groupID is the name replayReservation chose for the group, and hash
+// identifies the request for idempotent replays (see requestHash).
+// endAdmission is called once the parts are locked, or could not be.
+func (s *Service) reserveGroup(
+	ctx context.Context,
+	groupID, idempotencyKey, hash string,
+	specs []brokerv1alpha1.ReservationSpec,
+	parts []broker.GroupPart,
+	endAdmission func(),
+) (*ReservationResult, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	requesterID := specs[0].RequesterID
+
+	reservations := make([]*brokerv1alpha1.Reservation, 0, len(parts))
+	failAll := func(message string) {
+		for _, reservation := range reservations {
+			reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+			reservation.Status.Message = message
+			reservation.Status.LastUpdateTime = metav1.Now()
+			_ = s.k8sClient.Status().Update(ctx, reservation)
+		}
+	}
+
+	for i, part := range parts {
+		partSpec := specs[i]
+		partSpec.TargetClusterID = part.Cluster.Spec.ClusterID
+		partSpec.RequestedResources = part.Resources
+		partSpec.GroupID = groupID
+		partSpec.Queue = false
+
+		reservation := &brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-%d", groupID, i+1),
+				Namespace:   s.namespace,
+				Labels:      map[string]string{brokerv1alpha1.ReservationGroupLabel: groupID},
+				Annotations: reservationAnnotations(idempotencyKey, hash),
+			},
+			Spec: partSpec,
+		}
+		controllerutil.AddFinalizer(reservation, brokerv1alpha1.ReservationFinalizer)
+
+		if err := s.k8sClient.Create(ctx, reservation); err != nil {
+			endAdmission()
+			// A concurrent replay of the same request created the group first
+			if i == 0 && apierrors.IsAlreadyExists(err) {
+				if result, _, replayErr := s.replayReservation(ctx, idempotencyKey, requesterID, hash); result != nil || replayErr != nil {
+					return result, replayErr
+				}
+			}
+			logger.Error(err, "Failed to create group reservation part", "group", groupID)
+			failAll("Failed to create the other parts of the reservation group")
+			return nil, errorf(CodeInternal, "Failed to create reservation")
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	err := s.decisionEngine.LockGroup(ctx, parts)
+	endAdmission()
+	if err != nil {
+		logger.Error(err, "Failed to lock reservation group", "group", groupID)
+		failAll(fmt.Sprintf("Failed to lock resources: %v", err))
+		return nil, errorf(CodeConflict, "Failed to reserve resources: %v", err)
+	}
+
+	for _, reservation := range reservations {
+		if err := s.markReserved(ctx, reservation); err != nil {
+			logger.Error(err, "Failed to mark reservation group part Reserved", "reservation", reservation.Name)
+			return nil, s.recordFailed(ctx, reservations, err)
+		}
+	}
+
+	response := &dto.ReservationDTO{
+		ID:                 groupID,
+		RequesterID:        requesterID,
+		RequestedResources: totalResources(parts),
+		Status: dto.ReservationStatusDTO{
+			Phase:   string(brokerv1alpha1.ReservationPhaseReserved),
+			Message: fmt.Sprintf("Resources locked in %d parts", len(parts)),
+		},
+		GroupID: groupID,
+	}
+	for _, reservation := range reservations {
+		part := dto.FromReservation(reservation)
+		response.Parts = append(response.Parts, part)
+		response.CreatedAt = part.CreatedAt
+		response.Status.ReservedAt = part.Status.ReservedAt
+		response.Status.ExpiresAt = part.Status.ExpiresAt
+
+		logger.Info("Reservation group part locked",
+			"reservation", reservation.Name,
+			"group", groupID,
+			"targetCluster", reservation.Spec.TargetClusterID,
+			"cpu", reservation.Spec.RequestedResources.CPU.String(),
+			"memory", reservation.Spec.RequestedResources.Memory.String())
+	}
+
+	return &ReservationResult{Reservation: response, Outcome: OutcomeCreated}, nil
+}
+
+// totalResources sums the resources of all parts of a group
+func totalResources(parts []broker.GroupPart) dto.ResourceQuantitiesDTO {
+	var total brokerv1alpha1.RequestedResourceQuantities
+	for _, part := range parts {
+		total.CPU.Add(part.Resources.CPU)
+		total.Memory.Add(part.Resources.Memory)
+		if part.Resources.GPU != nil {
+			if total.GPU == nil {
+				total.GPU = &resource.Quantity{}
+			}
+			total.GPU.Add(*part.Resources.GPU)
+		}
+		for name, qty := range part.Resources.Extended {
+			if total.Extended == nil {
+				total.Extended = make(map[string]resource.Quantity)
+			}
+			sum := total.Extended[name]
+			sum.Add(qty)
+			total.Extended[name] = sum
+		}
+	}
+	return dto.FromRequestedResources(total)
+}
+
+// parseReservationRequest validates a reservation request and converts it
+// into the spec of the requester's Reservation. Errors are client errors.
+func (s *Service) parseReservationRequest(reqDTO *dto.ReservationRequestDTO, requesterID string) (*brokerv1alpha1.ReservationSpec, error) {
+	// Validate requested resources
+	if reqDTO.RequestedResources.CPU == "" || reqDTO.RequestedResources.Memory == "" {
+		return nil, errors.New("requestedResources.cpu and requestedResources.memory are required")
+	}
+
+	requestedCPU, err := resource.ParseQuantity(reqDTO.RequestedResources.CPU)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CPU quantity: %v", err)
+	}
+	requestedMemory, err := resource.ParseQuantity(reqDTO.RequestedResources.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory quantity: %v", err)
+	}
+
+	if requestedCPU.Sign() <= 0 || requestedMemory.Sign() <= 0 {
+		return nil, errors.New("requested CPU and memory must be greater than zero")
+	}
+
+	requestedResources := brokerv1alpha1.RequestedResourceQuantities{
+		CPU:    requestedCPU,
+		Memory: requestedMemory,
+	}
+
+	// GPUs are optional; only clusters advertising enough GPUs are eligible
+	if reqDTO.RequestedResources.GPU != "" {
+		requestedGPU, err := resource.ParseQuantity(reqDTO.RequestedResources.GPU)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU quantity: %v", err)
+		}
+		if requestedGPU.Sign() < 0 {
+			return nil, errors.New("requested GPU must not be negative")
+		}
+		if requestedGPU.Sign() > 0 {
+			requestedResources.GPU = &requestedGPU
+		}
+	}
+
+	// Extended resources are optional; only clusters advertising enough of each are eligible
+	requestedExtended, err := dto.ParseExtended(reqDTO.RequestedResources.Extended)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended resource: %v", err)
+	}
+	for name, qty := range requestedExtended {
+		if qty.Sign() < 0 {
+			return nil, fmt.Errorf("requested extended resource %s must not be negative", name)
+		}
+		if qty.Sign() == 0 {
+			delete(requestedExtended, name)
+		}
+	}
+	if len(requestedExtended) > 0 {
+		requestedResources.Extended = requestedExtended
+	}
+
+	// Validate scoring strategy override if provided
+	scoringStrategy := brokerv1alpha1.ScoringStrategyType(reqDTO.ScoringStrategy)
+	if _, err := broker.NewScoringStrategy(scoringStrategy); err != nil {
+		return nil, fmt.Errorf("invalid scoring strategy: %v", err)
+	}
+
+	// Validate placement constraints if provided
+	placement := dto.ToPlacementConstraints(reqDTO.Placement)
+	if err := broker.ValidatePlacement(placement); err != nil {
+		return nil, fmt.Errorf("invalid placement: %v", err)
+	}
+
+	// Parse duration if provided
+	var duration *metav1.Duration
+	if reqDTO.Duration != "" {
+		d, err := time.ParseDuration(reqDTO.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %v", err)
+		}
+		duration = &metav1.Duration{Duration: d}
+		if s.maxLifetime > 0 && d > s.maxLifetime {
+			return nil, fmt.Errorf("duration %s exceeds the maximum reservation lifetime of %s", d, s.maxLifetime)
+		}
+	}
+
+	// Parse the start of a future window if provided
+	var startTime *metav1.Time
+	if reqDTO.StartTime != nil {
+		if duration == nil {
+			return nil, errors.New("startTime requires a duration")
+		}
+		if !reqDTO.StartTime.After(time.Now()) {
+			return nil, errors.New("startTime must be in the future")
+		}
+		if reqDTO.Queue || reqDTO.Splittable {
+			return nil, errors.New("scheduled reservations cannot be queued or split")
+		}
+		startTime = &metav1.Time{Time: *reqDTO.StartTime}
+	}
+
+	return &brokerv1alpha1.ReservationSpec{
+		RequesterID:        requesterID,
+		RequestedResources: requestedResources,
+		Duration:           duration,
+		StartTime:          startTime,
+		Priority:           reqDTO.Priority,
+		ScoringStrategy:    scoringStrategy,
+		Placement:          placement,
+		Queue:              reqDTO.Queue,
+	}, nil
+}
+
+// placementRequestFor builds the decision engine input for a reservation spec
+func placementRequestFor(spec *brokerv1alpha1.ReservationSpec) broker.PlacementRequest {
+	request := broker.PlacementRequest{
+		RequesterID: spec.RequesterID,
+		Resources:   spec.RequestedResources,
+		Strategy:    spec.ScoringStrategy,
+		Placement:   spec.Placement,
+	}
+	if spec.StartTime != nil {
+		request.Window = broker.Window{
+			Start: spec.StartTime.Time,
+			End:   spec.StartTime.Add(spec.Duration.Duration),
+		}
+	} else if spec.Duration != nil {
+		request.Duration = spec.Duration.Duration
+	}
+	return request
+}
+
+// parseSplitRequest validates the split options of a request and returns its
+// minimum part size. Splitting divides CPU and memory proportionally; other
+// resources can't be divided. Errors are client errors.
+func parseSplitRequest(
+	reqDTO *dto.ReservationRequestDTO,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) (brokerv1alpha1.RequestedResourceQuantities, error) {
+	var minChunk brokerv1alpha1.RequestedResourceQuantities
+	if !reqDTO.Splittable {
+		return minChunk, nil
+	}
+
+	if requested.GPU != nil || len(requested.Extended) > 0 {
+		return minChunk, broker.ErrSplitUnsupported
+	}
+
+	if chunk := reqDTO.MinChunk; chunk != nil {
+		if chunk.CPU != "" {
+			cpu, err := resource.ParseQuantity(chunk.CPU)
+			if err != nil {
+				return minChunk, fmt.Errorf("invalid minChunk cpu: %w", err)
+			}
+			minChunk.CPU = cpu
+		}
+		if chunk.Memory != "" {
+			memory, err := resource.ParseQuantity(chunk.Memory)
+			if err != nil {
+				return minChunk, fmt.Errorf("invalid minChunk memory: %w", err)
+			}
+			minChunk.Memory = memory
+		}
+	}
+
+	if err := broker.ValidateMinChunk(requested, minChunk); err != nil {
+		return minChunk, fmt.Errorf("invalid minChunk: %w", err)
+	}
+	return minChunk, nil
+}
+
+// queue marks the reservation Pending with its queue position. The
+// reservation controller retries it whenever cluster capacity changes.
+func (s *Service) queue(ctx context.Context, reservation *brokerv1alpha1.Reservation) *ReservationResult {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhasePending
+	reservation.Status.LastUpdateTime = metav1.Now()
+
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, reservationList); err != nil {
+		logger.Error(err, "Failed to list reservations for queue position")
+		reservation.Status.Message = "Queued: waiting for capacity"
+	} else {
+		queued := broker.QueuedReservations(reservationList)
+		reservation.Status.QueuePosition = broker.QueuePosition(queued, reservation.Namespace, reservation.Name)
+		reservation.Status.Message = fmt.Sprintf("Queued: waiting for capacity (position %d of %d). Requested: %s CPU, %s Memory.",
+			reservation.Status.QueuePosition, len(queued),
+			reservation.Spec.RequestedResources.CPU.String(),
+			reservation.Spec.RequestedResources.Memory.String())
+	}
+
+	if err := s.k8sClient.Status().Update(ctx, reservation); err != nil {
+		logger.Error(err, "Failed to update queued reservation status")
+	}
+
+	logger.Info("Reservation queued",
+		"reservation", reservation.Name,
+		"requester", reservation.Spec.RequesterID,
+		"priority", reservation.Spec.Priority,
+		"position", reservation.Status.QueuePosition)
+
+	return &ReservationResult{Reservation: dto.FromReservation(reservation), Outcome: OutcomeQueued}
+}
+
+// schedule marks the reservation Scheduled. Its slot in the target cluster's
+// future capacity is held by the reservation itself and counted by the
+// decision engine from now on; the reservation controller locks the
+// resources when the window opens.
+func (s *Service) schedule(ctx context.Context, reservation *brokerv1alpha1.Reservation) (*ReservationResult, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	start := reservation.Spec.StartTime.UTC()
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+	reservation.Status.Message = fmt.Sprintf("Scheduled in cluster %s from %s to %s",
+		reservation.Spec.TargetClusterID,
+		start.Format(time.RFC3339),
+		start.Add(reservation.Spec.Duration.Duration).Format(time.RFC3339))
+	reservation.Status.LastUpdateTime = metav1.Now()
+
+	if err := s.k8sClient.Status().Update(ctx, reservation); err != nil {
+		logger.Error(err, "Failed to update scheduled reservation status")
+		return nil, errorf(CodeInternal, "Failed to schedule reservation")
+	}
+	s.decisionEngine.AssumeScheduled(reservation)
+
+	logger.Info("Reservation scheduled",
+		"reservation", reservation.Name,
+		"requester", reservation.Spec.RequesterID,
+		"targetCluster", reservation.Spec.TargetClusterID,
+		"startTime", start,
+		"duration", reservation.Spec.Duration.Duration)
+
+	return &ReservationResult{Reservation: dto.FromReservation(reservation), Outcome: OutcomeCreated}, nil
+}
+
+// GetReservation returns a reservation, e.g. so a requester can follow a
+// queued reservation until it is placed. Only the requester and the provider
+// of the reservation may read it.
+func (s *Service) GetReservation(ctx context.Context, clusterID, reservationID string) (*dto.ReservationDTO, error) {
+	reservation, err := s.getReservation(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal other clusters' reservations
+	if reservation.Spec.RequesterID != clusterID && reservation.Spec.TargetClusterID != clusterID {
+		return nil, errReservationNotFound
+	}
+	return dto.FromReservation(reservation), nil
+}
+
+// GetDecision explains how the broker placed (or failed to place) a
+// reservation: every candidate cluster, why it was filtered out, and each
+// eligible cluster's score. Only the requester may read it, since it
+// describes other clusters.
+func (s *Service) GetDecision(ctx context.Context, clusterID, reservationID string) (*dto.DecisionDTO, error) {
+	reservation, err := s.getReservation(ctx, clusterID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Spec.RequesterID != clusterID {
+		return nil, errReservationNotFound
+	}
+	if reservation.Status.Decision == nil {
+		return nil, errorf(CodeNotFound, "No decision recorded for this reservation")
+	}
+	return dto.FromDecisionRecord(reservation.Status.Decision), nil
+}
+
+// errReservationNotFound also answers for reservations of other clusters
+var errReservationNotFound = errorf(CodeNotFound, "Reservation not found")
+
+// getReservation fetches one reservation by name for the calling cluster,
+// without checking that it is the cluster's
+func (s *Service) getReservation(ctx context.Context, clusterID, reservationID string) (*brokerv1alpha1.Reservation, error) {
+	logger := log.FromContext(ctx).WithName("reservation-service")
+
+	if clusterID == "" {
+		return nil, errNoClusterID
+	}
+	if reservationID == "" {
+		return nil, errorf(CodeInvalid, "Missing reservation id")
+	}
+
+	reservation := &brokerv1alpha1.Reservation{}
+	if err := s.k8sClient.Get(ctx,
+		types.NamespacedName{Name: reservationID, Namespace: s.namespace},
+		reservation); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errReservationNotFound
+		}
+		logger.Error(err, "Failed to fetch reservation")
+		return nil, errorf(CodeInternal, "Failed to fetch reservation")
+	}
+	return reservation, nil
+}
+
+// queuedAhead returns the queued reservation ranked ahead of a new request
+// with the given spec that fits the cluster, or nil (see broker.QueuedAhead)
+func (s *Service) queuedAhead(
+	ctx context.Context,
+	spec *brokerv1alpha1.ReservationSpec,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+) (*brokerv1alpha1.Reservation, error) {
+	request := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()},
+		Spec:       *spec,
+	}
+	return s.decisionEngine.QueuedAhead(ctx, request, cluster)
+}
+
+// preemptedCount returns how many reservations the plan evicts (0 without preemption)
+func preemptedCount(plan *broker.PreemptionPlan) int {
+	if plan == nil {
+		return 0
+	}
+	return len(plan.Victims)
+}
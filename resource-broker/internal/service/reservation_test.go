@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Test: The admission ends once resources are locked, before the reservation is marked Reserved
+func TestReserve_EndsAdmissionAfterLock(t *testing.T) {
+	var s *Service
+	admittedConcurrently := false
+	funcs := &interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			reservation, ok := obj.(*brokerv1alpha1.Reservation)
+			if ok && reservation.Status.Phase == brokerv1alpha1.ReservationPhaseReserved {
+				admitted := make(chan struct{})
+				go func() {
+					s.decisionEngine.BeginAdmission()()
+					close(admitted)
+				}()
+				select {
+				case <-admitted:
+					admittedConcurrently = true
+				case <-time.After(time.Second):
+				}
+			}
+			return c.SubResource(subResource).Update(ctx, obj, opts...)
+		},
+	}
+	s, _ = newFakeService(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	request := smallRequest
+	if _, err := s.Reserve(context.Background(), "cluster-1", &request, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !admittedConcurrently {
+		t.Error("expected another admission to run while the reservation was marked Reserved")
+	}
+}
+
+// Test: A dry run that cannot check the queue fails as an internal error instead of answering Rejected
+func TestDryRun_QueueCheckFails(t *testing.T) {
+	funcs := &interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			// The queue lists every reservation; decisions list only the Scheduled ones
+			if _, ok := list.(*brokerv1alpha1.ReservationList); ok && len(opts) == 0 {
+				return errors.New("etcd unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}
+	s, _ := newFakeService(funcs, makeProvider("cluster-2", "8", "16Gi"))
+
+	request := smallRequest
+	result, err := s.DryRun(context.Background(), "cluster-1", &request)
+	if serviceErr := AsError(err); err == nil || serviceErr.Code != CodeInternal {
+		t.Fatalf("expected an internal error, got %+v (result %+v)", err, result)
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+// Service implements what agents ask the broker to do, independent of the
+// interface the request arrives on. The REST API, gRPC and MQTT decode their
+// own messages, authenticate the calling cluster and call it directly, so
+// every interface shares the same validation, locking and decision path.
+type Service struct {
+	k8sClient      client.Client
+	namespace      string // Namespace of advertisements and reservations
+	decisionEngine *broker.DecisionEngine
+	maxLifetime    time.Duration           // Cap on a reservation's total lifetime across renewals (0 = unlimited)
+	feed           *broker.ReservationFeed // Reservation changes for instruction streams (nil = polling only)
+}
+
+// New creates a service on the broker's k8s client and decision engine
+func New(
+	k8sClient client.Client,
+	namespace string,
+	decisionEngine *broker.DecisionEngine,
+	maxLifetime time.Duration,
+	feed *broker.ReservationFeed,
+) *Service {
+	return &Service{
+		k8sClient:      k8sClient,
+		namespace:      namespace,
+		decisionEngine: decisionEngine,
+		maxLifetime:    maxLifetime,
+		feed:           feed,
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// bookmarkInterval is how often an idle stream gets a bookmark, which keeps
+// the connection open and the requester's resume token current
+const bookmarkInterval = 30 * time.Second
+
+// ErrStreamingDisabled is returned by StreamInstructions when the broker
+// keeps no reservation feed
+var ErrStreamingDisabled = errorf(CodeUnimplemented, "Instruction streaming is not enabled on this broker")
+
+// InstructionSender delivers one event of an instruction stream. Bookmarks
+// carry a nil instruction and only advance the resume token.
+type InstructionSender func(token string, instruction *dto.ReservationDTO) error
+
+// StreamingEnabled reports whether the broker keeps the reservation feed
+// instruction streams need
+func (s *Service) StreamingEnabled() bool {
+	return s.feed != nil
+}
+
+// StreamInstructions sends the cluster's instructions as they change, until
+// ctx ends or send fails. It serves both the SSE endpoint and the gRPC stream.
+// The stream continues right after token. Without a token, or with one the
+// broker can no longer resume from (e.g. after a restart), it starts with
+// every current instruction, followed by a bookmark. Instructions may be
+// repeated, so handling them must be idempotent.
+func (s *Service) StreamInstructions(ctx context.Context, clusterID, token string, send InstructionSender) error {
+	logger := log.FromContext(ctx).WithName("instructions-service")
+
+	if clusterID == "" {
+		return errNoClusterID
+	}
+	if s.feed == nil {
+		return ErrStreamingDisabled
+	}
+
+	logger.Info("Instruction stream opened", "clusterID", clusterID, "resuming", token != "")
+	defer logger.Info("Instruction stream closed", "clusterID", clusterID)
+
+	bookmarks := time.NewTicker(bookmarkInterval)
+	defer bookmarks.Stop()
+
+	for {
+		// Taken first so a change published while sending is not missed
+		changed := s.feed.Changed()
+
+		changes, head, ok := s.feed.Since(token)
+		if !ok {
+			// Everything up to head is in the cache by now, so listing after
+			// taking head cannot miss a change
+			if err := s.sendCurrentInstructions(ctx, clusterID, head, send); err != nil {
+				logger.V(1).Info("Instruction stream ended", "clusterID", clusterID, "error", err)
+				return err
+			}
+		}
+		for _, change := range changes {
+			if !isInstructionFor(change.Reservation, clusterID) {
+				continue
+			}
+			if err := send(change.Token, dto.FromReservation(change.Reservation)); err != nil {
+				logger.V(1).Info("Instruction stream ended", "clusterID", clusterID, "error", err)
+				return err
+			}
+		}
+		token = head
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-bookmarks.C:
+			if err := send(token, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sendCurrentInstructions starts a stream over with every current
+// instruction of the cluster, followed by a bookmark at head
+func (s *Service) sendCurrentInstructions(ctx context.Context, clusterID, head string, send InstructionSender) error {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, reservationList); err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	for i := range reservationList.Items {
+		if !isInstructionFor(&reservationList.Items[i], clusterID) {
+			continue
+		}
+		if err := send(head, dto.FromReservation(&reservationList.Items[i])); err != nil {
+			return err
+		}
+	}
+	return send(head, nil)
+}
+
+// ClusterInstructionSender delivers one instruction to a cluster it is for
+type ClusterInstructionSender func(clusterID string, instruction *dto.ReservationDTO) error
+
+// BroadcastInstructions sends every instruction change to the clusters it is
+// for, until ctx ends or send fails. It serves push-based interfaces (MQTT),
+// whose agents fetch the current instructions themselves when they subscribe.
+// If changes fall out of the feed before they are sent, every current
+// instruction is sent again.
+func (s *Service) BroadcastInstructions(ctx context.Context, send ClusterInstructionSender) error {
+	logger := log.FromContext(ctx).WithName("instructions-service")
+
+	if s.feed == nil {
+		return ErrStreamingDisabled
+	}
+
+	token := s.feed.Head()
+	for {
+		changed := s.feed.Changed()
+
+		changes, head, ok := s.feed.Since(token)
+		if !ok {
+			logger.Info("Instruction broadcast fell behind, sending every current instruction")
+			if err := s.broadcastCurrentInstructions(ctx, send); err != nil {
+				return err
+			}
+		}
+		for _, change := range changes {
+			for _, clusterID := range instructionRecipients(change.Reservation) {
+				if err := send(clusterID, dto.FromReservation(change.Reservation)); err != nil {
+					return err
+				}
+			}
+		}
+		token = head
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// broadcastCurrentInstructions sends every current instruction to the clusters it is for
+func (s *Service) broadcastCurrentInstructions(ctx context.Context, send ClusterInstructionSender) error {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := s.k8sClient.List(ctx, reservationList); err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	for i := range reservationList.Items {
+		for _, clusterID := range instructionRecipients(&reservationList.Items[i]) {
+			if err := send(clusterID, dto.FromReservation(&reservationList.Items[i])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// instructionRecipients returns the clusters the reservation is an instruction
+// or a notice for (its provider, its requester, both or neither)
+func instructionRecipients(rsv *brokerv1alpha1.Reservation) []string {
+	var recipients []string
+	for _, clusterID := range []string{rsv.Spec.TargetClusterID, rsv.Spec.RequesterID} {
+		if clusterID != "" && !slices.Contains(recipients, clusterID) && isInstructionFor(rsv, clusterID) {
+			recipients = append(recipients, clusterID)
+		}
+	}
+	return recipients
+}
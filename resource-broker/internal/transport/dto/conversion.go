@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -38,6 +41,7 @@ func ToClusterAdvertisement(dto *AdvertisementDTO, namespace string) (*brokerv1a
 			ClusterID:   dto.ClusterID,
 			ClusterName: dto.ClusterName,
 			Timestamp:   metav1.Time{Time: dto.Timestamp},
+			Labels:      dto.Labels,
 			Resources: brokerv1alpha1.ResourceMetrics{
 				Capacity:    capacity,
 				Allocatable: allocatable,
@@ -48,6 +52,14 @@ func ToClusterAdvertisement(dto *AdvertisementDTO, namespace string) (*brokerv1a
 		},
 	}
 
+	if dto.Cost != nil {
+		clusterAdv.Spec.Cost = &brokerv1alpha1.CostInfo{
+			CPUCost:    dto.Cost.CPUCost,
+			MemoryCost: dto.Cost.MemoryCost,
+			Currency:   dto.Cost.Currency,
+		}
+	}
+
 	// CRITICAL: Preserve Reserved field from DTO if present (broker-managed)
 	if dto.Resources.Reserved != nil {
 		reserved, err := fromResourceQuantitiesDTO(*dto.Resources.Reserved)
@@ -66,6 +78,7 @@ func FromClusterAdvertisement(clusterAdv *brokerv1alpha1.ClusterAdvertisement) *
 		ClusterID:   clusterAdv.Spec.ClusterID,
 		ClusterName: clusterAdv.Spec.ClusterName,
 		Timestamp:   clusterAdv.Spec.Timestamp.Time,
+		Labels:      clusterAdv.Spec.Labels,
 		Resources: ResourceMetricsDTO{
 			Capacity:    toResourceQuantitiesDTO(clusterAdv.Spec.Resources.Capacity),
 			Allocatable: toResourceQuantitiesDTO(clusterAdv.Spec.Resources.Allocatable),
@@ -74,6 +87,14 @@ func FromClusterAdvertisement(clusterAdv *brokerv1alpha1.ClusterAdvertisement) *
 		},
 	}
 
+	if clusterAdv.Spec.Cost != nil {
+		dto.Cost = &CostInfoDTO{
+			CPUCost:    clusterAdv.Spec.Cost.CPUCost,
+			MemoryCost: clusterAdv.Spec.Cost.MemoryCost,
+			Currency:   clusterAdv.Spec.Cost.Currency,
+		}
+	}
+
 	// CRITICAL: Include Reserved field if present (broker-managed)
 	if clusterAdv.Spec.Resources.Reserved != nil {
 		reserved := toResourceQuantitiesDTO(*clusterAdv.Spec.Resources.Reserved)
@@ -86,23 +107,21 @@ func FromClusterAdvertisement(clusterAdv *brokerv1alpha1.ClusterAdvertisement) *
 // FromReservation converts broker's Reservation to DTO
 func FromReservation(rsv *brokerv1alpha1.Reservation) *ReservationDTO {
 	dto := &ReservationDTO{
-		ID:              rsv.Name,
-		RequesterID:     rsv.Spec.RequesterID,
-		TargetClusterID: rsv.Spec.TargetClusterID,
-		RequestedResources: ResourceQuantitiesDTO{
-			CPU:    rsv.Spec.RequestedResources.CPU.String(),
-			Memory: rsv.Spec.RequestedResources.Memory.String(),
-		},
+		ID:                 rsv.Name,
+		RequesterID:        rsv.Spec.RequesterID,
+		TargetClusterID:    rsv.Spec.TargetClusterID,
+		RequestedResources: FromRequestedResources(rsv.Spec.RequestedResources),
 		Status: ReservationStatusDTO{
-			Phase:   string(rsv.Status.Phase),
-			Message: rsv.Status.Message,
+			Phase:         string(rsv.Status.Phase),
+			Message:       rsv.Status.Message,
+			QueuePosition: rsv.Status.QueuePosition,
 		},
 		CreatedAt: rsv.CreationTimestamp.Time,
+		GroupID:   rsv.Spec.GroupID,
 	}
 
-	// Include GPU if present
-	if rsv.Spec.RequestedResources.GPU != nil {
-		dto.RequestedResources.GPU = rsv.Spec.RequestedResources.GPU.String()
+	if rsv.Spec.StartTime != nil {
+		dto.StartTime = &rsv.Spec.StartTime.Time
 	}
 
 	// Include status times
@@ -114,6 +133,53 @@ func FromReservation(rsv *brokerv1alpha1.Reservation) *ReservationDTO {
 		dto.Status.ExpiresAt = &rsv.Status.ExpiresAt.Time
 	}
 
+	if cond := meta.FindStatusCondition(rsv.Status.Conditions,
+		brokerv1alpha1.ReservationConditionProviderAccepted); cond != nil {
+		accepted := cond.Status == metav1.ConditionTrue
+		dto.Status.ProviderAccepted = &accepted
+	}
+
+	return dto
+}
+
+// FromDecisionRecord converts a Reservation's decision record to DTO
+func FromDecisionRecord(record *brokerv1alpha1.DecisionRecord) *DecisionDTO {
+	dto := &DecisionDTO{
+		DecidedAt:         record.DecidedAt.Time,
+		Strategy:          string(record.Strategy),
+		SelectedClusterID: record.SelectedClusterID,
+		Message:           record.Message,
+		Candidates:        make([]CandidateDTO, 0, len(record.Candidates)),
+	}
+	for _, candidate := range record.Candidates {
+		dto.Candidates = append(dto.Candidates, CandidateDTO{
+			ClusterID: candidate.ClusterID,
+			Eligible:  candidate.Eligible,
+			Reason:    string(candidate.Reason),
+			Detail:    candidate.Detail,
+			Score:     candidate.Score,
+
+			StrategyScore:  candidate.StrategyScore,
+			PlacementBonus: candidate.PlacementBonus,
+		})
+	}
+	return dto
+}
+
+// FromRequestedResources converts requested quantities to DTO format (string-based)
+func FromRequestedResources(rq brokerv1alpha1.RequestedResourceQuantities) ResourceQuantitiesDTO {
+	dto := ResourceQuantitiesDTO{
+		CPU:    rq.CPU.String(),
+		Memory: rq.Memory.String(),
+	}
+
+	// Include GPU if present
+	if rq.GPU != nil {
+		dto.GPU = rq.GPU.String()
+	}
+
+	dto.Extended = toExtendedDTO(rq.Extended)
+
 	return dto
 }
 
@@ -132,6 +198,8 @@ func toResourceQuantitiesDTO(rq brokerv1alpha1.ResourceQuantities) ResourceQuant
 		dto.Storage = rq.Storage.String()
 	}
 
+	dto.Extended = toExtendedDTO(rq.Extended)
+
 	return dto
 }
 
@@ -171,5 +239,78 @@ func fromResourceQuantitiesDTO(dto ResourceQuantitiesDTO) (brokerv1alpha1.Resour
 		rq.Storage = &storageQty
 	}
 
+	// Parse optional extended resources
+	extended, err := ParseExtended(dto.Extended)
+	if err != nil {
+		return rq, err
+	}
+	rq.Extended = extended
+
 	return rq, nil
 }
+
+// ParseExtended converts string-based extended resources to quantities.
+// Returns nil for an empty map.
+func ParseExtended(extended map[string]string) (map[string]resource.Quantity, error) {
+	if len(extended) == 0 {
+		return nil, nil
+	}
+
+	quantities := make(map[string]resource.Quantity, len(extended))
+	for name, value := range extended {
+		qty, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %w", name, err)
+		}
+		quantities[name] = qty
+	}
+	return quantities, nil
+}
+
+// toExtendedDTO converts extended resource quantities to strings
+func toExtendedDTO(extended map[string]resource.Quantity) map[string]string {
+	if len(extended) == 0 {
+		return nil
+	}
+
+	dto := make(map[string]string, len(extended))
+	for name, qty := range extended {
+		dto[name] = qty.String()
+	}
+	return dto
+}
+
+// ToPlacementConstraints converts placement DTO to the Reservation spec format.
+// Returns nil when no placement was requested.
+func ToPlacementConstraints(dto *PlacementDTO) *brokerv1alpha1.PlacementConstraints {
+	if dto == nil || (len(dto.Required) == 0 && len(dto.Preferred) == 0) {
+		return nil
+	}
+
+	placement := &brokerv1alpha1.PlacementConstraints{
+		Required: toLabelSelectorRequirements(dto.Required),
+	}
+	for _, term := range dto.Preferred {
+		placement.Preferred = append(placement.Preferred, brokerv1alpha1.PreferredPlacementTerm{
+			Weight:       term.Weight,
+			Requirements: toLabelSelectorRequirements(term.Requirements),
+		})
+	}
+	return placement
+}
+
+func toLabelSelectorRequirements(requirements []LabelRequirementDTO) []metav1.LabelSelectorRequirement {
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	result := make([]metav1.LabelSelectorRequirement, 0, len(requirements))
+	for _, requirement := range requirements {
+		result = append(result, metav1.LabelSelectorRequirement{
+			Key:      requirement.Key,
+			Operator: metav1.LabelSelectorOperator(requirement.Operator),
+			Values:   requirement.Values,
+		})
+	}
+	return result
+}
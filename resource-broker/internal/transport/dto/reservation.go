@@ -10,14 +10,72 @@ type ReservationDTO struct {
 	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
 	Status             ReservationStatusDTO  `json:"status"`
 	CreatedAt          time.Time             `json:"createdAt"`
+	StartTime          *time.Time            `json:"startTime,omitempty"` // set for scheduled reservations
+
+	// Set when the request was split across several clusters. The group
+	// response carries the group ID as ID and one entry per provider in Parts.
+	GroupID string            `json:"groupID,omitempty"`
+	Parts   []*ReservationDTO `json:"parts,omitempty"`
 }
 
 // ReservationStatusDTO represents the status of a reservation
 type ReservationStatusDTO struct {
-	Phase      string     `json:"phase"` // Pending, Reserved, Active, Released, Failed
+	Phase      string     `json:"phase"` // Pending, Scheduled, Reserved, Active, Orphaned, Released, Failed
 	Message    string     `json:"message"`
 	ReservedAt *time.Time `json:"reservedAt,omitempty"`
 	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+
+	QueuePosition int32 `json:"queuePosition,omitempty"` // 1-based, set while Pending in the queue
+
+	// ProviderAccepted is the provider's answer: true once it holds the
+	// capacity, false if it rejected the reservation, unset until it answers
+	ProviderAccepted *bool `json:"providerAccepted,omitempty"`
+}
+
+// DecisionDTO explains how the broker chose (or failed to choose) a cluster
+type DecisionDTO struct {
+	DecidedAt         time.Time      `json:"decidedAt"`
+	Strategy          string         `json:"strategy,omitempty"`
+	SelectedClusterID string         `json:"selectedClusterID,omitempty"`
+	Message           string         `json:"message,omitempty"`
+	Candidates        []CandidateDTO `json:"candidates"`
+}
+
+// CandidateDTO is the evaluation of one candidate cluster
+type CandidateDTO struct {
+	ClusterID string `json:"clusterID"`
+	Eligible  bool   `json:"eligible"`
+	Reason    string `json:"reason,omitempty"` // Self, Stale, PlacementMismatch, InsufficientCPU, ...
+	Detail    string `json:"detail,omitempty"` // e.g., "requested 4 CPU, available 2"
+	Score     string `json:"score,omitempty"`  // eligible clusters only
+
+	StrategyScore  string `json:"strategyScore,omitempty"`  // part of score from the scoring strategy
+	PlacementBonus string `json:"placementBonus,omitempty"` // part of score from preferred placement
+}
+
+// DryRunResultDTO reports what POST /api/v1/reservations would do for a
+// request, without creating a reservation or locking resources
+type DryRunResultDTO struct {
+	Feasible bool   `json:"feasible"`
+	Outcome  string `json:"outcome"` // Reserved, Split, Preemption, Queued, Rejected
+	Message  string `json:"message"`
+
+	TargetClusterID string          `json:"targetClusterID,omitempty"`
+	Parts           []DryRunPartDTO `json:"parts,omitempty"`     // Split outcome
+	Preempted       []string        `json:"preempted,omitempty"` // reservations that would be evicted
+	Decision        *DecisionDTO    `json:"decision,omitempty"`
+}
+
+// DryRunPartDTO is one provider's share of a split request
+type DryRunPartDTO struct {
+	TargetClusterID    string                `json:"targetClusterID"`
+	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
+}
+
+// ErrorDTO is an error response that carries the decision behind it
+type ErrorDTO struct {
+	Error    string       `json:"error"`
+	Decision *DecisionDTO `json:"decision,omitempty"`
 }
 
 // ReservationRequestDTO is sent by an agent to request a resource reservation.
@@ -25,5 +83,56 @@ type ReservationStatusDTO struct {
 type ReservationRequestDTO struct {
 	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
 	Priority           int32                 `json:"priority,omitempty"`
-	Duration           string                `json:"duration,omitempty"` // e.g., "1h", "30m"
+	Duration           string                `json:"duration,omitempty"`        // e.g., "1h", "30m"
+	StartTime          *time.Time            `json:"startTime,omitempty"`       // future window start; requires duration
+	ScoringStrategy    string                `json:"scoringStrategy,omitempty"` // e.g., "MostAllocated"
+	Placement          *PlacementDTO         `json:"placement,omitempty"`
+	Queue              bool                  `json:"queue,omitempty"` // wait for capacity (202 Accepted) instead of 409
+
+	// Splittable lets the broker divide the request across several providers
+	// when no single one fits. Each part is at least MinChunk (CPU and memory only).
+	Splittable bool                   `json:"splittable,omitempty"`
+	MinChunk   *ResourceQuantitiesDTO `json:"minChunk,omitempty"`
+}
+
+// RenewRequestDTO extends a reservation's expiry. The body is optional.
+type RenewRequestDTO struct {
+	Duration string `json:"duration,omitempty"` // new expiry is now + duration; defaults to the reservation's duration
+}
+
+// AcknowledgeRequestDTO is the provider's answer to a reservation it was instructed to hold
+type AcknowledgeRequestDTO struct {
+	Accepted bool   `json:"accepted"`
+	Reason   string `json:"reason,omitempty"` // why it was rejected, e.g. "local policy"
+}
+
+// ResizeRequestDTO changes the CPU and memory of a reservation in place.
+// GPUs and extended resources cannot be resized.
+type ResizeRequestDTO struct {
+	RequestedResources ResourceQuantitiesDTO `json:"requestedResources"`
+}
+
+// GangReservationRequestDTO reserves several resource blocks all-or-nothing.
+// Each member is placed on its own; members may share a cluster.
+type GangReservationRequestDTO struct {
+	Members []ReservationRequestDTO `json:"members"`
+}
+
+// PlacementDTO restricts and ranks candidate clusters by their advertised labels
+type PlacementDTO struct {
+	Required  []LabelRequirementDTO   `json:"required,omitempty"`  // all must match
+	Preferred []PreferredPlacementDTO `json:"preferred,omitempty"` // matching clusters rank higher
+}
+
+// LabelRequirementDTO matches one cluster label
+type LabelRequirementDTO struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"` // In, NotIn, Exists, DoesNotExist
+	Values   []string `json:"values,omitempty"`
+}
+
+// PreferredPlacementDTO favours clusters matching all of its requirements
+type PreferredPlacementDTO struct {
+	Weight       int32                 `json:"weight"` // 1-100
+	Requirements []LabelRequirementDTO `json:"requirements"`
 }
@@ -0,0 +1,191 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/grpc/brokerpb"
+)
+
+// The messages carry the same data as the DTOs, field for field; these
+// functions convert between the two.
+
+// toReservation converts a reservation or reservation group
+func toReservation(in *dto.ReservationDTO) *brokerpb.Reservation {
+	if in == nil {
+		return nil
+	}
+	out := &brokerpb.Reservation{
+		Id:                 in.ID,
+		RequesterId:        in.RequesterID,
+		TargetClusterId:    in.TargetClusterID,
+		RequestedResources: toResourceQuantities(&in.RequestedResources),
+		Status: &brokerpb.ReservationStatus{
+			Phase:            in.Status.Phase,
+			Message:          in.Status.Message,
+			ReservedAt:       toTimestamp(in.Status.ReservedAt),
+			ExpiresAt:        toTimestamp(in.Status.ExpiresAt),
+			QueuePosition:    in.Status.QueuePosition,
+			ProviderAccepted: in.Status.ProviderAccepted,
+		},
+		CreatedAt: toTimestamp(&in.CreatedAt),
+		StartTime: toTimestamp(in.StartTime),
+		GroupId:   in.GroupID,
+	}
+	for _, part := range in.Parts {
+		out.Parts = append(out.Parts, toReservation(part))
+	}
+	return out
+}
+
+// toReservations converts a list of reservations
+func toReservations(in []*dto.ReservationDTO) []*brokerpb.Reservation {
+	var out []*brokerpb.Reservation
+	for _, reservation := range in {
+		out = append(out, toReservation(reservation))
+	}
+	return out
+}
+
+// toAdvertisement converts an advertisement
+func toAdvertisement(in *dto.AdvertisementDTO) *brokerpb.Advertisement {
+	if in == nil {
+		return nil
+	}
+	out := &brokerpb.Advertisement{
+		ClusterId:   in.ClusterID,
+		ClusterName: in.ClusterName,
+		Resources: &brokerpb.ResourceMetrics{
+			Capacity:    toResourceQuantities(&in.Resources.Capacity),
+			Allocatable: toResourceQuantities(&in.Resources.Allocatable),
+			Allocated:   toResourceQuantities(&in.Resources.Allocated),
+			Reserved:    toResourceQuantities(in.Resources.Reserved),
+			Available:   toResourceQuantities(&in.Resources.Available),
+		},
+		Labels:    in.Labels,
+		Timestamp: toTimestamp(&in.Timestamp),
+	}
+	if in.Cost != nil {
+		out.Cost = &brokerpb.CostInfo{
+			CpuCost:    in.Cost.CPUCost,
+			MemoryCost: in.Cost.MemoryCost,
+			Currency:   in.Cost.Currency,
+		}
+	}
+	return out
+}
+
+// fromAdvertisement converts a published advertisement
+func fromAdvertisement(in *brokerpb.Advertisement) *dto.AdvertisementDTO {
+	resources := in.GetResources()
+	out := &dto.AdvertisementDTO{
+		ClusterID:   in.GetClusterId(),
+		ClusterName: in.GetClusterName(),
+		Resources: dto.ResourceMetricsDTO{
+			Capacity:    fromResourceQuantities(resources.GetCapacity()),
+			Allocatable: fromResourceQuantities(resources.GetAllocatable()),
+			Allocated:   fromResourceQuantities(resources.GetAllocated()),
+			Available:   fromResourceQuantities(resources.GetAvailable()),
+		},
+		Labels:    in.GetLabels(),
+		Timestamp: fromTimestamp(in.GetTimestamp()),
+	}
+	if reserved := resources.GetReserved(); reserved != nil {
+		quantities := fromResourceQuantities(reserved)
+		out.Resources.Reserved = &quantities
+	}
+	if cost := in.GetCost(); cost != nil {
+		out.Cost = &dto.CostInfoDTO{
+			CPUCost:    cost.GetCpuCost(),
+			MemoryCost: cost.GetMemoryCost(),
+			Currency:   cost.GetCurrency(),
+		}
+	}
+	return out
+}
+
+// fromReservationRequest converts a reservation request
+func fromReservationRequest(in *brokerpb.ReservationRequest) *dto.ReservationRequestDTO {
+	out := &dto.ReservationRequestDTO{
+		RequestedResources: fromResourceQuantities(in.GetRequestedResources()),
+		Priority:           in.GetPriority(),
+		Duration:           in.GetDuration(),
+		ScoringStrategy:    in.GetScoringStrategy(),
+		Queue:              in.GetQueue(),
+		Splittable:         in.GetSplittable(),
+	}
+	if in.GetStartTime() != nil {
+		start := fromTimestamp(in.GetStartTime())
+		out.StartTime = &start
+	}
+	if placement := in.GetPlacement(); placement != nil {
+		out.Placement = &dto.PlacementDTO{Required: fromLabelRequirements(placement.GetRequired())}
+		for _, preferred := range placement.GetPreferred() {
+			out.Placement.Preferred = append(out.Placement.Preferred, dto.PreferredPlacementDTO{
+				Weight:       preferred.GetWeight(),
+				Requirements: fromLabelRequirements(preferred.GetRequirements()),
+			})
+		}
+	}
+	if in.GetMinChunk() != nil {
+		minChunk := fromResourceQuantities(in.GetMinChunk())
+		out.MinChunk = &minChunk
+	}
+	return out
+}
+
+// fromLabelRequirements converts the label requirements of a placement
+func fromLabelRequirements(in []*brokerpb.LabelRequirement) []dto.LabelRequirementDTO {
+	var out []dto.LabelRequirementDTO
+	for _, requirement := range in {
+		out = append(out, dto.LabelRequirementDTO{
+			Key:      requirement.GetKey(),
+			Operator: requirement.GetOperator(),
+			Values:   requirement.GetValues(),
+		})
+	}
+	return out
+}
+
+// toResourceQuantities converts resource quantities; nil stays unset
+func toResourceQuantities(in *dto.ResourceQuantitiesDTO) *brokerpb.ResourceQuantities {
+	if in == nil {
+		return nil
+	}
+	return &brokerpb.ResourceQuantities{
+		Cpu:      in.CPU,
+		Memory:   in.Memory,
+		Gpu:      in.GPU,
+		Storage:  in.Storage,
+		Extended: in.Extended,
+	}
+}
+
+// fromResourceQuantities converts resource quantities; unset is empty
+func fromResourceQuantities(in *brokerpb.ResourceQuantities) dto.ResourceQuantitiesDTO {
+	return dto.ResourceQuantitiesDTO{
+		CPU:      in.GetCpu(),
+		Memory:   in.GetMemory(),
+		GPU:      in.GetGpu(),
+		Storage:  in.GetStorage(),
+		Extended: in.GetExtended(),
+	}
+}
+
+// toTimestamp converts a time; nil and the zero time stay unset
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// fromTimestamp converts a timestamp; unset is the zero time
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,338 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/api"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/api/middleware"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/grpc/brokerpb"
+)
+
+// Server serves the broker.v1.Broker gRPC service with mTLS.
+// Every RPC calls the same service as the REST API, so both interfaces share
+// the same validation, locking and decision path, and the messages carry the
+// same data as the JSON DTOs.
+type Server struct {
+	brokerpb.UnimplementedBrokerServer
+
+	grpcServer *grpc.Server
+	port       string
+	service    *service.Service
+}
+
+// NewServer creates a new gRPC server with mTLS, using the certificates of
+// the HTTP API (tls.crt, tls.key, ca.crt in certPath)
+func NewServer(port string, certPath string, svc *service.Service) (*Server, error) {
+	tlsConfig, err := api.LoadTLSConfig(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		port:    port,
+		service: svc,
+	}
+	s.grpcServer = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(authenticateUnary, logUnary),
+		grpc.ChainStreamInterceptor(authenticateStream),
+	)
+	brokerpb.RegisterBrokerServer(s.grpcServer, s)
+	healthpb.RegisterHealthServer(s.grpcServer, health.NewServer())
+
+	return s, nil
+}
+
+// Start begins serving gRPC requests
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %s: %w", s.port, err)
+	}
+	return s.grpcServer.Serve(listener)
+}
+
+// Shutdown gracefully shuts down the server, cutting open streams once ctx ends
+func (s *Server) Shutdown(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+	logger.Info("Shutting down gRPC server")
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// PublishAdvertisement stores the calling cluster's advertisement
+func (s *Server) PublishAdvertisement(
+	ctx context.Context,
+	adv *brokerpb.Advertisement,
+) (*brokerpb.PublishAdvertisementResponse, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	resp, err := s.service.PublishAdvertisement(ctx, clusterID, fromAdvertisement(adv))
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	return &brokerpb.PublishAdvertisementResponse{
+		Advertisement:        toAdvertisement(resp.Advertisement),
+		ProviderInstructions: toReservations(resp.ProviderInstructions),
+	}, nil
+}
+
+// RequestReservation reserves resources, or queues or schedules the request
+func (s *Server) RequestReservation(
+	ctx context.Context,
+	req *brokerpb.RequestReservationRequest,
+) (*brokerpb.Reservation, error) {
+	requesterID, _ := middleware.GetClusterID(ctx)
+	result, err := s.service.Reserve(ctx, requesterID, fromReservationRequest(req.GetReservation()), req.GetIdempotencyKey())
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	return toReservation(result.Reservation), nil
+}
+
+// RequestGangReservation reserves several resource blocks all-or-nothing
+func (s *Server) RequestGangReservation(
+	ctx context.Context,
+	req *brokerpb.RequestGangReservationRequest,
+) (*brokerpb.Reservation, error) {
+	gang := &dto.GangReservationRequestDTO{}
+	for _, member := range req.GetMembers() {
+		gang.Members = append(gang.Members, *fromReservationRequest(member))
+	}
+
+	requesterID, _ := middleware.GetClusterID(ctx)
+	result, err := s.service.ReserveGang(ctx, requesterID, gang, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	return toReservation(result.Reservation), nil
+}
+
+// GetReservation returns a reservation
+func (s *Server) GetReservation(ctx context.Context, ref *brokerpb.ReservationRef) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	return reservationResponse(s.service.GetReservation(ctx, clusterID, ref.GetId()))
+}
+
+// ActivateReservation reports that the requester started using a reservation
+func (s *Server) ActivateReservation(ctx context.Context, ref *brokerpb.ReservationRef) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	return reservationResponse(s.service.Activate(ctx, clusterID, ref.GetId()))
+}
+
+// AcknowledgeReservation records the provider's answer to a reservation
+func (s *Server) AcknowledgeReservation(
+	ctx context.Context,
+	req *brokerpb.AcknowledgeReservationRequest,
+) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	ack := &dto.AcknowledgeRequestDTO{Accepted: req.GetAccepted(), Reason: req.GetReason()}
+	return reservationResponse(s.service.Acknowledge(ctx, clusterID, req.GetId(), ack))
+}
+
+// HeartbeatReservation reports that the requester still uses a reservation
+func (s *Server) HeartbeatReservation(ctx context.Context, ref *brokerpb.ReservationRef) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	return reservationResponse(s.service.Heartbeat(ctx, clusterID, ref.GetId()))
+}
+
+// RenewReservation extends a reservation's expiry
+func (s *Server) RenewReservation(
+	ctx context.Context,
+	req *brokerpb.RenewReservationRequest,
+) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	renew := &dto.RenewRequestDTO{Duration: req.GetDuration()}
+	return reservationResponse(s.service.Renew(ctx, clusterID, req.GetId(), renew))
+}
+
+// ResizeReservation changes the CPU and memory of a reservation in place
+func (s *Server) ResizeReservation(
+	ctx context.Context,
+	req *brokerpb.ResizeReservationRequest,
+) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	resize := &dto.ResizeRequestDTO{RequestedResources: fromResourceQuantities(req.GetRequestedResources())}
+	return reservationResponse(s.service.Resize(ctx, clusterID, req.GetId(), resize))
+}
+
+// ReleaseReservation gives a reservation or reservation group back
+func (s *Server) ReleaseReservation(ctx context.Context, ref *brokerpb.ReservationRef) (*brokerpb.Reservation, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	return reservationResponse(s.service.Release(ctx, clusterID, ref.GetId()))
+}
+
+// FetchInstructions returns the calling cluster's current instructions
+func (s *Server) FetchInstructions(
+	ctx context.Context,
+	_ *brokerpb.FetchInstructionsRequest,
+) (*brokerpb.FetchInstructionsResponse, error) {
+	clusterID, _ := middleware.GetClusterID(ctx)
+	instructions, err := s.service.Instructions(ctx, clusterID)
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	return &brokerpb.FetchInstructionsResponse{Instructions: toReservations(instructions)}, nil
+}
+
+// WatchInstructions streams the calling cluster's instructions as they are issued
+func (s *Server) WatchInstructions(
+	req *brokerpb.WatchInstructionsRequest,
+	stream grpc.ServerStreamingServer[brokerpb.InstructionEvent],
+) error {
+	ctx := stream.Context()
+	clusterID, _ := middleware.GetClusterID(ctx)
+
+	err := s.service.StreamInstructions(ctx, clusterID, req.GetResumeToken(),
+		func(token string, instruction *dto.ReservationDTO) error {
+			return stream.Send(&brokerpb.InstructionEvent{
+				ResumeToken: token,
+				Instruction: toReservation(instruction),
+			})
+		})
+	var serviceErr *service.Error
+	if errors.As(err, &serviceErr) {
+		return grpcStatus(err)
+	}
+	return err
+}
+
+// reservationResponse converts the result of a reservation operation
+func reservationResponse(reservation *dto.ReservationDTO, err error) (*brokerpb.Reservation, error) {
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	return toReservation(reservation), nil
+}
+
+// grpcStatus converts a service error to a gRPC status error
+func grpcStatus(err error) error {
+	serviceErr := service.AsError(err)
+	return status.Error(statusCode(serviceErr.Code), serviceErr.Message)
+}
+
+// statusCode maps a service error code to the gRPC code agents handle like
+// the matching REST API status
+func statusCode(code service.Code) codes.Code {
+	switch code {
+	case service.CodeInvalid, service.CodeKeyReused:
+		return codes.InvalidArgument
+	case service.CodeForbidden:
+		return codes.PermissionDenied
+	case service.CodeNotFound:
+		return codes.NotFound
+	case service.CodeConflict:
+		return codes.FailedPrecondition
+	case service.CodeInProgress:
+		return codes.Unavailable
+	case service.CodeUnimplemented:
+		return codes.Unimplemented
+	default:
+		return codes.Internal
+	}
+}
+
+// healthServicePrefix is the method prefix of the standard health service,
+// which like /healthz needs no cluster identity
+var healthServicePrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// authenticate adds the cluster ID from the CN of the client certificate to
+// the context, like middleware.ValidateClientCertificate does for HTTP
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if strings.HasPrefix(fullMethod, healthServicePrefix) {
+		return ctx, nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Client certificate required")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Client certificate required")
+	}
+
+	clusterID := middleware.ClusterIDFromCertificate(tlsInfo.State.PeerCertificates[0])
+	if clusterID == "" {
+		return nil, status.Error(codes.PermissionDenied, "Invalid certificate: no cluster ID in CN")
+	}
+	return middleware.WithClusterID(ctx, clusterID), nil
+}
+
+// authenticateUnary authenticates unary calls
+func authenticateUnary(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticateStream authenticates streaming calls
+func authenticateStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticatedStream carries the context with the cluster ID to stream handlers
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// logUnary logs unary calls with duration and status, like middleware.Logging
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	// Get cluster ID from context if available
+	clusterID, _ := middleware.GetClusterID(ctx)
+
+	logger := log.FromContext(ctx).WithName("grpc-api")
+	logger.Info("gRPC request",
+		"method", info.FullMethod,
+		"clusterID", clusterID,
+		"code", status.Code(err).String(),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return resp, err
+}
@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/grpc/brokerpb"
+)
+
+// Test: Reservation DTOs convert to messages field for field, including optional fields and parts
+func TestToReservation(t *testing.T) {
+	start := time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)
+	accepted := true
+	reservation := &dto.ReservationDTO{
+		ID:                 "grp-1",
+		RequesterID:        "cluster-1",
+		RequestedResources: dto.ResourceQuantitiesDTO{CPU: "2000m", Memory: "4Gi", Extended: map[string]string{"amd.com/gpu": "1"}},
+		Status:             dto.ReservationStatusDTO{Phase: "Scheduled", ProviderAccepted: &accepted},
+		StartTime:          &start,
+		GroupID:            "grp-1",
+		Parts:              []*dto.ReservationDTO{{ID: "grp-1-0", TargetClusterID: "cluster-2"}},
+	}
+
+	msg := toReservation(reservation)
+
+	if msg.RequesterId != "cluster-1" || msg.GroupId != "grp-1" {
+		t.Errorf("Expected requester and group IDs to carry over, got %q and %q", msg.RequesterId, msg.GroupId)
+	}
+	if msg.RequestedResources.GetExtended()["amd.com/gpu"] != "1" {
+		t.Errorf("Expected extended resources to carry over, got %v", msg.RequestedResources.GetExtended())
+	}
+	if msg.Status.ProviderAccepted == nil || !*msg.Status.ProviderAccepted {
+		t.Error("Expected the provider's answer to carry over")
+	}
+	if !msg.StartTime.AsTime().Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, msg.StartTime.AsTime())
+	}
+	if len(msg.Parts) != 1 || msg.Parts[0].TargetClusterId != "cluster-2" {
+		t.Errorf("Expected one part on cluster-2, got %v", msg.Parts)
+	}
+}
+
+// Test: Reservation requests convert to the DTO the REST API decodes
+func TestFromReservationRequest(t *testing.T) {
+	start := time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)
+	req := fromReservationRequest(&brokerpb.ReservationRequest{
+		RequestedResources: &brokerpb.ResourceQuantities{Cpu: "2", Memory: "4Gi"},
+		Duration:           "1h",
+		StartTime:          timestamppb.New(start),
+		Placement: &brokerpb.Placement{
+			Required: []*brokerpb.LabelRequirement{{Key: "region", Operator: "In", Values: []string{"eu"}}},
+		},
+		Splittable: true,
+		MinChunk:   &brokerpb.ResourceQuantities{Cpu: "1"},
+	})
+
+	if req.RequestedResources.CPU != "2" || req.Duration != "1h" || !req.Splittable {
+		t.Errorf("Expected resources, duration and splittable to carry over, got %+v", req)
+	}
+	if req.StartTime == nil || !req.StartTime.Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, req.StartTime)
+	}
+	if req.Placement == nil || len(req.Placement.Required) != 1 || req.Placement.Required[0].Key != "region" {
+		t.Errorf("Expected the placement to carry over, got %+v", req.Placement)
+	}
+	if req.MinChunk == nil || req.MinChunk.CPU != "1" {
+		t.Errorf("Expected the minimum chunk to carry over, got %+v", req.MinChunk)
+	}
+
+	// Unset optional fields stay unset, so both interfaces hash the same request alike
+	empty := fromReservationRequest(&brokerpb.ReservationRequest{})
+	if empty.StartTime != nil || empty.Placement != nil || empty.MinChunk != nil {
+		t.Errorf("Expected unset optional fields, got %+v", empty)
+	}
+}
+
+// Test: Service errors map to gRPC codes with their message
+func TestGRPCStatus(t *testing.T) {
+	cases := map[service.Code]codes.Code{
+		service.CodeInvalid:    codes.InvalidArgument,
+		service.CodeForbidden:  codes.PermissionDenied,
+		service.CodeNotFound:   codes.NotFound,
+		service.CodeConflict:   codes.FailedPrecondition,
+		service.CodeKeyReused:  codes.InvalidArgument,
+		service.CodeInProgress: codes.Unavailable,
+		service.CodeInternal:   codes.Internal,
+	}
+	for code, want := range cases {
+		if got := statusCode(code); got != want {
+			t.Errorf("Expected %d to map to %s, got %s", code, want, got)
+		}
+	}
+
+	st := status.Convert(grpcStatus(&service.Error{Code: service.CodeConflict, Message: "No cluster has enough resources"}))
+	if st.Code() != codes.FailedPrecondition || st.Message() != "No cluster has enough resources" {
+		t.Errorf("Expected FailedPrecondition with the service message, got %s %q", st.Code(), st.Message())
+	}
+	if got := status.Code(grpcStatus(errors.New("etcd unavailable"))); got != codes.Internal {
+		t.Errorf("Expected other errors to map to Internal, got %s", got)
+	}
+}
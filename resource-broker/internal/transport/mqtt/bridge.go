@@ -0,0 +1,342 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+const (
+	// requestTimeout bounds how long one request from an agent may take
+	requestTimeout = 30 * time.Second
+
+	// publishTimeout bounds how long the broker waits for the MQTT server to
+	// accept a message before it gives up on it
+	publishTimeout = 10 * time.Second
+)
+
+// Bridge connects the broker to an external MQTT server as a client. It
+// serves the advertisements and requests agents publish with the broker
+// service, like the HTTP and gRPC APIs, and publishes every instruction
+// change to the clusters it is for.
+//
+// Messages are signed (see SignedMessage). The cluster ID of a message is
+// the CN of the certificate that signed it, like for the HTTP API; messages
+// signed by another cluster than the one of their topic are dropped.
+//
+// Publishing with QoS 1 only means the MQTT server accepted a message: a
+// cluster that is disconnected when its instruction or response is routed
+// may never get it. Agents retry requests that get no response and fetch
+// their instructions again after every reconnect.
+type Bridge struct {
+	client   paho.Client
+	service  *service.Service
+	signer   *Signer
+	verifier *Verifier
+}
+
+// NewBridge creates a bridge that connects with the given client options
+// (see ClientOptions), signs its messages with signer and verifies those of
+// agents with verifier
+func NewBridge(svc *service.Service, opts *paho.ClientOptions, signer *Signer, verifier *Verifier) *Bridge {
+	b := &Bridge{
+		service:  svc,
+		signer:   signer,
+		verifier: verifier,
+	}
+
+	opts.SetClientID("resource-broker").
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetOrderMatters(false). // Serve requests concurrently
+		SetOnConnectHandler(b.subscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Log.WithName("mqtt-bridge").Info("Connection to MQTT server lost, reconnecting", "error", err)
+		})
+	b.client = paho.NewClient(opts)
+	return b
+}
+
+// ClientOptions connects a bridge to an external MQTT server (e.g.,
+// tls://mosquitto:8883) with mTLS, using the certificates of the HTTP API
+// (tls.crt, tls.key, ca.crt in certPath)
+func ClientOptions(serverURL, certPath string) (*paho.ClientOptions, error) {
+	cert, err := tls.LoadX509KeyPair(
+		filepath.Join(certPath, "tls.crt"),
+		filepath.Join(certPath, "tls.key"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	caCert, err := os.ReadFile(filepath.Join(certPath, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to append CA certificate")
+	}
+
+	return paho.NewClientOptions().
+		AddBroker(serverURL).
+		SetTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      caCertPool,
+			MinVersion:   tls.VersionTLS12,
+		}), nil
+}
+
+// Start connects to the MQTT server and publishes instructions until ctx ends
+func (b *Bridge) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("mqtt-bridge")
+
+	// With ConnectRetry the client keeps connecting in the background
+	b.client.Connect()
+	defer b.client.Disconnect(250)
+
+	logger.Info("Publishing instructions over MQTT")
+	return b.service.BroadcastInstructions(ctx, b.publishInstruction)
+}
+
+// NeedLeaderElection is true: MQTT 3.1.1 has no shared subscriptions, so
+// every bridge would serve every request
+func (b *Bridge) NeedLeaderElection() bool {
+	return true
+}
+
+// subscribe (re)subscribes to the topics agents publish to, after every connect
+func (b *Bridge) subscribe(client paho.Client) {
+	logger := log.Log.WithName("mqtt-bridge")
+
+	token := client.SubscribeMultiple(map[string]byte{
+		clusterTopic("+", topicAdvertisement): 1,
+		clusterTopic("+", topicRequests):      1,
+	}, b.handleMessage)
+	if !token.WaitTimeout(publishTimeout) || token.Error() != nil {
+		logger.Error(token.Error(), "Failed to subscribe to agent topics")
+		return
+	}
+	logger.Info("Connected to MQTT server")
+}
+
+// handleMessage serves one message an agent published
+func (b *Bridge) handleMessage(_ paho.Client, msg paho.Message) {
+	logger := log.Log.WithName("mqtt-bridge")
+
+	topicClusterID, kind, ok := parseClusterTopic(msg.Topic())
+	if !ok {
+		return
+	}
+	clusterID, payload, err := b.verifier.Verify(msg.Topic(), msg.Payload())
+	if err != nil {
+		logger.Info("Dropped unverified message", "topic", msg.Topic(), "error", err.Error())
+		return
+	}
+	if clusterID != topicClusterID {
+		// Only the cluster itself may publish on its topics
+		logger.Info("Dropped message signed by another cluster", "topic", msg.Topic(), "signer", clusterID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	switch kind {
+	case topicAdvertisement:
+		// Instructions are published on the instructions topic, so the response is not needed
+		var adv dto.AdvertisementDTO
+		if err := json.Unmarshal(payload, &adv); err != nil {
+			logger.Info("Dropped malformed advertisement", "clusterID", clusterID)
+			return
+		}
+		if _, err := b.service.PublishAdvertisement(ctx, clusterID, &adv); err != nil {
+			logger.Info("Advertisement rejected", "clusterID", clusterID, "error", err.Error())
+		}
+
+	case topicRequests:
+		var req Request
+		if err := json.Unmarshal(payload, &req); err != nil || req.RequestID == "" {
+			// Without a request ID the agent could not match a response
+			logger.Info("Dropped malformed request", "clusterID", clusterID)
+			return
+		}
+
+		resp := b.serve(ctx, clusterID, &req)
+		resp.RequestID = req.RequestID
+		logger.V(1).Info("MQTT request",
+			"clusterID", clusterID,
+			"operation", req.Operation,
+			"reservation", req.ReservationID,
+			"status", resp.Status)
+
+		if err := b.publish(clusterTopic(clusterID, topicResponses), resp); err != nil {
+			logger.Error(err, "Failed to publish response", "clusterID", clusterID, "requestID", req.RequestID)
+		}
+	}
+}
+
+// serve runs one request as the cluster that signed it
+func (b *Bridge) serve(ctx context.Context, clusterID string, req *Request) *Response {
+	switch req.Operation {
+	case OperationReserve:
+		if req.Reservation == nil {
+			return invalidRequest(req.Operation)
+		}
+		result, err := b.service.Reserve(ctx, clusterID, req.Reservation, req.IdempotencyKey)
+		return reservationResult(result, err)
+
+	case OperationReserveGang:
+		if req.Gang == nil {
+			return invalidRequest(req.Operation)
+		}
+		result, err := b.service.ReserveGang(ctx, clusterID, req.Gang, req.IdempotencyKey)
+		return reservationResult(result, err)
+
+	case OperationGetReservation:
+		return reservationResponse(http.StatusOK)(b.service.GetReservation(ctx, clusterID, req.ReservationID))
+
+	case OperationActivate:
+		return reservationResponse(http.StatusAccepted)(b.service.Activate(ctx, clusterID, req.ReservationID))
+
+	case OperationAcknowledge:
+		if req.Acknowledge == nil {
+			return invalidRequest(req.Operation)
+		}
+		return reservationResponse(http.StatusOK)(b.service.Acknowledge(ctx, clusterID, req.ReservationID, req.Acknowledge))
+
+	case OperationHeartbeat:
+		return reservationResponse(http.StatusOK)(b.service.Heartbeat(ctx, clusterID, req.ReservationID))
+
+	case OperationRenew:
+		// The renewal is optional, as the REST API's body
+		renew := req.Renew
+		if renew == nil {
+			renew = &dto.RenewRequestDTO{}
+		}
+		return reservationResponse(http.StatusOK)(b.service.Renew(ctx, clusterID, req.ReservationID, renew))
+
+	case OperationResize:
+		if req.Resize == nil {
+			return invalidRequest(req.Operation)
+		}
+		return reservationResponse(http.StatusOK)(b.service.Resize(ctx, clusterID, req.ReservationID, req.Resize))
+
+	case OperationRelease:
+		return reservationResponse(http.StatusAccepted)(b.service.Release(ctx, clusterID, req.ReservationID))
+
+	case OperationFetchInstructions:
+		instructions, err := b.service.Instructions(ctx, clusterID)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return &Response{Status: http.StatusOK, Instructions: instructions}
+
+	default:
+		return &Response{Status: http.StatusBadRequest, Error: fmt.Sprintf("Unknown operation %q", req.Operation)}
+	}
+}
+
+// publishInstruction publishes one instruction to its cluster. Failures are
+// only logged: agents fetch every current instruction when they (re)subscribe.
+func (b *Bridge) publishInstruction(clusterID string, instruction *dto.ReservationDTO) error {
+	if err := b.publish(clusterTopic(clusterID, topicInstructions), instruction); err != nil {
+		log.Log.WithName("mqtt-bridge").Error(err, "Failed to publish instruction",
+			"clusterID", clusterID,
+			"reservation", instruction.ID)
+	}
+	return nil
+}
+
+// publish signs a message for topic, sends it with QoS 1 and waits for the
+// server to accept it
+func (b *Bridge) publish(topic string, payload any) error {
+	data, err := b.signer.Sign(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	token := b.client.Publish(topic, 1, false, data)
+	if !token.WaitTimeout(publishTimeout) {
+		return fmt.Errorf("timed out publishing to %s", topic)
+	}
+	return token.Error()
+}
+
+// reservationResult answers a served reservation request: 201 for a new
+// reservation, 202 while queued, 200 for a replay
+func reservationResult(result *service.ReservationResult, err error) *Response {
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	status := http.StatusCreated
+	switch result.Outcome {
+	case service.OutcomeQueued:
+		status = http.StatusAccepted
+	case service.OutcomeExisting:
+		status = http.StatusOK
+	}
+	return &Response{Status: status, Reservation: result.Reservation}
+}
+
+// reservationResponse answers an operation on a reservation with status on success
+func reservationResponse(status int) func(*dto.ReservationDTO, error) *Response {
+	return func(reservation *dto.ReservationDTO, err error) *Response {
+		if err != nil {
+			return errorResponse(err)
+		}
+		return &Response{Status: status, Reservation: reservation}
+	}
+}
+
+// invalidRequest answers a request without the fields of its operation
+func invalidRequest(operation Operation) *Response {
+	return &Response{Status: http.StatusBadRequest, Error: fmt.Sprintf("Invalid %s request", operation)}
+}
+
+// errorResponse answers a failed operation with the status the REST API uses for it
+func errorResponse(err error) *Response {
+	serviceErr := service.AsError(err)
+	resp := &Response{Status: responseStatus(serviceErr.Code), Error: serviceErr.Message}
+	if serviceErr.Decision != nil {
+		resp.Decision = dto.FromDecisionRecord(serviceErr.Decision)
+	}
+	return resp
+}
+
+// responseStatus maps a service error code to its HTTP status
+func responseStatus(code service.Code) int {
+	switch code {
+	case service.CodeInvalid:
+		return http.StatusBadRequest
+	case service.CodeForbidden:
+		return http.StatusForbidden
+	case service.CodeNotFound:
+		return http.StatusNotFound
+	case service.CodeConflict:
+		return http.StatusConflict
+	case service.CodeKeyReused:
+		return http.StatusUnprocessableEntity
+	case service.CodeInProgress:
+		return http.StatusServiceUnavailable
+	case service.CodeUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
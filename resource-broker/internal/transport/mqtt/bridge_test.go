@@ -0,0 +1,222 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/service"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// startBridge runs an in-process server with a bridge signing with a broker
+// certificate of ca, and returns the feed the bridge publishes instructions from
+func startBridge(t *testing.T, ca *testCA) (*Server, *broker.ReservationFeed) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	_ = brokerv1alpha1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithIndex(&brokerv1alpha1.Reservation{}, broker.ReservationPhaseField, broker.IndexReservationPhase).Build()
+
+	feed := broker.NewReservationFeed(16)
+	svc := service.New(fakeClient, "default", &broker.DecisionEngine{Client: fakeClient}, 0, feed)
+
+	server := NewServer()
+	opts := paho.NewClientOptions().
+		AddBroker("inprocess://broker").
+		SetCustomOpenConnectionFn(func(*url.URL, paho.ClientOptions) (net.Conn, error) {
+			return server.ConnectInProcess(), nil
+		})
+	signer := ca.signer(t, "liqo-resource-broker", x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
+	verifier := NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewBridge(svc, opts, signer, verifier).Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		server.Close()
+	})
+	return server, feed
+}
+
+// connectAgent connects an MQTT client to the server, like an agent would
+func connectAgent(t *testing.T, server *Server, clientID string) paho.Client {
+	t.Helper()
+
+	opts := paho.NewClientOptions().
+		AddBroker("inprocess://broker").
+		SetClientID(clientID).
+		SetCustomOpenConnectionFn(func(*url.URL, paho.ClientOptions) (net.Conn, error) {
+			return server.ConnectInProcess(), nil
+		})
+	client := paho.NewClient(opts)
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("Failed to connect: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(0) })
+	return client
+}
+
+// subscribe collects the payloads of the broker's messages on a topic,
+// dropping those not signed by the broker
+func subscribe(t *testing.T, client paho.Client, ca *testCA, topic string) <-chan json.RawMessage {
+	t.Helper()
+
+	verifier := NewVerifier(ca.pool(), x509.ExtKeyUsageServerAuth)
+	messages := make(chan json.RawMessage, 16)
+	token := client.Subscribe(topic, 1, func(_ paho.Client, msg paho.Message) {
+		if _, payload, err := verifier.Verify(msg.Topic(), msg.Payload()); err == nil {
+			messages <- payload
+		}
+	})
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("Failed to subscribe to %s: %v", topic, token.Error())
+	}
+	return messages
+}
+
+// request publishes a signed request until the bridge answers it (it may
+// still be subscribing); ok is false when it never does
+func request(t *testing.T, client paho.Client, signer *Signer, responses <-chan json.RawMessage, topic string, req Request) (resp *Response, ok bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		// Signed again every time, since the bridge drops replays
+		client.Publish(topic, 1, false, sign(t, signer, topic, &req)).Wait()
+
+		wait := time.After(200 * time.Millisecond)
+	waiting:
+		for {
+			select {
+			case data := <-responses:
+				var resp Response
+				if err := json.Unmarshal(data, &resp); err != nil {
+					t.Fatalf("Invalid response: %v", err)
+				}
+				if resp.RequestID != req.RequestID {
+					continue // Answer to an earlier attempt of another request
+				}
+				return &resp, true
+			case <-wait:
+				break waiting
+			}
+		}
+	}
+	return nil, false
+}
+
+// mustRequest is request, failing the test without an answer
+func mustRequest(t *testing.T, client paho.Client, signer *Signer, responses <-chan json.RawMessage, clusterID string, req Request) *Response {
+	t.Helper()
+
+	resp, ok := request(t, client, signer, responses, clusterTopic(clusterID, topicRequests), req)
+	if !ok {
+		t.Fatal("No response from the bridge")
+	}
+	return resp
+}
+
+// Test: Each operation is served by the broker service and answered on the cluster's responses topic
+func TestBridge_ServesRequests(t *testing.T) {
+	ca := newTestCA(t)
+	server, _ := startBridge(t, ca)
+	agent := connectAgent(t, server, "cluster-1")
+	signer := ca.signer(t, "cluster-1", x509.ExtKeyUsageClientAuth)
+	responses := subscribe(t, agent, ca, clusterTopic("cluster-1", topicResponses))
+
+	resp := mustRequest(t, agent, signer, responses, "cluster-1", Request{RequestID: "req-1", Operation: OperationFetchInstructions})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("Expected 200 for fetchInstructions, got %d: %s", resp.Status, resp.Error)
+	}
+
+	resp = mustRequest(t, agent, signer, responses, "cluster-1", Request{
+		RequestID:     "req-2",
+		Operation:     OperationGetReservation,
+		ReservationID: "missing",
+	})
+	if resp.Status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown reservation, got %d: %s", resp.Status, resp.Error)
+	}
+
+	resp = mustRequest(t, agent, signer, responses, "cluster-1", Request{RequestID: "req-3", Operation: OperationReserve})
+	if resp.Status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a reserve request without a reservation, got %d: %s", resp.Status, resp.Error)
+	}
+
+	resp = mustRequest(t, agent, signer, responses, "cluster-1", Request{RequestID: "req-4", Operation: "watch"})
+	if resp.Status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown operation, got %d: %s", resp.Status, resp.Error)
+	}
+}
+
+// Test: Messages signed by another cluster than the one of their topic, or by an untrusted CA, are dropped
+func TestBridge_DropsMessagesNotSignedByTheCluster(t *testing.T) {
+	ca := newTestCA(t)
+	server, _ := startBridge(t, ca)
+	agent := connectAgent(t, server, "cluster-1")
+	responses := subscribe(t, agent, ca, clusterTopic("cluster-1", topicResponses))
+
+	// Once the bridge answers, it is subscribed
+	mustRequest(t, agent, ca.signer(t, "cluster-1", x509.ExtKeyUsageClientAuth), responses, "cluster-1",
+		Request{RequestID: "ready", Operation: OperationFetchInstructions})
+
+	topic := clusterTopic("cluster-1", topicRequests)
+	impostors := map[string]*Signer{
+		"another cluster": ca.signer(t, "cluster-2", x509.ExtKeyUsageClientAuth),
+		"untrusted CA":    newTestCA(t).signer(t, "cluster-1", x509.ExtKeyUsageClientAuth),
+	}
+	for name, impostor := range impostors {
+		if resp, ok := request(t, agent, impostor, responses, topic, Request{RequestID: name, Operation: OperationFetchInstructions}); ok {
+			t.Errorf("Expected a request signed by %s to be dropped, got %d", name, resp.Status)
+		}
+	}
+}
+
+// Test: Instruction changes are published, signed, on the provider's instructions topic
+func TestBridge_PublishesInstructions(t *testing.T) {
+	ca := newTestCA(t)
+	server, feed := startBridge(t, ca)
+	agent := connectAgent(t, server, "cluster-2")
+	responses := subscribe(t, agent, ca, clusterTopic("cluster-2", topicResponses))
+	instructions := subscribe(t, agent, ca, clusterTopic("cluster-2", topicInstructions))
+
+	// Once the bridge answers, it is connected and following the feed
+	mustRequest(t, agent, ca.signer(t, "cluster-2", x509.ExtKeyUsageClientAuth), responses, "cluster-2",
+		Request{RequestID: "ping", Operation: OperationFetchInstructions})
+
+	feed.Publish(&brokerv1alpha1.Reservation{
+		Spec:   brokerv1alpha1.ReservationSpec{RequesterID: "cluster-1", TargetClusterID: "cluster-2"},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReserved},
+	})
+
+	select {
+	case data := <-instructions:
+		var instruction dto.ReservationDTO
+		if err := json.Unmarshal(data, &instruction); err != nil {
+			t.Fatalf("Invalid instruction: %v", err)
+		}
+		if instruction.TargetClusterID != "cluster-2" || instruction.Status.Phase != "Reserved" {
+			t.Errorf("Expected the Reserved reservation on cluster-2, got %+v", instruction)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No instruction published")
+	}
+}
@@ -0,0 +1,183 @@
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates like the broker's cert-manager CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// pool trusts the CA
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// signer issues a certificate for commonName and usages and returns its signer
+func (ca *testCA) signer(t *testing.T, commonName string, usages ...x509.ExtKeyUsage) *Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usages,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	signer, err := NewSigner(tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key})
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+// sign signs payload for topic or fails the test
+func sign(t *testing.T, signer *Signer, topic string, payload any) []byte {
+	t.Helper()
+
+	data, err := signer.Sign(topic, payload)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	return data
+}
+
+// Test: A signed message verifies once, on its topic, and yields the signer's CN and the payload
+func TestVerifier_AcceptsSignedMessage(t *testing.T) {
+	ca := newTestCA(t)
+	verifier := NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth)
+	topic := clusterTopic("cluster-1", topicRequests)
+	data := sign(t, ca.signer(t, "cluster-1", x509.ExtKeyUsageClientAuth), topic, &Request{RequestID: "req-1"})
+
+	clusterID, payload, err := verifier.Verify(topic, data)
+	if err != nil {
+		t.Fatalf("Expected the message to verify, got %v", err)
+	}
+	var req Request
+	if err := json.Unmarshal(payload, &req); err != nil || req.RequestID != "req-1" {
+		t.Errorf("Expected the signed request, got %s", payload)
+	}
+	if clusterID != "cluster-1" {
+		t.Errorf("Expected the signer's CN cluster-1, got %q", clusterID)
+	}
+
+	if _, _, err := verifier.Verify(topic, data); err == nil {
+		t.Error("Expected a replay to be rejected")
+	}
+}
+
+// Test: Messages are rejected on another topic, when altered, too old, or signed by a certificate not issued for the usage
+func TestVerifier_RejectsMessages(t *testing.T) {
+	ca := newTestCA(t)
+	agent := ca.signer(t, "cluster-1", x509.ExtKeyUsageClientAuth)
+	topic := clusterTopic("cluster-1", topicRequests)
+
+	tampered := func(change func(msg *SignedMessage)) []byte {
+		var msg SignedMessage
+		_ = json.Unmarshal(sign(t, agent, topic, &Request{RequestID: "req-1"}), &msg)
+		change(&msg)
+		data, _ := json.Marshal(&msg)
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		topic    string
+		data     []byte
+	}{
+		{
+			name:     "other topic",
+			verifier: NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth),
+			topic:    clusterTopic("cluster-2", topicRequests),
+			data:     sign(t, agent, topic, &Request{RequestID: "req-1"}),
+		},
+		{
+			name:     "altered payload",
+			verifier: NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth),
+			topic:    topic,
+			data: tampered(func(msg *SignedMessage) {
+				msg.Payload = json.RawMessage(`{"requestID":"req-2"}`)
+			}),
+		},
+		{
+			name:     "too old",
+			verifier: NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth),
+			topic:    topic,
+			data: tampered(func(msg *SignedMessage) {
+				msg.SignedAt = time.Now().Add(-2 * maxMessageAge).UnixMilli()
+			}),
+		},
+		{
+			name:     "agent certificate posing as the broker",
+			verifier: NewVerifier(ca.pool(), x509.ExtKeyUsageServerAuth),
+			topic:    topic,
+			data:     sign(t, agent, topic, &Request{RequestID: "req-1"}),
+		},
+		{
+			name:     "other CA",
+			verifier: NewVerifier(newTestCA(t).pool(), x509.ExtKeyUsageClientAuth),
+			topic:    topic,
+			data:     sign(t, agent, topic, &Request{RequestID: "req-1"}),
+		},
+		{
+			name:     "malformed",
+			verifier: NewVerifier(ca.pool(), x509.ExtKeyUsageClientAuth),
+			topic:    topic,
+			data:     []byte(`{"requestID":"req-1"}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.verifier.Verify(tt.topic, tt.data); err == nil {
+				t.Error("Expected the message to be rejected")
+			}
+		})
+	}
+}
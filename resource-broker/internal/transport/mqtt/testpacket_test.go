@@ -0,0 +1,253 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	packetConnect     byte = 1
+	packetConnack     byte = 2
+	packetPublish     byte = 3
+	packetPuback      byte = 4
+	packetPubrec      byte = 5
+	packetPubrel      byte = 6
+	packetPubcomp     byte = 7
+	packetSubscribe   byte = 8
+	packetSuback      byte = 9
+	packetUnsubscribe byte = 10
+	packetUnsuback    byte = 11
+	packetPingreq     byte = 12
+	packetPingresp    byte = 13
+	packetDisconnect  byte = 14
+)
+
+// CONNACK return codes
+const (
+	connackAccepted             byte = 0
+	connackUnacceptableProtocol byte = 1
+	connackIdentifierRejected   byte = 2
+)
+
+// subackFailure is the SUBACK return code of a refused subscription
+const subackFailure byte = 0x80
+
+// maxPacketSize bounds the packets a client may send (advertisements and
+// reservation requests are a few KiB)
+const maxPacketSize = 1 << 20
+
+// packet is one MQTT control packet: its type, the flags of the fixed header
+// and the rest of the packet
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// readPacket reads one control packet
+func readPacket(r *bufio.Reader) (*packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remaining length: up to 4 bytes, 7 bits each, least significant first
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > maxPacketSize {
+		return nil, fmt.Errorf("packet of %d bytes exceeds the limit of %d", length, maxPacketSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+// encode serializes the packet with its fixed header
+func (p *packet) encode() []byte {
+	out := []byte{p.kind<<4 | p.flags}
+	length := len(p.body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if length == 0 {
+			break
+		}
+	}
+	return append(out, p.body...)
+}
+
+// decoder reads the fields of a packet body
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) < 1 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *decoder) uint16() uint16 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) < 2 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.data)
+	d.data = d.data[2:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.uint16())
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(out []byte, s string) []byte {
+	out = binary.BigEndian.AppendUint16(out, uint16(len(s)))
+	return append(out, s...)
+}
+
+// connectPacket is the content of a CONNECT packet the server uses
+type connectPacket struct {
+	protocol  string
+	level     byte
+	clientID  string
+	keepAlive uint16
+}
+
+func decodeConnect(p *packet) (*connectPacket, error) {
+	d := &decoder{data: p.body}
+	c := &connectPacket{protocol: d.string(), level: d.byte()}
+	flags := d.byte()
+	c.keepAlive = d.uint16()
+	c.clientID = d.string()
+	if flags&0x04 != 0 {
+		// Will messages are accepted but never published
+		d.string()
+		d.bytes()
+	}
+	if flags&0x80 != 0 {
+		d.string() // Username: clients are identified by their certificate
+	}
+	if flags&0x40 != 0 {
+		d.bytes() // Password
+	}
+	return c, d.err
+}
+
+// publishPacket is an application message
+type publishPacket struct {
+	topic    string
+	qos      byte
+	packetID uint16
+	payload  []byte
+}
+
+func decodePublish(p *packet) (*publishPacket, error) {
+	d := &decoder{data: p.body}
+	pub := &publishPacket{topic: d.string(), qos: (p.flags >> 1) & 0x03}
+	if pub.qos > 0 {
+		pub.packetID = d.uint16()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if pub.qos > 2 {
+		return nil, errors.New("invalid QoS 3")
+	}
+	pub.payload = d.data
+	return pub, nil
+}
+
+func (pub *publishPacket) encode() []byte {
+	body := appendString(nil, pub.topic)
+	if pub.qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, pub.packetID)
+	}
+	body = append(body, pub.payload...)
+	return (&packet{kind: packetPublish, flags: pub.qos << 1, body: body}).encode()
+}
+
+// subscription is one topic filter of a SUBSCRIBE packet
+type subscription struct {
+	filter string
+	qos    byte
+}
+
+func decodeSubscribe(p *packet) (uint16, []subscription, error) {
+	d := &decoder{data: p.body}
+	packetID := d.uint16()
+	var subs []subscription
+	for d.err == nil && len(d.data) > 0 {
+		subs = append(subs, subscription{filter: d.string(), qos: d.byte()})
+	}
+	if d.err == nil && len(subs) == 0 {
+		d.err = errors.New("SUBSCRIBE without topic filters")
+	}
+	return packetID, subs, d.err
+}
+
+func decodeUnsubscribe(p *packet) (uint16, []string, error) {
+	d := &decoder{data: p.body}
+	packetID := d.uint16()
+	var filters []string
+	for d.err == nil && len(d.data) > 0 {
+		filters = append(filters, d.string())
+	}
+	return packetID, filters, d.err
+}
+
+// ack encodes a packet that only carries a packet identifier (PUBACK, SUBACK, ...)
+func ack(kind, flags byte, packetID uint16, extra ...byte) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	return (&packet{kind: kind, flags: flags, body: append(body, extra...)}).encode()
+}
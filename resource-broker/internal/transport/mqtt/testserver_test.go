@@ -0,0 +1,293 @@
+package mqtt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outboxSize is how many messages may wait for a slow client before it is disconnected
+const outboxSize = 256
+
+// Server is a minimal in-process MQTT 3.1.1 server, so bridge tests need
+// neither the network nor an external server. It keeps no sessions across
+// connections and delivers every message once with QoS 0; QoS 1 publishes
+// are acknowledged once routed, QoS 2 is refused. Production brokers connect
+// to an external MQTT server (see ClientOptions).
+type Server struct {
+	mu       sync.Mutex
+	sessions map[string]*session // By client ID
+	closed   bool
+}
+
+// NewServer creates an in-process MQTT server
+func NewServer() *Server {
+	return &Server{sessions: map[string]*session{}}
+}
+
+// ConnectInProcess returns a connection to the server
+func (s *Server) ConnectInProcess() net.Conn {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client
+}
+
+// Close disconnects every client
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.close()
+	}
+}
+
+// session is one connected client
+type session struct {
+	conn     net.Conn
+	clientID string
+
+	subscriptions map[string]struct{} // Topic filters, guarded by server.mu
+	outbox        chan []byte
+	closeOnce     sync.Once
+	done          chan struct{}
+}
+
+func (sess *session) close() {
+	sess.closeOnce.Do(func() {
+		close(sess.done)
+		sess.conn.Close()
+	})
+}
+
+// send queues an encoded packet, disconnecting clients that fall too far
+// behind; what was queued for them is lost
+func (sess *session) send(data []byte) {
+	select {
+	case sess.outbox <- data:
+	case <-sess.done:
+	default:
+		sess.close()
+	}
+}
+
+// writeLoop writes queued packets to the connection
+func (sess *session) writeLoop() {
+	for {
+		select {
+		case data := <-sess.outbox:
+			if _, err := sess.conn.Write(data); err != nil {
+				sess.close()
+				return
+			}
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+// serve runs the MQTT session of one connection until it ends
+func (s *Server) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	first, err := readPacket(reader)
+	if err != nil || first.kind != packetConnect {
+		conn.Close()
+		return
+	}
+	connect, err := decodeConnect(first)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if connect.protocol != "MQTT" || connect.level != 4 {
+		conn.Write((&packet{kind: packetConnack, body: []byte{0, connackUnacceptableProtocol}}).encode())
+		conn.Close()
+		return
+	}
+	if connect.clientID == "" {
+		conn.Write((&packet{kind: packetConnack, body: []byte{0, connackIdentifierRejected}}).encode())
+		conn.Close()
+		return
+	}
+
+	sess := &session{
+		conn:          conn,
+		clientID:      connect.clientID,
+		subscriptions: map[string]struct{}{},
+		outbox:        make(chan []byte, outboxSize),
+		done:          make(chan struct{}),
+	}
+
+	// A client connecting again with the same ID takes over from the old connection
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	previous := s.sessions[sess.clientID]
+	s.sessions[sess.clientID] = sess
+	s.mu.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+
+	defer func() {
+		sess.close()
+		s.mu.Lock()
+		if s.sessions[sess.clientID] == sess {
+			delete(s.sessions, sess.clientID)
+		}
+		s.mu.Unlock()
+	}()
+
+	go sess.writeLoop()
+	sess.send((&packet{kind: packetConnack, body: []byte{0, connackAccepted}}).encode())
+
+	// Clients must send something within one and a half keep-alive periods
+	idleTimeout := time.Duration(connect.keepAlive) * 1500 * time.Millisecond
+	for {
+		if idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		} else {
+			conn.SetReadDeadline(time.Time{})
+		}
+
+		p, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		if err := s.handle(sess, p); err != nil {
+			return
+		}
+	}
+}
+
+// errDisconnect ends a session the client closed with DISCONNECT
+var errDisconnect = errors.New("client disconnected")
+
+// handle processes one packet from a client
+func (s *Server) handle(sess *session, p *packet) error {
+	switch p.kind {
+	case packetPublish:
+		pub, err := decodePublish(p)
+		if err != nil {
+			return err
+		}
+		if strings.ContainsAny(pub.topic, "+#") || pub.topic == "" {
+			return fmt.Errorf("invalid topic name %q", pub.topic)
+		}
+		if pub.qos == 2 {
+			return errors.New("QoS 2 is not supported")
+		}
+		s.route(pub)
+		if pub.qos == 1 {
+			sess.send(ack(packetPuback, 0, pub.packetID))
+		}
+
+	case packetPuback:
+		// Outgoing messages are sent with QoS 0, so there is nothing to acknowledge
+
+	case packetSubscribe:
+		packetID, subs, err := decodeSubscribe(p)
+		if err != nil {
+			return err
+		}
+		codes := make([]byte, len(subs))
+		s.mu.Lock()
+		for i, sub := range subs {
+			if !validFilter(sub.filter) {
+				codes[i] = subackFailure
+				continue
+			}
+			// Every subscription is granted QoS 0, whatever was asked for
+			sess.subscriptions[sub.filter] = struct{}{}
+			codes[i] = 0
+		}
+		s.mu.Unlock()
+		sess.send(ack(packetSuback, 0, packetID, codes...))
+
+	case packetUnsubscribe:
+		packetID, filters, err := decodeUnsubscribe(p)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		for _, filter := range filters {
+			delete(sess.subscriptions, filter)
+		}
+		s.mu.Unlock()
+		sess.send(ack(packetUnsuback, 0, packetID))
+
+	case packetPingreq:
+		sess.send((&packet{kind: packetPingresp}).encode())
+
+	case packetDisconnect:
+		return errDisconnect
+
+	default:
+		return fmt.Errorf("unexpected packet type %d", p.kind)
+	}
+	return nil
+}
+
+// route delivers a message once, with QoS 0, to every client connected and
+// subscribed to its topic
+func (s *Server) route(pub *publishPacket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := (&publishPacket{topic: pub.topic, payload: pub.payload}).encode()
+	for _, sess := range s.sessions {
+		for filter := range sess.subscriptions {
+			if topicMatches(filter, pub.topic) {
+				sess.send(out)
+				break
+			}
+		}
+	}
+}
+
+// validFilter reports whether a topic filter uses the wildcards correctly
+func validFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return false
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return false
+		}
+	}
+	return true
+}
+
+// topicMatches reports whether a topic name matches a topic filter
+// ("+" matches one level, a trailing "#" any number of levels)
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
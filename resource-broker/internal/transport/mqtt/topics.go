@@ -0,0 +1,85 @@
+package mqtt
+
+import (
+	"strings"
+
+	"github.com/mehdiazizian/liqo-resource-broker/internal/transport/dto"
+)
+
+// Topics of one cluster, under TopicRoot/<clusterID>/. Every message is a
+// SignedMessage; its payload is:
+//   - advertisement: the agent's AdvertisementDTO;
+//   - requests: a Request from the agent;
+//   - responses: the broker's Response to each Request;
+//   - instructions: each instruction for the cluster as a ReservationDTO, as
+//     returned by GET /api/v1/instructions.
+const (
+	TopicRoot = "rear/clusters"
+
+	topicAdvertisement = "advertisement"
+	topicRequests      = "requests"
+	topicResponses     = "responses"
+	topicInstructions  = "instructions"
+)
+
+// Operation is one broker call an agent can make over MQTT
+type Operation string
+
+// Operations, each the counterpart of a REST API endpoint
+const (
+	OperationReserve           Operation = "reserve"           // POST /api/v1/reservations
+	OperationReserveGang       Operation = "reserveGang"       // POST /api/v1/reservations:gang
+	OperationGetReservation    Operation = "getReservation"    // GET /api/v1/reservations/{id}
+	OperationActivate          Operation = "activate"          // POST /api/v1/reservations/{id}/activate
+	OperationAcknowledge       Operation = "acknowledge"       // POST /api/v1/reservations/{id}/acknowledge
+	OperationHeartbeat         Operation = "heartbeat"         // POST /api/v1/reservations/{id}/heartbeat
+	OperationRenew             Operation = "renew"             // POST /api/v1/reservations/{id}/renew
+	OperationResize            Operation = "resize"            // POST /api/v1/reservations/{id}/resize
+	OperationRelease           Operation = "release"           // DELETE /api/v1/reservations/{id}
+	OperationFetchInstructions Operation = "fetchInstructions" // GET /api/v1/instructions
+)
+
+// Request is one call from an agent. Besides the request ID and operation,
+// only the fields of that operation are set.
+type Request struct {
+	RequestID string    `json:"requestID"` // Echoed in the response
+	Operation Operation `json:"operation"`
+
+	ReservationID  string                         `json:"reservationID,omitempty"`  // Operations on one reservation
+	IdempotencyKey string                         `json:"idempotencyKey,omitempty"` // reserve, reserveGang
+	Reservation    *dto.ReservationRequestDTO     `json:"reservation,omitempty"`    // reserve
+	Gang           *dto.GangReservationRequestDTO `json:"gang,omitempty"`           // reserveGang
+	Acknowledge    *dto.AcknowledgeRequestDTO     `json:"acknowledge,omitempty"`    // acknowledge
+	Renew          *dto.RenewRequestDTO           `json:"renew,omitempty"`          // renew (optional)
+	Resize         *dto.ResizeRequestDTO          `json:"resize,omitempty"`         // resize
+}
+
+// Response is the broker's answer to a Request
+type Response struct {
+	RequestID string `json:"requestID"`
+	Status    int    `json:"status"` // HTTP status code the REST API would answer
+	Error     string `json:"error,omitempty"`
+	// Decision explains why no cluster was chosen, as in the REST API's error body
+	Decision *dto.DecisionDTO `json:"decision,omitempty"`
+
+	Reservation  *dto.ReservationDTO   `json:"reservation,omitempty"`  // Operations on reservations
+	Instructions []*dto.ReservationDTO `json:"instructions,omitempty"` // fetchInstructions
+}
+
+// clusterTopic is a topic of one cluster
+func clusterTopic(clusterID, kind string) string {
+	return TopicRoot + "/" + clusterID + "/" + kind
+}
+
+// parseClusterTopic splits a cluster topic into cluster ID and kind
+func parseClusterTopic(topic string) (clusterID, kind string, ok bool) {
+	rest, found := strings.CutPrefix(topic, TopicRoot+"/")
+	if !found {
+		return "", "", false
+	}
+	clusterID, kind, found = strings.Cut(rest, "/")
+	if !found || clusterID == "" || strings.Contains(kind, "/") {
+		return "", "", false
+	}
+	return clusterID, kind, true
+}
@@ -201,7 +201,7 @@ start_broker() {
     log_info "Starting broker..."
     KUBECONFIG="$KUBECONFIGS_DIR/$BROKER_CLUSTER.kubeconfig" \
         "$BROKER_DIR/bin/broker" \
-        --broker-interface=http \
+        --enable-http \
         --http-port="$BROKER_PORT" \
         --http-cert-path="$CERTS_DIR/broker" \
         --http-namespace=default \
@@ -61,7 +61,7 @@ echo ""
 
 # Run broker with HTTP interface
 ./bin/broker \
-    --broker-interface=http \
+    --enable-http \
     --http-port=8443 \
     --http-cert-path="$CERT_DIR" \
     --http-namespace=default \